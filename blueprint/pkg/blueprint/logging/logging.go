@@ -252,6 +252,42 @@ func (stack *Callstack) String() string {
 	return strings.Join(s, "\n")
 }
 
+// Modules whose callsites are considered to be part of Blueprint rather than part of an application's wiring spec
+var blueprintModules = []string{
+	"github.com/blueprint-uservices/blueprint/blueprint",
+	"github.com/blueprint-uservices/blueprint/plugins",
+}
+
+// Returns the first callsite in the stack that resides outside of Blueprint's compiler and plugins.
+// Typically this is the line of the application's wiring spec that caused the call.
+// If every callsite resides within Blueprint, returns the outermost callsite.
+// Returns nil if the stack is empty.
+func (stack *Callstack) WiringCallsite() *Callsite {
+	if stack == nil || len(stack.Stack) == 0 {
+		return nil
+	}
+	for i := range stack.Stack {
+		if !isBlueprintModule(stack.Stack[i].Source.Module) {
+			return &stack.Stack[i]
+		}
+	}
+	return &stack.Stack[len(stack.Stack)-1]
+}
+
+func isBlueprintModule(module string) bool {
+	for _, m := range blueprintModules {
+		if module == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the filename of the callsite, relative to the workspace if the file is within a workspace
+func (cs Callsite) Filename() string {
+	return cs.Source.WorkspaceFilename
+}
+
 // Gets the current callstack including file information.
 // Blueprint's wiring spec uses this so that logging statements and error messages
 // can be attributed back to the appropriate wiring spec line.
@@ -261,8 +297,27 @@ func GetCallstack() *Callstack {
 	if n == 0 {
 		return nil
 	}
+	return Callers(pc[:n]).Callstack()
+}
+
+// The program counters of a callstack.  Capturing Callers is cheap compared to [GetCallstack], which looks up
+// source file information for every frame; the Callers can be resolved to a [Callstack] later, if needed.
+type Callers []uintptr
+
+// Gets the program counters of the current callstack.  Call [Callers.Callstack] to resolve them.
+func GetCallers() Callers {
+	pc := make([]uintptr, 10)
+	n := runtime.Callers(3, pc)
+	return pc[:n]
+}
+
+// Resolves the program counters to a [Callstack] including file information.  Returns nil if there are none.
+func (pc Callers) Callstack() *Callstack {
+	if len(pc) < 3 {
+		return nil
+	}
 
-	frames := runtime.CallersFrames(pc[:n-2])
+	frames := runtime.CallersFrames(pc[:len(pc)-2])
 	callstack := &Callstack{}
 	for {
 		frame, more := frames.Next()
@@ -39,11 +39,12 @@ func Define[ServerType ir.IRNode](spec wiring.WiringSpec, addressName string, po
 	setAddressDef[ServerType](spec, addressName, pointsTo)
 
 	// Define the IRMetadata node for the address, used during the build process
+	metadata := wiring.WiringOpts{Metadata: true}
 	spec.Define(addressName, options.Reachability, func(wiring.Namespace) (ir.IRNode, error) {
 		addr := &Address[ServerType]{}
 		addr.AddrName = addressName
 		return addr, nil
-	})
+	}, metadata)
 
 	// Add IRConfig nodes for the server bind address and client address
 	spec.Define(bind(addressName), options.Reachability, func(wiring.Namespace) (ir.IRNode, error) {
@@ -51,13 +52,17 @@ func Define[ServerType ir.IRNode](spec wiring.WiringSpec, addressName string, po
 		conf.AddressName = addressName
 		conf.Key = bind(addressName)
 		return conf, nil
-	})
+	}, metadata)
 	spec.Define(dial(addressName), options.Reachability, func(wiring.Namespace) (ir.IRNode, error) {
 		conf := &DialConfig{}
 		conf.AddressName = addressName
 		conf.Key = dial(addressName)
 		return conf, nil
-	})
+	}, metadata)
+
+	wiring.AddReference(spec, addressName, wiring.Reference{To: pointsTo, Kind: wiring.AddressWithoutServer})
+	wiring.AddReference(spec, addressName, wiring.Reference{To: bind(addressName)})
+	wiring.AddReference(spec, addressName, wiring.Reference{To: dial(addressName)})
 }
 
 // Gets the [DialConfig] configuration node of addressName from the namespace.
@@ -48,6 +48,7 @@ func AddNodeTo[NamespaceNodeType any](spec wiring.WiringSpec, namespaceName stri
 	if ptr == nil {
 		// Not a pointer, no special handling needed
 		spec.AddProperty(namespaceName, prop_CHILDREN, childName)
+		wiring.AddReference(spec, namespaceName, wiring.Reference{To: childName})
 		return
 	}
 
@@ -86,8 +87,11 @@ func AddNodeTo[NamespaceNodeType any](spec wiring.WiringSpec, namespaceName stri
 		return ptrNextNode, err
 	}, opts)
 
+	wiring.AddReference(spec, modifierName, wiring.Reference{To: namespaceName})
+
 	// The namespace also instantiates the modifier
 	spec.AddProperty(namespaceName, prop_CHILDREN, ptrNext)
+	wiring.AddReference(spec, namespaceName, wiring.Reference{To: ptrNext})
 }
 
 // Used in conjunction with [AddNodeTo].  InstantiateNamespace derives a new child namespace
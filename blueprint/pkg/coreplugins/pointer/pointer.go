@@ -65,6 +65,16 @@ func (ptr PointerDef) String() string {
 	return b.String()
 }
 
+// Returns the names of the client side modifiers of the pointer, in the order that they were added.
+func (ptr *PointerDef) SrcModifiers() []string {
+	return append([]string(nil), ptr.srcModifiers...)
+}
+
+// Returns the names of the server side modifiers of the pointer, starting with the outermost modifier.
+func (ptr *PointerDef) DstModifiers() []string {
+	return append([]string(nil), ptr.dstModifiers...)
+}
+
 // Additional options that can be specified when creating a pointer.
 // If not specified, defaults are used.
 type PointerOpts struct {
@@ -104,6 +114,11 @@ func CreatePointer[SrcNodeType any](spec wiring.WiringSpec, name string, dst str
 		opts = options[0]
 	}
 
+	wiring.AddReference(spec, name, wiring.Reference{To: dst, Kind: wiring.PointerWithoutDestination})
+
+	// Whenever the destination is part of the application, so are the pointer's modifiers
+	wiring.AddReference(spec, dst, wiring.Reference{To: name})
+
 	if opts.RequireUniqueness != nil {
 		dstName := name + ".dst"
 		spec.Alias(dstName, dst)
@@ -136,6 +151,8 @@ func CreatePointer[SrcNodeType any](spec wiring.WiringSpec, name string, dst str
 	})
 
 	spec.SetProperty(name, "ptr", ptr)
+	wiring.AddReference(spec, name, wiring.Reference{To: ptr.srcHead})
+	wiring.AddReference(spec, name, wiring.Reference{To: ptr.dstHead})
 
 	return ptr
 }
@@ -162,6 +179,7 @@ func (ptr *PointerDef) AddSrcModifier(spec wiring.WiringSpec, modifierName strin
 	ptr.srcTail = modifierName + ".ptr.src.next"
 	spec.Alias(ptr.srcTail, ptr.interfaceNode)
 	ptr.srcModifiers = append(ptr.srcModifiers, modifierName)
+	wiring.AddReference(spec, modifierName, wiring.Reference{To: ptr.srcTail})
 
 	return ptr.srcTail
 }
@@ -188,6 +206,8 @@ func (ptr *PointerDef) AddDstModifier(spec wiring.WiringSpec, modifierName strin
 		spec.Alias(ptr.srcTail, ptr.interfaceNode)
 	}
 	ptr.dstModifiers = append([]string{ptr.dstHead}, ptr.dstModifiers...)
+	wiring.AddReference(spec, modifierName, wiring.Reference{To: nextDst})
+	wiring.AddReference(spec, ptr.name, wiring.Reference{To: ptr.dstHead})
 	return nextDst
 }
 
@@ -52,7 +52,7 @@ func RequireUniqueness(spec wiring.WiringSpec, alias string, visibility any) {
 		md.node = nil
 		md.namespace = nil
 		return md, nil
-	})
+	}, wiring.WiringOpts{Metadata: true})
 
 	checkName := name + ".uniqueness_check"
 	spec.Define(checkName, def.NodeType, func(namespace wiring.Namespace) (ir.IRNode, error) {
@@ -68,7 +68,9 @@ func RequireUniqueness(spec wiring.WiringSpec, alias string, visibility any) {
 		md.namespace = namespace
 		err := namespace.Get(name, &md.node)
 		return md.node, err
-	})
+	}, wiring.WiringOpts{Metadata: true})
 
+	wiring.AddReference(spec, checkName, wiring.Reference{To: mdName})
+	wiring.AddReference(spec, checkName, wiring.Reference{To: name})
 	spec.Alias(alias, checkName)
 }
@@ -92,7 +92,7 @@ func buildArtifactGeneratorNodes(outputdir string, nodes []IRNode) ([]IRNode, er
 				return nil, err
 			}
 
-			if err := gen.GenerateArtifacts(subdir); err != nil {
+			if err := GenerateCached(node, subdir, func() error { return gen.GenerateArtifacts(subdir) }); err != nil {
 				return nil, err
 			}
 		} else {
@@ -104,11 +104,23 @@ func buildArtifactGeneratorNodes(outputdir string, nodes []IRNode) ([]IRNode, er
 
 func (r *registry) buildAll(outputDir string, nodes []IRNode) (err error) {
 	// Create output directory
-	if info, err := os.Stat(outputDir); err == nil && info.IsDir() {
-		return blueprint.Errorf("output directory %v already exists", outputDir)
-	}
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return blueprint.Errorf("unable to create output directory %v due to %v", outputDir, err.Error())
+	if incrementalCompilation {
+		if activeCache, err = openArtifactCache(outputDir); err != nil {
+			return err
+		}
+		defer func() {
+			if err == nil {
+				err = activeCache.finish()
+			}
+			activeCache = nil
+		}()
+	} else {
+		if info, err := os.Stat(outputDir); err == nil && info.IsDir() {
+			return blueprint.Errorf("output directory %v already exists", outputDir)
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return blueprint.Errorf("unable to create output directory %v due to %v", outputDir, err.Error())
+		}
 	}
 
 	// Try to group like-nodes into namespaces first
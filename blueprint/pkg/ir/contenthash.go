@@ -0,0 +1,221 @@
+package ir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Optionally implemented by values that are derived from files on the local filesystem, such as
+// parsed source code.  When computing a [ContentHash], the contents of the files are hashed in
+// place of the value itself.
+type ProvidesInputFiles interface {
+	// Returns the paths of files or directories.  Directories are hashed recursively.
+	InputFiles() []string
+}
+
+// Values from these packages are skipped when computing a [ContentHash].  They are derived from
+// source files, whose contents are hashed instead (see [ProvidesInputFiles]), and they contain
+// positions that depend on the order in which files were parsed.
+var skippedPackages = []string{"go/ast", "go/token", "go/types", "golang.org/x/tools/go/"}
+
+// Computes a hash over the contents of a node and everything reachable from it: its fields,
+// the nodes that it references, and the contents of any input files (see [ProvidesInputFiles]).
+//
+// The hash is stable across runs of the compiler, as long as the node's inputs are unchanged.
+func ContentHash(node any) (string, error) {
+	h := newContentHasher()
+	digest, err := h.digest(reflect.ValueOf(node))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+type pointerKey struct {
+	t   reflect.Type
+	ptr uintptr
+}
+
+type contentHasher struct {
+	digests    map[pointerKey][]byte
+	inProgress map[pointerKey]struct{}
+	files      map[string][]byte
+	err        error
+}
+
+func newContentHasher() *contentHasher {
+	return &contentHasher{
+		digests:    make(map[pointerKey][]byte),
+		inProgress: make(map[pointerKey]struct{}),
+		files:      make(map[string][]byte),
+	}
+}
+
+func (h *contentHasher) digest(v reflect.Value) ([]byte, error) {
+	w := sha256.New()
+	h.write(w, v)
+	return w.Sum(nil), h.err
+}
+
+func writeString(w io.Writer, s string) {
+	binary.Write(w, binary.LittleEndian, uint64(len(s)))
+	io.WriteString(w, s)
+}
+
+func isSkipped(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	pkg := t.PkgPath()
+	for _, skipped := range skippedPackages {
+		if pkg == skipped || (strings.HasSuffix(skipped, "/") && strings.HasPrefix(pkg, skipped)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *contentHasher) write(w hash.Hash, v reflect.Value) {
+	if !v.IsValid() {
+		writeString(w, "invalid")
+		return
+	}
+	if isSkipped(v.Type()) {
+		writeString(w, "skipped:"+v.Type().String())
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		fmt.Fprintf(w, "%t;", v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(w, "%d;", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(w, "%d;", v.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(w, "%v;", v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		fmt.Fprintf(w, "%v;", v.Complex())
+	case reflect.String:
+		writeString(w, v.String())
+	case reflect.Interface:
+		if v.IsNil() {
+			writeString(w, "nil")
+			return
+		}
+		writeString(w, v.Elem().Type().String())
+		h.write(w, v.Elem())
+	case reflect.Pointer:
+		if v.IsNil() {
+			writeString(w, "nil")
+			return
+		}
+		w.Write(h.pointerDigest(v))
+	case reflect.Struct:
+		t := v.Type()
+		writeString(w, t.String())
+		for i := 0; i < t.NumField(); i++ {
+			writeString(w, t.Field(i).Name)
+			h.write(w, v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		fmt.Fprintf(w, "[%d]", v.Len())
+		for i := 0; i < v.Len(); i++ {
+			h.write(w, v.Index(i))
+		}
+	case reflect.Map:
+		// Hash the entries in the order of their keys' digests, since map iteration order is random
+		type entry struct {
+			key   []byte
+			value reflect.Value
+		}
+		var entries []entry
+		iter := v.MapRange()
+		for iter.Next() {
+			kw := sha256.New()
+			h.write(kw, iter.Key())
+			entries = append(entries, entry{key: kw.Sum(nil), value: iter.Value()})
+		}
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+		fmt.Fprintf(w, "map[%d]", len(entries))
+		for _, e := range entries {
+			w.Write(e.key)
+			h.write(w, e.value)
+		}
+	default:
+		// Funcs, chans, and unsafe pointers can't be meaningfully hashed
+		writeString(w, v.Kind().String())
+	}
+}
+
+// Pointers are hashed separately and memoized, so that shared values are only hashed once
+func (h *contentHasher) pointerDigest(v reflect.Value) []byte {
+	key := pointerKey{t: v.Type(), ptr: v.Pointer()}
+	if digest, done := h.digests[key]; done {
+		return digest
+	}
+	if _, cycle := h.inProgress[key]; cycle {
+		sum := sha256.Sum256([]byte("cycle:" + v.Type().String()))
+		return sum[:]
+	}
+	h.inProgress[key] = struct{}{}
+	defer delete(h.inProgress, key)
+
+	w := sha256.New()
+	writeString(w, v.Type().String())
+	// Values reached through unexported fields can't be converted to interfaces directly
+	if ptr, isInputs := reflect.NewAt(v.Type().Elem(), v.UnsafePointer()).Interface().(ProvidesInputFiles); isInputs {
+		for _, path := range ptr.InputFiles() {
+			writeString(w, path)
+			w.Write(h.fileDigest(path))
+		}
+	} else {
+		h.write(w, v.Elem())
+	}
+	digest := w.Sum(nil)
+	h.digests[key] = digest
+	return digest
+}
+
+func (h *contentHasher) fileDigest(path string) []byte {
+	if digest, done := h.files[path]; done {
+		return digest
+	}
+	w := sha256.New()
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(path, p)
+		writeString(w, rel)
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	})
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	digest := w.Sum(nil)
+	h.files[path] = digest
+	return digest
+}
@@ -0,0 +1,251 @@
+package ir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"golang.org/x/exp/slog"
+)
+
+/*
+Incremental compilation.
+
+When enabled, generated artifacts are cached in the output directory.  Plugins that generate
+self-contained directories of artifacts, such as golang workspaces, processes, and containers,
+generate them through [GenerateCached].  A content hash of the node's inputs is recorded for
+each such directory; if the hash is unchanged the next time the application is compiled to the
+same output directory, the directory is left as-is and its generation is skipped.
+
+Directories whose nodes no longer exist in the application are pruned, as are any files that
+a regenerated node didn't regenerate.
+*/
+
+// The name of the directory, within the output directory, where the cache manifest is stored
+const cacheDirName = ".blueprint"
+const cacheManifestName = "cache.json"
+
+var incrementalCompilation = false
+
+// The cache for the current call to [ApplicationNode.GenerateArtifacts]; nil if caching is disabled
+var activeCache *artifactCache
+
+// Enables or disables incremental compilation for subsequent calls to [ApplicationNode.GenerateArtifacts].
+//
+// When enabled, GenerateArtifacts can be called with an output directory that already exists, as long as
+// that directory was previously generated with incremental compilation enabled.  Directories of artifacts
+// whose inputs are unchanged are not regenerated.
+func SetIncrementalCompilation(enabled bool) {
+	incrementalCompilation = enabled
+}
+
+// Returns the directory, within the output directory, where plugins can persist state that generated artifacts depend
+// on across incremental compilations, such as the seeds of keys.  Returns the empty string if incremental compilation
+// is disabled, in which case every artifact is regenerated and nothing needs to be persisted.
+func StateDir() (string, error) {
+	if activeCache == nil {
+		return "", nil
+	}
+	dir := filepath.Join(activeCache.outputDir, cacheDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", blueprint.Errorf("unable to create %v due to %v", cacheDirName, err.Error())
+	}
+	return dir, nil
+}
+
+type artifactCache struct {
+	outputDir string
+	compiler  []byte            // Hash of the compiler binary, which includes all plugins and templates
+	hashes    map[string]string // Previous hashes of directories, relative to outputDir
+	generated map[string]string // Directories generated or retained in this run
+	hasher    *contentHasher
+}
+
+func openArtifactCache(outputDir string) (*artifactCache, error) {
+	cache := &artifactCache{
+		outputDir: outputDir,
+		hashes:    make(map[string]string),
+		generated: make(map[string]string),
+		hasher:    newContentHasher(),
+	}
+
+	if executable, err := os.Executable(); err == nil {
+		cache.compiler = cache.hasher.fileDigest(executable)
+	}
+	if cache.hasher.err != nil || cache.compiler == nil {
+		return nil, blueprint.Errorf("unable to hash the compiler binary for incremental compilation")
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, os.MkdirAll(outputDir, 0755)
+	} else if err != nil {
+		return nil, blueprint.Errorf("unable to read output directory %v due to %v", outputDir, err.Error())
+	}
+	if len(entries) == 0 {
+		return cache, nil
+	}
+
+	// Refuse to touch existing directories that weren't generated incrementally, since we might delete files
+	data, err := os.ReadFile(cache.manifestPath())
+	if err != nil {
+		return nil, blueprint.Errorf("output directory %v already exists and was not generated with incremental compilation", outputDir)
+	}
+	if err := json.Unmarshal(data, &cache.hashes); err != nil {
+		slog.Warn(fmt.Sprintf("Ignoring invalid incremental compilation cache %v due to %v", cache.manifestPath(), err.Error()))
+		cache.hashes = make(map[string]string)
+	}
+
+	// Files in the output directory that aren't cached will be regenerated
+	return cache, cache.clean(outputDir)
+}
+
+func (cache *artifactCache) manifestPath() string {
+	return filepath.Join(cache.outputDir, cacheDirName, cacheManifestName)
+}
+
+func (cache *artifactCache) relative(dir string) (string, error) {
+	rel, err := filepath.Rel(cache.outputDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", blueprint.Errorf("%v is not within the output directory %v", dir, cache.outputDir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Returns true if rel is a cached directory, or contains a cached directory
+func (cache *artifactCache) containsCached(rel string) bool {
+	for cached := range cache.hashes {
+		if cached == rel || strings.HasPrefix(cached, rel+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes everything within dir, except for cached directories and the cache manifest
+func (cache *artifactCache) clean(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return blueprint.Errorf("unable to read %v due to %v", dir, err.Error())
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		rel, err := cache.relative(path)
+		if err != nil {
+			return err
+		}
+		if rel == cacheDirName {
+			continue
+		}
+		if entry.IsDir() && cache.containsCached(rel) {
+			if _, isCached := cache.hashes[rel]; !isCached {
+				// An intermediate directory; its other contents are stale
+				if err := cache.clean(path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return blueprint.Errorf("unable to remove stale output %v due to %v", path, err.Error())
+		}
+	}
+	return nil
+}
+
+func (cache *artifactCache) generate(node IRNode, dir string, generate func() error) error {
+	rel, err := cache.relative(dir)
+	if err != nil {
+		return err
+	}
+
+	hash, err := cache.hash(node)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Unable to compute content hash of %v, it will be regenerated: %v", node.Name(), err.Error()))
+	}
+
+	if previous, exists := cache.hashes[rel]; exists && hash != "" && hash == previous {
+		// Retain this directory and any cached directories within it
+		for cached, cachedHash := range cache.hashes {
+			if cached == rel || strings.HasPrefix(cached, rel+"/") {
+				cache.generated[cached] = cachedHash
+			}
+		}
+		slog.Info(fmt.Sprintf("%v is unchanged, skipping generation of %v", node.Name(), dir))
+		return nil
+	}
+
+	// Clear out the previous artifacts, retaining nested cached directories that might be reused
+	if _, err := os.Stat(dir); err == nil {
+		if err := cache.clean(dir); err != nil {
+			return err
+		}
+	}
+	delete(cache.hashes, rel)
+	if err := generate(); err != nil {
+		return err
+	}
+	if hash != "" {
+		cache.generated[rel] = hash
+	}
+	return nil
+}
+
+func (cache *artifactCache) hash(node IRNode) (string, error) {
+	digest, err := cache.hasher.digest(reflect.ValueOf(node))
+	if err != nil {
+		return "", err
+	}
+	combined := sha256.New()
+	combined.Write(cache.compiler)
+	combined.Write(digest)
+	return hex.EncodeToString(combined.Sum(nil)), nil
+}
+
+// Removes cached directories that weren't used by this run, and saves the manifest
+func (cache *artifactCache) finish() error {
+	for rel := range cache.hashes {
+		if _, used := cache.generated[rel]; used {
+			continue
+		}
+		path := filepath.Join(cache.outputDir, filepath.FromSlash(rel))
+		if err := os.RemoveAll(path); err != nil {
+			return blueprint.Errorf("unable to remove stale output %v due to %v", path, err.Error())
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(cache.outputDir, cacheDirName), 0755); err != nil {
+		return blueprint.Errorf("unable to create %v due to %v", cacheDirName, err.Error())
+	}
+	data, err := json.MarshalIndent(cache.generated, "", "  ")
+	if err != nil {
+		return blueprint.Errorf("unable to serialize incremental compilation cache due to %v", err.Error())
+	}
+	if err := os.WriteFile(cache.manifestPath(), data, 0644); err != nil {
+		return blueprint.Errorf("unable to write %v due to %v", cache.manifestPath(), err.Error())
+	}
+	return nil
+}
+
+// Generates the artifacts of node into dir by calling generate.  generate must only write to dir.
+//
+// If incremental compilation is enabled (see [SetIncrementalCompilation]) then generation is skipped
+// if the inputs of node are unchanged since artifacts were last generated into dir.  The inputs of a
+// node are its content hash (see [ContentHash]) and the compiler binary, which includes all plugins
+// and their templates.
+//
+// Plugins should use this for nodes that generate self-contained directories of artifacts, such as
+// golang workspaces, processes, and containers.
+func GenerateCached(node IRNode, dir string, generate func() error) error {
+	if activeCache == nil {
+		return generate()
+	}
+	return activeCache.generate(node, dir, generate)
+}
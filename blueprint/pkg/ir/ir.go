@@ -48,6 +48,22 @@ func (v *IRValue) String() string {
 	return "\"" + v.Value + "\""
 }
 
+// A namespace node is an IR node that contains other IR nodes, for example a golang process
+// or a linux container.  Namespace nodes implement NamespaceNode so that tools that traverse
+// the IR (e.g. to export or compare it) can discover the namespace's contents.
+type NamespaceNode interface {
+	IRNode
+
+	// Returns the type of the namespace, e.g. GolangProcessNode
+	GetNamespaceType() string
+
+	// Returns the nodes that this namespace receives as arguments from its parent namespace
+	GetArgNodes() []IRNode
+
+	// Returns the nodes that were built inside this namespace
+	GetChildNodes() []IRNode
+}
+
 // Most IRNodes can generate code artifacts but they do so in the context of some
 // [BuildContext].  A few IRNodes, however, can generate artifacts independent of
 // any external context.  Those IRNodes implement the ArtifactGenerator interface.
@@ -79,6 +95,21 @@ func (node *ApplicationNode) String() string {
 	return PrettyPrintNamespace(node.ApplicationName, "BlueprintApplication", nil, node.Children)
 }
 
+// Implements [NamespaceNode]
+func (node *ApplicationNode) GetNamespaceType() string {
+	return "BlueprintApplication"
+}
+
+// Implements [NamespaceNode]
+func (node *ApplicationNode) GetArgNodes() []IRNode {
+	return nil
+}
+
+// Implements [NamespaceNode]
+func (node *ApplicationNode) GetChildNodes() []IRNode {
+	return node.Children
+}
+
 func (app *ApplicationNode) GenerateArtifacts(dir string) error {
 	return defaultBuilders.buildAll(dir, app.Children)
 }
@@ -0,0 +1,333 @@
+// Package irdiff compares the IR of two Blueprint applications, e.g. the IR produced by two
+// different wiring specs of the same application.
+//
+// The comparison is structural: nodes are matched by name, so that a service that moves from
+// one process or container to another is reported as a placement change rather than as a node
+// being removed and another being added.  A [Diff] reports:
+//   - nodes that were added or removed
+//   - nodes whose placement (i.e. the namespaces that contain them) changed
+//   - nodes whose properties (type, kind, or description) changed, and addresses whose
+//     configuration changed
+//   - pointers whose client-side or server-side modifier chains changed
+//
+// Usage:
+//
+//	oldApp, err := oldSpec.BuildIR(nodes...)
+//	newApp, err := newSpec.BuildIR(nodes...)
+//	diff := irdiff.Compare(irexport.Export(oldSpec, oldApp), irexport.Export(newSpec, newApp))
+//	fmt.Println(diff.Text())
+//
+// The cmdbuilder plugin exposes this with its -diff flag.
+package irdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/irexport"
+)
+
+type (
+	// The differences between two exported IR graphs
+	Diff struct {
+		Old       string          `json:"old"`
+		New       string          `json:"new"`
+		Added     []NodeInfo      `json:"added"`
+		Removed   []NodeInfo      `json:"removed"`
+		Moved     []PlacementDiff `json:"moved"`
+		Changed   []PropertyDiff  `json:"changed"`
+		Modifiers []ModifierDiff  `json:"modifiers"`
+	}
+
+	// A node that exists in only one of the graphs.  Placements are the paths of the
+	// namespaces that contain the node, relative to the application; the empty string
+	// is the application itself.
+	NodeInfo struct {
+		Name       string   `json:"name"`
+		Kind       string   `json:"kind"`
+		Type       string   `json:"type"`
+		Placements []string `json:"placements"`
+	}
+
+	// A node whose placement changed
+	PlacementDiff struct {
+		Name string   `json:"name"`
+		Old  []string `json:"old"`
+		New  []string `json:"new"`
+	}
+
+	// A property of a node or address that changed
+	PropertyDiff struct {
+		Name     string `json:"name"`
+		Property string `json:"property"`
+		Old      string `json:"old"`
+		New      string `json:"new"`
+	}
+
+	// A pointer whose modifier chains changed
+	ModifierDiff struct {
+		Name   string   `json:"name"`
+		OldSrc []string `json:"old_src"`
+		NewSrc []string `json:"new_src"`
+		OldDst []string `json:"old_dst"`
+		NewDst []string `json:"new_dst"`
+	}
+)
+
+// The instances of a named node within a graph
+type nodeInstances struct {
+	kinds        map[string]struct{}
+	types        map[string]struct{}
+	descriptions map[string]struct{}
+	placements   map[string]struct{}
+}
+
+// Compares two exported IR graphs.  oldGraph and newGraph should be exported with their wiring
+// specs (see [irexport.Export]) in order to compare pointer modifier chains.
+func Compare(oldGraph, newGraph *irexport.Graph) *Diff {
+	d := &Diff{
+		Old:       oldGraph.Application,
+		New:       newGraph.Application,
+		Added:     []NodeInfo{},
+		Removed:   []NodeInfo{},
+		Moved:     []PlacementDiff{},
+		Changed:   []PropertyDiff{},
+		Modifiers: []ModifierDiff{},
+	}
+
+	oldNodes, newNodes := collectNodes(oldGraph), collectNodes(newGraph)
+	for _, name := range unionKeys(oldNodes, newNodes) {
+		oldNode, inOld := oldNodes[name]
+		newNode, inNew := newNodes[name]
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, newNode.info(name))
+		case !inNew:
+			d.Removed = append(d.Removed, oldNode.info(name))
+		default:
+			if oldPlacements, newPlacements := sortedSet(oldNode.placements), sortedSet(newNode.placements); !equal(oldPlacements, newPlacements) {
+				d.Moved = append(d.Moved, PlacementDiff{Name: name, Old: oldPlacements, New: newPlacements})
+			}
+			d.compareProperty(name, "kind", oldNode.kinds, newNode.kinds)
+			d.compareProperty(name, "type", oldNode.types, newNode.types)
+			d.compareProperty(name, "description", oldNode.descriptions, newNode.descriptions)
+		}
+	}
+
+	d.compareAddresses(oldGraph, newGraph)
+	d.comparePointers(oldGraph, newGraph)
+	return d
+}
+
+func collectNodes(g *irexport.Graph) map[string]*nodeInstances {
+	nodes := make(map[string]*nodeInstances)
+	for _, node := range g.Nodes {
+		if node.ID == g.Application {
+			continue
+		}
+		instances, exists := nodes[node.Name]
+		if !exists {
+			instances = &nodeInstances{
+				kinds:        make(map[string]struct{}),
+				types:        make(map[string]struct{}),
+				descriptions: make(map[string]struct{}),
+				placements:   make(map[string]struct{}),
+			}
+			nodes[node.Name] = instances
+		}
+		instances.kinds[node.Kind] = struct{}{}
+		instances.types[node.Type] = struct{}{}
+		instances.descriptions[node.Description] = struct{}{}
+		instances.placements[relativePath(g, node.Namespace)] = struct{}{}
+	}
+	return nodes
+}
+
+// Namespace IDs are prefixed with the application name, which we strip so that applications with different names can be compared
+func relativePath(g *irexport.Graph, namespaceID string) string {
+	if namespaceID == g.Application {
+		return ""
+	}
+	return strings.TrimPrefix(namespaceID, g.Application+"/")
+}
+
+func (n *nodeInstances) info(name string) NodeInfo {
+	return NodeInfo{
+		Name:       name,
+		Kind:       strings.Join(sortedSet(n.kinds), ", "),
+		Type:       strings.Join(sortedSet(n.types), ", "),
+		Placements: sortedSet(n.placements),
+	}
+}
+
+func (d *Diff) compareProperty(name, property string, oldValues, newValues map[string]struct{}) {
+	oldValue, newValue := strings.Join(sortedSet(oldValues), "; "), strings.Join(sortedSet(newValues), "; ")
+	if oldValue != newValue {
+		d.Changed = append(d.Changed, PropertyDiff{Name: name, Property: property, Old: oldValue, New: newValue})
+	}
+}
+
+func (d *Diff) compareAddresses(oldGraph, newGraph *irexport.Graph) {
+	addrProperties := func(g *irexport.Graph) map[string]map[string]string {
+		addrs := make(map[string]map[string]string)
+		for _, addr := range g.Addresses {
+			addrs[addr.Name] = map[string]string{
+				"server":     relativePath(g, addr.Server),
+				"bind_value": addr.BindValue,
+				"dial_value": addr.DialValue,
+			}
+		}
+		return addrs
+	}
+	oldAddrs, newAddrs := addrProperties(oldGraph), addrProperties(newGraph)
+	for _, name := range unionKeys(oldAddrs, newAddrs) {
+		oldAddr, inOld := oldAddrs[name]
+		newAddr, inNew := newAddrs[name]
+		if !inOld || !inNew {
+			// Reported as an added or removed node
+			continue
+		}
+		for _, property := range unionKeys(oldAddr, newAddr) {
+			if oldAddr[property] != newAddr[property] {
+				d.Changed = append(d.Changed, PropertyDiff{Name: name, Property: property, Old: oldAddr[property], New: newAddr[property]})
+			}
+		}
+	}
+}
+
+func (d *Diff) comparePointers(oldGraph, newGraph *irexport.Graph) {
+	pointers := func(g *irexport.Graph) map[string]irexport.Pointer {
+		ptrs := make(map[string]irexport.Pointer)
+		for _, ptr := range g.Pointers {
+			ptrs[ptr.Name] = ptr
+		}
+		return ptrs
+	}
+	oldPtrs, newPtrs := pointers(oldGraph), pointers(newGraph)
+	for _, name := range unionKeys(oldPtrs, newPtrs) {
+		oldPtr, inOld := oldPtrs[name]
+		newPtr, inNew := newPtrs[name]
+		if !inOld || !inNew {
+			// Reported as an added or removed node
+			continue
+		}
+		if equal(oldPtr.SrcModifiers, newPtr.SrcModifiers) && equal(oldPtr.DstModifiers, newPtr.DstModifiers) {
+			continue
+		}
+		d.Modifiers = append(d.Modifiers, ModifierDiff{
+			Name:   name,
+			OldSrc: nonNil(oldPtr.SrcModifiers),
+			NewSrc: nonNil(newPtr.SrcModifiers),
+			OldDst: nonNil(oldPtr.DstModifiers),
+			NewDst: nonNil(newPtr.DstModifiers),
+		})
+	}
+}
+
+// Returns true if there are no differences
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0 && len(d.Changed) == 0 && len(d.Modifiers) == 0
+}
+
+// Serializes the diff as an indented JSON document
+func (d *Diff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Renders the diff as human-readable text
+func (d *Diff) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.Old, d.New)
+	if d.Empty() {
+		b.WriteString("No differences\n")
+		return b.String()
+	}
+	if len(d.Added) > 0 {
+		b.WriteString("\nAdded nodes:\n")
+		for _, n := range d.Added {
+			fmt.Fprintf(&b, "  + %s (%s) in %s\n", n.Name, n.Type, placements(n.Placements))
+		}
+	}
+	if len(d.Removed) > 0 {
+		b.WriteString("\nRemoved nodes:\n")
+		for _, n := range d.Removed {
+			fmt.Fprintf(&b, "  - %s (%s) in %s\n", n.Name, n.Type, placements(n.Placements))
+		}
+	}
+	if len(d.Moved) > 0 {
+		b.WriteString("\nPlacement changes:\n")
+		for _, m := range d.Moved {
+			fmt.Fprintf(&b, "  ~ %s: %s -> %s\n", m.Name, placements(m.Old), placements(m.New))
+		}
+	}
+	if len(d.Changed) > 0 {
+		b.WriteString("\nProperty changes:\n")
+		for _, c := range d.Changed {
+			fmt.Fprintf(&b, "  ~ %s %s: %q -> %q\n", c.Name, c.Property, c.Old, c.New)
+		}
+	}
+	if len(d.Modifiers) > 0 {
+		b.WriteString("\nModifier chain changes:\n")
+		for _, m := range d.Modifiers {
+			if !equal(m.OldSrc, m.NewSrc) {
+				fmt.Fprintf(&b, "  ~ %s client: [%s] -> [%s]\n", m.Name, strings.Join(m.OldSrc, " -> "), strings.Join(m.NewSrc, " -> "))
+			}
+			if !equal(m.OldDst, m.NewDst) {
+				fmt.Fprintf(&b, "  ~ %s server: [%s] -> [%s]\n", m.Name, strings.Join(m.OldDst, " -> "), strings.Join(m.NewDst, " -> "))
+			}
+		}
+	}
+	return b.String()
+}
+
+func placements(paths []string) string {
+	var names []string
+	for _, path := range paths {
+		if path == "" {
+			path = "(application)"
+		}
+		names = append(names, path)
+	}
+	return strings.Join(names, ", ")
+}
+
+func unionKeys[V1, V2 any](a map[string]V1, b map[string]V2) []string {
+	keys := make(map[string]struct{})
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return sortedSet(keys)
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
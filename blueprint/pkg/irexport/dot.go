@@ -0,0 +1,127 @@
+package irexport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint/stringutil"
+)
+
+// Renders the graph as a Graphviz DOT document.
+//
+// Namespaces are rendered as nested clusters.  Namespace arguments are omitted, since
+// they duplicate the edges of the nodes within the namespace.  Each address is rendered
+// as a dashed edge from its dial config to its bind config, and pointer modifier chains
+// are listed in a separate cluster.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", quote(g.Application))
+	b.WriteString("  compound=true;\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=8];\n")
+
+	namespaces := make(map[string]Namespace)
+	for _, ns := range g.Namespaces {
+		namespaces[ns.ID] = ns
+	}
+	nodes := make(map[string]Node)
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+
+	// The root namespace is the application itself, whose contents are rendered at the top level
+	if root, exists := namespaces[g.Application]; exists {
+		for _, child := range root.Children {
+			b.WriteString(stringutil.Indent(g.dotNode(nodes[child], namespaces, nodes), 2))
+			b.WriteString("\n")
+		}
+	}
+
+	for _, edge := range g.Edges {
+		if edge.Label == "arg" {
+			continue
+		}
+		from, to := edge.From, edge.To
+		if from == g.Application || to == g.Application {
+			continue
+		}
+		var attrs []string
+		attrs = append(attrs, "label="+quote(edge.Label))
+		if _, isNamespace := namespaces[from]; isNamespace {
+			attrs = append(attrs, "ltail="+quote(clusterID(from)))
+			from = anchorID(from)
+		}
+		if _, isNamespace := namespaces[to]; isNamespace {
+			attrs = append(attrs, "lhead="+quote(clusterID(to)))
+			to = anchorID(to)
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", quote(from), quote(to), strings.Join(attrs, ", "))
+	}
+
+	for _, addr := range g.Addresses {
+		if addr.Dial != "" && addr.Bind != "" {
+			fmt.Fprintf(&b, "  %s -> %s [label=%s, style=dashed, color=gray40];\n", quote(addr.Dial), quote(addr.Bind), quote(addr.Name))
+		}
+	}
+
+	if len(g.Pointers) > 0 {
+		fmt.Fprintf(&b, "  subgraph %s {\n", quote("cluster_pointers"))
+		b.WriteString("    label=\"Pointers\";\n")
+		b.WriteString("    style=dotted;\n")
+		for _, ptr := range g.Pointers {
+			label := fmt.Sprintf("%s: [%s] -> [%s]", ptr.Name, strings.Join(ptr.SrcModifiers, " -> "), strings.Join(ptr.DstModifiers, " -> "))
+			fmt.Fprintf(&b, "    %s [shape=plaintext, label=%s];\n", quote("pointer:"+ptr.Name), quote(label))
+		}
+		b.WriteString("  }\n")
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (g *Graph) dotNode(node Node, namespaces map[string]Namespace, nodes map[string]Node) string {
+	ns, isNamespace := namespaces[node.ID]
+	if !isNamespace {
+		return fmt.Sprintf("%s [label=%s, %s];", quote(node.ID), quote(node.Description), nodeStyle(node.Kind))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "subgraph %s {\n", quote(clusterID(node.ID)))
+	fmt.Fprintf(&b, "  label=%s;\n", quote(fmt.Sprintf("%s (%s)", ns.Name, ns.Type)))
+	fmt.Fprintf(&b, "  %s [shape=point, style=invis];\n", quote(anchorID(node.ID)))
+	for _, child := range ns.Children {
+		b.WriteString(stringutil.Indent(g.dotNode(nodes[child], namespaces, nodes), 2))
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func nodeStyle(kind string) string {
+	switch kind {
+	case KindConfig:
+		return "shape=note"
+	case KindMetadata:
+		return "shape=box, style=dashed"
+	case KindValue:
+		return "shape=plaintext"
+	default:
+		return "shape=box, style=rounded"
+	}
+}
+
+func clusterID(namespaceID string) string {
+	return "cluster_" + namespaceID
+}
+
+func anchorID(namespaceID string) string {
+	return namespaceID + ".anchor"
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "\"" + s + "\""
+}
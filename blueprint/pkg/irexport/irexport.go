@@ -0,0 +1,344 @@
+// Package irexport exports the IR of a Blueprint application as a graph.
+//
+// The exported graph contains the application's namespaces and the nodes built within each
+// namespace; the edges between nodes; the bind and dial configuration of each address; and,
+// if the wiring spec is provided, the client and server modifier chains of each pointer.
+//
+// The graph can be rendered as a Graphviz DOT document, e.g. to draw architecture diagrams,
+// or serialized as a JSON document.  The JSON document is deterministic, so that it can be
+// checked in and diffed to review topology changes.
+//
+// Usage:
+//
+//	app, err := spec.BuildIR("my_service")
+//	graph := irexport.Export(spec, app)
+//	dot := graph.DOT()
+//	json, err := graph.JSON()
+//
+// [WriteFiles] is a convenience method that writes both documents to a directory.
+package irexport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+)
+
+// The kinds of node that can appear in an exported [Graph]
+const (
+	KindNamespace = "namespace"
+	KindConfig    = "config"
+	KindMetadata  = "metadata"
+	KindValue     = "value"
+	KindNode      = "node"
+)
+
+type (
+	// The exported IR of an application
+	Graph struct {
+		Application string      `json:"application"`
+		Namespaces  []Namespace `json:"namespaces"`
+		Nodes       []Node      `json:"nodes"`
+		Edges       []Edge      `json:"edges"`
+		Addresses   []Address   `json:"addresses"`
+		Pointers    []Pointer   `json:"pointers"`
+	}
+
+	// A namespace node, such as the application, a golang process, or a container.
+	// Args and Children contain node IDs.
+	Namespace struct {
+		ID       string   `json:"id"`
+		Name     string   `json:"name"`
+		Type     string   `json:"type"`
+		Parent   string   `json:"parent,omitempty"`
+		Args     []string `json:"args"`
+		Children []string `json:"children"`
+	}
+
+	// An IR node.  The ID of a node is its name, qualified by the IDs of the
+	// namespaces that it resides in, e.g. "app/leaf_proc/leaf_service".
+	Node struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Kind        string `json:"kind"`
+		Type        string `json:"type"`
+		Namespace   string `json:"namespace,omitempty"`
+		Description string `json:"description"`
+	}
+
+	// A reference from one node to another.  Label is the name of the struct
+	// field that holds the reference, or "arg" for namespace arguments.
+	Edge struct {
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Label string `json:"label"`
+	}
+
+	// An address, along with the config nodes that the server binds to and
+	// that clients dial.  BindValue and DialValue are only set if the
+	// config nodes have been assigned a value.
+	Address struct {
+		Name      string `json:"name"`
+		Server    string `json:"server,omitempty"`
+		Bind      string `json:"bind,omitempty"`
+		BindValue string `json:"bind_value,omitempty"`
+		Dial      string `json:"dial,omitempty"`
+		DialValue string `json:"dial_value,omitempty"`
+	}
+
+	// The modifier chains of a pointer, as declared in the wiring spec
+	Pointer struct {
+		Name         string   `json:"name"`
+		SrcModifiers []string `json:"src_modifiers"`
+		DstModifiers []string `json:"dst_modifiers"`
+	}
+)
+
+type exporter struct {
+	graph      *Graph
+	ids        map[any]string
+	nodes      map[string]ir.IRNode
+	namespaces map[string]*Namespace
+	edges      map[Edge]struct{}
+}
+
+// Exports the IR of app as a [Graph].
+//
+// spec is optional; if it is provided then the pointers that were declared in spec will
+// also be included in the exported graph.
+func Export(spec wiring.WiringSpec, app *ir.ApplicationNode) *Graph {
+	e := &exporter{
+		graph: &Graph{
+			Application: app.Name(),
+			Namespaces:  []Namespace{},
+			Nodes:       []Node{},
+			Edges:       []Edge{},
+			Addresses:   []Address{},
+			Pointers:    []Pointer{},
+		},
+		ids:        make(map[any]string),
+		nodes:      make(map[string]ir.IRNode),
+		namespaces: make(map[string]*Namespace),
+		edges:      make(map[Edge]struct{}),
+	}
+
+	// First assign IDs to every node, since edges can point to nodes in any namespace
+	e.addNode(app, "")
+	e.addEdges()
+	e.addAddresses()
+	if spec != nil {
+		e.addPointers(spec)
+	}
+
+	g := e.graph
+	for _, namespace := range e.namespaces {
+		sort.Strings(namespace.Args)
+		sort.Strings(namespace.Children)
+		g.Namespaces = append(g.Namespaces, *namespace)
+	}
+	sort.Slice(g.Namespaces, func(i, j int) bool { return g.Namespaces[i].ID < g.Namespaces[j].ID })
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Label < b.Label
+	})
+	sort.Slice(g.Addresses, func(i, j int) bool { return g.Addresses[i].Name < g.Addresses[j].Name })
+	return g
+}
+
+// Serializes the graph as an indented JSON document
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// Writes the IR of app to dir as the files ir.dot and ir.json.  See [Export].
+func WriteFiles(dir string, spec wiring.WiringSpec, app *ir.ApplicationNode) error {
+	graph := Export(spec, app)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return blueprint.Errorf("unable to create IR export directory %v due to %v", dir, err.Error())
+	}
+	jsonBytes, err := graph.JSON()
+	if err != nil {
+		return blueprint.Errorf("unable to serialize IR of %v to JSON due to %v", app.Name(), err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ir.json"), jsonBytes, 0644); err != nil {
+		return blueprint.Errorf("unable to write IR of %v to JSON due to %v", app.Name(), err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ir.dot"), []byte(graph.DOT()), 0644); err != nil {
+		return blueprint.Errorf("unable to write IR of %v to DOT due to %v", app.Name(), err.Error())
+	}
+	return nil
+}
+
+// Nodes are usually pointers, but we fall back to names for any non-comparable node types
+func nodeKey(node ir.IRNode) any {
+	if reflect.TypeOf(node).Comparable() {
+		return node
+	}
+	return node.Name()
+}
+
+func (e *exporter) addNode(node ir.IRNode, namespaceID string) string {
+	if id, exists := e.ids[nodeKey(node)]; exists {
+		return id
+	}
+	id := node.Name()
+	if namespaceID != "" {
+		id = namespaceID + "/" + node.Name()
+	}
+	// Disambiguate distinct nodes that share a name within a namespace
+	for i := 2; e.nodes[id] != nil; i++ {
+		id = namespaceID + "/" + node.Name() + "#" + strconv.Itoa(i)
+	}
+	e.ids[nodeKey(node)] = id
+	e.nodes[id] = node
+
+	e.graph.Nodes = append(e.graph.Nodes, Node{
+		ID:          id,
+		Name:        node.Name(),
+		Kind:        kindOf(node),
+		Type:        typeOf(node),
+		Namespace:   namespaceID,
+		Description: describe(node),
+	})
+
+	if parent, exists := e.namespaces[namespaceID]; exists {
+		parent.Children = append(parent.Children, id)
+	}
+
+	if ns, isNamespace := node.(ir.NamespaceNode); isNamespace {
+		e.namespaces[id] = &Namespace{
+			ID:       id,
+			Name:     node.Name(),
+			Type:     ns.GetNamespaceType(),
+			Parent:   namespaceID,
+			Args:     []string{},
+			Children: []string{},
+		}
+		for _, child := range ns.GetChildNodes() {
+			e.addNode(child, id)
+		}
+	}
+	return id
+}
+
+func (e *exporter) addEdge(from, to, label string) {
+	edge := Edge{From: from, To: to, Label: label}
+	if _, exists := e.edges[edge]; !exists {
+		e.edges[edge] = struct{}{}
+		e.graph.Edges = append(e.graph.Edges, edge)
+	}
+}
+
+func (e *exporter) addEdges() {
+	// Iterate over a snapshot, because referenced nodes that don't reside in any namespace (e.g. values) get added along the way
+	nodes := append([]Node(nil), e.graph.Nodes...)
+	for _, n := range nodes {
+		node := e.nodes[n.ID]
+		if ns, isNamespace := node.(ir.NamespaceNode); isNamespace {
+			namespace := e.namespaces[n.ID]
+			for _, arg := range ns.GetArgNodes() {
+				argID := e.addNode(arg, n.Namespace)
+				namespace.Args = append(namespace.Args, argID)
+				e.addEdge(n.ID, argID, "arg")
+			}
+			continue
+		}
+		for _, ref := range references(node) {
+			e.addEdge(n.ID, e.addNode(ref.node, n.Namespace), ref.label)
+		}
+	}
+}
+
+func (e *exporter) addAddresses() {
+	addrs := make(map[string]*Address)
+	getAddr := func(name string) *Address {
+		if _, exists := addrs[name]; !exists {
+			addrs[name] = &Address{Name: name}
+		}
+		return addrs[name]
+	}
+	for id, node := range e.nodes {
+		switch n := node.(type) {
+		case address.Node:
+			addr := getAddr(n.Name())
+			if dst := n.GetDestination(); dst != nil {
+				addr.Server = e.ids[nodeKey(dst)]
+			}
+		case *address.BindConfig:
+			addr := getAddr(n.AddressName)
+			addr.Bind = id
+			if n.HasValue() {
+				addr.BindValue = n.Value()
+			}
+		case *address.DialConfig:
+			addr := getAddr(n.AddressName)
+			addr.Dial = id
+			if n.HasValue() {
+				addr.DialValue = n.Value()
+			}
+		}
+	}
+	for _, addr := range addrs {
+		e.graph.Addresses = append(e.graph.Addresses, *addr)
+	}
+}
+
+func (e *exporter) addPointers(spec wiring.WiringSpec) {
+	names := spec.Defs()
+	sort.Strings(names)
+	for _, name := range names {
+		if ptr := pointer.GetPointer(spec, name); ptr != nil {
+			e.graph.Pointers = append(e.graph.Pointers, Pointer{
+				Name:         name,
+				SrcModifiers: append([]string{}, ptr.SrcModifiers()...),
+				DstModifiers: append([]string{}, ptr.DstModifiers()...),
+			})
+		}
+	}
+}
+
+func kindOf(node ir.IRNode) string {
+	switch node.(type) {
+	case ir.NamespaceNode:
+		return KindNamespace
+	case ir.IRConfig:
+		return KindConfig
+	case ir.IRMetadata:
+		return KindMetadata
+	case *ir.IRValue:
+		return KindValue
+	default:
+		return KindNode
+	}
+}
+
+func typeOf(node ir.IRNode) string {
+	t := reflect.TypeOf(node)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.String()
+}
+
+// The first line of the node's String representation
+func describe(node ir.IRNode) string {
+	desc, _, _ := strings.Cut(node.String(), "\n")
+	return strings.TrimSuffix(strings.TrimSpace(desc), " {")
+}
@@ -0,0 +1,87 @@
+package irexport
+
+import (
+	"reflect"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+)
+
+type reference struct {
+	label string
+	node  ir.IRNode
+}
+
+var irNodeType = reflect.TypeOf((*ir.IRNode)(nil)).Elem()
+
+// IR nodes don't expose their dependencies through a common interface, so we discover them by
+// looking for exported struct fields that hold IR nodes, or slices or maps of IR nodes.
+//
+// Embedded interfaces are skipped because plugins embed them to declare which interfaces a node
+// implements, rather than to reference another node.  Embedded structs are searched recursively.
+func references(node ir.IRNode) []reference {
+	v := reflect.ValueOf(node)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var refs []reference
+	collectStructRefs(node, v, &refs)
+	return refs
+}
+
+func collectStructRefs(self ir.IRNode, v reflect.Value, refs *[]reference) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		if field.Anonymous {
+			if value.Kind() == reflect.Struct {
+				collectStructRefs(self, value, refs)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < value.Len(); j++ {
+				collectRef(self, field.Name, value.Index(j), refs)
+			}
+		case reflect.Map:
+			iter := value.MapRange()
+			for iter.Next() {
+				collectRef(self, field.Name, iter.Value(), refs)
+			}
+		default:
+			collectRef(self, field.Name, value, refs)
+		}
+	}
+}
+
+func collectRef(self ir.IRNode, label string, value reflect.Value, refs *[]reference) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return
+		}
+	default:
+		return
+	}
+	if !value.CanInterface() {
+		return
+	}
+	if !value.Type().Implements(irNodeType) && !(value.Kind() == reflect.Interface && value.Elem().Type().Implements(irNodeType)) {
+		return
+	}
+	node := value.Interface().(ir.IRNode)
+	if nodeKey(node) == nodeKey(self) {
+		return
+	}
+	*refs = append(*refs, reference{label: label, node: node})
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"golang.org/x/exp/slog"
 )
 
 // Builds the IR of an application using the definitions of the provided spec.  Returns
@@ -20,6 +21,15 @@ func BuildApplicationIR(spec WiringSpec, name string, nodesToInstantiate ...stri
 	// Create the root application namespace
 	app := &ir.ApplicationNode{ApplicationName: name}
 
+	// Validate the wiring spec before building anything, so that errors point back to the wiring spec
+	diagnostics := spec.Validate(nodesToInstantiate...)
+	for _, d := range diagnostics.Warnings() {
+		slog.Warn(d.String())
+	}
+	if err := diagnostics.Err(); err != nil {
+		return app, err
+	}
+
 	namespace := &namespaceimpl{
 		NamespaceName:   name,
 		NamespaceType:   "BlueprintApplication",
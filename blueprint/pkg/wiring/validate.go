@@ -0,0 +1,362 @@
+package wiring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint/logging"
+)
+
+// The severity of a [Diagnostic].  Only errors cause validation to fail; warnings are informational.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// The kind of problem reported by a [Diagnostic]
+type DiagnosticKind string
+
+const (
+	DanglingReference         DiagnosticKind = "dangling reference"
+	AliasCycle                DiagnosticKind = "alias cycle"
+	Unreachable               DiagnosticKind = "unreachable"
+	UndefinedNode             DiagnosticKind = "undefined node"
+	PointerWithoutDestination DiagnosticKind = "pointer without destination"
+	AddressWithoutServer      DiagnosticKind = "address without server"
+)
+
+// A problem with a wiring spec, found by [WiringSpec.Validate].
+//
+// File and Line are the location in the wiring spec of the offending definition.  They are
+// empty if the location is unknown.
+type Diagnostic struct {
+	Severity Severity
+	Kind     DiagnosticKind
+	Name     string // The name of the offending definition
+	Message  string
+	File     string
+	Line     int
+}
+
+// Formats the diagnostic in the conventional file:line: severity: message format
+func (d Diagnostic) String() string {
+	if d.File == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Message)
+}
+
+// The diagnostics reported by [WiringSpec.Validate]
+type Diagnostics []Diagnostic
+
+// Returns only the diagnostics with [SeverityError]
+func (ds Diagnostics) Errors() Diagnostics {
+	var errs Diagnostics
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	return errs
+}
+
+// Returns only the diagnostics with [SeverityWarning]
+func (ds Diagnostics) Warnings() Diagnostics {
+	var warnings Diagnostics
+	for _, d := range ds {
+		if d.Severity == SeverityWarning {
+			warnings = append(warnings, d)
+		}
+	}
+	return warnings
+}
+
+// Returns a [ValidationError] if there are any diagnostics with [SeverityError]; nil otherwise
+func (ds Diagnostics) Err() error {
+	if errs := ds.Errors(); len(errs) > 0 {
+		return &ValidationError{Diagnostics: errs}
+	}
+	return nil
+}
+
+// Returned by [WiringSpec.BuildIR] when validation of the wiring spec fails
+type ValidationError struct {
+	Diagnostics Diagnostics
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("wiring spec validation failed:")
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n  ")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+// A reference from one definition to another, declared with [AddReference].
+type Reference struct {
+	// The name of the referenced node
+	To string
+
+	// The kind of diagnostic to report if To is not defined.  Defaults to [DanglingReference]
+	Kind DiagnosticKind
+
+	// If true, then a missing node is not reported.  Plugins use this for references that might
+	// legitimately not exist in the wiring spec, e.g. arguments that could be hard-coded values.
+	Optional bool
+}
+
+func (ref Reference) String() string {
+	return ref.To
+}
+
+var prop_REFERENCES = "references"
+
+// Declares that the node name references (i.e. will get from its namespace) the node ref.To.
+//
+// This method is intended for use by plugins when they define nodes.  BuildFuncs can get
+// arbitrary nodes, so Blueprint cannot determine the dependencies of a node without building it.
+// Declaring references enables [WiringSpec.Validate] to check, prior to building the IR, that
+// referenced nodes exist and that all defined nodes are reachable.
+func AddReference(spec WiringSpec, name string, ref Reference) {
+	if ref.Kind == "" {
+		ref.Kind = DanglingReference
+	}
+	spec.AddProperty(name, prop_REFERENCES, ref)
+}
+
+// Validates the wiring spec without building any IR.  Checks for:
+//   - references and aliases to nodes that are not defined
+//   - cycles of aliases
+//   - pointers whose destination is not defined
+//   - addresses whose server is not defined
+//   - properties set for nodes that are never defined
+//   - definitions that are not reachable from nodesToInstantiate (reported as warnings, except for
+//     metadata definitions; see [WiringOpts])
+//
+// If nodesToInstantiate is empty then all nodes are considered to be instantiated.
+func (spec *wiringSpecImpl) Validate(nodesToInstantiate ...string) Diagnostics {
+	v := &validator{spec: spec}
+	v.checkAliases()
+	v.checkReferences()
+	v.checkUndefined()
+	if len(nodesToInstantiate) > 0 {
+		v.checkReachability(nodesToInstantiate)
+	}
+	for _, name := range nodesToInstantiate {
+		if _, cycle := v.resolve(name); cycle {
+			continue
+		}
+		if !v.isDefined(name) {
+			v.report(SeverityError, DanglingReference, name, nil, "%s was requested but is not defined in the wiring spec", name)
+		}
+	}
+
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i], v.diagnostics[j]
+		if a.Severity != b.Severity {
+			return a.Severity == SeverityError
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return v.diagnostics
+}
+
+type validator struct {
+	spec        *wiringSpecImpl
+	diagnostics Diagnostics
+	missing     map[string]struct{} // Nodes already reported as missing
+}
+
+func (v *validator) report(severity Severity, kind DiagnosticKind, name string, callstack *logging.Callstack, format string, args ...any) {
+	d := Diagnostic{
+		Severity: severity,
+		Kind:     kind,
+		Name:     name,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if callsite := callstack.WiringCallsite(); callsite != nil {
+		d.File = callsite.Filename()
+		d.Line = callsite.LineNumber
+	}
+	v.diagnostics = append(v.diagnostics, d)
+}
+
+// Follows aliases starting from name.  Returns the final name, and true if the aliases contain a cycle.
+func (v *validator) resolve(name string) (string, bool) {
+	seen := map[string]struct{}{}
+	for {
+		if _, cycle := seen[name]; cycle {
+			return name, true
+		}
+		seen[name] = struct{}{}
+		next, isAlias := v.spec.aliases[name]
+		if !isAlias {
+			return name, false
+		}
+		name = next
+	}
+}
+
+// Reports whether name resolves to a node with a BuildFunc
+func (v *validator) isDefined(name string) bool {
+	resolved, cycle := v.resolve(name)
+	if cycle {
+		return false
+	}
+	def, exists := v.spec.defs[resolved]
+	return exists && def.Build != nil
+}
+
+func (v *validator) callstackOf(name string) *logging.Callstack {
+	if def, exists := v.spec.defs[name]; exists {
+		if callsites := def.Properties["callsite"]; len(callsites) > 0 {
+			if callstack, isCallstack := callsites[0].(*logging.Callstack); isCallstack {
+				return callstack
+			}
+		}
+		return def.created.Callstack()
+	}
+	return v.spec.aliasCallsites[name].Callstack()
+}
+
+func (v *validator) markMissing(name string) bool {
+	if v.missing == nil {
+		v.missing = make(map[string]struct{})
+	}
+	resolved, _ := v.resolve(name)
+	if _, reported := v.missing[resolved]; reported {
+		return false
+	}
+	v.missing[resolved] = struct{}{}
+	return true
+}
+
+func (v *validator) checkAliases() {
+	reported := make(map[string]struct{})
+	for _, alias := range sortedKeys(v.spec.aliases) {
+		resolved, cycle := v.resolve(alias)
+		if !cycle {
+			continue
+		}
+		// Report each cycle only once, attributed to the alias where the cycle closes
+		if _, done := reported[resolved]; done {
+			continue
+		}
+		var cycleNames []string
+		for name := resolved; ; {
+			reported[name] = struct{}{}
+			cycleNames = append(cycleNames, name)
+			name = v.spec.aliases[name]
+			if name == resolved {
+				break
+			}
+		}
+		cycleNames = append(cycleNames, resolved)
+		v.report(SeverityError, AliasCycle, resolved, v.callstackOf(resolved), "aliases form a cycle: %s", strings.Join(cycleNames, " -> "))
+	}
+}
+
+func (v *validator) checkReferences() {
+	// Each missing node is only reported once, so report the more specific kinds of reference first
+	v.checkReferencesOfKind(func(kind DiagnosticKind) bool { return kind != DanglingReference })
+	v.checkReferencesOfKind(func(kind DiagnosticKind) bool { return kind == DanglingReference })
+
+	// Aliases to missing nodes, that haven't already been reported through a reference
+	for _, alias := range sortedKeys(v.spec.aliases) {
+		resolved, cycle := v.resolve(alias)
+		if cycle || v.isDefined(resolved) || !v.markMissing(resolved) {
+			continue
+		}
+		v.report(SeverityError, DanglingReference, alias, v.callstackOf(alias), "alias %s resolves to %s, which is not defined in the wiring spec", alias, resolved)
+	}
+}
+
+func (v *validator) checkReferencesOfKind(include func(DiagnosticKind) bool) {
+	for _, name := range sortedKeys(v.spec.defs) {
+		def := v.spec.defs[name]
+		for _, r := range def.Properties[prop_REFERENCES] {
+			ref, isRef := r.(Reference)
+			if !isRef || !include(ref.Kind) {
+				continue
+			}
+			if _, cycle := v.resolve(ref.To); cycle || ref.Optional || v.isDefined(ref.To) {
+				continue
+			}
+			if !v.markMissing(ref.To) {
+				continue
+			}
+			switch ref.Kind {
+			case PointerWithoutDestination:
+				v.report(SeverityError, ref.Kind, name, v.callstackOf(name), "pointer %s has no destination; %s is not defined in the wiring spec", name, ref.To)
+			case AddressWithoutServer:
+				v.report(SeverityError, ref.Kind, name, v.callstackOf(name), "address %s has no matching server; %s is not defined in the wiring spec", name, ref.To)
+			default:
+				v.report(SeverityError, ref.Kind, name, v.callstackOf(name), "%s references %s, which is not defined in the wiring spec", name, ref.To)
+			}
+		}
+	}
+}
+
+// Nodes that have properties but were never defined
+func (v *validator) checkUndefined() {
+	for _, name := range sortedKeys(v.spec.defs) {
+		if def := v.spec.defs[name]; def.Build == nil {
+			if _, reported := v.missing[name]; reported {
+				continue
+			}
+			v.report(SeverityWarning, UndefinedNode, name, v.callstackOf(name), "properties are set for %s but it is never defined in the wiring spec", name)
+		}
+	}
+}
+
+func (v *validator) checkReachability(roots []string) {
+	reached := make(map[string]struct{})
+	var visit func(name string)
+	visit = func(name string) {
+		if _, done := reached[name]; done {
+			return
+		}
+		reached[name] = struct{}{}
+		if next, isAlias := v.spec.aliases[name]; isAlias {
+			visit(next)
+			return
+		}
+		if def, exists := v.spec.defs[name]; exists {
+			for _, r := range def.Properties[prop_REFERENCES] {
+				if ref, isRef := r.(Reference); isRef {
+					visit(ref.To)
+				}
+			}
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+
+	for _, name := range sortedKeys(v.spec.defs) {
+		if def := v.spec.defs[name]; def.Build == nil || def.Options.Metadata {
+			continue
+		}
+		if _, isReached := reached[name]; isReached {
+			continue
+		}
+		v.report(SeverityWarning, Unreachable, name, v.callstackOf(name), "%s is defined but is not reachable from the nodes being built (%s)", name, strings.Join(roots, ", "))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
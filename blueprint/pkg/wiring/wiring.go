@@ -63,6 +63,11 @@ type WiringSpec interface {
 	AddError(err error) // Used by plugins to signify an error; the error will be returned by a call to Err or GetBlueprint
 	Err() error         // Gets an error if there is currently one
 
+	// Checks the wiring spec for problems such as dangling references and alias cycles, without building
+	// any IR.  Diagnostics refer back to the wiring spec line of the offending definition.  BuildIR
+	// implicitly calls Validate and fails if any errors are reported.
+	Validate(nodesToInstantiate ...string) Diagnostics
+
 	BuildIR(nodesToInstantiate ...string) (*ir.ApplicationNode, error) // After defining everything, this builds the IR for the specified named nodes (implicitly including dependencies of those nodes)
 }
 
@@ -77,6 +82,11 @@ type WiringOpts struct {
 	// a BuildFunc is not added as a node to the namespace or as an edge, since the node originated
 	// from some other BuildFunc and therefore was already added as a node or edge.
 	ProxyNode bool
+
+	// Used by plugins to indicate that the node is metadata about other nodes, such as an address or a
+	// uniqueness check, rather than a part of the application.  [WiringSpec.Validate] does not warn when
+	// such nodes are unreachable.  Defaults to false.
+	Metadata bool
 }
 
 type WiringDef struct {
@@ -85,14 +95,17 @@ type WiringDef struct {
 	Build      BuildFunc
 	Properties map[string][]any
 	Options    WiringOpts
+
+	created logging.Callers // Where the def was first created; resolved only if validation reports it
 }
 
 type wiringSpecImpl struct {
 	WiringSpec
-	name    string
-	defs    map[string]*WiringDef
-	aliases map[string]string
-	errors  []error
+	name           string
+	defs           map[string]*WiringDef
+	aliases        map[string]string
+	aliasCallsites map[string]logging.Callers
+	errors         []error
 }
 
 func NewWiringSpec(name string) WiringSpec {
@@ -100,6 +113,7 @@ func NewWiringSpec(name string) WiringSpec {
 	spec.name = name
 	spec.defs = make(map[string]*WiringDef)
 	spec.aliases = make(map[string]string)
+	spec.aliasCallsites = make(map[string]logging.Callers)
 	spec.errors = nil
 	return &spec
 }
@@ -167,8 +181,11 @@ func (spec *wiringSpecImpl) getDef(name string, createIfAbsent bool) *WiringDef
 		def := WiringDef{}
 		def.Name = name
 		def.Properties = make(map[string][]any)
+		// Until the node is defined, the callsite is wherever the def was first created
+		def.created = logging.GetCallers()
 		spec.defs[name] = &def
 		delete(spec.aliases, name)
+		delete(spec.aliasCallsites, name)
 		return &def
 	} else {
 		return nil
@@ -211,6 +228,7 @@ func (spec *wiringSpecImpl) Alias(alias string, pointsto string) {
 		delete(spec.defs, alias)
 	}
 	spec.aliases[alias] = pointsto
+	spec.aliasCallsites[alias] = logging.GetCallers()
 }
 
 // If the provided name is an alias, returns what it points to.
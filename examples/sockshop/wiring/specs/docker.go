@@ -30,7 +30,7 @@ import (
 
 // A wiring spec that deploys each service into its own Docker container and using gRPC to communicate between services.
 //
-// All RPC calls are retried up to 3 times.
+// Failed RPC calls are retried up to 3 times, with jittered backoff and a retry budget of 10%.
 // RPC clients use a client pool with 10 clients.
 // All services are instrumented with OpenTelemetry and traces are exported to Zipkin
 //
@@ -50,7 +50,7 @@ func makeDockerSpec(spec wiring.WiringSpec) ([]string, error) {
 	// Modifiers that will be applied to all services
 	applyDockerDefaults := func(serviceName string, useHTTP ...bool) {
 		// Golang-level modifiers that add functionality
-		retries.AddRetries(spec, serviceName, 3)
+		retries.AddRetries(spec, serviceName, 3, retries.DecorrelatedJitter("10ms", "1s"), retries.Budget(0.1, 10))
 		clientpool.Create(spec, serviceName, 10)
 		opentelemetry.Instrument(spec, serviceName, trace_collector)
 		if len(useHTTP) > 0 && useHTTP[0] {
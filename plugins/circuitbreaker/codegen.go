@@ -10,23 +10,21 @@ import (
 	"golang.org/x/exp/slog"
 )
 
-func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, MinReqs int64, FailureRate float64, Interval string) error {
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
 	pkg, err := builder.CreatePackage(outputPackage)
 	if err != nil {
 		return err
 	}
 
 	client := clientArgs{
-		Package:     pkg,
-		Service:     wrapped,
-		Name:        wrapped.BaseName + "_CircuitBreakerClient",
-		MinReqs:     MinReqs,
-		FailureRate: FailureRate,
-		Interval:    Interval,
-		Imports:     gogen.NewImports(pkg.Name),
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_CircuitBreakerClient",
+		Imports: gogen.NewImports(pkg.Name),
 	}
 
-	client.Imports.AddPackages("context", "time", "github.com/mercari/go-circuitbreaker")
+	client.Imports.AddPackages("context")
+	client.CircuitBreaker = client.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/circuitbreaker")
 
 	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, wrapped.BaseName+"CircuitBreakerClient"))
 	outputFile := filepath.Join(client.Package.Path, wrapped.BaseName+"_CircuitBreakerClient.go")
@@ -34,13 +32,11 @@ func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterfa
 }
 
 type clientArgs struct {
-	Package     golang.PackageInfo
-	Service     *gocode.ServiceInterface
-	Name        string
-	MinReqs     int64
-	FailureRate float64
-	Interval    string
-	Imports     *gogen.Imports
+	Package        golang.PackageInfo
+	Service        *gocode.ServiceInterface
+	Name           string
+	CircuitBreaker string // The import name of the runtime circuitbreaker package
+	Imports        *gogen.Imports
 }
 
 var clientTemplate = `// Blueprint: Auto-generated by CircuitBreaker Plugin
@@ -50,43 +46,28 @@ package {{.Package.ShortName}}
 
 type {{.Name}} struct {
 	Client {{.Imports.NameOf .Service.UserType}}
-	MinReqs int64
-	FailureRate float64
-	cb *circuitbreaker.CircuitBreaker
+	cb *{{.CircuitBreaker}}.ServiceBreaker
 }
 
-func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}) (*{{.Name}}, error) {
-	handler := &{{.Name}}{}
-	handler.Client = client
-	handler.MinReqs = {{.MinReqs}}
-	handler.FailureRate = {{.FailureRate}}
-
-	dur, err := time.ParseDuration("{{.Interval}}")
+func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}, service string, caller string, policies string) (*{{.Name}}, error) {
+	cb, err := {{.CircuitBreaker}}.NewServiceBreaker(ctx, service, caller, policies)
 	if err != nil {
 		return nil, err
 	}
-
-	cb := circuitbreaker.New(
-		circuitbreaker.WithFailOnContextCancel(true),
-		circuitbreaker.WithFailOnContextDeadline(true),
-		circuitbreaker.WithCounterResetInterval(dur),
-		circuitbreaker.WithTripFunc(circuitbreaker.NewTripFuncFailureRate(handler.MinReqs,handler.FailureRate)),
-	)
+	handler := &{{.Name}}{}
+	handler.Client = client
 	handler.cb = cb
-
 	return handler, nil
 }
 
-{{$service := .Service.Name -}}
 {{$receiver := .Name -}}
 {{ range $_, $f := .Service.Methods }}
 func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
-	if !client.cb.Ready() {
-		err = circuitbreaker.ErrOpen
-		return
-	}
-	defer func() { err = client.cb.Done(ctx, err)}()
-	return client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+	err = client.cb.Call(ctx, "{{$f.Name}}", func(ctx context.Context) error {
+		{{RetVars $f "err"}} = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return err
+	})
+	return
 }
 {{end}}
 `
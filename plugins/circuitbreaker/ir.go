@@ -9,6 +9,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/circuitbreaker"
 )
 
 // Blueprint IR node representing a CircuitBreaker
@@ -21,9 +22,9 @@ type CircuitBreakerClient struct {
 	Wrapped      golang.Service
 
 	outputPackage string
-	Min_Reqs      int64
-	FailureRate   float64
-	Interval      string
+	ServiceName   string           // The name of the service called by the client, for events and metrics
+	Caller        string           // The name of the namespace that the client is instantiated in
+	Policies      runtime.Policies // Keyed by method name, or "*" for methods without their own policy
 }
 
 func (node *CircuitBreakerClient) ImplementsGolangNode() {}
@@ -33,10 +34,10 @@ func (node *CircuitBreakerClient) Name() string {
 }
 
 func (node *CircuitBreakerClient) String() string {
-	return node.Name() + " = CircuitBreaker(" + node.Wrapped.Name() + ")"
+	return node.Name() + " = CircuitBreaker(" + node.Wrapped.Name() + ", " + node.Policies.String() + ")"
 }
 
-func newCircuitBreakerClient(name string, server ir.IRNode, min_reqs int64, failure_rate float64, interval string) (*CircuitBreakerClient, error) {
+func newCircuitBreakerClient(name string, server ir.IRNode, serviceName string, caller string) (*CircuitBreakerClient, error) {
 	serverNode, is_callable := server.(golang.Service)
 	if !is_callable {
 		return nil, blueprint.Errorf("circuitbreaker client wrapper requires %s to be a golang service but got %s", server.Name(), reflect.TypeOf(server).String())
@@ -46,9 +47,9 @@ func newCircuitBreakerClient(name string, server ir.IRNode, min_reqs int64, fail
 	node.InstanceName = name
 	node.Wrapped = serverNode
 	node.outputPackage = "cb"
-	node.Min_Reqs = min_reqs
-	node.FailureRate = failure_rate
-	node.Interval = interval
+	node.ServiceName = serviceName
+	node.Caller = caller
+	node.Policies = make(runtime.Policies)
 
 	return node, nil
 }
@@ -71,7 +72,13 @@ func (node *CircuitBreakerClient) GenerateFuncs(builder golang.ModuleBuilder) er
 		return err
 	}
 
-	return generateClient(builder, iface, node.outputPackage, node.Min_Reqs, node.FailureRate, node.Interval)
+	for method := range node.Policies {
+		if _, exists := iface.Methods[method]; !exists && method != "*" {
+			return blueprint.Errorf("unable to add a circuit breaker to %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+	}
+
+	return generateClient(builder, iface, node.outputPackage)
 }
 
 func (node *CircuitBreakerClient) AddInstantiation(builder golang.NamespaceBuilder) error {
@@ -91,9 +98,13 @@ func (node *CircuitBreakerClient) AddInstantiation(builder golang.NamespaceBuild
 			Arguments: []gocode.Variable{
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "client", Type: iface},
+				{Name: "service", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "caller", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "policies", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped})
+	args := []ir.IRNode{node.Wrapped, &ir.IRValue{Value: node.ServiceName}, &ir.IRValue{Value: node.Caller}, &ir.IRValue{Value: node.Policies.String()}}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
 }
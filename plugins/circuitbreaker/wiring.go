@@ -1,14 +1,40 @@
 // Package circuitbreaker provides a Blueprint modifier for the client side of service calls.
 //
-// The plugin wraps clients with a circuitbreaker that blocks any new requests from being sent out over a connection if the failure rate exceeds a provided number in a fixed duration. The block is removed after the completion of the fixed duration interval.
+// The plugin wraps clients with circuit breakers that reject calls without making them when a method of the service
+// is failing.  Each method has its own breaker, so a misbehaving method does not trip the breaker for the service's
+// other methods.  A breaker trips when any of the following conditions is met:
+//   - [FailureRate] is the fraction of the calls in an interval that fail
+//   - [ConsecutiveFailures] is the number of consecutive failed calls
+//   - [SlowCallRate] is the fraction of the calls in an interval that are slower than a threshold
+//
+// After a timeout, the breaker admits probe calls (see [HalfOpen]) and closes if the probes succeed.
+//
+// Policies can be configured for the service as a whole and for individual methods with [Method], and for the
+// clients of particular callers with [Caller].  Example usage to trip the breaker of each method of cart_service when
+// half of its calls fail, and the breaker of GetCart after 5 consecutive failures:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/circuitbreaker"
+//	circuitbreaker.Add(spec, "cart_service",
+//		circuitbreaker.FailureRate(0.5), circuitbreaker.MinRequests(100),
+//		circuitbreaker.Method("GetCart", circuitbreaker.ConsecutiveFailures(5)))
+//
+// Breakers log their state changes and record metrics using the application's metric collector.  Calls that are
+// rejected by a server-side rate limiter or load shedder (see the ratelimit and loadshedding plugins) are not
+// counted as failures.  The plugin utilizes code in the [runtime/plugins/circuitbreaker] package.
+//
+// [runtime/plugins/circuitbreaker]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/circuitbreaker
 package circuitbreaker
 
 import (
+	"strings"
+	"time"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/circuitbreaker"
 	"golang.org/x/exp/slog"
 )
 
@@ -16,10 +42,19 @@ import (
 // Uses a [blueprint.WiringSpec].
 // Circuit breaker trips when `failure_rate` percentage of requests fail. Minimum number of requests for the circuit to break is specified using `min_reqs`.
 // The circuit breaker counters are reset after `interval` duration.
+// Each method of the service has its own circuit breaker; use [Add] for other policies.
 // Usage:
 //
 //	AddCircuitBreaker(spec, "serviceA", 1000, 0.1, "1s")
 func AddCircuitBreaker(spec wiring.WiringSpec, serviceName string, min_reqs int64, failure_rate float64, interval string) {
+	Add(spec, serviceName, MinRequests(int(min_reqs)), FailureRate(failure_rate), Interval(interval))
+}
+
+// Adds circuit breakers with the policies configured by `options` to all clients of the specified service.
+// Usage:
+//
+//	Add(spec, "serviceA", circuitbreaker.ConsecutiveFailures(5), circuitbreaker.HalfOpen("10s", 3))
+func Add(spec wiring.WiringSpec, serviceName string, options ...Option) {
 	clientWrapper := serviceName + ".client.cb"
 
 	ptr := pointer.GetPointer(spec, serviceName)
@@ -30,13 +65,157 @@ func AddCircuitBreaker(spec wiring.WiringSpec, serviceName string, min_reqs int6
 
 	clientNext := ptr.AddSrcModifier(spec, clientWrapper)
 
-	spec.Define(clientWrapper, &CircuitBreakerClient{Min_Reqs: min_reqs, FailureRate: failure_rate}, func(ns wiring.Namespace) (ir.IRNode, error) {
+	spec.Define(clientWrapper, &CircuitBreakerClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
 		var wrapped golang.Service
 
 		if err := ns.Get(clientNext, &wrapped); err != nil {
 			return nil, blueprint.Errorf("CircuitBreaker %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
 		}
 
-		return newCircuitBreakerClient(clientWrapper, wrapped, min_reqs, failure_rate, interval)
+		node, err := newCircuitBreakerClient(clientWrapper, wrapped, serviceName, ns.Name())
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node, "*"); err != nil {
+				return nil, err
+			}
+		}
+		if len(node.Policies) == 0 {
+			return nil, blueprint.Errorf("no circuit breaker policies for %v in %v", clientWrapper, ns.Name())
+		}
+		for method, policy := range node.Policies {
+			if err := policy.Validate(); err != nil {
+				return nil, blueprint.Errorf("invalid policy for %v of %v: %s", method, clientWrapper, err.Error())
+			}
+		}
+		return node, nil
+	})
+}
+
+// An option for [Add] that configures the policy of method, which is "*" for methods without their own policy.
+type Option func(node *CircuitBreakerClient, method string) error
+
+func update(f func(policy *runtime.Policy) error) Option {
+	return func(node *CircuitBreakerClient, method string) error {
+		policy := node.Policies[method]
+		if err := f(&policy); err != nil {
+			return blueprint.Errorf("invalid circuit breaker option for %v of %v: %s", method, node.InstanceName, err.Error())
+		}
+		node.Policies[method] = policy
+		return nil
+	}
+}
+
+// [FailureRate] is an [Option] that trips the breaker when `rate` of the calls in an interval fail, e.g. 0.5.
+func FailureRate(rate float64) Option {
+	return update(func(policy *runtime.Policy) error {
+		policy.FailureRate = rate
+		return nil
 	})
 }
+
+// [ConsecutiveFailures] is an [Option] that trips the breaker after `n` consecutive calls fail.
+func ConsecutiveFailures(n int) Option {
+	return update(func(policy *runtime.Policy) error {
+		policy.ConsecutiveFailures = n
+		return nil
+	})
+}
+
+// [SlowCallRate] is an [Option] that trips the breaker when `rate` of the calls in an interval take longer than
+// `threshold`, e.g. SlowCallRate("200ms", 0.5).  Slow calls count towards the rate whether or not they fail.
+func SlowCallRate(threshold string, rate float64) Option {
+	return update(func(policy *runtime.Policy) (err error) {
+		policy.SlowCall, err = time.ParseDuration(threshold)
+		policy.SlowCallRate = rate
+		return
+	})
+}
+
+// [MinRequests] is an [Option] that only applies [FailureRate] and [SlowCallRate] once `n` calls are made in an
+// interval.
+func MinRequests(n int) Option {
+	return update(func(policy *runtime.Policy) error {
+		policy.MinRequests = n
+		return nil
+	})
+}
+
+// [Interval] is an [Option] that sets how often the counts of calls for [FailureRate] and [SlowCallRate] are reset,
+// e.g. "10s".
+func Interval(interval string) Option {
+	return update(func(policy *runtime.Policy) (err error) {
+		policy.Interval, err = time.ParseDuration(interval)
+		return
+	})
+}
+
+// [HalfOpen] is an [Option] that sets how long a tripped breaker stays open, e.g. "5s", after which it admits
+// `probes` calls.  The breaker closes if all of the probes succeed, and opens again if any probe fails.
+func HalfOpen(openTimeout string, probes int) Option {
+	return update(func(policy *runtime.Policy) (err error) {
+		policy.OpenTimeout, err = time.ParseDuration(openTimeout)
+		policy.HalfOpenProbes = probes
+		return
+	})
+}
+
+// [Method] is an [Option] that applies `options` to the breaker of the specified method instead of the service as a
+// whole.  The policy of the method does not inherit from the policy of the service.
+func Method(method string, options ...Option) Option {
+	return func(node *CircuitBreakerClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("circuitbreaker.Method(%v) cannot be nested in circuitbreaker.Method(%v) for %v", method, scope, node.InstanceName)
+		}
+		if method == "" || method == "*" || strings.ContainsAny(method, ",:;= ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		for _, option := range options {
+			if err := option(node, method); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// [Caller] is an [Option] that only applies `options` to the clients that are instantiated in the namespace named
+// `caller`, e.g. the process of a calling service.  Other callers ignore the options.
+func Caller(caller string, options ...Option) Option {
+	return func(node *CircuitBreakerClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("circuitbreaker.Caller(%v) cannot be nested in circuitbreaker.Method(%v) for %v", caller, scope, node.InstanceName)
+		}
+		if node.Caller != caller {
+			return nil
+		}
+		for _, option := range options {
+			if err := option(node, scope); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// [Policies] is an [Option] that sets policies from a string such as
+// "*=failurerate:0.5,minreqs:100;GetCart=consecutive:5"; see the [runtime/plugins/circuitbreaker] package for the
+// format.
+//
+// [runtime/plugins/circuitbreaker]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/circuitbreaker
+func Policies(policies string) Option {
+	return func(node *CircuitBreakerClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("circuitbreaker.Policies cannot be nested in circuitbreaker.Method(%v) for %v", scope, node.InstanceName)
+		}
+		parsed, err := runtime.ParsePolicies(policies)
+		if err != nil {
+			return blueprint.Errorf("invalid policies for %v: %s", node.InstanceName, err.Error())
+		}
+		for method, policy := range parsed {
+			node.Policies[method] = policy
+		}
+		return nil
+	}
+}
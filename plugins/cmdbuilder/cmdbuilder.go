@@ -50,6 +50,62 @@
 //
 //	go run main.go -o build -w myspec
 //
+// To additionally export the application's IR as Graphviz DOT and JSON files (ir.dot and ir.json)
+// alongside the generated artifacts, add the -export flag
+//
+//	go run main.go -o build -w myspec -export
+//
+// By default the output directory must not already exist.  To recompile a spec into an existing
+// output directory, add the -incremental flag.  Artifacts are then only regenerated for the
+// processes, containers, and other nodes whose inputs changed since the last incremental compilation,
+// e.g. because a workflow source file or a modifier in the wiring spec changed.
+//
+//	go run main.go -o build -w myspec -incremental
+//
+// To compare the IR of two wiring specs instead of generating artifacts, specify the other spec
+// with the -diff flag.  The differences are printed, and written to the output directory as
+// irdiff.txt and irdiff.json
+//
+//	go run main.go -o build -w basic -diff docker
+//
+// # Declarative Wiring Specs
+//
+// Wiring specs can also be declared in a YAML or JSON file, which is compiled by specifying the
+// file with the -f argument instead of -w:
+//
+//	go run main.go -o build -f docker.yaml
+//
+// A declarative wiring spec lists backends, workflow services, and the modifiers to apply to
+// those services, in the order that they should be applied.  Modifiers apply to all services
+// unless services are listed.  For example:
+//
+//	name: docker
+//	description: Deploys each service in a separate container with gRPC
+//	backends:
+//	  - name: user_db
+//	    type: mongodb.Container
+//	services:
+//	  - name: user_service
+//	    type: github.com/blueprint-uservices/blueprint/examples/sockshop/workflow/user.UserService
+//	    args: [user_db]
+//	  - name: payment_service
+//	    type: github.com/blueprint-uservices/blueprint/examples/sockshop/workflow/payment.PaymentService
+//	    args: ["500"]
+//	modifiers:
+//	  - type: retries.AddRetries
+//	    args: [3]
+//	  - type: grpc.Deploy
+//	  - type: goproc.CreateProcess
+//	    name: shared_proc
+//	    services: [user_service, payment_service]
+//	  - type: linuxcontainer.CreateContainer
+//	    name: shared_ctr
+//	    services: [shared_proc]
+//	instantiate: [shared_ctr]
+//
+// The packages of workflow services must be dependencies of the wiring spec's module.  Additional
+// backend and modifier types can be made available with [RegisterBackend] and [RegisterModifier].
+//
 // [wiring/main.go]: https://github.com/Blueprint-uServices/blueprint/blob/main/examples/sockshop/wiring/main.go
 package cmdbuilder
 
@@ -57,10 +113,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint/logging"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/irdiff"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/irexport"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/dockercompose"
 	"github.com/blueprint-uservices/blueprint/plugins/environment"
@@ -84,15 +143,19 @@ type SpecOption struct {
 // wiring specs.  Makes it easy to choose which spec to compile.
 // See the Blueprint example applications for usage
 type CmdBuilder struct {
-	Name      string
-	OutputDir string
-	Quiet     bool
-	SpecName  string
-	Env       bool
-	Port      uint16
-	Spec      SpecOption
-	Wiring    wiring.WiringSpec
-	IR        *ir.ApplicationNode
+	Name        string
+	OutputDir   string
+	Quiet       bool
+	SpecName    string
+	SpecFile    string
+	Env         bool
+	Port        uint16
+	ExportIR    bool
+	DiffName    string
+	Incremental bool
+	Spec        SpecOption
+	Wiring      wiring.WiringSpec
+	IR          *ir.ApplicationNode
 
 	Registry map[string]SpecOption
 }
@@ -131,17 +194,25 @@ func (b *CmdBuilder) Add(specs ...SpecOption) {
 func (b *CmdBuilder) ParseArgs() {
 	output_dir := flag.String("o", "", "Target output directory for compilation.")
 	spec_name := flag.String("w", "", "Wiring spec to compile.  One of:\n"+b.List())
+	spec_file := flag.String("f", "", "A declarative wiring spec file (YAML or JSON) to compile.  Can be used instead of -w.")
 	quiet := flag.Bool("quiet", false, "Suppress verbose compiler output.")
 	env := flag.Bool("env", true, "Generate a .env file that sets service address and port environment variables")
 	port := flag.Uint("port", 12345, "Sets the port to start at when assigning service ports.  Only used when generating a .env file.")
+	export := flag.Bool("export", false, "Export the application's IR as Graphviz DOT and JSON files (ir.dot and ir.json) to the output directory.")
+	incremental := flag.Bool("incremental", false, "Allow the output directory to already exist, and only regenerate the artifacts whose inputs changed since it was last compiled with -incremental.")
+	diff := flag.String("diff", "", "Instead of generating artifacts, compare the IR of the wiring spec with the IR of this wiring spec, and write the differences to the output directory (irdiff.txt and irdiff.json).")
 
 	flag.Parse()
 
 	b.OutputDir = *output_dir
 	b.Quiet = *quiet
 	b.SpecName = *spec_name
+	b.SpecFile = *spec_file
 	b.Env = *env
 	b.Port = uint16(*port)
+	b.ExportIR = *export
+	b.DiffName = *diff
+	b.Incremental = *incremental
 }
 
 func (b *CmdBuilder) ValidateArgs() error {
@@ -149,16 +220,30 @@ func (b *CmdBuilder) ValidateArgs() error {
 		return fmt.Errorf("output directory not specified, specify with -o")
 	}
 
-	if b.SpecName == "" {
-		return fmt.Errorf("wiring spec not specified, specify with -w")
-	}
-
-	if spec, specExists := b.Registry[b.SpecName]; specExists {
+	if b.SpecFile != "" {
+		if b.SpecName != "" {
+			return fmt.Errorf("only one of -w and -f can be specified")
+		}
+		decl, err := LoadSpec(b.SpecFile)
+		if err != nil {
+			return err
+		}
+		b.Spec = decl.SpecOption()
+		b.SpecName = b.Spec.Name
+	} else if b.SpecName == "" {
+		return fmt.Errorf("wiring spec not specified, specify with -w or -f")
+	} else if spec, specExists := b.Registry[b.SpecName]; specExists {
 		b.Spec = spec
 	} else {
 		return fmt.Errorf("unknown wiring spec \"%v\", expected one of:\n%v", b.SpecName, b.List())
 	}
 
+	if b.DiffName != "" {
+		if _, specExists := b.Registry[b.DiffName]; !specExists {
+			return fmt.Errorf("unknown wiring spec \"%v\" to diff against, expected one of:\n%v", b.DiffName, b.List())
+		}
+	}
+
 	if b.Quiet {
 		slog.Info("Suppressing compiler logging")
 		logging.DisableCompilerLogging()
@@ -186,29 +271,78 @@ func (b *CmdBuilder) Build() error {
 		environment.AssignPorts(b.Port)
 	}
 
-	// Define the wiring spec
-	slog.Info(fmt.Sprintf("Building %v-%v to %v", b.Name, b.SpecName, b.OutputDir))
-	b.Wiring = wiring.NewWiringSpec(b.Name)
-	nodesToBuild, err := b.Spec.Build(b.Wiring)
+	// Define the wiring spec and construct the IR
+	var err error
+	b.Wiring, b.IR, err = b.buildIR(b.Spec)
 	if err != nil {
-		return fmt.Errorf("unable to build %v-%v wiring due to %v", b.Name, b.SpecName, err.Error())
+		return err
 	}
-	slog.Info(fmt.Sprintf("Constructed %v WiringSpec %v: \n%v", b.Name, b.SpecName, b.Wiring))
 
-	// Construct the IR
-	b.IR, err = b.Wiring.BuildIR(nodesToBuild...)
-	slog.Info(fmt.Sprintf("%v %v IR: \n%v", b.Name, b.SpecName, b.IR))
-	if err != nil {
-		return fmt.Errorf("unable to construct %v-%v IR due to %v", b.Name, b.SpecName, err.Error())
+	if b.DiffName != "" {
+		return b.diff()
 	}
 
 	// Generate artifacts
+	ir.SetIncrementalCompilation(b.Incremental)
 	slog.Info(fmt.Sprintf("Generating %v-%v artifacts to %v", b.Name, b.SpecName, b.OutputDir))
 	err = b.IR.GenerateArtifacts(b.OutputDir)
 	if err != nil {
 		return fmt.Errorf("unable to generate %v-%v artifacts due to %v", b.Name, b.SpecName, err.Error())
 	}
 
+	if b.ExportIR {
+		slog.Info(fmt.Sprintf("Exporting %v-%v IR to %v", b.Name, b.SpecName, b.OutputDir))
+		if err := irexport.WriteFiles(b.OutputDir, b.Wiring, b.IR); err != nil {
+			return fmt.Errorf("unable to export %v-%v IR due to %v", b.Name, b.SpecName, err.Error())
+		}
+	}
+
 	slog.Info(fmt.Sprintf("Successfully generated %v-%v to %v", b.Name, b.SpecName, b.OutputDir))
 	return nil
 }
+
+func (b *CmdBuilder) buildIR(option SpecOption) (wiring.WiringSpec, *ir.ApplicationNode, error) {
+	slog.Info(fmt.Sprintf("Building %v-%v to %v", b.Name, option.Name, b.OutputDir))
+	spec := wiring.NewWiringSpec(b.Name)
+	nodesToBuild, err := option.Build(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to build %v-%v wiring due to %v", b.Name, option.Name, err.Error())
+	}
+	slog.Info(fmt.Sprintf("Constructed %v WiringSpec %v: \n%v", b.Name, option.Name, spec))
+
+	app, err := spec.BuildIR(nodesToBuild...)
+	slog.Info(fmt.Sprintf("%v %v IR: \n%v", b.Name, option.Name, app))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to construct %v-%v IR due to %v", b.Name, option.Name, err.Error())
+	}
+	return spec, app, nil
+}
+
+// Builds the IR of the spec specified with -diff, and compares it to the IR of the spec specified with -w
+func (b *CmdBuilder) diff() error {
+	other := b.Registry[b.DiffName]
+	otherWiring, otherIR, err := b.buildIR(other)
+	if err != nil {
+		return err
+	}
+
+	d := irdiff.Compare(irexport.Export(b.Wiring, b.IR), irexport.Export(otherWiring, otherIR))
+	d.Old, d.New = b.SpecName, b.DiffName
+
+	if err := os.MkdirAll(b.OutputDir, 0755); err != nil {
+		return fmt.Errorf("unable to create output directory %v due to %v", b.OutputDir, err.Error())
+	}
+	jsonBytes, err := d.JSON()
+	if err != nil {
+		return fmt.Errorf("unable to serialize diff of %v and %v due to %v", b.SpecName, b.DiffName, err.Error())
+	}
+	if err := os.WriteFile(filepath.Join(b.OutputDir, "irdiff.json"), jsonBytes, 0644); err != nil {
+		return fmt.Errorf("unable to write diff of %v and %v due to %v", b.SpecName, b.DiffName, err.Error())
+	}
+	text := d.Text()
+	if err := os.WriteFile(filepath.Join(b.OutputDir, "irdiff.txt"), []byte(text), 0644); err != nil {
+		return fmt.Errorf("unable to write diff of %v and %v due to %v", b.SpecName, b.DiffName, err.Error())
+	}
+	fmt.Print(text)
+	return nil
+}
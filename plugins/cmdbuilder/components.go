@@ -0,0 +1,502 @@
+package cmdbuilder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/circuitbreaker"
+	"github.com/blueprint-uservices/blueprint/plugins/clientpool"
+	"github.com/blueprint-uservices/blueprint/plugins/faults"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/gotests"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/healthchecker"
+	"github.com/blueprint-uservices/blueprint/plugins/hedging"
+	"github.com/blueprint-uservices/blueprint/plugins/http"
+	"github.com/blueprint-uservices/blueprint/plugins/jaeger"
+	"github.com/blueprint-uservices/blueprint/plugins/latency"
+	"github.com/blueprint-uservices/blueprint/plugins/linuxcontainer"
+	"github.com/blueprint-uservices/blueprint/plugins/loadbalancer"
+	"github.com/blueprint-uservices/blueprint/plugins/loadshedding"
+	"github.com/blueprint-uservices/blueprint/plugins/memcached"
+	"github.com/blueprint-uservices/blueprint/plugins/mongodb"
+	"github.com/blueprint-uservices/blueprint/plugins/mysql"
+	"github.com/blueprint-uservices/blueprint/plugins/opentelemetry"
+	"github.com/blueprint-uservices/blueprint/plugins/rabbitmq"
+	"github.com/blueprint-uservices/blueprint/plugins/ratelimit"
+	"github.com/blueprint-uservices/blueprint/plugins/redis"
+	"github.com/blueprint-uservices/blueprint/plugins/responsecache"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/thrift"
+	"github.com/blueprint-uservices/blueprint/plugins/timeouts"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/plugins/zipkin"
+)
+
+// Defines a backend named name in the wiring spec, using the args from a [BackendDecl].
+// Returns the name of the defined node.
+type BackendFunc func(spec wiring.WiringSpec, name string, args []string) (string, error)
+
+// Applies a modifier to the wiring spec, as described by a [ModifierDecl].  The Services
+// of the [ModifierDecl] are always populated.
+type ModifierFunc func(spec wiring.WiringSpec, decl ModifierDecl) error
+
+var backends = map[string]BackendFunc{
+	"mongodb.Container":   noArgs(mongodb.Container),
+	"mysql.Container":     noArgs(mysql.Container),
+	"redis.Container":     noArgs(redis.Container),
+	"memcached.Container": noArgs(memcached.Container),
+	"rabbitmq.Container": func(spec wiring.WiringSpec, name string, args []string) (string, error) {
+		if err := checkArgs(args, 1); err != nil {
+			return "", err
+		}
+		return rabbitmq.Container(spec, name, args[0]), nil
+	},
+	"simple.NoSQLDB":      simpleBackend(simple.NoSQLDB),
+	"simple.RelationalDB": simpleBackend(simple.RelationalDB),
+	"simple.Queue":        simpleBackend(simple.Queue),
+	"simple.Cache":        simpleBackend(simple.Cache),
+	"zipkin.Collector":    noArgs(zipkin.Collector),
+	"jaeger.Collector":    noArgs(jaeger.Collector),
+}
+
+var modifiers = map[string]ModifierFunc{
+	"grpc.Deploy":                      grpcDeploy,
+	"http.Deploy":                      httpDeploy,
+	"thrift.Deploy":                    thriftDeploy,
+	"healthchecker.AddHealthCheckAPI":  eachService(healthchecker.AddHealthCheckAPI),
+	"goproc.Deploy":                    eachService(func(spec wiring.WiringSpec, serviceName string) { goproc.Deploy(spec, serviceName) }),
+	"linuxcontainer.Deploy":            eachService(func(spec wiring.WiringSpec, serviceName string) { linuxcontainer.Deploy(spec, serviceName) }),
+	"retries.AddRetries":               retriesAddRetries,
+	"retries.AddRetriesWithTimeouts":   retriesAddRetriesWithTimeouts,
+	"clientpool.Create":                clientpoolCreate,
+	"timeouts.Add":                     eachServiceWithArg(timeoutsAdd),
+	"latency.AddFixed":                 eachServiceWithArg(latencyAddFixed),
+	"latency.Add":                      eachServiceWithArg(latencyAdd),
+	"faults.AddServer":                 faultsAdd(faults.AddServer),
+	"faults.AddClient":                 faultsAdd(faults.AddClient),
+	"ratelimit.Add":                    ratelimitAdd,
+	"loadshedding.Add":                 loadsheddingAdd,
+	"opentelemetry.Instrument":         eachServiceWithArg(opentelemetry.Instrument),
+	"circuitbreaker.AddCircuitBreaker": circuitbreakerAddCircuitBreaker,
+	"circuitbreaker.Add":               circuitbreakerAdd,
+	"hedging.Add":                      hedgingAdd,
+	"responsecache.Add":                responsecacheAdd,
+	"loadbalancer.Create":              loadbalancerCreate,
+	"loadbalancer.Replicate":           loadbalancerReplicate,
+	"goproc.CreateProcess":             namedGroup(goproc.CreateProcess),
+	"goproc.AddToProcess":              addToGroup(goproc.AddToProcess),
+	"linuxcontainer.CreateContainer":   namedGroup(linuxcontainer.CreateContainer),
+	"linuxcontainer.AddToContainer":    addToGroup(linuxcontainer.AddToContainer),
+	"gotests.Test":                     gotestsTest,
+}
+
+// Modifiers that declare nodes other than their Name, which later modifiers can refer to
+var declaredNodes = map[string]func(decl ModifierDecl) []string{
+	"loadbalancer.Replicate": replicaNames,
+}
+
+// Registers a backend type that can be used by declarative wiring specs, e.g. by a plugin that
+// isn't built into cmdbuilder.  Replaces any existing backend type with the same name.
+func RegisterBackend(typeName string, f BackendFunc) {
+	backends[typeName] = f
+}
+
+// Registers a modifier type that can be used by declarative wiring specs, e.g. by a plugin that
+// isn't built into cmdbuilder.  Replaces any existing modifier type with the same name.
+func RegisterModifier(typeName string, f ModifierFunc) {
+	modifiers[typeName] = f
+}
+
+func checkArgs(args []string, expected int) error {
+	if len(args) != expected {
+		return blueprint.Errorf("expected %v arguments but got %v", expected, args)
+	}
+	return nil
+}
+
+func noArgs(f func(spec wiring.WiringSpec, name string) string) BackendFunc {
+	return func(spec wiring.WiringSpec, name string, args []string) (string, error) {
+		if err := checkArgs(args, 0); err != nil {
+			return "", err
+		}
+		return f(spec, name), nil
+	}
+}
+
+// Simple backends optionally take a snapshot path and interval, as for [simple.Persist]
+func simpleBackend(f func(spec wiring.WiringSpec, name string, options ...simple.Option) string) BackendFunc {
+	return func(spec wiring.WiringSpec, name string, args []string) (string, error) {
+		switch len(args) {
+		case 0:
+			return f(spec, name), nil
+		case 1:
+			return f(spec, name, simple.Persist(args[0], "")), nil
+		case 2:
+			return f(spec, name, simple.Persist(args[0], args[1])), nil
+		}
+		return "", blueprint.Errorf("expected at most 2 arguments (snapshot path and interval) but got %v", args)
+	}
+}
+
+func eachService(f func(spec wiring.WiringSpec, serviceName string)) ModifierFunc {
+	return func(spec wiring.WiringSpec, decl ModifierDecl) error {
+		if err := checkArgs(decl.Args, 0); err != nil {
+			return err
+		}
+		for _, serviceName := range decl.Services {
+			f(spec, serviceName)
+		}
+		return nil
+	}
+}
+
+func eachServiceWithArg(f func(spec wiring.WiringSpec, serviceName string, arg string)) ModifierFunc {
+	return func(spec wiring.WiringSpec, decl ModifierDecl) error {
+		if err := checkArgs(decl.Args, 1); err != nil {
+			return err
+		}
+		for _, serviceName := range decl.Services {
+			f(spec, serviceName, decl.Args[0])
+		}
+		return nil
+	}
+}
+
+// Modifiers such as goproc.CreateProcess that create a single named node containing the services
+func namedGroup(f func(spec wiring.WiringSpec, name string, children ...string) string) ModifierFunc {
+	return func(spec wiring.WiringSpec, decl ModifierDecl) error {
+		if decl.Name == "" {
+			return blueprint.Errorf("a name must be specified")
+		}
+		if err := checkArgs(decl.Args, 0); err != nil {
+			return err
+		}
+		f(spec, decl.Name, decl.Services...)
+		return nil
+	}
+}
+
+// Modifiers such as goproc.AddToProcess that add the services to an existing named node
+func addToGroup(f func(spec wiring.WiringSpec, name string, child string)) ModifierFunc {
+	return func(spec wiring.WiringSpec, decl ModifierDecl) error {
+		if decl.Name == "" {
+			return blueprint.Errorf("a name must be specified")
+		}
+		if err := checkArgs(decl.Args, 0); err != nil {
+			return err
+		}
+		for _, serviceName := range decl.Services {
+			f(spec, decl.Name, serviceName)
+		}
+		return nil
+	}
+}
+
+func retriesAddRetries(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if err := checkArgs(decl.Args, 1); err != nil {
+		return err
+	}
+	maxRetries, err := strconv.ParseInt(decl.Args[0], 10, 64)
+	if err != nil {
+		return blueprint.Errorf("invalid max_retries %v", decl.Args[0])
+	}
+	for _, serviceName := range decl.Services {
+		retries.AddRetries(spec, serviceName, maxRetries)
+	}
+	return nil
+}
+
+func retriesAddRetriesWithTimeouts(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if err := checkArgs(decl.Args, 2); err != nil {
+		return err
+	}
+	maxRetries, err := strconv.ParseInt(decl.Args[0], 10, 64)
+	if err != nil {
+		return blueprint.Errorf("invalid max_retries %v", decl.Args[0])
+	}
+	for _, serviceName := range decl.Services {
+		retries.AddRetriesWithTimeouts(spec, serviceName, maxRetries, decl.Args[1])
+	}
+	return nil
+}
+
+// The arg is a timeout such as "1s", or per-method timeouts as for [timeouts.Timeouts]
+func timeoutsAdd(spec wiring.WiringSpec, serviceName string, timeout string) {
+	timeouts.Add(spec, serviceName, timeout)
+}
+
+func latencyAddFixed(spec wiring.WiringSpec, serviceName string, latencyValue string) {
+	latency.AddFixed(spec, serviceName, latencyValue)
+}
+
+func latencyAdd(spec wiring.WiringSpec, serviceName string, distribution string) {
+	latency.Add(spec, serviceName, latency.Parse(distribution))
+}
+
+// Each arg is a fault schedule, as for [faults.Schedule]
+func faultsAdd(f func(spec wiring.WiringSpec, serviceName string, options ...faults.Option)) ModifierFunc {
+	return func(spec wiring.WiringSpec, decl ModifierDecl) error {
+		var options []faults.Option
+		for _, schedule := range decl.Args {
+			options = append(options, faults.Schedule(schedule))
+		}
+		for _, serviceName := range decl.Services {
+			f(spec, serviceName, options...)
+		}
+		return nil
+	}
+}
+
+// An optional arg, "tls" or "mtls", deploys the services with [grpc.TLS] or [grpc.MutualTLS]
+func grpcDeploy(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if len(decl.Args) > 1 {
+		return blueprint.Errorf("expected at most one arg but got %v", decl.Args)
+	}
+	var options []grpc.Option
+	for _, arg := range decl.Args {
+		switch arg {
+		case "tls":
+			options = append(options, grpc.TLS())
+		case "mtls":
+			options = append(options, grpc.MutualTLS())
+		default:
+			return blueprint.Errorf("expected tls or mtls but got %v", arg)
+		}
+	}
+	for _, serviceName := range decl.Services {
+		grpc.Deploy(spec, serviceName, options...)
+	}
+	return nil
+}
+
+// An optional arg, "tls" or "mtls", deploys the services with [thrift.TLS] or [thrift.MutualTLS]
+func thriftDeploy(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if len(decl.Args) > 1 {
+		return blueprint.Errorf("expected at most one arg but got %v", decl.Args)
+	}
+	var options []thrift.Option
+	for _, arg := range decl.Args {
+		switch arg {
+		case "tls":
+			options = append(options, thrift.TLS())
+		case "mtls":
+			options = append(options, thrift.MutualTLS())
+		default:
+			return blueprint.Errorf("expected tls or mtls but got %v", arg)
+		}
+	}
+	for _, serviceName := range decl.Services {
+		thrift.Deploy(spec, serviceName, options...)
+	}
+	return nil
+}
+
+// Args "tls" and "mtls" deploy the services with [http.TLS] or [http.MutualTLS].  Any other args are sets of routes,
+// as for [http.Routes], and deploy the services in REST mode.  An empty arg uses the default routes.
+func httpDeploy(spec wiring.WiringSpec, decl ModifierDecl) error {
+	var options []http.Option
+	for _, arg := range decl.Args {
+		switch arg {
+		case "tls":
+			options = append(options, http.TLS())
+		case "mtls":
+			options = append(options, http.MutualTLS())
+		default:
+			options = append(options, http.Routes(arg))
+		}
+	}
+	for _, serviceName := range decl.Services {
+		http.Deploy(spec, serviceName, options...)
+	}
+	return nil
+}
+
+// Each arg is a set of limits, as for [ratelimit.Limits]
+func ratelimitAdd(spec wiring.WiringSpec, decl ModifierDecl) error {
+	var options []ratelimit.Option
+	for _, limits := range decl.Args {
+		options = append(options, ratelimit.Limits(limits))
+	}
+	for _, serviceName := range decl.Services {
+		ratelimit.Add(spec, serviceName, options...)
+	}
+	return nil
+}
+
+// Args are a load shedding policy, e.g. "codel(5ms, 100ms, 100)", and optionally the priorities of methods, e.g. "GetCart:1,ListItems:-1"
+func loadsheddingAdd(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if len(decl.Args) != 1 && len(decl.Args) != 2 {
+		return blueprint.Errorf("expected a policy and optionally priorities but got %v", decl.Args)
+	}
+	var options []loadshedding.Option
+	if len(decl.Args) == 2 {
+		options = append(options, loadshedding.Priorities(decl.Args[1]))
+	}
+	for _, serviceName := range decl.Services {
+		loadshedding.Add(spec, serviceName, loadshedding.Parse(decl.Args[0]), options...)
+	}
+	return nil
+}
+
+func clientpoolCreate(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if err := checkArgs(decl.Args, 1); err != nil {
+		return err
+	}
+	numClients, err := strconv.Atoi(decl.Args[0])
+	if err != nil {
+		return blueprint.Errorf("invalid number of clients %v", decl.Args[0])
+	}
+	for _, serviceName := range decl.Services {
+		clientpool.Create(spec, serviceName, numClients)
+	}
+	return nil
+}
+
+func circuitbreakerAddCircuitBreaker(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if err := checkArgs(decl.Args, 3); err != nil {
+		return err
+	}
+	minReqs, err := strconv.ParseInt(decl.Args[0], 10, 64)
+	if err != nil {
+		return blueprint.Errorf("invalid min_reqs %v", decl.Args[0])
+	}
+	failureRate, err := strconv.ParseFloat(decl.Args[1], 64)
+	if err != nil {
+		return blueprint.Errorf("invalid failure_rate %v", decl.Args[1])
+	}
+	for _, serviceName := range decl.Services {
+		circuitbreaker.AddCircuitBreaker(spec, serviceName, minReqs, failureRate, decl.Args[2])
+	}
+	return nil
+}
+
+// Each arg is a set of policies, as for [circuitbreaker.Policies]
+func circuitbreakerAdd(spec wiring.WiringSpec, decl ModifierDecl) error {
+	var options []circuitbreaker.Option
+	for _, policies := range decl.Args {
+		options = append(options, circuitbreaker.Policies(policies))
+	}
+	for _, serviceName := range decl.Services {
+		circuitbreaker.Add(spec, serviceName, options...)
+	}
+	return nil
+}
+
+// Each arg is a set of policies, as for [hedging.Policies]
+func hedgingAdd(spec wiring.WiringSpec, decl ModifierDecl) error {
+	var options []hedging.Option
+	for _, policies := range decl.Args {
+		options = append(options, hedging.Policies(policies))
+	}
+	for _, serviceName := range decl.Services {
+		hedging.Add(spec, serviceName, options...)
+	}
+	return nil
+}
+
+// Args are the name of a cache backend, followed by sets of policies, as for [responsecache.Policies]
+func responsecacheAdd(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if len(decl.Args) < 2 {
+		return blueprint.Errorf("expected a cache and policies but got %v", decl.Args)
+	}
+	var options []responsecache.Option
+	for _, policies := range decl.Args[1:] {
+		options = append(options, responsecache.Policies(policies))
+	}
+	for _, serviceName := range decl.Services {
+		responsecache.Add(spec, serviceName, decl.Args[0], options...)
+	}
+	return nil
+}
+
+// Defines a load-balanced service called Name whose callers balance their calls across the services.  An optional
+// arg is a config, as for [loadbalancer.Config].
+func loadbalancerCreate(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if decl.Name == "" {
+		return blueprint.Errorf("a name must be specified")
+	}
+	options, err := loadbalancerOptions(decl.Args)
+	if err != nil {
+		return err
+	}
+	loadbalancer.Create(spec, decl.Name, decl.Services, options...)
+	return nil
+}
+
+// Args are the number of replicas and optionally a config, as for [loadbalancer.Config].  The single service is a
+// template: each replica is a service with the same type and args, named Name_1 to Name_n as by
+// [loadbalancer.Replicate], which later modifiers can deploy.  Callers use the load-balanced service called Name.
+func loadbalancerReplicate(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if decl.Name == "" {
+		return blueprint.Errorf("a name must be specified")
+	}
+	if len(decl.Services) != 1 {
+		return blueprint.Errorf("expected a single service to replicate but got %v", decl.Services)
+	}
+	template, declared := decl.serviceDecls[decl.Services[0]]
+	if !declared {
+		return blueprint.Errorf("%v is not a service, so it cannot be replicated", decl.Services[0])
+	}
+	if len(decl.Args) == 0 {
+		return blueprint.Errorf("expected the number of replicas and optionally a config but got %v", decl.Args)
+	}
+	n, err := strconv.Atoi(decl.Args[0])
+	if err != nil || n < 1 {
+		return blueprint.Errorf("invalid number of replicas %v", decl.Args[0])
+	}
+	options, err := loadbalancerOptions(decl.Args[1:])
+	if err != nil {
+		return err
+	}
+	pkg, name, _ := splitServiceType(template.Type)
+	loadbalancer.Replicate(spec, decl.Name, n, func(replica string) {
+		workflow.ServiceByName(spec, pkg, name, replica, template.Args...)
+	}, options...)
+	return nil
+}
+
+func loadbalancerOptions(args []string) ([]loadbalancer.Option, error) {
+	if len(args) > 1 {
+		return nil, blueprint.Errorf("expected at most one config but got %v", args)
+	}
+	var options []loadbalancer.Option
+	for _, config := range args {
+		options = append(options, loadbalancer.Config(config))
+	}
+	return options, nil
+}
+
+// Returns the names of the replicas that loadbalancer.Replicate declares
+func replicaNames(decl ModifierDecl) []string {
+	if len(decl.Args) == 0 {
+		return nil
+	}
+	n, _ := strconv.Atoi(decl.Args[0])
+	var names []string
+	for i := 1; i <= n; i++ {
+		names = append(names, fmt.Sprintf("%v_%v", decl.Name, i))
+	}
+	return names
+}
+
+func gotestsTest(spec wiring.WiringSpec, decl ModifierDecl) error {
+	if err := checkArgs(decl.Args, 0); err != nil {
+		return err
+	}
+	gotests.Test(spec, decl.Services...)
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,184 @@
+package cmdbuilder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// A wiring spec declared in a YAML or JSON file rather than in Go code.  See [LoadSpec].
+	DeclarativeSpec struct {
+		Name        string         `yaml:"name"`
+		Description string         `yaml:"description"`
+		Backends    []BackendDecl  `yaml:"backends"`
+		Services    []ServiceDecl  `yaml:"services"`
+		Modifiers   []ModifierDecl `yaml:"modifiers"`
+		Instantiate []string       `yaml:"instantiate"` // The nodes to build, e.g. containers
+	}
+
+	// A backend or other standalone node such as a trace collector.  Type must be a registered
+	// backend type, e.g. "mongodb.Container"; see [RegisterBackend].
+	BackendDecl struct {
+		Name string   `yaml:"name"`
+		Type string   `yaml:"type"`
+		Args []string `yaml:"args"`
+	}
+
+	// A workflow service.  Type is the fully-qualified name of the service's interface or
+	// implementation, e.g. "github.com/blueprint-uservices/blueprint/examples/sockshop/workflow/user.UserService".
+	// Args are the service's constructor arguments, as for [workflow.Service].
+	ServiceDecl struct {
+		Name string   `yaml:"name"`
+		Type string   `yaml:"type"`
+		Args []string `yaml:"args"`
+	}
+
+	// A modifier applied to services, e.g. "grpc.Deploy" or "retries.AddRetries"; see [RegisterModifier].
+	//
+	// Services lists the services to modify; if omitted, the modifier is applied to all services.
+	// Name is only used by modifiers that create or add to a named node, such as "goproc.CreateProcess".
+	// The named node can then be listed in the Services of later modifiers, e.g. to add a process
+	// to a container.
+	ModifierDecl struct {
+		Type     string   `yaml:"type"`
+		Name     string   `yaml:"name"`
+		Services []string `yaml:"services"`
+		Args     []string `yaml:"args"`
+
+		serviceDecls map[string]ServiceDecl // The services that the spec declares, by name
+	}
+)
+
+// Loads a [DeclarativeSpec] from a YAML or JSON file.  If the spec doesn't specify a name,
+// the name of the file (without its extension) is used.
+func LoadSpec(filename string) (*DeclarativeSpec, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, blueprint.Errorf("unable to read wiring spec file %v due to %v", filename, err.Error())
+	}
+	return ParseSpec(filename, data)
+}
+
+// Parses a [DeclarativeSpec] from YAML or JSON data.  filename is only used for naming and error messages.
+func ParseSpec(filename string, data []byte) (*DeclarativeSpec, error) {
+	decl := &DeclarativeSpec{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(decl); err != nil {
+		return nil, blueprint.Errorf("unable to parse wiring spec file %v due to %v", filename, err.Error())
+	}
+	if decl.Name == "" {
+		decl.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	return decl, decl.validate()
+}
+
+// Checks that the spec only refers to registered types and to services that it declares
+func (decl *DeclarativeSpec) validate() error {
+	if len(decl.Instantiate) == 0 {
+		return blueprint.Errorf("wiring spec %v does not specify any nodes to instantiate", decl.Name)
+	}
+	names := make(map[string]struct{})
+	checkName := func(name string) error {
+		if name == "" {
+			return blueprint.Errorf("wiring spec %v has a backend or service without a name", decl.Name)
+		}
+		if _, exists := names[name]; exists {
+			return blueprint.Errorf("wiring spec %v declares %v more than once", decl.Name, name)
+		}
+		names[name] = struct{}{}
+		return nil
+	}
+	for _, backend := range decl.Backends {
+		if err := checkName(backend.Name); err != nil {
+			return err
+		}
+		if _, exists := backends[backend.Type]; !exists {
+			return blueprint.Errorf("backend %v has unknown type %q; expected one of %v", backend.Name, backend.Type, strings.Join(sortedKeys(backends), ", "))
+		}
+	}
+	for _, service := range decl.Services {
+		if err := checkName(service.Name); err != nil {
+			return err
+		}
+		if _, _, err := splitServiceType(service.Type); err != nil {
+			return blueprint.Errorf("service %v has invalid type %q: %v", service.Name, service.Type, err.Error())
+		}
+	}
+	for _, modifier := range decl.Modifiers {
+		if _, exists := modifiers[modifier.Type]; !exists {
+			return blueprint.Errorf("unknown modifier type %q; expected one of %v", modifier.Type, strings.Join(sortedKeys(modifiers), ", "))
+		}
+		for _, service := range modifier.Services {
+			if _, exists := names[service]; !exists {
+				return blueprint.Errorf("modifier %v is applied to %v, which is not declared before it in wiring spec %v", modifier.Type, service, decl.Name)
+			}
+		}
+		// Modifiers such as goproc.CreateProcess declare a node that later modifiers can refer to
+		if modifier.Name != "" {
+			names[modifier.Name] = struct{}{}
+		}
+		if declares, exists := declaredNodes[modifier.Type]; exists {
+			for _, name := range declares(modifier) {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+// Splits a fully-qualified type name such as "example.com/app/workflow/user.UserService"
+func splitServiceType(serviceType string) (pkg string, name string, err error) {
+	i := strings.LastIndex(serviceType, ".")
+	if i <= strings.LastIndex(serviceType, "/") || i == len(serviceType)-1 {
+		return "", "", blueprint.Errorf("expected a fully-qualified type of the form package/path.TypeName")
+	}
+	return serviceType[:i], serviceType[i+1:], nil
+}
+
+// Defines the backends, services, and modifiers of the declarative spec in the wiring spec.
+// Returns the nodes to instantiate.
+func (decl *DeclarativeSpec) Build(spec wiring.WiringSpec) ([]string, error) {
+	for _, backend := range decl.Backends {
+		if _, err := backends[backend.Type](spec, backend.Name, backend.Args); err != nil {
+			return nil, blueprint.Errorf("unable to define backend %v due to %v", backend.Name, err.Error())
+		}
+	}
+
+	var allServices []string
+	serviceDecls := make(map[string]ServiceDecl)
+	for _, service := range decl.Services {
+		pkg, name, _ := splitServiceType(service.Type)
+		workflow.ServiceByName(spec, pkg, name, service.Name, service.Args...)
+		allServices = append(allServices, service.Name)
+		serviceDecls[service.Name] = service
+	}
+
+	for _, modifier := range decl.Modifiers {
+		if len(modifier.Services) == 0 {
+			modifier.Services = allServices
+		}
+		modifier.serviceDecls = serviceDecls
+		if err := modifiers[modifier.Type](spec, modifier); err != nil {
+			return nil, blueprint.Errorf("unable to apply modifier %v due to %v", modifier.Type, err.Error())
+		}
+	}
+
+	return decl.Instantiate, nil
+}
+
+// Returns a [SpecOption] that builds the declarative spec
+func (decl *DeclarativeSpec) SpecOption() SpecOption {
+	return SpecOption{
+		Name:        decl.Name,
+		Description: decl.Description,
+		Build:       decl.Build,
+	}
+}
@@ -27,5 +27,5 @@ func buildDefaultContainerWorkspace(outputDir string, nodes []ir.IRNode) error {
 	if err != nil {
 		return err
 	}
-	return ctr.GenerateArtifacts(subdir)
+	return ir.GenerateCached(ctr, subdir, func() error { return ctr.GenerateArtifacts(subdir) })
 }
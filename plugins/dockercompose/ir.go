@@ -24,3 +24,18 @@ func (node *Deployment) Name() string {
 func (node *Deployment) String() string {
 	return ir.PrettyPrintNamespace(node.DeploymentName, "DockerApp", node.Edges, node.Nodes)
 }
+
+// Implements [ir.NamespaceNode]
+func (node *Deployment) GetNamespaceType() string {
+	return "DockerApp"
+}
+
+// Implements [ir.NamespaceNode]
+func (node *Deployment) GetArgNodes() []ir.IRNode {
+	return node.Edges
+}
+
+// Implements [ir.NamespaceNode]
+func (node *Deployment) GetChildNodes() []ir.IRNode {
+	return node.Nodes
+}
@@ -0,0 +1,77 @@
+package faults
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file.
+func generateFaultInjector(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	injector := injectorArgs{
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_FaultInjector",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	injector.Imports.AddPackages("context")
+	injector.Faults = injector.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/faults")
+	slog.Info(fmt.Sprintf("Generating %v/%v", injector.Package.PackageName, injector.Name))
+	outputFile := filepath.Join(injector.Package.Path, injector.Name+".go")
+
+	return gogen.ExecuteTemplateToFile("FaultInjector", injectorTemplate, injector, outputFile)
+}
+
+type injectorArgs struct {
+	Package golang.PackageInfo
+	Service *gocode.ServiceInterface
+	Name    string
+	Faults  string // The import name of the runtime faults package
+	Imports *gogen.Imports
+}
+
+var injectorTemplate = `// Blueprint: Auto-generated by FaultInjector Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Service {{.Imports.NameOf .Service.UserType}}
+	injector *{{.Faults}}.Injector
+}
+
+func New_{{.Name}} (ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, schedule string, controlAddr string) (*{{.Name}}, error) {
+	injector, err := {{.Faults}}.NewInjector(schedule)
+	if err != nil {
+		return nil, err
+	}
+	if err := injector.Serve(ctx, controlAddr); err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.Service = service
+	handler.injector = injector
+	return handler, nil
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (handler *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	err = handler.injector.Call(ctx, "{{$f.Name}}", func(ctx context.Context) error {
+		{{RetVars $f "err"}} = handler.Service.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return err
+	})
+	return
+}
+{{end}}
+`
@@ -0,0 +1,125 @@
+package faults
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/faults"
+)
+
+// Blueprint IR Node representing a fault injector on the server or client side of a service
+type FaultInjector struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName  string
+	Wrapped       golang.Service
+	outputPackage string
+
+	Rules   []runtime.Rule      // The initial fault schedule
+	Control *address.BindConfig // The address of the control endpoint for changing the schedule at runtime
+}
+
+func newFaultInjector(name string, wrapped ir.IRNode) (*FaultInjector, error) {
+	service, is_service := wrapped.(golang.Service)
+	if !is_service {
+		return nil, blueprint.Errorf("fault injector %s requires %s to be a golang service but got %s", name, wrapped.Name(), reflect.TypeOf(wrapped).String())
+	}
+
+	node := &FaultInjector{}
+	node.InstanceName = name
+	node.Wrapped = service
+	node.outputPackage = "faultinjector"
+	return node, nil
+}
+
+// Implements [ir.IRNode]
+func (node *FaultInjector) ImplementsGolangNode() {}
+
+// Implements [golang.Service]
+func (node *FaultInjector) ImplementsGolangService() {}
+
+// Implements [ir.IRNode]
+func (node *FaultInjector) Name() string {
+	return node.InstanceName
+}
+
+// Implements [ir.IRNode]
+func (node *FaultInjector) String() string {
+	args := []string{node.Wrapped.Name()}
+	if schedule := node.schedule(); schedule != "" {
+		args = append(args, schedule)
+	}
+	args = append(args, "control="+node.Control.Name())
+	return node.Name() + " = FaultInjector(" + strings.Join(args, ", ") + ")"
+}
+
+func (node *FaultInjector) schedule() string {
+	return runtime.Schedule{Rules: node.Rules}.String()
+}
+
+// Implements [golang.Service]
+func (node *FaultInjector) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements [golang.Service]
+func (node *FaultInjector) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements [golang.GeneratesFuncs]
+func (node *FaultInjector) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range node.Rules {
+		if _, exists := iface.Methods[rule.Method]; !exists && rule.Method != "*" {
+			return blueprint.Errorf("unable to inject faults into %v.%v as the method does not exist", node.Wrapped.Name(), rule.Method)
+		}
+	}
+
+	return generateFaultInjector(builder, iface, node.outputPackage)
+}
+
+// Implements [golang.Instantiable]
+func (node *FaultInjector) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_FaultInjector", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "service", Type: iface},
+				{Name: "schedule", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "controlAddr", Type: &gocode.BasicType{Name: "string"}},
+			},
+		},
+	}
+
+	args := []ir.IRNode{node.Wrapped, &ir.IRValue{Value: node.schedule()}, node.Control}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
+}
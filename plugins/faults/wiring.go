@@ -0,0 +1,150 @@
+// Package faults provides a Blueprint modifier that injects faults into the server or client side of service calls.
+//
+// The plugin generates a wrapper that, with a configurable probability per method, injects one of the following faults
+// into a call instead of (or after) making the call:
+//   - [Error] returns an error without making the call
+//   - [Panic] panics without making the call
+//   - [Hang] blocks until the caller's context is done, without making the call
+//   - [Drop] makes the call but drops its response, returning an error
+//
+// Example usage to fail 10% of GetCart requests and hang 1% of all requests on the server side of cart_service:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/faults"
+//	faults.AddServer(spec, "cart_service", faults.Error("GetCart", 0.1), faults.Hang("*", 0.01))
+//
+// Each fault injector also serves a small HTTP control endpoint, so that the fault schedule can be changed at runtime,
+// e.g. to run chaos experiments against a deployed application without recompiling it.  The control endpoint's address
+// is the `bind_addr` configuration of the injector's control address, e.g. `cart_service.server.faults.control.bind_addr`.
+// See the [runtime/plugins/faults] package for the endpoint's API.
+//
+// [runtime/plugins/faults]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/faults
+package faults
+
+import (
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/faults"
+	"golang.org/x/exp/slog"
+)
+
+// Adds a fault injector to the server side of the specified service.
+// Faults are injected by the server, so they affect calls from all of the service's clients.
+// Usage:
+//
+//	AddServer(spec, "my_service", faults.Error("MyMethod", 0.1))
+func AddServer(spec wiring.WiringSpec, serviceName string, options ...Option) {
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add a fault injector to " + serviceName + " as it is not a pointer")
+		return
+	}
+	injector := serviceName + ".server.faults"
+	define(spec, injector, ptr.AddDstModifier(spec, injector), options)
+}
+
+// Adds a fault injector to the client side of the specified service.
+// Faults are injected by the client, so the service itself is unaffected.
+// Usage:
+//
+//	AddClient(spec, "my_service", faults.Drop("MyMethod", 0.1))
+//
+// Clients in different processes share the same control address, so they must not be deployed on the same host.
+func AddClient(spec wiring.WiringSpec, serviceName string, options ...Option) {
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add a fault injector to " + serviceName + " as it is not a pointer")
+		return
+	}
+	injector := serviceName + ".client.faults"
+	define(spec, injector, ptr.AddSrcModifier(spec, injector), options)
+}
+
+func define(spec wiring.WiringSpec, injector string, next string, options []Option) {
+	controlAddr := injector + ".control.addr"
+	address.Define[*FaultInjector](spec, controlAddr, injector)
+
+	spec.Define(injector, &FaultInjector{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+		if err := ns.Get(next, &wrapped); err != nil {
+			return nil, blueprint.Errorf("FaultInjector %s expected %s to be a golang.Service, but encountered %s", injector, next, err)
+		}
+
+		node, err := newFaultInjector(injector, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+
+		err = address.Bind[*FaultInjector](ns, controlAddr, node, &node.Control)
+		return node, err
+	})
+}
+
+// An option for [AddServer] and [AddClient] that adds a rule to the injector's initial fault schedule.
+// Rules are considered in order, and at most one fault is injected into each call.
+type Option func(*FaultInjector) error
+
+// [Error] is an [Option] that fails calls to `method` with `probability`, between 0 and 1, by returning an error
+// without making the call.  `method` can be "*" to fail calls to all methods.
+func Error(method string, probability float64) Option {
+	return rule(method, runtime.Error, probability)
+}
+
+// [Panic] is an [Option] that panics in calls to `method` with `probability`, between 0 and 1, without making the call.
+// `method` can be "*" for all methods.
+func Panic(method string, probability float64) Option {
+	return rule(method, runtime.Panic, probability)
+}
+
+// [Hang] is an [Option] that hangs calls to `method` with `probability`, between 0 and 1, until the caller's context is
+// done, without making the call.  `method` can be "*" for all methods.
+func Hang(method string, probability float64) Option {
+	return rule(method, runtime.Hang, probability)
+}
+
+// [Drop] is an [Option] that drops the responses of calls to `method` with `probability`, between 0 and 1.  The call is
+// made, but an error is returned instead of its response.  `method` can be "*" for all methods.
+func Drop(method string, probability float64) Option {
+	return rule(method, runtime.Drop, probability)
+}
+
+// [Schedule] is an [Option] that adds the rules of a schedule string such as "GetCart:error:0.1,*:hang:0.01", i.e. a
+// comma-separated list of method:kind:probability rules, where kind is one of error, panic, hang, or drop.
+func Schedule(schedule string) Option {
+	return func(node *FaultInjector) error {
+		s, err := runtime.ParseSchedule(schedule)
+		if err != nil {
+			return blueprint.Errorf("invalid fault schedule for %v: %s", node.InstanceName, err.Error())
+		}
+		for _, r := range s.Rules {
+			if err := rule(r.Method, r.Kind, r.Probability)(node); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func rule(method string, kind runtime.Kind, probability float64) Option {
+	return func(node *FaultInjector) error {
+		if method == "" || strings.ContainsAny(method, ",: ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		r := runtime.Rule{Method: method, Kind: kind, Probability: probability}
+		if err := (runtime.Schedule{Rules: []runtime.Rule{r}}).Validate(); err != nil {
+			return blueprint.Errorf("invalid fault for %v: %s", node.InstanceName, err.Error())
+		}
+		node.Rules = append(node.Rules, r)
+		return nil
+	}
+}
@@ -9,6 +9,7 @@ require golang.org/x/exp v0.0.0-20240416160154-fe59bbe5cc7f
 require (
 	github.com/otiai10/copy v1.14.0
 	golang.org/x/mod v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -319,10 +319,19 @@ func (f *ParsedField) Parse() error {
 }
 
 func (f *ParsedFunc) Parse() error {
+	// Names of the type parameters of a generic function
+	var typeParams []string
+	if f.Ast.TypeParams != nil {
+		for _, field := range f.Ast.TypeParams.List {
+			for _, name := range field.Names {
+				typeParams = append(typeParams, name.Name)
+			}
+		}
+	}
 	if f.Ast.Params != nil {
 		for _, p := range f.Ast.Params.List {
 			// Determine the argument's type
-			argType := f.File.ResolveType(p.Type)
+			argType := f.File.ResolveType(p.Type, typeParams...)
 			if argType == nil {
 				return blueprint.Errorf("%v unable to resolve type of argument %v", f.Name, p.Type)
 			}
@@ -341,7 +350,7 @@ func (f *ParsedFunc) Parse() error {
 	if f.Ast.Results != nil {
 		for _, r := range f.Ast.Results.List {
 			// Determine the retval's type
-			retType := f.File.ResolveType(r.Type)
+			retType := f.File.ResolveType(r.Type, typeParams...)
 			if retType == nil {
 				return blueprint.Errorf("%v unable to resolve type of retval %v", f.Name, r.Type)
 			}
@@ -724,6 +733,32 @@ func (f *ParsedFunc) AsConstructor() *gocode.Constructor {
 	}
 }
 
+// Implements ir.ProvidesInputFiles, so that incremental compilation hashes the module's
+// source files rather than its parsed ASTs
+func (mod *ParsedModule) InputFiles() []string {
+	files := []string{filepath.Join(mod.SrcDir, "go.mod")}
+	for _, pkg := range mod.Packages {
+		files = append(files, pkg.InputFiles()...)
+	}
+	slices.Sort(files)
+	return files
+}
+
+// Implements ir.ProvidesInputFiles
+func (pkg *ParsedPackage) InputFiles() []string {
+	var files []string
+	for _, f := range pkg.Files {
+		files = append(files, f.Path)
+	}
+	slices.Sort(files)
+	return files
+}
+
+// Implements ir.ProvidesInputFiles
+func (f *ParsedFile) InputFiles() []string {
+	return []string{f.Path}
+}
+
 func (set *ParsedModuleSet) String() string {
 	var modStrings []string
 	for _, mod := range set.Modules {
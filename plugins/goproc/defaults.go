@@ -33,5 +33,5 @@ func buildDefaultGolangWorkspace(outputDir string, nodes []ir.IRNode) error {
 	if err != nil {
 		return err
 	}
-	return proc.GenerateArtifacts(procDir)
+	return ir.GenerateCached(proc, procDir, func() error { return proc.GenerateArtifacts(procDir) })
 }
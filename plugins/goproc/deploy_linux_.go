@@ -37,7 +37,7 @@ func (node *Process) AddProcessArtifacts(builder linux.ProcessWorkspace) error {
 	}
 
 	// Generate the regular artifacts for the process
-	if err := node.GenerateArtifacts(outputDir); err != nil {
+	if err := ir.GenerateCached(node, outputDir, func() error { return node.GenerateArtifacts(outputDir) }); err != nil {
 		return err
 	}
 
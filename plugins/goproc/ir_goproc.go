@@ -39,3 +39,18 @@ func (proc *Process) Name() string {
 func (proc *Process) String() string {
 	return ir.PrettyPrintNamespace(proc.InstanceName, "GolangProcessNode", proc.Edges, proc.Nodes)
 }
+
+// Implements [ir.NamespaceNode]
+func (proc *Process) GetNamespaceType() string {
+	return "GolangProcessNode"
+}
+
+// Implements [ir.NamespaceNode]
+func (proc *Process) GetArgNodes() []ir.IRNode {
+	return proc.Edges
+}
+
+// Implements [ir.NamespaceNode]
+func (proc *Process) GetChildNodes() []ir.IRNode {
+	return proc.Nodes
+}
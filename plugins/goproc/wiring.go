@@ -165,6 +165,9 @@ func CreateClientProcess(spec wiring.WiringSpec, procName string, children ...st
 		}
 		return proc, err
 	})
+	for _, child := range children {
+		wiring.AddReference(spec, procName, wiring.Reference{To: child})
+	}
 
 	return procName
 }
@@ -175,6 +178,7 @@ func CreateClientProcess(spec wiring.WiringSpec, procName string, children ...st
 // [opentelemetry]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/opentelemetry
 func SetMetricCollector(spec wiring.WiringSpec, procName string, metricCollNodeName string) {
 	spec.SetProperty(procName, "metricCollector", metricCollNodeName)
+	wiring.AddReference(spec, procName, wiring.Reference{To: metricCollNodeName})
 }
 
 // SetLogger is not used directly by wiring specs; instead it is used by other plugins such as
@@ -183,6 +187,7 @@ func SetMetricCollector(spec wiring.WiringSpec, procName string, metricCollNodeN
 // [opentelemetry]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/opentelemetry
 func SetLogger(spec wiring.WiringSpec, procName string, loggerNodeName string) {
 	spec.SetProperty(procName, "logger", loggerNodeName)
+	wiring.AddReference(spec, procName, wiring.Reference{To: loggerNodeName})
 }
 
 // Defines the default metric collector
@@ -45,6 +45,21 @@ func (lib *testLibrary) String() string {
 	return ir.PrettyPrintNamespace(lib.LibraryName, "GolangTests", lib.Edges, lib.Nodes)
 }
 
+// Implements [ir.NamespaceNode]
+func (lib *testLibrary) GetNamespaceType() string {
+	return "GolangTests"
+}
+
+// Implements [ir.NamespaceNode]
+func (lib *testLibrary) GetArgNodes() []ir.IRNode {
+	return lib.Edges
+}
+
+// Implements [ir.NamespaceNode]
+func (lib *testLibrary) GetChildNodes() []ir.IRNode {
+	return lib.Nodes
+}
+
 /*
 Implements [ir.ArtifactGenerator]
 
@@ -27,7 +27,13 @@ func GenerateClient(builder golang.ModuleBuilder, service *gocode.ServiceInterfa
 	client.Imports.AddPackages(
 		"context", "time",
 		"google.golang.org/grpc",
+		"google.golang.org/grpc/credentials",
 		"google.golang.org/grpc/credentials/insecure",
+		"google.golang.org/grpc/metadata",
+		"google.golang.org/grpc/status",
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig",
 	)
 
 	slog.Info(fmt.Sprintf("Generating %v/%v.go", client.Package.PackageName, client.Name))
@@ -56,9 +62,18 @@ type {{.Name}} struct {
 	Timeout time.Duration
 }
 
-func New_{{.Name}}(ctx context.Context, serverAddress string) (*{{.Name}}, error) {
+// tlsCredentials is the name of the client's TLS credentials, or the empty string if the server does not use TLS
+func New_{{.Name}}(ctx context.Context, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Client(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
 	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if tlsConfig != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
 	duration, err := time.ParseDuration("1s")
 	if err != nil {
 		return nil, err
@@ -87,12 +102,18 @@ func (client *{{$receiver}}) {{SignatureWithRetVars $f}} {
 	ctx, cancel := context.WithTimeout(ctx, client.Timeout)
 	defer cancel()
 
-	// Make the remote call
-	rsp, err := client.Client.{{$f.Name}}(ctx, req)
-	if err == nil {
-		err = ctx.Err()
-	}
+	// Propagate the deadline and metadata of the call
+	md := metadata.MD{}
+	propagation.Inject(ctx, propagation.MultiMapCarrier(md))
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	// Make the remote call, and reconstruct the error that it returns
+	var trailer metadata.MD
+	rsp, err := client.Client.{{$f.Name}}(ctx, req, grpc.Trailer(&trailer))
 	if err != nil {
+		if st, isStatus := status.FromError(err); isStatus {
+			err = rpcerrors.FromStatus(rpcerrors.Code(st.Code()), st.Message(), trailer.Get(rpcerrors.Header))
+		}
 		return
 	}
 
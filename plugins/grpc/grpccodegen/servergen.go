@@ -29,8 +29,15 @@ func GenerateServerHandler(builder golang.ModuleBuilder, service *gocode.Service
 	}
 
 	server.Imports.AddPackages(
-		"context", "net",
+		"context", "crypto/tls", "net",
 		"google.golang.org/grpc",
+		"google.golang.org/grpc/codes",
+		"google.golang.org/grpc/credentials",
+		"google.golang.org/grpc/metadata",
+		"google.golang.org/grpc/status",
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig",
 	)
 
 	slog.Info(fmt.Sprintf("Generating %v/%v_GRPCServer.go", server.Package.PackageName, service.Name))
@@ -57,12 +64,19 @@ type {{.Name}} struct {
 	Unimplemented{{.Service.Name}}Server
 	Service {{.Imports.NameOf .Service.UserType}}
 	Address string
+	TLSConfig *tls.Config // nil if the server does not use TLS
 }
 
-func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string) (*{{.Name}}, error) {
+// tlsCredentials is the name of the server's TLS credentials, or the empty string if the server does not use TLS
+func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Server(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
 	handler := &{{.Name}}{}
 	handler.Service = service
 	handler.Address = serverAddress
+	handler.TLSConfig = tlsConfig
 	return handler, nil
 }
 
@@ -73,7 +87,11 @@ func (handler *{{.Name}}) Run(ctx context.Context) error {
 		return err
 	}
 
-	s := grpc.NewServer()
+	var opts []grpc.ServerOption
+	if handler.TLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(handler.TLSConfig)))
+	}
+	s := grpc.NewServer(opts...)
 	Register{{.Service.Name}}Server(s, handler)
 
 	go func() {
@@ -91,10 +109,17 @@ func (handler *{{.Name}}) Run(ctx context.Context) error {
 {{ range $_, $f := .Service.Methods }}
 func (handler *{{$receiver}}) {{$f.Name -}}
 		(ctx context.Context, req *{{$service}}_{{$f.Name}}_Request) (*{{$service}}_{{$f.Name}}_Response, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx, cancel := propagation.Extract(ctx, propagation.MultiMapCarrier(md))
+	defer cancel()
+
 	{{ArgVarsEquals $f}} req.unmarshall()
 	{{RetVars $f "err"}} := handler.Service.{{$f.Name}}({{ArgVars $f "ctx"}})
 	if err != nil {
-		return nil, err
+		// The envelope of the error is sent in a trailer, so that clients can reconstruct it
+		envelope := rpcerrors.Encode(err)
+		grpc.SetTrailer(ctx, metadata.Pairs(rpcerrors.Header, envelope.String()))
+		return nil, status.Error(codes.Code(envelope.Code), envelope.Message)
 	}
 
 	rsp := &{{$service}}_{{$f.Name}}_Response{}
@@ -9,6 +9,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
 	"github.com/blueprint-uservices/blueprint/plugins/grpc/grpccodegen"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 	"golang.org/x/exp/slog"
 )
 
@@ -37,6 +38,9 @@ func newGolangClient(name string, addr *address.Address[*golangServer]) (*golang
 }
 
 func (n *golangClient) String() string {
+	if n.ServerAddr.Server != nil && n.ServerAddr.Server.TLS != nil {
+		return n.InstanceName + " = GRPCClient(" + n.ServerAddr.Dial.Name() + ", " + n.ServerAddr.Server.TLS.Mode.String() + ")"
+	}
 	return n.InstanceName + " = GRPCClient(" + n.ServerAddr.Dial.Name() + ")"
 }
 
@@ -112,12 +116,24 @@ func (node *golangClient) AddInstantiation(builder golang.NamespaceBuilder) erro
 			Arguments: []gocode.Variable{
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "addr", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "tlsCredentials", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
+	// Clients of servers that use TLS are issued their credentials when they are instantiated
+	var creds *tlscerts.Credentials
+	if server := node.ServerAddr.Server; server.TLS != nil {
+		if creds, err = server.TLS.IssueClient(node.InstanceName+".tls", node.InstanceName); err != nil {
+			return err
+		}
+		if err := creds.AddInstantiation(builder); err != nil {
+			return err
+		}
+	}
+
 	slog.Info(fmt.Sprintf("Instantiating GRPCClient %v in %v/%v", node.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.ServerAddr.Dial})
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.ServerAddr.Dial, tlscerts.Arg(creds)})
 }
 
 func (node *golangClient) ImplementsGolangNode()    {}
@@ -9,6 +9,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
 	"github.com/blueprint-uservices/blueprint/plugins/grpc/grpccodegen"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 	"golang.org/x/exp/slog"
 )
 
@@ -25,6 +26,8 @@ type golangServer struct {
 	InstanceName string
 	Bind         *address.BindConfig
 	Wrapped      golang.Service
+	TLSMode      tlscerts.Mode
+	TLS          *tlscerts.Credentials // The credentials of the server, if it uses TLS
 
 	outputPackage string
 }
@@ -52,6 +55,9 @@ func newGolangServer(name string, service golang.Service) (*golangServer, error)
 }
 
 func (n *golangServer) String() string {
+	if n.TLS != nil {
+		return n.InstanceName + " = GRPCServer(" + n.Wrapped.Name() + ", " + n.Bind.Name() + ", " + n.TLS.Mode.String() + ")"
+	}
 	return n.InstanceName + " = GRPCServer(" + n.Wrapped.Name() + ", " + n.Bind.Name() + ")"
 }
 
@@ -106,12 +112,19 @@ func (node *golangServer) AddInstantiation(builder golang.NamespaceBuilder) erro
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "service", Type: iface},
 				{Name: "serverAddr", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "tlsCredentials", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
+	if node.TLS != nil {
+		if err := node.TLS.AddInstantiation(builder); err != nil {
+			return err
+		}
+	}
+
 	slog.Info(fmt.Sprintf("Instantiating GRPCServer %v in %v/%v", node.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Bind})
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Bind, tlscerts.Arg(node.TLS)})
 }
 
 func (node *golangServer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
@@ -15,6 +15,10 @@
 // Any application-level service modifiers (e.g. tracing) should be applied to the service *before*
 // deploying it with gRPC.
 //
+// To encrypt the traffic between clients and the server, deploy the service with the [TLS] or [MutualTLS] option:
+//
+//	grpc.Deploy(spec, "my_service", grpc.TLS())
+//
 // After deploying a service to gRPC, you will probably want to deploy the service in a process.
 //
 // # Example
@@ -29,6 +33,9 @@
 // The gRPC client requires an argument `dial_addr` to know which hostname and port to connect to.
 // This is a host:port string, typically looking something like "192.168.1.2:12345" or "myhost:12345"
 //
+// Servers and clients that use TLS do not require any arguments; their certificates are generated at compile time
+// and embedded in the processes that run them.  See the [tlscerts] package for details.
+//
 // Blueprint can automatically generate these addresses in some circumstances, but usually they have
 // to be specified by you when running the application, such as when running processes or containers.
 // For example, the process and container plugins will complain if arguments are missing.
@@ -46,6 +53,7 @@
 // can be found on the [gRPC Quick Start].
 //
 // [grpccodegen]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/grpc/grpccodegen
+// [tlscerts]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/tlscerts
 // [grpc wiring spec]: https://github.com/Blueprint-uServices/blueprint/tree/main/examples/sockshop/wiring/specs/grpc.go
 // [gRPC Quick Start]: https://grpc.io/docs/languages/go/quickstart/
 package grpc
@@ -57,6 +65,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 	"golang.org/x/exp/slog"
 )
 
@@ -73,7 +82,9 @@ import (
 // Deploying a service with GRPC increases the visibility of the service within the application.
 // By default, any other service running in any other container or namespace can now contact
 // this service.
-func Deploy(spec wiring.WiringSpec, serviceName string) {
+//
+// `options` configure the server, e.g. [TLS].
+func Deploy(spec wiring.WiringSpec, serviceName string, options ...Option) {
 	// The nodes that we are defining
 	grpcClient := serviceName + ".grpc_client"
 	grpcServer := serviceName + ".grpc_server"
@@ -114,9 +125,39 @@ func Deploy(spec wiring.WiringSpec, serviceName string) {
 		if err != nil {
 			return nil, err
 		}
+		for _, option := range options {
+			if err := option(server); err != nil {
+				return nil, err
+			}
+		}
+		if server.TLS, err = tlscerts.IssueServer(spec, grpcServer+".tls", serviceName, server.TLSMode); err != nil {
+			return nil, err
+		}
 
 		err = address.Bind[*golangServer](namespace, grpcAddr, server, &server.Bind)
 		server.Bind.PreferredPort = 12345
 		return server, err
 	})
 }
+
+// An option for [Deploy]
+type Option func(*golangServer) error
+
+// [TLS] is an [Option] that serves the service with TLS.  Clients verify that the server presents a certificate for
+// the name of the service, which is issued by the application's development CA at compile time.
+func TLS() Option {
+	return func(node *golangServer) error {
+		node.TLSMode = tlscerts.TLS
+		return nil
+	}
+}
+
+// [MutualTLS] is an [Option] that serves the service with mutual TLS.  In addition to [TLS], the server requires
+// clients to present a certificate issued by the application's development CA, which is issued to each client at
+// compile time.
+func MutualTLS() Option {
+	return func(node *golangServer) error {
+		node.TLSMode = tlscerts.MutualTLS
+		return nil
+	}
+}
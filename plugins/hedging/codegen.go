@@ -0,0 +1,78 @@
+package hedging
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	client := clientArgs{
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_HedgingClient",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	client.Imports.AddPackages("context")
+	client.Hedging = client.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/hedging")
+
+	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, wrapped.BaseName+"HedgingClient"))
+	outputFile := filepath.Join(client.Package.Path, wrapped.BaseName+"_HedgingClient.go")
+	return gogen.ExecuteTemplateToFile("Hedging", clientTemplate, client, outputFile)
+}
+
+type clientArgs struct {
+	Package golang.PackageInfo
+	Service *gocode.ServiceInterface
+	Name    string
+	Hedging string // The import name of the runtime hedging package
+	Imports *gogen.Imports
+}
+
+// Each attempt stores its results in the return values only if its response is the one that is used
+var clientTemplate = `// Blueprint: Auto-generated by Hedging Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Client {{.Imports.NameOf .Service.UserType}}
+	hedger *{{.Hedging}}.ServiceHedger
+}
+
+func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}, service string, policies string) (*{{.Name}}, error) {
+	hedger, err := {{.Hedging}}.NewServiceHedger(ctx, service, policies)
+	if err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.Client = client
+	handler.hedger = hedger
+	return handler, nil
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	err = client.hedger.Call(ctx, "{{$f.Name}}", func(ctx context.Context) (func(), error) {
+		{{range $i, $_ := $f.Returns}}r{{$i}}, {{end}}err := client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+		{{- if $f.Returns}}
+		return func() { {{RetVars $f}} = {{range $i, $_ := $f.Returns}}{{if $i}}, {{end}}r{{$i}}{{end}} }, err
+		{{- else}}
+		return nil, err
+		{{- end}}
+	})
+	return
+}
+{{end}}
+`
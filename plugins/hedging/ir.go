@@ -0,0 +1,112 @@
+package hedging
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/hedging"
+)
+
+// Blueprint IR node representing a client that hedges calls
+type HedgingClient struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName string
+	Wrapped      golang.Service
+
+	outputPackage string
+	ServiceName   string           // The name of the service called by the client, for metrics
+	Policies      runtime.Policies // Keyed by method name; only these methods are hedged
+
+	idempotent map[string]bool           // The methods marked as idempotent in the wiring spec
+	options    map[string]runtime.Policy // The options of the service ("*") and of methods, before they are merged into Policies
+}
+
+func (node *HedgingClient) ImplementsGolangNode() {}
+
+func (node *HedgingClient) Name() string {
+	return node.InstanceName
+}
+
+func (node *HedgingClient) String() string {
+	return node.Name() + " = Hedging(" + node.Wrapped.Name() + ", " + node.Policies.String() + ")"
+}
+
+func newHedgingClient(name string, server ir.IRNode, serviceName string) (*HedgingClient, error) {
+	serverNode, is_callable := server.(golang.Service)
+	if !is_callable {
+		return nil, blueprint.Errorf("hedging client wrapper requires %s to be a golang service but got %s", server.Name(), reflect.TypeOf(server).String())
+	}
+
+	node := &HedgingClient{}
+	node.InstanceName = name
+	node.Wrapped = serverNode
+	node.outputPackage = "hedging"
+	node.ServiceName = serviceName
+	node.Policies = make(runtime.Policies)
+	node.idempotent = make(map[string]bool)
+	node.options = make(map[string]runtime.Policy)
+
+	return node, nil
+}
+
+func (node *HedgingClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+func (node *HedgingClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+func (node *HedgingClient) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for method := range node.Policies {
+		if _, exists := iface.Methods[method]; !exists {
+			return blueprint.Errorf("unable to hedge %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+	}
+
+	return generateClient(builder, iface, node.outputPackage)
+}
+
+func (node *HedgingClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_HedgingClient", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "client", Type: iface},
+				{Name: "service", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "policies", Type: &gocode.BasicType{Name: "string"}},
+			},
+		},
+	}
+
+	args := []ir.IRNode{node.Wrapped, &ir.IRValue{Value: node.ServiceName}, &ir.IRValue{Value: node.Policies.String()}}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
+}
@@ -0,0 +1,192 @@
+// Package hedging provides a Blueprint modifier for the client side of service calls.
+//
+// The plugin wraps clients with a hedging mechanism that reduces tail latency.  When a call to a hedged method has
+// not completed after a delay, the client sends a duplicate request, and uses the first successful response and
+// cancels the others.  The delay is either fixed ([Delay]) or a percentile of the method's observed latencies
+// ([Percentile]).  Hedges add load to the service, so [MaxRate] caps the ratio of hedges to calls.
+//
+// A hedged call may be processed more than once by the service, so only the methods that are marked with
+// [Idempotent] are hedged.  Calls to other methods are passed through.  Example usage to hedge GetCart after 50ms,
+// and GetProduct after the 95th percentile of its latencies:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/hedging"
+//	hedging.Add(spec, "cart_service", hedging.Idempotent("GetCart", "GetProduct"), hedging.Delay("50ms"),
+//		hedging.Method("GetProduct", hedging.Percentile(0.95)))
+//
+// Modifiers that are added after hedging apply to each attempt; e.g. to bound each attempt with a timeout, add the
+// timeouts plugin after hedging.  The plugin utilizes code in the [runtime/plugins/hedging]
+// package, and records metrics using the application's metric collector.
+//
+// [runtime/plugins/hedging]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/hedging
+package hedging
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/hedging"
+	"golang.org/x/exp/slog"
+)
+
+// Hedges calls to the idempotent methods of the specified service, as configured by `options`.  At least one method
+// must be marked with [Idempotent].
+// Usage:
+//
+//	Add(spec, "serviceA", hedging.Idempotent("GetCart"), hedging.Delay("50ms"))
+func Add(spec wiring.WiringSpec, serviceName string, options ...Option) {
+	clientWrapper := serviceName + ".client.hedging"
+
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add hedging to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	clientNext := ptr.AddSrcModifier(spec, clientWrapper)
+
+	spec.Define(clientWrapper, &HedgingClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+
+		if err := ns.Get(clientNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("Hedging %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
+		}
+
+		node, err := newHedgingClient(clientWrapper, wrapped, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node, "*"); err != nil {
+				return nil, err
+			}
+		}
+		if len(node.idempotent) == 0 {
+			return nil, blueprint.Errorf("no methods of %v are marked with hedging.Idempotent for %v", serviceName, clientWrapper)
+		}
+		for method := range node.options {
+			if method != "*" && !node.idempotent[method] {
+				return nil, blueprint.Errorf("unable to hedge %v.%v as it is not marked with hedging.Idempotent", serviceName, method)
+			}
+		}
+		for method := range node.idempotent {
+			policy := node.options["*"].Merge(node.options[method])
+			if err := policy.Validate(); err != nil {
+				return nil, blueprint.Errorf("invalid policy for %v of %v: %s", method, clientWrapper, err.Error())
+			}
+			node.Policies[method] = policy
+		}
+		return node, nil
+	})
+}
+
+// An option for [Add] that configures the policy of method, which is "*" for all of the idempotent methods.
+type Option func(node *HedgingClient, method string) error
+
+func update(f func(policy *runtime.Policy) error) Option {
+	return func(node *HedgingClient, method string) error {
+		policy := node.options[method]
+		if err := f(&policy); err != nil {
+			return blueprint.Errorf("invalid hedging option for %v of %v: %s", method, node.InstanceName, err.Error())
+		}
+		node.options[method] = policy
+		return nil
+	}
+}
+
+// [Idempotent] is an [Option] that marks `methods` as idempotent, so that their calls are hedged.  Calls to methods
+// that are not idempotent are never hedged.
+func Idempotent(methods ...string) Option {
+	return func(node *HedgingClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("hedging.Idempotent cannot be nested in hedging.Method(%v) for %v", scope, node.InstanceName)
+		}
+		for _, method := range methods {
+			if method == "" || method == "*" || strings.ContainsAny(method, ",:;= ") {
+				return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+			}
+			node.idempotent[method] = true
+		}
+		return nil
+	}
+}
+
+// [Delay] is an [Option] that hedges calls that have not completed after `delay`, e.g. "50ms".  With a
+// [Percentile], the delay is used until enough latencies are observed to estimate the percentile.
+func Delay(delay string) Option {
+	return update(func(policy *runtime.Policy) (err error) {
+		policy.Delay, err = time.ParseDuration(delay)
+		return
+	})
+}
+
+// [Percentile] is an [Option] that hedges calls that have not completed after the `p`th percentile of the latencies
+// observed by the client, e.g. 0.95.
+func Percentile(p float64) Option {
+	return update(func(policy *runtime.Policy) error {
+		policy.Percentile = p
+		return nil
+	})
+}
+
+// [MaxHedges] is an [Option] that sets the maximum number of hedges for a call; the default is 1.  Each hedge is sent
+// after a further delay.
+func MaxHedges(n int) Option {
+	return update(func(policy *runtime.Policy) error {
+		policy.MaxHedges = n
+		return nil
+	})
+}
+
+// [MaxRate] is an [Option] that caps the ratio of hedges to calls, e.g. 0.2 to add at most 20% extra load; the
+// default is 0.1.
+func MaxRate(rate float64) Option {
+	return update(func(policy *runtime.Policy) error {
+		policy.MaxRate = rate
+		return nil
+	})
+}
+
+// [Method] is an [Option] that applies `options` to the specified method instead of all of the idempotent methods.
+// The options override the corresponding options of the service.  The method must be marked with [Idempotent].
+func Method(method string, options ...Option) Option {
+	return func(node *HedgingClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("hedging.Method(%v) cannot be nested in hedging.Method(%v) for %v", method, scope, node.InstanceName)
+		}
+		if method == "" || method == "*" || strings.ContainsAny(method, ",:;= ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		for _, option := range options {
+			if err := option(node, method); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// [Policies] is an [Option] that sets policies from a string such as "GetCart=delay:50ms;GetProduct=percentile:0.95";
+// see the [runtime/plugins/hedging] package for the format.  The methods in the string are marked as [Idempotent].
+//
+// [runtime/plugins/hedging]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/hedging
+func Policies(policies string) Option {
+	return func(node *HedgingClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("hedging.Policies cannot be nested in hedging.Method(%v) for %v", scope, node.InstanceName)
+		}
+		parsed, err := runtime.ParsePolicies(policies)
+		if err != nil {
+			return blueprint.Errorf("invalid policies for %v: %s", node.InstanceName, err.Error())
+		}
+		for method, policy := range parsed {
+			node.idempotent[method] = true
+			node.options[method] = policy
+		}
+		return nil
+	}
+}
@@ -25,7 +25,10 @@ func GenerateClient(builder golang.ModuleBuilder, service *gocode.ServiceInterfa
 	}
 
 	client.Imports.AddPackages(
-		"net/http", "encoding/json", "context", "time", "net/url", "fmt", "io",
+		"net/http", "encoding/json", "context", "time", "net/url", "io",
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig",
 	)
 
 	slog.Info(fmt.Sprintf("Generating %v/%v.go", client.Package.PackageName, client.Name))
@@ -52,7 +55,12 @@ type {{.Name}} struct {
 	ServerAddress string
 }
 
-func New_{{.Name}}(ctx context.Context, serverAddress string) (*{{.Name}}, error) {
+// tlsCredentials is the name of the client's TLS credentials, or the empty string if the server does not use TLS
+func New_{{.Name}}(ctx context.Context, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Client(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
 	duration, err := time.ParseDuration("1s")
 	if err != nil {
 		return nil, err
@@ -64,6 +72,12 @@ func New_{{.Name}}(ctx context.Context, serverAddress string) (*{{.Name}}, error
 	c.Client = client
 	c.Timeout = duration
 	c.ServerAddress = "http://" + serverAddress
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+		c.ServerAddress = "https://" + serverAddress
+	}
 	return c, nil
 }
 
@@ -90,16 +104,16 @@ func (client *{{$receiver}}) {{SignatureWithRetVars $f}} {
 	}
 	encoded_url.RawQuery = vals.Encode()
 
-	resp, err := client.Client.Get(encoded_url.String())
+	http_req, err := http.NewRequestWithContext(ctx, "GET", encoded_url.String(), nil)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-	statusOk := resp.StatusCode >= 200 && resp.StatusCode < 300
-	if !statusOk {
-		err = fmt.Errorf("StatusCode was %d", resp.StatusCode)
+	propagation.Inject(ctx, propagation.HeaderCarrier(http_req.Header))
+	resp, err := client.Client.Do(http_req)
+	if err != nil {
 		return
 	}
+	defer resp.Body.Close()
 	response := struct {
 		{{range $i, $arg := $f.Returns}}
 		Ret{{$i}} {{NameOf $arg.Type}}
@@ -109,6 +123,10 @@ func (client *{{$receiver}}) {{SignatureWithRetVars $f}} {
 	if err != nil {
 		return
 	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = rpcerrors.FromHTTP(resp.StatusCode, resp_bytes)
+		return
+	}
 	err = json.Unmarshal(resp_bytes, &response)
 	if err != nil {
 		return
@@ -0,0 +1,385 @@
+package httpcodegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+	"golang.org/x/exp/slog"
+)
+
+// The path at which the generated server serves the OpenAPI document of the service; keep in sync with the templates
+const openAPIPath = "/openapi.json"
+
+/*
+Generates the OpenAPI 3 document of a service that is served with the provided methods, writes it to
+{{BaseName}}_openapi.json in outputPackage, and returns it.
+
+The JSON Schemas of arguments and results are derived from the struct and type declarations that goparser finds in
+the workflow spec and in the generated code.  Types that cannot be described, such as generic structs or types from
+packages that goparser does not find, are described by the empty schema, which allows any value, and a warning is
+logged.  The fields of embedded structs are left out of the schemas of structs, even though encoding/json includes
+them.
+*/
+func generateOpenAPI(builder golang.ModuleBuilder, service *gocode.ServiceInterface, methods []restMethod, outputPackage string) (string, error) {
+	// Parse the current output code to get definitions that may have been generated by other plugins
+	modules := workflowspec.Get().Derive().Modules
+	if err := modules.AddWorkspace(builder.Workspace().Info().Path); err != nil {
+		return "", err
+	}
+
+	b := &openAPIBuilder{
+		Code:  modules,
+		Names: make(map[gocode.UserType]string),
+		Doc: &openAPIDocument{
+			OpenAPI:    "3.0.3",
+			Info:       openAPIInfo{Title: service.Name, Version: "1.0.0"},
+			Paths:      make(map[string]map[string]*openAPIOperation),
+			Components: openAPIComponents{Schemas: make(map[string]*jsonSchema)},
+		},
+	}
+	b.Doc.Components.Schemas[errorSchemaName] = errorSchema()
+	for _, m := range methods {
+		b.addMethod(m)
+	}
+
+	doc, err := json.MarshalIndent(b.Doc, "", "  ")
+	if err != nil {
+		return "", blueprint.Errorf("unable to encode the OpenAPI document of %v due to %v", service.Name, err.Error())
+	}
+
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return "", err
+	}
+	slog.Info(fmt.Sprintf("Generating %v/%v_openapi.json", pkg.PackageName, service.BaseName))
+	outputFile := filepath.Join(pkg.Path, service.BaseName+"_openapi.json")
+	if err := os.WriteFile(outputFile, doc, 0644); err != nil {
+		return "", blueprint.Errorf("unable to write %v due to %v", outputFile, err.Error())
+	}
+	return string(doc), nil
+}
+
+// Describes the methods of the default mode, which are served at GET /{MethodName} with their arguments in URL query
+// parameters and their results in the response fields Ret0, Ret1, etc.
+func legacyMethods(service *gocode.ServiceInterface) []restMethod {
+	var methods []restMethod
+	for _, f := range sortedMethods(service) {
+		m := restMethod{Func: f, Route: Route{Verb: "GET", Path: "/" + f.Name}, QueryArgs: f.Arguments}
+		for i, ret := range f.Returns {
+			name := fmt.Sprintf("Ret%v", i)
+			m.Results = append(m.Results, restField{Variable: ret, Field: name, JSON: name})
+		}
+		methods = append(methods, m)
+	}
+	return methods
+}
+
+/* A subset of the OpenAPI 3.0 document structure */
+type (
+	openAPIDocument struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       openAPIInfo                             `json:"info"`
+		Paths      map[string]map[string]*openAPIOperation `json:"paths"` // Operations keyed by path then lowercase verb
+		Components openAPIComponents                       `json:"components"`
+	}
+
+	openAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	openAPIComponents struct {
+		Schemas map[string]*jsonSchema `json:"schemas"`
+	}
+
+	openAPIOperation struct {
+		OperationID string                      `json:"operationId"`
+		Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *openAPIBody                `json:"requestBody,omitempty"`
+		Responses   map[string]*openAPIResponse `json:"responses"`
+	}
+
+	openAPIParameter struct {
+		Name     string                   `json:"name"`
+		In       string                   `json:"in"`
+		Required bool                     `json:"required,omitempty"`
+		Schema   *jsonSchema              `json:"schema,omitempty"`
+		Content  map[string]*openAPIMedia `json:"content,omitempty"` // For parameters that are JSON-encoded
+	}
+
+	openAPIBody struct {
+		Content map[string]*openAPIMedia `json:"content"`
+	}
+
+	openAPIResponse struct {
+		Description string                   `json:"description"`
+		Content     map[string]*openAPIMedia `json:"content,omitempty"`
+	}
+
+	openAPIMedia struct {
+		Schema *jsonSchema `json:"schema"`
+	}
+
+	jsonSchema struct {
+		Ref                  string                 `json:"$ref,omitempty"`
+		AllOf                []*jsonSchema          `json:"allOf,omitempty"`
+		Type                 string                 `json:"type,omitempty"`
+		Format               string                 `json:"format,omitempty"`
+		Minimum              *int                   `json:"minimum,omitempty"`
+		Nullable             bool                   `json:"nullable,omitempty"`
+		Items                *jsonSchema            `json:"items,omitempty"`
+		AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+		Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+		Required             []string               `json:"required,omitempty"`
+		Enum                 []string               `json:"enum,omitempty"`
+	}
+
+	openAPIBuilder struct {
+		Code  *goparser.ParsedModuleSet
+		Doc   *openAPIDocument
+		Names map[gocode.UserType]string // Mapping from golang types to the names of their component schemas
+	}
+)
+
+func (b *openAPIBuilder) addMethod(m restMethod) {
+	op := &openAPIOperation{OperationID: m.Name, Responses: make(map[string]*openAPIResponse)}
+	for _, arg := range m.PathArgs {
+		op.Parameters = append(op.Parameters, b.parameter(arg, "path"))
+	}
+	for _, arg := range m.QueryArgs {
+		op.Parameters = append(op.Parameters, b.parameter(arg, "query"))
+	}
+	if len(m.BodyArgs) > 0 {
+		op.RequestBody = &openAPIBody{Content: jsonContent(b.object(m.BodyArgs))}
+	}
+
+	response := b.object(m.Results)
+	op.Responses["200"] = &openAPIResponse{Description: "The results of " + m.Name, Content: jsonContent(response)}
+	op.Responses["default"] = &openAPIResponse{
+		Description: "The error returned by " + m.Name + ", or why the arguments could not be decoded, with the HTTP status of its code",
+		Content:     jsonContent(&jsonSchema{Ref: "#/components/schemas/" + errorSchemaName}),
+	}
+
+	// Path templates in OpenAPI do not have regular expressions
+	path := pathVariable.ReplaceAllString(m.Route.Path, "{$1}")
+	if _, exists := b.Doc.Paths[path]; !exists {
+		b.Doc.Paths[path] = make(map[string]*openAPIOperation)
+	}
+	b.Doc.Paths[path][strings.ToLower(m.Route.Verb)] = op
+}
+
+// Arguments of type string are sent as-is; other arguments are JSON-encoded
+func (b *openAPIBuilder) parameter(arg gocode.Variable, in string) *openAPIParameter {
+	param := &openAPIParameter{Name: arg.Name, In: in, Required: in == "path"}
+	schema := b.schema(arg.Type)
+	if basic, isBasic := arg.Type.(*gocode.BasicType); isBasic && basic.Name == "string" {
+		param.Schema = schema
+	} else {
+		param.Content = jsonContent(schema)
+	}
+	return param
+}
+
+// Returns the schema of a JSON object with the provided fields
+func (b *openAPIBuilder) object(fields []restField) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	for _, field := range fields {
+		schema.Properties[field.JSON] = b.schema(field.Type)
+	}
+	return schema
+}
+
+var basicSchemas = map[string]jsonSchema{
+	"bool":   {Type: "boolean"},
+	"string": {Type: "string"},
+	"int":    {Type: "integer", Format: "int64"}, "int8": {Type: "integer", Format: "int32"},
+	"int16": {Type: "integer", Format: "int32"}, "int32": {Type: "integer", Format: "int32"},
+	"int64": {Type: "integer", Format: "int64"},
+	"uint":  {Type: "integer", Format: "int64"}, "uint8": {Type: "integer", Format: "int32"},
+	"uint16": {Type: "integer", Format: "int32"}, "uint32": {Type: "integer", Format: "int64"},
+	"uint64":  {Type: "integer", Format: "int64"},
+	"byte":    {Type: "integer", Format: "int32"},
+	"rune":    {Type: "integer", Format: "int32"},
+	"float32": {Type: "number", Format: "float"},
+	"float64": {Type: "number", Format: "double"},
+}
+
+// Schemas of types from the standard library that have their own JSON encodings
+var builtinSchemas = map[string]jsonSchema{
+	"time.Time":                {Type: "string", Format: "date-time"},
+	"time.Duration":            {Type: "integer", Format: "int64"},
+	"encoding/json.RawMessage": {},
+}
+
+// Returns the schema of t, or the empty schema if t cannot be described.  The errors of describe are logged as
+// warnings, so they do not include the call stack.
+func (b *openAPIBuilder) schema(t gocode.TypeName) *jsonSchema {
+	schema, err := b.describe(t)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Describing %v as any JSON value in the OpenAPI document of %v: %v", t, b.Doc.Info.Title, err.Error()))
+		return &jsonSchema{}
+	}
+	return schema
+}
+
+func (b *openAPIBuilder) describe(t gocode.TypeName) (*jsonSchema, error) {
+	switch t := t.(type) {
+	case *gocode.BasicType:
+		if schema, exists := basicSchemas[t.Name]; exists {
+			if strings.HasPrefix(t.Name, "uint") || t.Name == "byte" {
+				schema.Minimum = new(int)
+			}
+			return &schema, nil
+		}
+		return nil, fmt.Errorf("%v cannot be encoded as JSON", t)
+	case *gocode.Slice:
+		// JSON encodes byte slices as base64 strings
+		if basic, isBasic := t.SliceOf.(*gocode.BasicType); isBasic && (basic.Name == "byte" || basic.Name == "uint8") {
+			return &jsonSchema{Type: "string", Format: "byte"}, nil
+		}
+		return &jsonSchema{Type: "array", Items: b.schema(t.SliceOf), Nullable: true}, nil
+	case *gocode.Ellipsis:
+		return &jsonSchema{Type: "array", Items: b.schema(t.EllipsisOf), Nullable: true}, nil
+	case *gocode.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: b.schema(t.ValueType), Nullable: true}, nil
+	case *gocode.Pointer:
+		schema := b.schema(t.PointerTo)
+		if schema.Ref != "" {
+			// Siblings of $ref are ignored, so nullable references are wrapped
+			return &jsonSchema{AllOf: []*jsonSchema{schema}, Nullable: true}, nil
+		}
+		schema.Nullable = true
+		return schema, nil
+	case *gocode.AnyType, *gocode.InterfaceType:
+		return &jsonSchema{}, nil
+	case *gocode.UserType:
+		return b.userType(t)
+	default:
+		return nil, fmt.Errorf("%v cannot be described by a JSON schema", t)
+	}
+}
+
+// Adds a component schema for a declared type, and returns a reference to it
+func (b *openAPIBuilder) userType(t *gocode.UserType) (*jsonSchema, error) {
+	if gocode.IsBuiltinPackage(t.Package) {
+		if schema, exists := builtinSchemas[t.Package+"."+t.Name]; exists {
+			return &schema, nil
+		}
+		return nil, fmt.Errorf("%v does not have a known JSON encoding", t)
+	}
+
+	// The schema might already exist
+	if name, exists := b.Names[*t]; exists {
+		return &jsonSchema{Ref: "#/components/schemas/" + name}, nil
+	}
+
+	pkg, err := b.Code.GetPackage(t.Package)
+	if err != nil {
+		return nil, fmt.Errorf("could not find package %v for type %v", t.Package, t)
+	}
+
+	struc, hasStruct := pkg.Structs[t.Name]
+	var underlying gocode.TypeName
+	if hasStruct {
+		if len(struc.TypeParams) > 0 {
+			return nil, fmt.Errorf("%v is generic, which is not supported", t)
+		}
+	} else if underlying = declaredType(pkg, t.Name); underlying == nil {
+		return nil, fmt.Errorf("could not find %v within %v", t.Name, t.Package)
+	}
+
+	// Types with the same name in different packages are qualified by their package
+	name := t.Name
+	if _, exists := b.Doc.Components.Schemas[name]; exists {
+		name = pkg.ShortName + "." + t.Name
+	}
+	b.Names[*t] = name
+
+	schema := &jsonSchema{}
+	b.Doc.Components.Schemas[name] = schema
+	if hasStruct {
+		schema.Type = "object"
+		schema.Properties = make(map[string]*jsonSchema)
+		for _, field := range struc.FieldsList {
+			// We ignore promoted and anonymous struct / interface extensions, and unexported fields.  This differs
+			// from encoding/json, which includes the fields of embedded structs.
+			if _, isNamed := struc.Fields[field.Name]; !isNamed || !ast.IsExported(field.Name) {
+				continue
+			}
+			jsonName := jsonFieldName(field)
+			if jsonName == "-" {
+				continue
+			}
+			schema.Properties[jsonName] = b.schema(field.Type)
+		}
+	} else {
+		// Types such as enums are encoded the same as the type that they are declared as
+		*schema = *b.schema(underlying)
+	}
+	return &jsonSchema{Ref: "#/components/schemas/" + name}, nil
+}
+
+// Returns the name of a struct field in JSON, which is its name unless its json tag renames or omits it
+func jsonFieldName(field *goparser.ParsedField) string {
+	if field.Ast.Tag != nil {
+		if tag, err := strconv.Unquote(field.Ast.Tag.Value); err == nil {
+			name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+			if name != "" {
+				return name
+			}
+		}
+	}
+	return field.Name
+}
+
+// Returns the type that a non-struct type of pkg is declared as, or nil if it is not declared
+func declaredType(pkg *goparser.ParsedPackage, name string) gocode.TypeName {
+	for _, f := range pkg.Files {
+		for _, decl := range f.Ast.Decls {
+			d, isGenDecl := decl.(*ast.GenDecl)
+			if !isGenDecl {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if typespec, isTypeSpec := spec.(*ast.TypeSpec); isTypeSpec && typespec.Name.Name == name && typespec.TypeParams == nil {
+					return f.ResolveType(typespec.Type)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func jsonContent(schema *jsonSchema) map[string]*openAPIMedia {
+	return map[string]*openAPIMedia{"application/json": {Schema: schema}}
+}
+
+// The name of the component schema of the envelopes of errors, which is reserved so that it does not collide with
+// the schemas of types
+const errorSchemaName = "ErrorEnvelope"
+
+// Returns the schema of [rpcerrors.Envelope]
+func errorSchema() *jsonSchema {
+	return &jsonSchema{
+		Type:     "object",
+		Required: []string{"code", "message"},
+		Properties: map[string]*jsonSchema{
+			"code":    {Type: "string", Enum: rpcerrors.CodeNames()},
+			"message": {Type: "string"},
+			"type":    {Type: "string"},
+			"details": {},
+		},
+	}
+}
@@ -0,0 +1,431 @@
+package httpcodegen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+/*
+This function is used by the HTTP plugin to generate the server-side HTTP service in REST mode, where methods are
+served at their routes and accept JSON request bodies.
+*/
+func GenerateRESTServerHandler(builder golang.ModuleBuilder, service *gocode.ServiceInterface, routes Routes, outputPackage string) error {
+	methods, err := restMethods(service, routes)
+	if err != nil {
+		return err
+	}
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	openAPI, err := generateOpenAPI(builder, service, methods, outputPackage)
+	if err != nil {
+		return err
+	}
+
+	server := &restArgs{
+		Package: pkg,
+		Service: service,
+		Methods: methods,
+		Name:    service.BaseName + "_HTTPServerHandler",
+		OpenAPI: openAPI,
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	server.Imports.AddPackages("context", "crypto/tls", "encoding/json", "net/http", "github.com/gorilla/mux", "log",
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig")
+	for _, m := range methods {
+		if len(m.BodyArgs) > 0 {
+			server.Imports.AddPackages("errors", "io")
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Generating %v/%v_HTTPServer.go", server.Package.PackageName, service.BaseName))
+	outputFile := filepath.Join(server.Package.Path, service.BaseName+"_HTTPServer.go")
+	return gogen.ExecuteTemplateToFile("HTTPRESTServer", restServerTemplate, server, outputFile)
+}
+
+// This function is used by the HTTP plugin to generate the client-side HTTP service in REST mode
+func GenerateRESTClient(builder golang.ModuleBuilder, service *gocode.ServiceInterface, routes Routes, outputPackage string) error {
+	methods, err := restMethods(service, routes)
+	if err != nil {
+		return err
+	}
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	client := &restArgs{
+		Package: pkg,
+		Service: service,
+		Methods: methods,
+		Name:    service.BaseName + "_HTTPClient",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	client.Imports.AddPackages("bytes", "context", "encoding/json", "io", "net/http", "net/url", "time",
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig")
+
+	slog.Info(fmt.Sprintf("Generating %v/%v.go", client.Package.PackageName, client.Name))
+	outputFile := filepath.Join(client.Package.Path, client.Name+".go")
+	return gogen.ExecuteTemplateToFile("HTTPRESTClient", restClientTemplate, client, outputFile)
+}
+
+// Arguments to the REST template code
+type restArgs struct {
+	Package golang.PackageInfo
+	Service *gocode.ServiceInterface
+	Methods []restMethod
+	Name    string
+	OpenAPI string // The OpenAPI document of the service, which is only generated for the server
+	Imports *gogen.Imports
+}
+
+// A method and where its arguments and results are in requests and responses
+type restMethod struct {
+	gocode.Func
+	Route     Route
+	Path      []pathSegment     // The path template, split into literals and variables
+	PathArgs  []gocode.Variable // Arguments that are variables of the path template
+	QueryArgs []gocode.Variable // Arguments that are URL query parameters
+	BodyArgs  []restField       // Arguments that are fields of the JSON request body
+	Results   []restField       // The fields of the JSON response body, one for each return value except the error
+}
+
+type pathSegment struct {
+	Literal string
+	Arg     *gocode.Variable
+}
+
+// A field of a JSON request or response body
+type restField struct {
+	gocode.Variable
+	Field string // The name of the field in the generated struct
+	JSON  string // The name of the field in JSON
+}
+
+func restMethods(service *gocode.ServiceInterface, routes Routes) ([]restMethod, error) {
+	if err := routes.Validate(service); err != nil {
+		return nil, err
+	}
+	var methods []restMethod
+	for _, f := range sortedMethods(service) {
+		m := restMethod{Func: f, Route: routes.Get(f.Name)}
+
+		inPath := make(map[string]bool)
+		rest := m.Route.Path
+		for _, match := range pathVariable.FindAllStringSubmatchIndex(m.Route.Path, -1) {
+			name := m.Route.Path[match[2]:match[3]]
+			arg, _ := argument(f, name)
+			m.Path = append(m.Path, pathSegment{Literal: m.Route.Path[len(m.Route.Path)-len(rest) : match[0]]}, pathSegment{Arg: &arg})
+			rest = m.Route.Path[match[1]:]
+			if !inPath[name] {
+				m.PathArgs = append(m.PathArgs, arg)
+			}
+			inPath[name] = true
+		}
+		if rest != "" {
+			m.Path = append(m.Path, pathSegment{Literal: rest})
+		}
+
+		for _, arg := range f.Arguments {
+			switch {
+			case inPath[arg.Name]:
+			case m.Route.HasBody():
+				m.BodyArgs = append(m.BodyArgs, restField{Variable: arg, Field: fieldName(arg.Name), JSON: arg.Name})
+			default:
+				m.QueryArgs = append(m.QueryArgs, arg)
+			}
+		}
+
+		for i, ret := range f.Returns {
+			name := ret.Name
+			if name == "" && len(f.Returns) == 1 {
+				name = "result"
+			} else if name == "" {
+				name = fmt.Sprintf("result%v", i)
+			}
+			m.Results = append(m.Results, restField{Variable: ret, Field: fieldName(name), JSON: name})
+		}
+		methods = append(methods, m)
+	}
+	return methods, nil
+}
+
+// Returns an exported struct field name for a JSON field name
+func fieldName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+var restServerTemplate = `// Blueprint: Auto-generated by HTTP Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Service {{.Imports.NameOf .Service.UserType}}
+	Address string
+	TLSConfig *tls.Config // nil if the server does not use TLS
+}
+
+// tlsCredentials is the name of the server's TLS credentials, or the empty string if the server does not use TLS
+func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Server(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.Service = service
+	handler.Address = serverAddress
+	handler.TLSConfig = tlsConfig
+	return handler, nil
+}
+
+// Blueprint: Run is called automatically in a separate goroutine by runtime/plugins/golang/di.go
+func (handler *{{.Name}}) Run(ctx context.Context) error {
+	router := mux.NewRouter()
+	router.Methods("GET").Path("/openapi.json").HandlerFunc(handler.serveOpenAPI)
+	// Add routes for the mux router
+	{{ range $_, $m := .Methods }}
+	router.Methods("{{$m.Route.Verb}}").Path("{{$m.Route.Path}}").HandlerFunc(handler.{{$m.Name}})
+	{{- end}}
+	srv := &http.Server {
+		Addr: handler.Address,
+		Handler: router,
+		TLSConfig: handler.TLSConfig,
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			srv.Shutdown(ctx)
+		}
+	}()
+
+	if handler.TLSConfig != nil {
+		// The certificate and key of the server are in its TLSConfig
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// The OpenAPI document of the service
+const {{.Service.BaseName}}_OpenAPI = {{printf "%q" .OpenAPI}}
+
+// Serves the OpenAPI document of the service
+func (handler *{{.Name}}) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte({{.Service.BaseName}}_OpenAPI))
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $m := .Methods }}
+func (handler *{{$receiver}}) {{$m.Name -}}
+	(w http.ResponseWriter, r *http.Request) {
+	var err error
+	defer r.Body.Close()
+	{{- if $m.PathArgs}}
+	path_vars := mux.Vars(r)
+	{{- end}}
+	{{- range $_, $arg := $m.PathArgs}}
+	{{if eq (NameOf $arg.Type) "string" -}}
+	{{$arg.Name}} := path_vars["{{$arg.Name}}"]
+	{{- else -}}
+	var {{$arg.Name}} {{NameOf $arg.Type}}
+	if err = json.Unmarshal([]byte(path_vars["{{$arg.Name}}"]), &{{$arg.Name}}); err != nil {
+		rpcerrors.WriteHTTP(w, rpcerrors.New(rpcerrors.InvalidArgument, "invalid {{$arg.Name}}: " + err.Error()))
+		return
+	}
+	{{- end}}
+	{{- end}}
+	{{- range $_, $arg := $m.QueryArgs}}
+	{{if eq (NameOf $arg.Type) "string" -}}
+	{{$arg.Name}} := r.URL.Query().Get("{{$arg.Name}}")
+	{{- else -}}
+	var {{$arg.Name}} {{NameOf $arg.Type}}
+	if query_{{$arg.Name}} := r.URL.Query().Get("{{$arg.Name}}"); query_{{$arg.Name}} != "" {
+		if err = json.Unmarshal([]byte(query_{{$arg.Name}}), &{{$arg.Name}}); err != nil {
+			rpcerrors.WriteHTTP(w, rpcerrors.New(rpcerrors.InvalidArgument, "invalid {{$arg.Name}}: " + err.Error()))
+			return
+		}
+	}
+	{{- end}}
+	{{- end}}
+	{{- if $m.BodyArgs}}
+	req_body := struct {
+		{{- range $_, $arg := $m.BodyArgs}}
+		{{$arg.Field}} {{NameOf $arg.Type}} {{JsonField $arg.JSON}}
+		{{- end}}
+	}{}
+	if err = json.NewDecoder(r.Body).Decode(&req_body); err != nil && !errors.Is(err, io.EOF) {
+		rpcerrors.WriteHTTP(w, rpcerrors.New(rpcerrors.InvalidArgument, "invalid request body: " + err.Error()))
+		return
+	}
+	{{- range $_, $arg := $m.BodyArgs}}
+	{{$arg.Name}} := req_body.{{$arg.Field}}
+	{{- end}}
+	{{- end}}
+	ctx, cancel := propagation.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	defer cancel()
+	{{RetVars $m.Func "err"}} {{HasNewReturnVars $m.Func}} handler.Service.{{$m.Name}}({{ArgVars $m.Func "ctx"}})
+	if err != nil {
+		log.Println(err.Error())
+		rpcerrors.WriteHTTP(w, err)
+		return
+	}
+	response := struct {
+		{{- range $_, $ret := $m.Results}}
+		{{$ret.Field}} {{NameOf $ret.Type}} {{JsonField $ret.JSON}}
+		{{- end}}
+	}{}
+	{{- range $i, $ret := $m.Results}}
+	response.{{$ret.Field}} = ret{{$i}}
+	{{- end}}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+{{end}}
+`
+
+var restClientTemplate = `// Blueprint: Auto-generated by the HTTP Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Client *http.Client
+	Timeout time.Duration
+	ServerAddress string
+}
+
+// tlsCredentials is the name of the client's TLS credentials, or the empty string if the server does not use TLS
+func New_{{.Name}}(ctx context.Context, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Client(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := time.ParseDuration("1s")
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: duration,
+	}
+	c := &{{.Name}}{}
+	c.Client = client
+	c.Timeout = duration
+	c.ServerAddress = "http://" + serverAddress
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.Transport = transport
+		c.ServerAddress = "https://" + serverAddress
+	}
+	return c, nil
+}
+
+// Sends a request and decodes the JSON response into response
+func (client *{{.Name}}) do(ctx context.Context, verb string, path string, query url.Values, body any, response any) error {
+	encoded_url, err := url.Parse(client.ServerAddress + path)
+	if err != nil {
+		return err
+	}
+	encoded_url.RawQuery = query.Encode()
+
+	var request_body io.Reader
+	if body != nil {
+		body_bytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		request_body = bytes.NewReader(body_bytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, verb, encoded_url.String(), request_body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	propagation.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	resp_bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return rpcerrors.FromHTTP(resp.StatusCode, resp_bytes)
+	}
+	return json.Unmarshal(resp_bytes, response)
+}
+
+{{$receiver := .Name -}}
+{{- range $_, $m := .Methods }}
+func (client *{{$receiver}}) {{SignatureWithRetVars $m.Func}} {
+	{{- range $_, $arg := $m.PathArgs}}
+	{{- if ne (NameOf $arg.Type) "string"}}
+	path_{{$arg.Name}}, err := json.Marshal({{$arg.Name}})
+	if err != nil {
+		return
+	}
+	{{- end}}
+	{{- end}}
+	req_path := {{range $i, $s := $m.Path}}{{if $i}} + {{end}}{{if $s.Arg}}url.PathEscape({{if eq (NameOf $s.Arg.Type) "string"}}{{$s.Arg.Name}}{{else}}string(path_{{$s.Arg.Name}}){{end}}){{else}}{{printf "%q" $s.Literal}}{{end}}{{end}}
+
+	req_query := url.Values{}
+	{{- range $_, $arg := $m.QueryArgs}}
+	{{if eq (NameOf $arg.Type) "string" -}}
+	req_query.Add("{{$arg.Name}}", {{$arg.Name}})
+	{{- else -}}
+	query_{{$arg.Name}}, err := json.Marshal({{$arg.Name}})
+	if err != nil {
+		return
+	}
+	req_query.Add("{{$arg.Name}}", string(query_{{$arg.Name}}))
+	{{- end}}
+	{{- end}}
+
+	{{- if $m.BodyArgs}}
+	req_body := struct {
+		{{- range $_, $arg := $m.BodyArgs}}
+		{{$arg.Field}} {{NameOf $arg.Type}} {{JsonField $arg.JSON}}
+		{{- end}}
+	}{}
+	{{- range $_, $arg := $m.BodyArgs}}
+	req_body.{{$arg.Field}} = {{$arg.Name}}
+	{{- end}}
+	{{- end}}
+
+	response := struct {
+		{{- range $_, $ret := $m.Results}}
+		{{$ret.Field}} {{NameOf $ret.Type}} {{JsonField $ret.JSON}}
+		{{- end}}
+	}{}
+	err = client.do(ctx, "{{$m.Route.Verb}}", req_path, req_query, {{if $m.BodyArgs}}&req_body{{else}}nil{{end}}, &response)
+	if err != nil {
+		return
+	}
+	{{- range $i, $ret := $m.Results}}
+	ret{{$i}} = response.{{$ret.Field}}
+	{{- end}}
+	return
+}
+{{end}}
+`
@@ -0,0 +1,159 @@
+package httpcodegen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+)
+
+// The HTTP verb and path template of a method that is served in REST mode, e.g. GET /carts/{customerID}.
+//
+// The variables of the path template are bound to the method's arguments with the same names.  Other arguments are
+// read from the JSON request body for POST, PUT, and PATCH, and from URL query parameters for GET and DELETE.
+type Route struct {
+	Verb string
+	Path string
+}
+
+// Routes of the methods of a service, keyed by method name.  Methods without a route are served at POST /{MethodName}.
+type Routes map[string]Route
+
+// Matches the variables of a path template, e.g. {customerID} or {id:[0-9]+}
+var pathVariable = regexp.MustCompile(`\{([^{}:]+)(:[^{}]*)?\}`)
+
+var verbs = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// Parses a route such as "GET /carts/{customerID}"
+func ParseRoute(s string) (Route, error) {
+	verb, path, found := strings.Cut(strings.TrimSpace(s), " ")
+	route := Route{Verb: strings.ToUpper(verb), Path: strings.TrimSpace(path)}
+	if !found {
+		return Route{}, fmt.Errorf("expected a verb and a path but got %q", s)
+	}
+	return route, route.Validate()
+}
+
+// Returns an error if the verb is not one of GET, POST, PUT, PATCH, or DELETE, or the path is not absolute
+func (r Route) Validate() error {
+	if !verbs[r.Verb] {
+		return fmt.Errorf("unsupported HTTP verb %q; expected GET, POST, PUT, PATCH, or DELETE", r.Verb)
+	}
+	if !strings.HasPrefix(r.Path, "/") || strings.ContainsAny(r.Path, " ;?") {
+		return fmt.Errorf("invalid path %q; expected an absolute path without a query", r.Path)
+	}
+	return nil
+}
+
+// Reports whether arguments that are not in the path are read from the request body
+func (r Route) HasBody() bool {
+	return r.Verb == "POST" || r.Verb == "PUT" || r.Verb == "PATCH"
+}
+
+// Returns the names of the variables of the path template
+func (r Route) Variables() []string {
+	var names []string
+	for _, match := range pathVariable.FindAllStringSubmatch(r.Path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// Returns the route in the format parsed by [ParseRoute]
+func (r Route) String() string {
+	return r.Verb + " " + r.Path
+}
+
+// Parses routes from a semicolon-separated list of method=route pairs, where each route is in the format parsed by
+// [ParseRoute], e.g. "GetCart=GET /carts/{customerID};AddItem=POST /carts/{customerID}/items".
+func ParseRoutes(s string) (Routes, error) {
+	routes := make(Routes)
+	if strings.TrimSpace(s) == "" {
+		return routes, nil
+	}
+	for _, entry := range strings.Split(s, ";") {
+		method, route, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || method == "" {
+			return nil, fmt.Errorf("expected method=route but got %v", entry)
+		}
+		if _, exists := routes[method]; exists {
+			return nil, fmt.Errorf("duplicate route for %v", method)
+		}
+		r, err := ParseRoute(route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route for %v: %w", method, err)
+		}
+		routes[method] = r
+	}
+	return routes, nil
+}
+
+// Returns the route of method, which is POST /{MethodName} if the method has no route
+func (routes Routes) Get(method string) Route {
+	if route, exists := routes[method]; exists {
+		return route
+	}
+	return Route{Verb: "POST", Path: "/" + method}
+}
+
+// Returns an error if a route is for a method that does not exist, has path variables that are not arguments of
+// the method, or has the same verb and path as another method or the OpenAPI document
+func (routes Routes) Validate(service *gocode.ServiceInterface) error {
+	for method := range routes {
+		if _, exists := service.Methods[method]; !exists {
+			return fmt.Errorf("route for %v.%v, which does not exist", service.Name, method)
+		}
+	}
+	used := map[string]string{"GET " + openAPIPath: "the OpenAPI document"}
+	for _, method := range sortedMethods(service) {
+		route := routes.Get(method.Name)
+		if err := route.Validate(); err != nil {
+			return fmt.Errorf("invalid route for %v: %w", method.Name, err)
+		}
+		for _, name := range route.Variables() {
+			if _, isArg := argument(method, name); !isArg {
+				return fmt.Errorf("the route %v of %v has a variable {%v} that is not an argument", route, method.Name, name)
+			}
+		}
+		key := route.Verb + " " + pathVariable.ReplaceAllString(route.Path, "{}")
+		if other, exists := used[key]; exists {
+			return fmt.Errorf("%v and %v have the same route %v", other, method.Name, route)
+		}
+		used[key] = method.Name
+	}
+	return nil
+}
+
+// Returns the routes in the format parsed by [ParseRoutes], with methods in alphabetical order
+func (routes Routes) String() string {
+	var methods []string
+	for method := range routes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	var entries []string
+	for _, method := range methods {
+		entries = append(entries, method+"="+routes[method].String())
+	}
+	return strings.Join(entries, ";")
+}
+
+func sortedMethods(service *gocode.ServiceInterface) []gocode.Func {
+	var methods []gocode.Func
+	for _, method := range service.Methods {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+func argument(f gocode.Func, name string) (gocode.Variable, bool) {
+	for _, arg := range f.Arguments {
+		if arg.Name == name {
+			return arg, true
+		}
+	}
+	return gocode.Variable{}, false
+}
@@ -19,14 +19,23 @@ func GenerateServerHandler(builder golang.ModuleBuilder, service *gocode.Service
 		return err
 	}
 
+	openAPI, err := generateOpenAPI(builder, service, legacyMethods(service), outputPackage)
+	if err != nil {
+		return err
+	}
+
 	server := &serverArgs{
 		Package: pkg,
 		Service: service,
 		Name:    service.BaseName + "_HTTPServerHandler",
+		OpenAPI: openAPI,
 		Imports: gogen.NewImports(pkg.Name),
 	}
 
-	server.Imports.AddPackages("context", "encoding/json", "net/http", "github.com/gorilla/mux", "log")
+	server.Imports.AddPackages("context", "crypto/tls", "encoding/json", "net/http", "github.com/gorilla/mux", "log",
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig")
 
 	slog.Info(fmt.Sprintf("Generating %v/%v_HTTPServer.go", server.Package.PackageName, service.BaseName))
 	outputFile := filepath.Join(server.Package.Path, service.BaseName+"_HTTPServer.go")
@@ -40,6 +49,7 @@ type serverArgs struct {
 	Package golang.PackageInfo
 	Service *gocode.ServiceInterface
 	Name    string         // Name of the generated wrapper class
+	OpenAPI string         // The OpenAPI document of the service
 	Imports *gogen.Imports // Manages imports for us
 }
 
@@ -51,18 +61,26 @@ package {{.Package.ShortName}}
 type {{.Name}} struct {
 	Service {{.Imports.NameOf .Service.UserType}}
 	Address string
+	TLSConfig *tls.Config // nil if the server does not use TLS
 }
 
-func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string) (*{{.Name}}, error) {
+// tlsCredentials is the name of the server's TLS credentials, or the empty string if the server does not use TLS
+func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Server(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
 	handler := &{{.Name}}{}
 	handler.Service = service
 	handler.Address = serverAddress
+	handler.TLSConfig = tlsConfig
 	return handler, nil
 }
 
 // Blueprint: Run is called automatically in a separate goroutine by runtime/plugins/golang/di.go
 func (handler *{{.Name}}) Run(ctx context.Context) error {
 	router := mux.NewRouter()
+	router.Methods("GET").Path("/openapi.json").HandlerFunc(handler.serveOpenAPI)
 	// Add paths for the mux router
 	{{ range $_, $f := .Service.Methods }}
 	router.Path("/{{$f.Name}}").HandlerFunc(handler.{{$f.Name}})
@@ -70,6 +88,7 @@ func (handler *{{.Name}}) Run(ctx context.Context) error {
 	srv := &http.Server {
 		Addr: handler.Address,
 		Handler: router,
+		TLSConfig: handler.TLSConfig,
 	}
 
 	go func() {
@@ -79,9 +98,22 @@ func (handler *{{.Name}}) Run(ctx context.Context) error {
 		}
 	}()
 
+	if handler.TLSConfig != nil {
+		// The certificate and key of the server are in its TLSConfig
+		return srv.ListenAndServeTLS("", "")
+	}
 	return srv.ListenAndServe()
 }
 
+// The OpenAPI document of the service
+const {{.Service.BaseName}}_OpenAPI = {{printf "%q" .OpenAPI}}
+
+// Serves the OpenAPI document of the service
+func (handler *{{.Name}}) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte({{.Service.BaseName}}_OpenAPI))
+}
+
 {{$service := .Service.Name -}}
 {{$receiver := .Name -}}
 {{ range $_, $f := .Service.Methods }}
@@ -98,18 +130,18 @@ func (handler *{{$receiver}}) {{$f.Name -}}
 	if request_{{$arg.Name}} != "" {
 		err = json.Unmarshal([]byte(request_{{$arg.Name}}), &{{$arg.Name}})
 		if err != nil {
-			log.Println(err.Error())
-			http.Error(w, err.Error(), 500)
+			rpcerrors.WriteHTTP(w, rpcerrors.New(rpcerrors.InvalidArgument, "invalid {{$arg.Name}}: " + err.Error()))
 			return
 		}
 	}
 	{{- end}}
 	{{end}}
-	ctx := context.Background()
+	ctx, cancel := propagation.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	defer cancel()
 	{{RetVars $f "err"}} {{HasNewReturnVars $f}} handler.Service.{{$f.Name}}({{ArgVars $f "ctx"}})
 	if err != nil {
 		log.Println(err.Error())
-		http.Error(w, err.Error(), 500)
+		rpcerrors.WriteHTTP(w, err)
 		return
 	}
 	response := struct {
@@ -9,6 +9,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
 	"github.com/blueprint-uservices/blueprint/plugins/http/httpcodegen"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 )
 
 // IRNode representing a client to a Golang server.
@@ -35,6 +36,9 @@ func newGolangHttpClient(name string, addr *address.Address[*golangHttpServer])
 }
 
 func (n *GolangHttpClient) String() string {
+	if n.ServerAddr.Server != nil && n.ServerAddr.Server.TLS != nil {
+		return n.InstanceName + " = HTTPClient(" + n.ServerAddr.Dial.Name() + ", " + n.ServerAddr.Server.TLS.Mode.String() + ")"
+	}
 	return n.InstanceName + " = HTTPClient(" + n.ServerAddr.Dial.Name() + ")"
 }
 
@@ -73,6 +77,9 @@ func (node *GolangHttpClient) GenerateFuncs(builder golang.ModuleBuilder) error
 		return err
 	}
 
+	if server := node.ServerAddr.Server; server.REST {
+		return httpcodegen.GenerateRESTClient(builder, iface, server.Routes, node.outputPackage)
+	}
 	return httpcodegen.GenerateClient(builder, iface, node.outputPackage)
 }
 
@@ -94,11 +101,23 @@ func (node *GolangHttpClient) AddInstantiation(builder golang.NamespaceBuilder)
 			Arguments: []gocode.Variable{
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "addr", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "tlsCredentials", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.ServerAddr.Dial})
+	// Clients of servers that use TLS are issued their credentials when they are instantiated
+	var creds *tlscerts.Credentials
+	if server := node.ServerAddr.Server; server.TLS != nil {
+		if creds, err = server.TLS.IssueClient(node.InstanceName+".tls", node.InstanceName); err != nil {
+			return err
+		}
+		if err := creds.AddInstantiation(builder); err != nil {
+			return err
+		}
+	}
+
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.ServerAddr.Dial, tlscerts.Arg(creds)})
 }
 
 func (node *GolangHttpClient) ImplementsGolangNode()    {}
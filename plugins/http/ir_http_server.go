@@ -11,6 +11,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
 	"github.com/blueprint-uservices/blueprint/plugins/http/httpcodegen"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 )
 
 // IRNode representing a Golang HTTP server.
@@ -24,6 +25,12 @@ type golangHttpServer struct {
 	Bind         *address.BindConfig
 	Wrapped      golang.Service
 
+	REST   bool               // If true, methods are served with JSON request bodies at their routes
+	Routes httpcodegen.Routes // The routes of methods in REST mode
+
+	TLSMode tlscerts.Mode
+	TLS     *tlscerts.Credentials // The credentials of the server, if it uses TLS
+
 	outputPackage string
 }
 
@@ -50,12 +57,20 @@ func newGolangHttpServer(name string, wrapped ir.IRNode) (*golangHttpServer, err
 	node := &golangHttpServer{}
 	node.InstanceName = name
 	node.Wrapped = service
+	node.Routes = make(httpcodegen.Routes)
 	node.outputPackage = "http"
 	return node, nil
 }
 
 func (n *golangHttpServer) String() string {
-	return n.InstanceName + " = HTTPServer(" + n.Wrapped.Name() + ", " + n.Bind.Name() + ")"
+	args := n.Wrapped.Name() + ", " + n.Bind.Name()
+	if n.REST {
+		args += ", REST(" + n.Routes.String() + ")"
+	}
+	if n.TLS != nil {
+		args += ", " + n.TLS.Mode.String()
+	}
+	return n.InstanceName + " = HTTPServer(" + args + ")"
 }
 
 func (n *golangHttpServer) Name() string {
@@ -69,7 +84,11 @@ func (node *golangHttpServer) GenerateFuncs(builder golang.ModuleBuilder) error
 		return err
 	}
 
-	err = httpcodegen.GenerateServerHandler(builder, iface, node.outputPackage)
+	if node.REST {
+		err = httpcodegen.GenerateRESTServerHandler(builder, iface, node.Routes, node.outputPackage)
+	} else {
+		err = httpcodegen.GenerateServerHandler(builder, iface, node.outputPackage)
+	}
 	if err != nil {
 		return err
 	}
@@ -95,10 +114,17 @@ func (node *golangHttpServer) AddInstantiation(builder golang.NamespaceBuilder)
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "service", Type: iface},
 				{Name: "serverAddr", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "tlsCredentials", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Bind})
+
+	if node.TLS != nil {
+		if err := node.TLS.AddInstantiation(builder); err != nil {
+			return err
+		}
+	}
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Bind, tlscerts.Arg(node.TLS)})
 }
 
 func (node *golangHttpServer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
@@ -9,15 +9,37 @@
 //
 // The plugin implements a server-side handler and client-side
 // library that calls the server. This is implemented within the [httpcodegen] package.
+//
+// By default, every method is served at /{MethodName}, with its arguments in URL query parameters and its results in
+// the response fields Ret0, Ret1, etc.  With the [REST] option, methods accept JSON request bodies, are served at
+// configurable routes (see [Route]), and return results in named JSON fields, e.g.
+//
+//	http.Deploy(spec, "cart_service", http.REST(),
+//		http.Route("GetCart", "GET /carts/{customerID}"),
+//		http.Route("AddItem", "POST /carts/{customerID}/items"))
+//
+// In both modes, the plugin also generates an OpenAPI 3 document that describes the service's methods, with JSON
+// Schemas derived from the workflow spec's types.  The document is written to the generated http package as
+// {{ServiceName}}_openapi.json, and the server serves it at GET /openapi.json.  Types that the plugin cannot
+// describe, such as generic structs, are described as any JSON value, and the fields of embedded structs are left out.
+//
+// With the [TLS] or [MutualTLS] option, the server is served over HTTPS, with a certificate that is generated at
+// compile time; see the [tlscerts] package.
+//
+// [tlscerts]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/tlscerts
 package http
 
 import (
+	"strings"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/http/httpcodegen"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 	"golang.org/x/exp/slog"
 )
 
@@ -33,7 +55,9 @@ import (
 //
 // Deploying a service with HTTP increases the visibility of the service within the application.
 // By default, any other service running in any other container or namespace can now contact this service.
-func Deploy(spec wiring.WiringSpec, serviceName string) {
+//
+// `options` configure how methods are exposed, e.g. [REST], and whether the server uses [TLS].
+func Deploy(spec wiring.WiringSpec, serviceName string, options ...Option) {
 	// The nodes that we are defining
 	httpClient := serviceName + ".http_client"
 	httpServer := serviceName + ".http_server"
@@ -73,8 +97,84 @@ func Deploy(spec wiring.WiringSpec, serviceName string) {
 		if err != nil {
 			return nil, err
 		}
+		for _, option := range options {
+			if err := option(server); err != nil {
+				return nil, err
+			}
+		}
+		if server.TLS, err = tlscerts.IssueServer(spec, httpServer+".tls", serviceName, server.TLSMode); err != nil {
+			return nil, err
+		}
 
 		err = address.Bind[*golangHttpServer](ns, httpAddr, server, &server.Bind)
 		return server, err
 	})
 }
+
+// An option for [Deploy]
+type Option func(*golangHttpServer) error
+
+// [REST] is an [Option] that serves methods in REST mode.  Methods accept their arguments in JSON request bodies, and
+// return their results in JSON response bodies with named fields.  The name of a field is the name of the return value
+// in the service interface, or "result" if the return value is unnamed ("result0", "result1", etc. if there are
+// several).  Methods are served at POST /{MethodName}, unless they have a [Route].
+func REST() Option {
+	return func(node *golangHttpServer) error {
+		node.REST = true
+		return nil
+	}
+}
+
+// [Route] is an [Option] that serves `method` at `route`, an HTTP verb and path template, e.g.
+// "GET /carts/{customerID}".  The variables of the path template are bound to the method's arguments with the same
+// names.  Other arguments are in the JSON request body for POST, PUT, and PATCH, and are URL query parameters for GET
+// and DELETE.  Implies [REST].
+func Route(method string, route string) Option {
+	return func(node *golangHttpServer) error {
+		if method == "" || strings.ContainsAny(method, "=; ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		r, err := httpcodegen.ParseRoute(route)
+		if err != nil {
+			return blueprint.Errorf("invalid route for %v of %v: %s", method, node.InstanceName, err.Error())
+		}
+		node.REST = true
+		node.Routes[method] = r
+		return nil
+	}
+}
+
+// [Routes] is an [Option] that sets the routes of methods from a string such as
+// "GetCart=GET /carts/{customerID};AddItem=POST /carts/{customerID}/items"; see [Route].  Implies [REST].
+func Routes(routes string) Option {
+	return func(node *golangHttpServer) error {
+		parsed, err := httpcodegen.ParseRoutes(routes)
+		if err != nil {
+			return blueprint.Errorf("invalid routes for %v: %s", node.InstanceName, err.Error())
+		}
+		node.REST = true
+		for method, route := range parsed {
+			node.Routes[method] = route
+		}
+		return nil
+	}
+}
+
+// [TLS] is an [Option] that serves the service over HTTPS.  Clients verify that the server presents a certificate for
+// the name of the service, which is issued by the application's development CA at compile time.
+func TLS() Option {
+	return func(node *golangHttpServer) error {
+		node.TLSMode = tlscerts.TLS
+		return nil
+	}
+}
+
+// [MutualTLS] is an [Option] that serves the service over HTTPS with mutual TLS.  In addition to [TLS], the server
+// requires clients to present a certificate issued by the application's development CA, which is issued to each
+// client at compile time.
+func MutualTLS() Option {
+	return func(node *golangHttpServer) error {
+		node.TLSMode = tlscerts.MutualTLS
+		return nil
+	}
+}
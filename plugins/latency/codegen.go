@@ -24,7 +24,8 @@ func generateServerWrapper(builder golang.ModuleBuilder, wrapped *gocode.Service
 		Imports: gogen.NewImports(pkg.Name),
 	}
 
-	server.Imports.AddPackages("context", "time")
+	server.Imports.AddPackages("context")
+	server.Latency = server.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/latency")
 	slog.Info(fmt.Sprintf("Generating %v/%v", server.Package.PackageName, wrapped.BaseName+"_LatencyInjector"))
 	outputFile := filepath.Join(server.Package.Path, wrapped.BaseName+"_LatencyInjector.go")
 
@@ -35,6 +36,7 @@ type serverArgs struct {
 	Package golang.PackageInfo
 	Service *gocode.ServiceInterface
 	Name    string
+	Latency string // The import name of the runtime latency package
 	Imports *gogen.Imports
 }
 
@@ -45,17 +47,17 @@ package {{.Package.ShortName}}
 
 type {{.Name}} struct {
 	Server {{.Imports.NameOf .Service.UserType}}
-	Latency time.Duration
+	injector *{{.Latency}}.Injector
 }
 
-func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserType}}, latency_str string) (*{{.Name}}, error) {
-	handler := &{{.Name}}{}
-	handler.Server = server
-	latency, err := time.ParseDuration(latency_str)
+func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserType}}, distribution string, methods string, fraction string) (*{{.Name}}, error) {
+	injector, err := {{.Latency}}.NewInjector(distribution, methods, fraction)
 	if err != nil {
 		return nil, err
 	}
-	handler.Latency = latency
+	handler := &{{.Name}}{}
+	handler.Server = server
+	handler.injector = injector
 	return handler, nil
 }
 
@@ -63,7 +65,9 @@ func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserT
 {{$receiver := .Name -}}
 {{ range $_, $f := .Service.Methods }}
 func (server *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
-	time.Sleep(server.Latency)
+	if err = server.injector.Inject(ctx, "{{$f.Name}}"); err != nil {
+		return
+	}
 	return server.Server.{{$f.Name}}({{ArgVars $f "ctx"}})
 }
 {{end}}
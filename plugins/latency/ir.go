@@ -3,6 +3,8 @@ package latency
 import (
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
@@ -20,10 +22,13 @@ type LatencyInjectorWrapper struct {
 	InstanceName  string
 	Wrapped       golang.Service
 	outputPackage string
-	LatencyValue  *ir.IRValue
+
+	Distribution string   // The latency distribution, in the format parsed by the runtime latency package
+	Methods      []string // If non-empty, latency is only injected into these methods
+	Fraction     float64  // The fraction of requests into which latency is injected
 }
 
-func newLatencyInjectorWrapper(name string, server ir.IRNode, latency string) (*LatencyInjectorWrapper, error) {
+func newLatencyInjectorWrapper(name string, server ir.IRNode, distribution string) (*LatencyInjectorWrapper, error) {
 	serverNode, is_callable := server.(golang.Service)
 	if !is_callable {
 		return nil, blueprint.Errorf("latency injector wrapper requires %s to be a golang service but got %s", server.Name(), reflect.TypeOf(server).String())
@@ -33,7 +38,8 @@ func newLatencyInjectorWrapper(name string, server ir.IRNode, latency string) (*
 	node.InstanceName = name
 	node.Wrapped = serverNode
 	node.outputPackage = "latencyinjector"
-	node.LatencyValue = &ir.IRValue{Value: latency}
+	node.Distribution = distribution
+	node.Fraction = 1
 	return node, nil
 }
 
@@ -50,7 +56,14 @@ func (node *LatencyInjectorWrapper) Name() string {
 
 // Implements [ir.IRNode]
 func (node *LatencyInjectorWrapper) String() string {
-	return node.Name() + " = LatencyInjector(" + node.Wrapped.Name() + ")"
+	args := []string{node.Wrapped.Name(), node.Distribution}
+	if len(node.Methods) > 0 {
+		args = append(args, fmt.Sprintf("methods=%v", node.Methods))
+	}
+	if node.Fraction < 1 {
+		args = append(args, fmt.Sprintf("fraction=%v", node.Fraction))
+	}
+	return node.Name() + " = LatencyInjector(" + strings.Join(args, ", ") + ")"
 }
 
 // Implements [golang.Service]
@@ -74,6 +87,12 @@ func (node *LatencyInjectorWrapper) GenerateFuncs(builder golang.ModuleBuilder)
 		return err
 	}
 
+	for _, method := range node.Methods {
+		if _, exists := iface.Methods[method]; !exists {
+			return blueprint.Errorf("unable to inject latency into %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+	}
+
 	return generateServerWrapper(builder, iface, node.outputPackage)
 }
 
@@ -95,10 +114,18 @@ func (node *LatencyInjectorWrapper) AddInstantiation(builder golang.NamespaceBui
 			Arguments: []gocode.Variable{
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "server", Type: iface},
-				{Name: "latency", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "distribution", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "methods", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "fraction", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.LatencyValue})
+	args := []ir.IRNode{
+		node.Wrapped,
+		&ir.IRValue{Value: node.Distribution},
+		&ir.IRValue{Value: strings.Join(node.Methods, ",")},
+		&ir.IRValue{Value: strconv.FormatFloat(node.Fraction, 'g', -1, 64)},
+	}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
 }
@@ -1,28 +1,32 @@
 // Package latencyinjector provides a Blueprint modifier for the server side of service calls.
 //
 // The plugin configures the server side to inject a user-defined amount of latency.
-// Currently latency is injected for all requests and only a pre-defined duration is supported with no variance/noise added.
-// The plugin will generate a wrapper class that will sleep for a fixed amount of time (the specified latency to be injected)
+// The plugin will generate a wrapper class that will sleep for an amount of time (the latency to be injected)
 // before invoking the handler for handling the request.
 // Example Usage to add 100ms latency to each request:
 //    import "github.com/blueprint-uservices/blueprint/plugins/latency"
 //    latency.AddFixed(spec, "my_service", "100ms")
 //
-// TODO: Allow latency to be injected selectively to a subset of requests based using
-// random sampling or by matching attributes of a request.
-// TODO: Sample injected latency from a configurable distribution and support choosing
-// the distribution.
+// Latency can also be sampled from a distribution, and injected selectively into a subset of the service's methods
+// and a random fraction of requests.  Example usage to add log-normally distributed latency to 10% of GetCart requests:
+//    latency.Add(spec, "cart_service", latency.LogNormal("50ms", 0.5), latency.Methods("GetCart"), latency.Fraction(0.1))
 //
-// If you need the above feature(s) consider submitting a PR, raising a feature request,
-// or posting on the Blueprint slack/mailing list.
+// The supported distributions are [Fixed], [Normal], [Exponential], [LogNormal], [Pareto], and [Empirical].
+// The plugin also utilizes some code in the [runtime/plugins/latency] package.
+//
+// [runtime/plugins/latency]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/latency
 package latency
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/latency"
 	"golang.org/x/exp/slog"
 )
 
@@ -32,7 +36,15 @@ import (
 // The `latency` string must be a sequence of decimal numbers, each with optional fraction and a unit suffix, such as "300ms", "1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h". Negative signed values such as "-1.5h" would result in no explicit latency being added; although it might result in the go runtime re-scheduling the running goroutine.
 // Usage:
 //   AddFixed(spec, "my_service", "100ms")
-func AddFixed(spec wiring.WiringSpec, serviceName string, latency string) {
+func AddFixed(spec wiring.WiringSpec, serviceName string, latency string, options ...Option) {
+	Add(spec, serviceName, Fixed(latency), options...)
+}
+
+// Adds latency sampled from `distribution` on the server side during request processing for the specified service.
+// Uses a [blueprint.WiringSpec]
+// Usage:
+//   Add(spec, "my_service", latency.Normal("100ms", "20ms"), latency.Fraction(0.5))
+func Add(spec wiring.WiringSpec, serviceName string, distribution Distribution, options ...Option) {
 	serverWrapper := serviceName + ".server.latency"
 	ptr := pointer.GetPointer(spec, serviceName)
 	if ptr == nil {
@@ -48,6 +60,101 @@ func AddFixed(spec wiring.WiringSpec, serviceName string, latency string) {
 			return nil, blueprint.Errorf("LatencyInjector %s expected %s to be a golang.Service, but encountered %s", serverWrapper, serverNext, err)
 		}
 
-		return newLatencyInjectorWrapper(serverWrapper, wrapped, latency)
+		dist, err := distribution()
+		if err != nil {
+			return nil, blueprint.Errorf("invalid latency distribution for %s: %s", serverWrapper, err.Error())
+		}
+		node, err := newLatencyInjectorWrapper(serverWrapper, wrapped, dist.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
 	})
 }
+
+// A distribution of latencies for [Add].  The distribution is checked when the wiring spec is built.
+type Distribution func() (latency.Distribution, error)
+
+// [Fixed] is a [Distribution] that always injects `latency`, e.g. "100ms".
+func Fixed(latency string) Distribution {
+	return Parse(latency)
+}
+
+// [Normal] is a [Distribution] of normally distributed latency, e.g. Normal("100ms", "20ms").
+// Negative samples inject no latency.
+func Normal(mean string, stddev string) Distribution {
+	return Parse(fmt.Sprintf("normal(%v, %v)", mean, stddev))
+}
+
+// [Exponential] is a [Distribution] of exponentially distributed latency with the specified `mean`, e.g. "50ms".
+func Exponential(mean string) Distribution {
+	return Parse(fmt.Sprintf("exponential(%v)", mean))
+}
+
+// [LogNormal] is a [Distribution] of log-normally distributed latency, e.g. LogNormal("50ms", 0.5).
+// `median` is the median latency, and `sigma` is the standard deviation of the logarithm of the latency;
+// larger values of `sigma` give longer tails.
+func LogNormal(median string, sigma float64) Distribution {
+	return Parse(fmt.Sprintf("lognormal(%v, %v)", median, sigma))
+}
+
+// [Pareto] is a [Distribution] of Pareto distributed latency, e.g. Pareto("10ms", 1.5).
+// `scale` is the minimum latency and `shape` is the tail index; smaller values of `shape` give heavier tails.
+func Pareto(scale string, shape float64) Distribution {
+	return Parse(fmt.Sprintf("pareto(%v, %v)", scale, shape))
+}
+
+// [Empirical] is a [Distribution] defined by the empirical cumulative distribution function in the file at `path`,
+// e.g. measured from a production system.  Each line of the file is a "latency,probability" pair, e.g.
+//
+//	# latency, probability
+//	5ms, 0.5
+//	20ms, 0.99
+//	250ms, 1
+//
+// The file is read when the wiring spec is built, and the distribution is included in the generated code.
+func Empirical(path string) Distribution {
+	return func() (latency.Distribution, error) {
+		return latency.LoadEmpirical(path)
+	}
+}
+
+// [Parse] is a [Distribution] parsed from a string such as "normal(100ms, 20ms)"; see the [runtime/plugins/latency]
+// package for the format.
+func Parse(spec string) Distribution {
+	return func() (latency.Distribution, error) {
+		return latency.ParseDistribution(spec)
+	}
+}
+
+// An option for [Add] and [AddFixed]
+type Option func(*LatencyInjectorWrapper) error
+
+// [Methods] is an option that only injects latency into the specified methods of the service.
+func Methods(methods ...string) Option {
+	return func(node *LatencyInjectorWrapper) error {
+		for _, method := range methods {
+			if method == "" || strings.ContainsAny(method, ", ") {
+				return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+			}
+		}
+		node.Methods = append(node.Methods, methods...)
+		return nil
+	}
+}
+
+// [Fraction] is an option that only injects latency into a randomly sampled `fraction` of requests, between 0 and 1.
+func Fraction(fraction float64) Option {
+	return func(node *LatencyInjectorWrapper) error {
+		if fraction < 0 || fraction > 1 {
+			return blueprint.Errorf("invalid fraction %v for %v; must be between 0 and 1", fraction, node.InstanceName)
+		}
+		node.Fraction = fraction
+		return nil
+	}
+}
@@ -28,5 +28,5 @@ func buildDefaultLinuxWorkspace(outputDir string, nodes []ir.IRNode) error {
 	if err != nil {
 		return err
 	}
-	return ctr.GenerateArtifacts(ctrDir)
+	return ir.GenerateCached(ctr, ctrDir, func() error { return ctr.GenerateArtifacts(ctrDir) })
 }
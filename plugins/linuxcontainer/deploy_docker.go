@@ -3,6 +3,7 @@ package linuxcontainer
 import (
 	"fmt"
 
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/docker"
 	"github.com/blueprint-uservices/blueprint/plugins/linuxcontainer/dockergen"
 	"golang.org/x/exp/slog"
@@ -69,7 +70,7 @@ func (node *Container) AddContainerArtifacts(target docker.ContainerWorkspace) e
 	// The docker workspace extends the Finish() implementation
 	// to also generate the Dockerfile
 	workspace := NewDockerWorkspace(node.Name(), dir)
-	if err := node.generateArtifacts(workspace); err != nil {
+	if err := ir.GenerateCached(node, dir, func() error { return node.generateArtifacts(workspace) }); err != nil {
 		return err
 	}
 	return nil
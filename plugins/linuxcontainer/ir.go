@@ -40,3 +40,18 @@ func (ctr *Container) Name() string {
 func (ctr *Container) String() string {
 	return ir.PrettyPrintNamespace(ctr.InstanceName, "LinuxContainer", ctr.Edges, ctr.Nodes)
 }
+
+// Implements [ir.NamespaceNode]
+func (ctr *Container) GetNamespaceType() string {
+	return "LinuxContainer"
+}
+
+// Implements [ir.NamespaceNode]
+func (ctr *Container) GetArgNodes() []ir.IRNode {
+	return ctr.Edges
+}
+
+// Implements [ir.NamespaceNode]
+func (ctr *Container) GetChildNodes() []ir.IRNode {
+	return ctr.Nodes
+}
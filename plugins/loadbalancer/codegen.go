@@ -0,0 +1,78 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	client := clientArgs{
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_LoadBalancer",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	client.Imports.AddPackages("context", "strings")
+	client.LoadBalancer = client.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/loadbalancer")
+
+	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, client.Name))
+	outputFile := filepath.Join(client.Package.Path, client.Name+".go")
+	return gogen.ExecuteTemplateToFile("LoadBalancer", clientTemplate, client, outputFile)
+}
+
+type clientArgs struct {
+	Package      golang.PackageInfo
+	Service      *gocode.ServiceInterface
+	Name         string
+	LoadBalancer string // The import name of the runtime loadbalancer package
+	Imports      *gogen.Imports
+}
+
+var clientTemplate = `// Blueprint: Auto-generated by LoadBalancer Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	replicas []{{.Imports.NameOf .Service.UserType}}
+	balancer *{{.LoadBalancer}}.Balancer
+}
+
+// names is a comma-separated list of the names of the replicas
+func New_{{.Name}} (ctx context.Context, service string, names string, config string, replicas ...{{.Imports.NameOf .Service.UserType}}) (*{{.Name}}, error) {
+	var rs []{{.LoadBalancer}}.Replica
+	for i, name := range strings.Split(names, ",") {
+		rs = append(rs, {{.LoadBalancer}}.Replica{Name: name, Client: replicas[i]})
+	}
+	balancer, err := {{.LoadBalancer}}.NewBalancer(ctx, service, config, rs)
+	if err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.replicas = replicas
+	handler.balancer = balancer
+	return handler, nil
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	err = client.balancer.Call(ctx, func(ctx context.Context, replica int) error {
+		{{RetVars $f "err"}} = client.replicas[replica].{{$f.Name}}({{ArgVars $f "ctx"}})
+		return err
+	})
+	return
+}
+{{end}}
+`
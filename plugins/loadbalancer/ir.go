@@ -0,0 +1,129 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/loadbalancer"
+)
+
+// Blueprint IR node representing a client that balances calls across the replicas of a service
+type LoadBalancerClient struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName string
+	ServiceName  string
+	ReplicaNames []string         // The names of the replicas in the wiring spec, for events and metrics
+	Replicas     []golang.Service // The clients of the replicas
+	Config       runtime.Config
+
+	outputPackage string
+}
+
+func (node *LoadBalancerClient) ImplementsGolangNode() {}
+
+func (node *LoadBalancerClient) Name() string {
+	return node.InstanceName
+}
+
+func (node *LoadBalancerClient) String() string {
+	var args []string
+	for _, replica := range node.Replicas {
+		args = append(args, replica.Name())
+	}
+	if config := node.Config.String(); config != "" {
+		args = append(args, config)
+	}
+	return node.Name() + " = LoadBalancer(" + strings.Join(args, ", ") + ")"
+}
+
+func newLoadBalancerClient(name string, serviceName string, replicaNames []string, replicas []ir.IRNode) (*LoadBalancerClient, error) {
+	node := &LoadBalancerClient{}
+	node.InstanceName = name
+	node.ServiceName = serviceName
+	node.ReplicaNames = replicaNames
+	node.outputPackage = "loadbalancer"
+	for _, replica := range replicas {
+		client, is_callable := replica.(golang.Service)
+		if !is_callable {
+			return nil, blueprint.Errorf("load balancer %s requires replica %s to be a golang service but got %s", name, replica.Name(), reflect.TypeOf(replica).String())
+		}
+		node.Replicas = append(node.Replicas, client)
+	}
+	return node, nil
+}
+
+func (node *LoadBalancerClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	for _, replica := range node.Replicas {
+		if err := replica.AddInterfaces(builder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (node *LoadBalancerClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Replicas[0].GetInterface(ctx)
+}
+
+func (node *LoadBalancerClient) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Replicas[0])
+	if err != nil {
+		return err
+	}
+	for _, replica := range node.Replicas[1:] {
+		replicaIface, err := golang.GetGoInterface(builder, replica)
+		if err != nil {
+			return err
+		}
+		if replicaIface.Name != iface.Name {
+			return blueprint.Errorf("load balancer %v requires its replicas to have the same interface, but %v is %v and %v is %v", node.InstanceName, node.Replicas[0].Name(), iface.Name, replica.Name(), replicaIface.Name)
+		}
+	}
+
+	return generateClient(builder, iface, node.outputPackage)
+}
+
+func (node *LoadBalancerClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Replicas[0])
+	if err != nil {
+		return err
+	}
+
+	// The generated constructor is variadic in the replicas
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_LoadBalancer", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "service", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "names", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "config", Type: &gocode.BasicType{Name: "string"}},
+			},
+		},
+	}
+
+	args := []ir.IRNode{&ir.IRValue{Value: node.ServiceName}, &ir.IRValue{Value: strings.Join(node.ReplicaNames, ",")}, &ir.IRValue{Value: node.Config.String()}}
+	for i, replica := range node.Replicas {
+		constructor.Arguments = append(constructor.Arguments, gocode.Variable{Name: fmt.Sprintf("replica%v", i), Type: iface})
+		args = append(args, replica)
+	}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
+}
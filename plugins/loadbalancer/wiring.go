@@ -0,0 +1,175 @@
+// Package loadbalancer provides a Blueprint modifier that balances the calls to a service across replicas of the
+// service.
+//
+// A pointer to a service resolves to a single server, so a service that is deployed twice cannot otherwise be
+// load-balanced by its callers.  With the loadbalancer plugin, a wiring spec declares N replicas of a service, each
+// with its own address, process, and container, and a load-balanced service that callers use in place of the
+// replicas.  Callers get a generated client that picks a replica for each call with a policy: [RoundRobin],
+// [Random], [LeastOutstanding], or [PowerOfTwoChoices].
+//
+// Replicas are ejected, i.e. not picked, after consecutive failed calls (see [Eject]), and while they fail health
+// checks (see [HealthCheck]).  Health checks require the replicas to have a health check API; see the
+// healthchecker plugin.
+//
+// Example usage, where the frontend balances its calls across 3 replicas of the user service, each in its own
+// container:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/loadbalancer"
+//	user_service := loadbalancer.Replicate(spec, "user_service", 3, func(replica string) {
+//		workflow.Service[user.UserService](spec, replica, user_db)
+//		healthchecker.AddHealthCheckAPI(spec, replica)
+//		grpc.Deploy(spec, replica)
+//		linuxcontainer.Deploy(spec, replica)
+//	}, loadbalancer.PowerOfTwoChoices())
+//	frontend := workflow.Service[frontend.Frontend](spec, "frontend", user_service)
+//
+// Client modifiers such as retries can be applied to the load-balanced service; each retry can then be sent to a
+// different replica.  Server modifiers must be applied to each replica instead.
+//
+// The plugin utilizes code in the [runtime/plugins/loadbalancer] package, and records metrics using the
+// application's metric collector.
+//
+// [runtime/plugins/loadbalancer]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/loadbalancer
+package loadbalancer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/loadbalancer"
+)
+
+// Declares `n` replicas of a service and returns the name of the load-balanced service, `serviceName`.
+//
+// The replicas are named serviceName_1 to serviceName_n, and each is defined by calling `define` with its name.
+// define should declare the replica and deploy it, e.g. with workflow.Service and linuxcontainer.Deploy.
+// Callers of serviceName balance their calls across the replicas as configured by `options`; see [Create].
+func Replicate(spec wiring.WiringSpec, serviceName string, n int, define func(replicaName string), options ...Option) string {
+	var replicas []string
+	for i := 1; i <= n; i++ {
+		replica := fmt.Sprintf("%v_%v", serviceName, i)
+		define(replica)
+		replicas = append(replicas, replica)
+	}
+	return Create(spec, serviceName, replicas, options...)
+}
+
+// Defines a load-balanced service called `serviceName` whose callers balance their calls across `replicas`, which
+// are services that are already defined in the wiring spec.  The replicas must have the same interface.
+// Returns serviceName.
+//
+// serviceName is a pointer, so client modifiers can be applied to it.
+//
+// Usage:
+//
+//	Create(spec, "user_service", []string{"user_service_a", "user_service_b"}, loadbalancer.LeastOutstanding())
+func Create(spec wiring.WiringSpec, serviceName string, replicas []string, options ...Option) string {
+	balancerName := serviceName + ".lb"
+
+	spec.Define(balancerName, &LoadBalancerClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		if len(replicas) == 0 {
+			return nil, blueprint.Errorf("load balancer %v has no replicas", balancerName)
+		}
+		var nodes []ir.IRNode
+		for _, replica := range replicas {
+			var node ir.IRNode
+			if err := ns.Get(replica, &node); err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		}
+
+		node, err := newLoadBalancerClient(balancerName, serviceName, replicas, nodes)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+		if err := node.Config.Validate(); err != nil {
+			return nil, blueprint.Errorf("invalid config for %v: %s", balancerName, err.Error())
+		}
+		return node, nil
+	})
+	for _, replica := range replicas {
+		wiring.AddReference(spec, balancerName, wiring.Reference{To: replica})
+	}
+
+	// The balancer is a client, so each caller instantiates its own
+	pointer.CreatePointer[*LoadBalancerClient](spec, serviceName, balancerName, pointer.PointerOpts{RequireUniqueness: nil})
+	return serviceName
+}
+
+// An option for [Create] and [Replicate] that configures the load balancer
+type Option func(node *LoadBalancerClient) error
+
+func policy(policy runtime.Policy) Option {
+	return func(node *LoadBalancerClient) error {
+		node.Config.Policy = policy
+		return nil
+	}
+}
+
+// [RoundRobin] is an [Option] that picks each replica in turn.  This is the default.
+func RoundRobin() Option {
+	return policy(runtime.RoundRobin)
+}
+
+// [Random] is an [Option] that picks a replica uniformly at random.
+func Random() Option {
+	return policy(runtime.Random)
+}
+
+// [LeastOutstanding] is an [Option] that picks the replica with the fewest calls in progress from the caller.
+func LeastOutstanding() Option {
+	return policy(runtime.LeastOutstanding)
+}
+
+// [PowerOfTwoChoices] is an [Option] that picks two replicas at random, and uses the one with fewer calls in
+// progress from the caller.
+func PowerOfTwoChoices() Option {
+	return policy(runtime.PowerOfTwo)
+}
+
+// [Eject] is an [Option] that ejects a replica for `duration`, e.g. "30s", after `failures` consecutive calls fail
+// with a transport or timeout error.  The default is 5 failures and 30s.
+func Eject(failures int, duration string) Option {
+	return func(node *LoadBalancerClient) (err error) {
+		node.Config.Failures = failures
+		if node.Config.Ejection, err = time.ParseDuration(duration); err != nil {
+			return blueprint.Errorf("invalid ejection duration for %v: %s", node.InstanceName, err.Error())
+		}
+		return nil
+	}
+}
+
+// [HealthCheck] is an [Option] that checks the health of replicas every `interval`, e.g. "5s"; the default is
+// "10s".  A replica that fails a health check is ejected until a check succeeds.  Only replicas with a health
+// check API are checked.
+func HealthCheck(interval string) Option {
+	return func(node *LoadBalancerClient) (err error) {
+		if node.Config.HealthCheck, err = time.ParseDuration(interval); err != nil {
+			return blueprint.Errorf("invalid health check interval for %v: %s", node.InstanceName, err.Error())
+		}
+		return nil
+	}
+}
+
+// [Config] is an [Option] that sets the config from a string such as "policy:p2c,failures:3,healthcheck:5s"; see
+// the [runtime/plugins/loadbalancer] package for the format.
+//
+// [runtime/plugins/loadbalancer]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/loadbalancer
+func Config(config string) Option {
+	return func(node *LoadBalancerClient) (err error) {
+		if node.Config, err = runtime.ParseConfig(config); err != nil {
+			return blueprint.Errorf("invalid config for %v: %s", node.InstanceName, err.Error())
+		}
+		return nil
+	}
+}
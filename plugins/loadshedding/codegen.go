@@ -0,0 +1,74 @@
+package loadshedding
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file.
+func generateServerWrapper(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	server := serverArgs{
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_LoadShedder",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	server.Imports.AddPackages("context")
+	server.Shedding = server.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/loadshedding")
+	slog.Info(fmt.Sprintf("Generating %v/%v", server.Package.PackageName, server.Name))
+	outputFile := filepath.Join(server.Package.Path, server.Name+".go")
+
+	return gogen.ExecuteTemplateToFile("LoadShedder", serverTemplate, server, outputFile)
+}
+
+type serverArgs struct {
+	Package  golang.PackageInfo
+	Service  *gocode.ServiceInterface
+	Name     string
+	Shedding string // The import name of the runtime loadshedding package
+	Imports  *gogen.Imports
+}
+
+var serverTemplate = `// Blueprint: Auto-generated by LoadShedder Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Server {{.Imports.NameOf .Service.UserType}}
+	shedder *{{.Shedding}}.Shedder
+}
+
+func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserType}}, policy string, priorities string) (*{{.Name}}, error) {
+	shedder, err := {{.Shedding}}.NewShedder(policy, priorities)
+	if err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.Server = server
+	handler.shedder = shedder
+	return handler, nil
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (server *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	err = server.shedder.Call(ctx, "{{$f.Name}}", func(ctx context.Context) error {
+		{{RetVars $f "err"}} = server.Server.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return err
+	})
+	return
+}
+{{end}}
+`
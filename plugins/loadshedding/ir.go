@@ -0,0 +1,131 @@
+package loadshedding
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+)
+
+// Blueprint IR Node representing a server side load shedder
+type LoadShedder struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName  string
+	Wrapped       golang.Service
+	outputPackage string
+
+	Policy     string         // The load shedding policy, in the format parsed by the runtime loadshedding package
+	Priorities map[string]int // The priorities of methods; methods without a priority have priority 0
+}
+
+func newLoadShedder(name string, server ir.IRNode, policy string) (*LoadShedder, error) {
+	serverNode, is_callable := server.(golang.Service)
+	if !is_callable {
+		return nil, blueprint.Errorf("load shedder %s requires %s to be a golang service but got %s", name, server.Name(), reflect.TypeOf(server).String())
+	}
+
+	node := &LoadShedder{}
+	node.InstanceName = name
+	node.Wrapped = serverNode
+	node.outputPackage = "loadshedding"
+	node.Policy = policy
+	node.Priorities = make(map[string]int)
+	return node, nil
+}
+
+// Implements [ir.IRNode]
+func (node *LoadShedder) ImplementsGolangNode() {}
+
+// Implements [golang.Service]
+func (node *LoadShedder) ImplementsGolangService() {}
+
+// Implements [ir.IRNode]
+func (node *LoadShedder) Name() string {
+	return node.InstanceName
+}
+
+// Implements [ir.IRNode]
+func (node *LoadShedder) String() string {
+	args := []string{node.Wrapped.Name(), node.Policy}
+	if len(node.Priorities) > 0 {
+		args = append(args, "priorities="+node.priorities())
+	}
+	return node.Name() + " = LoadShedder(" + strings.Join(args, ", ") + ")"
+}
+
+// Returns the priorities in the format parsed by the runtime loadshedding package, sorted by method
+func (node *LoadShedder) priorities() string {
+	var priorities []string
+	for method, priority := range node.Priorities {
+		priorities = append(priorities, fmt.Sprintf("%v:%v", method, priority))
+	}
+	sort.Strings(priorities)
+	return strings.Join(priorities, ",")
+}
+
+// Implements [golang.Service]
+func (node *LoadShedder) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements [golang.Service]
+func (node *LoadShedder) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements [golang.GeneratesFuncs]
+func (node *LoadShedder) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for method := range node.Priorities {
+		if _, exists := iface.Methods[method]; !exists {
+			return blueprint.Errorf("unable to prioritize %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+	}
+
+	return generateServerWrapper(builder, iface, node.outputPackage)
+}
+
+// Implements [golang.Instantiable]
+func (node *LoadShedder) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_LoadShedder", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "server", Type: iface},
+				{Name: "policy", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "priorities", Type: &gocode.BasicType{Name: "string"}},
+			},
+		},
+	}
+
+	args := []ir.IRNode{node.Wrapped, &ir.IRValue{Value: node.Policy}, &ir.IRValue{Value: node.priorities()}}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
+}
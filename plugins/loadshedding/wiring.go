@@ -0,0 +1,132 @@
+// Package loadshedding provides a Blueprint modifier that sheds load on the server side of a service when the service
+// is overloaded, e.g. for experiments with metastable failures.
+//
+// The plugin generates a server-side wrapper that tracks the calls in flight and admits calls up to a concurrency
+// limit.  Calls in excess of the limit wait in a queue, and calls that wait too long are shed.  The wrapper is
+// configured with a [Policy]:
+//   - [CoDel] has a fixed concurrency limit, and shortens how long calls may wait when the queueing delay exceeds
+//     a target
+//   - [Gradient] adapts the concurrency limit to the latency of calls, as in Netflix's Gradient2 concurrency limiter
+//
+// Higher-priority calls are admitted first, so the lowest-priority calls are the first to be shed.  The priority of a
+// method is configured with [Priority].  Example usage to shed load from cart_service when the queueing delay
+// exceeds 5ms, shedding ListItems before other methods:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/loadshedding"
+//	loadshedding.Add(spec, "cart_service", loadshedding.CoDel("5ms", "100ms", 100), loadshedding.Priority("ListItems", -1))
+//
+// Shed calls return the same error as calls rejected by the ratelimit plugin, so the retries plugin retries them, and
+// the circuitbreaker plugin does not count them as failures.  The plugin utilizes code in the
+// [runtime/plugins/loadshedding] package.
+//
+// [runtime/plugins/loadshedding]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/loadshedding
+package loadshedding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/loadshedding"
+	"golang.org/x/exp/slog"
+)
+
+// Adds a load shedder with the specified `policy` to the server side of the specified service.
+// Usage:
+//
+//	Add(spec, "my_service", loadshedding.Gradient("50ms", 1000))
+func Add(spec wiring.WiringSpec, serviceName string, policy Policy, options ...Option) {
+	serverWrapper := serviceName + ".server.loadshedding"
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add a load shedder to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	serverNext := ptr.AddDstModifier(spec, serverWrapper)
+
+	spec.Define(serverWrapper, &LoadShedder{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+		if err := ns.Get(serverNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("LoadShedder %s expected %s to be a golang.Service, but encountered %s", serverWrapper, serverNext, err)
+		}
+
+		p, err := policy()
+		if err != nil {
+			return nil, blueprint.Errorf("invalid load shedding policy for %s: %s", serverWrapper, err.Error())
+		}
+		node, err := newLoadShedder(serverWrapper, wrapped, p.String())
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	})
+}
+
+// A load shedding policy for [Add].  The policy is checked when the wiring spec is built.
+type Policy func() (loadshedding.Policy, error)
+
+// [CoDel] is a [Policy] with a fixed limit of `concurrency` calls in flight.  Calls may wait for up to `interval` to
+// be admitted; however, if the minimum queueing delay over an interval exceeds `target`, calls may only wait for up to
+// `target` until the queueing delay recovers.  e.g. CoDel("5ms", "100ms", 100)
+func CoDel(target string, interval string, concurrency int) Policy {
+	return Parse(fmt.Sprintf("codel(%v, %v, %v)", target, interval, concurrency))
+}
+
+// [Gradient] is a [Policy] that adapts the limit of calls in flight between 1 and `maxLimit`, decreasing the limit
+// when the short-term average latency of calls exceeds the long-term average.  Calls may wait in the queue for up to
+// `maxWait` to be admitted; `maxWait` is not a latency target and does not affect the limit.
+// e.g. Gradient("50ms", 1000)
+func Gradient(maxWait string, maxLimit int) Policy {
+	return Parse(fmt.Sprintf("gradient(%v, %v)", maxWait, maxLimit))
+}
+
+// [Parse] is a [Policy] parsed from a string such as "codel(5ms, 100ms, 100)"; see the [runtime/plugins/loadshedding]
+// package for the format.
+//
+// [runtime/plugins/loadshedding]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/loadshedding
+func Parse(spec string) Policy {
+	return func() (loadshedding.Policy, error) {
+		return loadshedding.ParsePolicy(spec)
+	}
+}
+
+// An option for [Add]
+type Option func(*LoadShedder) error
+
+// [Priority] is an [Option] that sets the priority of `method`.  Calls to higher-priority methods are admitted first,
+// and calls to lower-priority methods are shed first.  Methods without a priority have priority 0.
+func Priority(method string, priority int) Option {
+	return func(node *LoadShedder) error {
+		if method == "" || strings.ContainsAny(method, ",: ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		node.Priorities[method] = priority
+		return nil
+	}
+}
+
+// [Priorities] is an [Option] that sets the priorities of methods from a string such as "GetCart:1,ListItems:-1".
+func Priorities(priorities string) Option {
+	return func(node *LoadShedder) error {
+		parsed, err := loadshedding.ParsePriorities(priorities)
+		if err != nil {
+			return blueprint.Errorf("invalid priorities for %v: %s", node.InstanceName, err.Error())
+		}
+		for method, priority := range parsed {
+			if err := Priority(method, priority)(node); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -117,6 +117,9 @@ func Instrument(spec wiring.WiringSpec, serviceName string, collectorName string
 		return newOpenTelemetryServerWrapper(serverWrapper, wrapped, collectorClient)
 	})
 
+	wiring.AddReference(spec, clientWrapper, wiring.Reference{To: collectorName})
+	wiring.AddReference(spec, serverWrapper, wiring.Reference{To: collectorName})
+
 }
 
 // [Logger] can be used by wiring specs to install a process-level ot logger for process `processName` to be used in tandem with an OT Tracer. Replaces the existing logger installed for the process.
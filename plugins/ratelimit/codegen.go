@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file.
+func generateServerWrapper(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	server := serverArgs{
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_RateLimiter",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	server.Imports.AddPackages("context")
+	server.RateLimit = server.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit")
+	slog.Info(fmt.Sprintf("Generating %v/%v", server.Package.PackageName, server.Name))
+	outputFile := filepath.Join(server.Package.Path, server.Name+".go")
+
+	return gogen.ExecuteTemplateToFile("RateLimiter", serverTemplate, server, outputFile)
+}
+
+type serverArgs struct {
+	Package   golang.PackageInfo
+	Service   *gocode.ServiceInterface
+	Name      string
+	RateLimit string // The import name of the runtime ratelimit package
+	Imports   *gogen.Imports
+}
+
+var serverTemplate = `// Blueprint: Auto-generated by RateLimiter Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Server {{.Imports.NameOf .Service.UserType}}
+	limiter *{{.RateLimit}}.ServiceLimiter
+}
+
+func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserType}}, limits string) (*{{.Name}}, error) {
+	limiter, err := {{.RateLimit}}.NewServiceLimiter(limits)
+	if err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.Server = server
+	handler.limiter = limiter
+	return handler, nil
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (server *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	err = server.limiter.Call(ctx, "{{$f.Name}}", func(ctx context.Context) error {
+		{{RetVars $f "err"}} = server.Server.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return err
+	})
+	return
+}
+{{end}}
+`
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+)
+
+// Blueprint IR Node representing a server side rate limiter
+type RateLimiter struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName  string
+	Wrapped       golang.Service
+	outputPackage string
+
+	Limits runtime.Limits // Keyed by method name, or "*" for the service
+}
+
+func newRateLimiter(name string, server ir.IRNode) (*RateLimiter, error) {
+	serverNode, is_callable := server.(golang.Service)
+	if !is_callable {
+		return nil, blueprint.Errorf("rate limiter %s requires %s to be a golang service but got %s", name, server.Name(), reflect.TypeOf(server).String())
+	}
+
+	node := &RateLimiter{}
+	node.InstanceName = name
+	node.Wrapped = serverNode
+	node.outputPackage = "ratelimit"
+	node.Limits = make(runtime.Limits)
+	return node, nil
+}
+
+// Implements [ir.IRNode]
+func (node *RateLimiter) ImplementsGolangNode() {}
+
+// Implements [golang.Service]
+func (node *RateLimiter) ImplementsGolangService() {}
+
+// Implements [ir.IRNode]
+func (node *RateLimiter) Name() string {
+	return node.InstanceName
+}
+
+// Implements [ir.IRNode]
+func (node *RateLimiter) String() string {
+	return node.Name() + " = RateLimiter(" + node.Wrapped.Name() + ", " + node.Limits.String() + ")"
+}
+
+// Implements [golang.Service]
+func (node *RateLimiter) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements [golang.Service]
+func (node *RateLimiter) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements [golang.GeneratesFuncs]
+func (node *RateLimiter) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for method := range node.Limits {
+		if _, exists := iface.Methods[method]; !exists && method != "*" {
+			return blueprint.Errorf("unable to rate limit %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+	}
+
+	return generateServerWrapper(builder, iface, node.outputPackage)
+}
+
+// Implements [golang.Instantiable]
+func (node *RateLimiter) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_RateLimiter", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "server", Type: iface},
+				{Name: "limits", Type: &gocode.BasicType{Name: "string"}},
+			},
+		},
+	}
+
+	args := []ir.IRNode{node.Wrapped, &ir.IRValue{Value: node.Limits.String()}}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
+}
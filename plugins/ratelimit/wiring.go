@@ -0,0 +1,148 @@
+// Package ratelimit provides a Blueprint modifier that protects the server side of a service from overload.
+//
+// The plugin generates a server-side wrapper that admits calls subject to the following limits, which can be
+// configured for the service as a whole and for individual methods:
+//   - [Rate] is a token-bucket limit on the number of calls per second
+//   - [Concurrency] is a limit on the number of calls in flight
+//   - [Queue] is the number of calls that can wait when the rate or concurrency limit is reached
+//
+// Calls that cannot be admitted are rejected with an error from the [runtime/plugins/ratelimit] package.  The retries
+// plugin retries rejected calls, and the circuitbreaker plugin does not count them as failures.
+//
+// Example usage to limit cart_service to 100 calls per second with up to 50 calls in flight, and GetCart to 5 calls in
+// flight, with up to 20 calls waiting:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/ratelimit"
+//	ratelimit.Add(spec, "cart_service",
+//		ratelimit.Rate(100, 10), ratelimit.Concurrency(50), ratelimit.Queue(20),
+//		ratelimit.Method("GetCart", ratelimit.Concurrency(5), ratelimit.Queue(20)))
+//
+// The service's limits apply to all of its calls in aggregate, in addition to the limits of the called method.
+//
+// [runtime/plugins/ratelimit]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/ratelimit
+package ratelimit
+
+import (
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+	"golang.org/x/exp/slog"
+)
+
+// Adds a rate limiter to the server side of the specified service.
+// Usage:
+//
+//	Add(spec, "my_service", ratelimit.Rate(100, 10), ratelimit.Method("MyMethod", ratelimit.Concurrency(5)))
+func Add(spec wiring.WiringSpec, serviceName string, options ...Option) {
+	serverWrapper := serviceName + ".server.ratelimit"
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add a rate limiter to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	serverNext := ptr.AddDstModifier(spec, serverWrapper)
+
+	spec.Define(serverWrapper, &RateLimiter{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+		if err := ns.Get(serverNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("RateLimiter %s expected %s to be a golang.Service, but encountered %s", serverWrapper, serverNext, err)
+		}
+
+		node, err := newRateLimiter(serverWrapper, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node, "*"); err != nil {
+				return nil, err
+			}
+		}
+		for method, limit := range node.Limits {
+			if err := limit.Validate(); err != nil {
+				return nil, blueprint.Errorf("invalid limit for %v of %v: %s", method, serverWrapper, err.Error())
+			}
+		}
+		return node, nil
+	})
+}
+
+// An option for [Add] that configures the limits of method, which is "*" for the service as a whole.
+type Option func(node *RateLimiter, method string) error
+
+func update(f func(limit *runtime.Limit)) Option {
+	return func(node *RateLimiter, method string) error {
+		limit := node.Limits[method]
+		f(&limit)
+		node.Limits[method] = limit
+		return nil
+	}
+}
+
+// [Rate] is an [Option] that limits calls to `rps` calls per second on average.  `burst` is the size of the token
+// bucket, i.e. the number of calls that can be made at once after a period without calls.
+func Rate(rps float64, burst int) Option {
+	return update(func(limit *runtime.Limit) {
+		limit.Rate = rps
+		limit.Burst = burst
+	})
+}
+
+// [Concurrency] is an [Option] that limits the number of calls in flight to `maxInFlight`.
+func Concurrency(maxInFlight int) Option {
+	return update(func(limit *runtime.Limit) {
+		limit.Concurrency = maxInFlight
+	})
+}
+
+// [Queue] is an [Option] that allows up to `size` calls to wait when the rate or concurrency limit is reached.
+// By default calls are rejected immediately.  Waiting calls are rejected if the caller's deadline would expire
+// before a call is admitted by the rate limit.
+func Queue(size int) Option {
+	return update(func(limit *runtime.Limit) {
+		limit.Queue = size
+	})
+}
+
+// [Method] is an [Option] that applies `options` to the specified method instead of the service as a whole.
+func Method(method string, options ...Option) Option {
+	return func(node *RateLimiter, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("ratelimit.Method(%v) cannot be nested in ratelimit.Method(%v) for %v", method, scope, node.InstanceName)
+		}
+		if method == "" || method == "*" || strings.ContainsAny(method, ",:;= ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		for _, option := range options {
+			if err := option(node, method); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// [Limits] is an [Option] that sets limits from a string such as "*=rate:100,burst:10;GetCart=concurrency:5";
+// see the [runtime/plugins/ratelimit] package for the format.
+//
+// [runtime/plugins/ratelimit]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/ratelimit
+func Limits(limits string) Option {
+	return func(node *RateLimiter, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("ratelimit.Limits cannot be nested in ratelimit.Method(%v) for %v", scope, node.InstanceName)
+		}
+		parsed, err := runtime.ParseLimits(limits)
+		if err != nil {
+			return blueprint.Errorf("invalid limits for %v: %s", node.InstanceName, err.Error())
+		}
+		for method, limit := range parsed {
+			node.Limits[method] = limit
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package responsecache
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file.
+func generateServerWrapper(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	server := serverArgs{
+		Package: pkg,
+		Service: wrapped,
+		Name:    wrapped.BaseName + "_ResponseCache",
+		Imports: gogen.NewImports(pkg.Name),
+	}
+
+	server.Imports.AddPackages("context", "github.com/blueprint-uservices/blueprint/runtime/core/backend")
+	server.ResponseCache = server.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/responsecache")
+	slog.Info(fmt.Sprintf("Generating %v/%v", server.Package.PackageName, server.Name))
+	outputFile := filepath.Join(server.Package.Path, server.Name+".go")
+
+	return gogen.ExecuteTemplateToFile("ResponseCache", serverTemplate, server, outputFile)
+}
+
+type serverArgs struct {
+	Package       golang.PackageInfo
+	Service       *gocode.ServiceInterface
+	Name          string
+	ResponseCache string // The import name of the runtime responsecache package
+	Imports       *gogen.Imports
+}
+
+// The arguments of a call are serialized for its cache key, and its results are read from or written to the cache
+// through pointers to the return values
+var serverTemplate = `// Blueprint: Auto-generated by ResponseCache Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Server {{.Imports.NameOf .Service.UserType}}
+	cache  *{{.ResponseCache}}.ServiceCache
+}
+
+func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserType}}, cache backend.Cache, service string, policies string) (*{{.Name}}, error) {
+	serviceCache, err := {{.ResponseCache}}.NewServiceCache(ctx, service, cache, policies)
+	if err != nil {
+		return nil, err
+	}
+	handler := &{{.Name}}{}
+	handler.Server = server
+	handler.cache = serviceCache
+	return handler, nil
+}
+
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (server *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	err = server.cache.Call(ctx, "{{$f.Name}}", []any{ {{- ArgVars $f -}} }, []any{ {{- range $i, $_ := $f.Returns}}{{if $i}}, {{end}}&ret{{$i}}{{end -}} }, func(ctx context.Context) error {
+		{{RetVars $f "err"}} = server.Server.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return err
+	})
+	return
+}
+{{end}}
+`
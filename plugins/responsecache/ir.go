@@ -0,0 +1,123 @@
+package responsecache
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/responsecache"
+)
+
+// Blueprint IR Node representing a server side wrapper that caches the responses of methods
+type ResponseCache struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName  string
+	Wrapped       golang.Service
+	outputPackage string
+
+	ServiceName string           // The name of the service, for cache keys and metrics
+	Cache       ir.IRNode        // The backend.Cache that responses are stored in
+	Policies    runtime.Policies // Keyed by method name; methods without a policy are not cached
+}
+
+func newResponseCache(name string, server ir.IRNode, serviceName string, cache ir.IRNode) (*ResponseCache, error) {
+	serverNode, is_callable := server.(golang.Service)
+	if !is_callable {
+		return nil, blueprint.Errorf("response cache %s requires %s to be a golang service but got %s", name, server.Name(), reflect.TypeOf(server).String())
+	}
+
+	node := &ResponseCache{}
+	node.InstanceName = name
+	node.Wrapped = serverNode
+	node.outputPackage = "responsecache"
+	node.ServiceName = serviceName
+	node.Cache = cache
+	node.Policies = make(runtime.Policies)
+	return node, nil
+}
+
+// Implements [ir.IRNode]
+func (node *ResponseCache) ImplementsGolangNode() {}
+
+// Implements [golang.Service]
+func (node *ResponseCache) ImplementsGolangService() {}
+
+// Implements [ir.IRNode]
+func (node *ResponseCache) Name() string {
+	return node.InstanceName
+}
+
+// Implements [ir.IRNode]
+func (node *ResponseCache) String() string {
+	return node.Name() + " = ResponseCache(" + node.Wrapped.Name() + ", " + node.Cache.Name() + ", " + node.Policies.String() + ")"
+}
+
+// Implements [golang.Service]
+func (node *ResponseCache) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements [golang.Service]
+func (node *ResponseCache) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements [golang.GeneratesFuncs]
+func (node *ResponseCache) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for method, policy := range node.Policies {
+		f, exists := iface.Methods[method]
+		if !exists {
+			return blueprint.Errorf("unable to cache %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+		if policy.TTL > 0 && len(f.Returns) == 0 {
+			return blueprint.Errorf("unable to cache %v.%v as the method returns nothing but an error", node.Wrapped.Name(), method)
+		}
+	}
+
+	return generateServerWrapper(builder, iface, node.outputPackage)
+}
+
+// Implements [golang.Instantiable]
+func (node *ResponseCache) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_ResponseCache", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "server", Type: iface},
+				{Name: "cache", Type: &gocode.UserType{Package: "github.com/blueprint-uservices/blueprint/runtime/core/backend", Name: "Cache"}},
+				{Name: "service", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "policies", Type: &gocode.BasicType{Name: "string"}},
+			},
+		},
+	}
+
+	args := []ir.IRNode{node.Wrapped, node.Cache, &ir.IRValue{Value: node.ServiceName}, &ir.IRValue{Value: node.Policies.String()}}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
+}
@@ -0,0 +1,156 @@
+// Package responsecache provides a Blueprint modifier for the server side of a service that caches the responses of
+// read-heavy methods, so that services do not need to hand-code cache-aside logic.
+//
+// The plugin generates a server-side wrapper that looks up the results of cached methods in a cache before calling
+// the service, and stores the results of successful calls in the cache with a TTL.  The cache key is derived from the
+// method name and the serialized arguments of the call.  The cache can be any [backend.Cache] defined in the wiring
+// spec, e.g. simple.Cache, redis.Container, or memcached.Container.
+//
+// Calls to write methods invalidate the cached results of the methods they list; see [Invalidates].  Example usage
+// to cache ListItems for 1 minute and GetItem for 10s, and invalidate both when items are updated:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/responsecache"
+//	catalogue_cache := simple.Cache(spec, "catalogue_cache")
+//	responsecache.Add(spec, "catalogue_service", catalogue_cache,
+//		responsecache.Cached("1m", "ListItems"), responsecache.Cached("10s", "GetItem"),
+//		responsecache.Invalidates("UpdateItem", "ListItems", "GetItem"))
+//
+// The results of a cached method must depend only on its arguments, and not e.g. on the caller or on request
+// metadata.  The arguments and results of cached methods must be serializable as JSON.  The plugin utilizes code in
+// the [runtime/plugins/responsecache] package, and records metrics using the application's metric collector.
+//
+// [backend.Cache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/backend
+// [runtime/plugins/responsecache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/responsecache
+package responsecache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/responsecache"
+	"golang.org/x/exp/slog"
+)
+
+// Caches the responses of the methods of the specified service in `cacheName`, a [backend.Cache], as configured by
+// `options`.  At least one method must be [Cached].
+// Usage:
+//
+//	Add(spec, "catalogue_service", "catalogue_cache", responsecache.Cached("30s", "ListItems"))
+//
+// [backend.Cache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/backend
+func Add(spec wiring.WiringSpec, serviceName string, cacheName string, options ...Option) {
+	serverWrapper := serviceName + ".server.responsecache"
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add a response cache to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	serverNext := ptr.AddDstModifier(spec, serverWrapper)
+
+	spec.Define(serverWrapper, &ResponseCache{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+		if err := ns.Get(serverNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("ResponseCache %s expected %s to be a golang.Service, but encountered %s", serverWrapper, serverNext, err)
+		}
+
+		var cache ir.IRNode
+		if err := ns.Get(cacheName, &cache); err != nil {
+			return nil, blueprint.Errorf("ResponseCache %s unable to get the cache %s: %s", serverWrapper, cacheName, err.Error())
+		}
+
+		node, err := newResponseCache(serverWrapper, wrapped, serviceName, cache)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+		cached := false
+		for _, policy := range node.Policies {
+			cached = cached || policy.TTL > 0
+		}
+		if !cached {
+			return nil, blueprint.Errorf("no methods of %v are cached by %v", serviceName, serverWrapper)
+		}
+		if err := node.Policies.Validate(); err != nil {
+			return nil, blueprint.Errorf("invalid policies for %v: %s", serverWrapper, err.Error())
+		}
+		return node, nil
+	})
+	wiring.AddReference(spec, serverWrapper, wiring.Reference{To: cacheName})
+}
+
+// An option for [Add]
+type Option func(*ResponseCache) error
+
+func validMethod(node *ResponseCache, method string) error {
+	if method == "" || method == "*" || strings.ContainsAny(method, ",:;=| ") {
+		return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+	}
+	return nil
+}
+
+// [Cached] is an [Option] that caches the results of `methods` for `ttl`, e.g. "30s".  Only the results of
+// successful calls are cached.
+func Cached(ttl string, methods ...string) Option {
+	return func(node *ResponseCache) error {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return blueprint.Errorf("invalid ttl %q for %v", ttl, node.InstanceName)
+		}
+		for _, method := range methods {
+			if err := validMethod(node, method); err != nil {
+				return err
+			}
+			policy := node.Policies[method]
+			policy.TTL = d
+			node.Policies[method] = policy
+		}
+		return nil
+	}
+}
+
+// [Invalidates] is an [Option] that invalidates all of the cached results of the `cached` methods whenever
+// `method` is called, e.g. a write method that updates the data returned by the cached methods.  The cached methods
+// must be [Cached].
+func Invalidates(method string, cached ...string) Option {
+	return func(node *ResponseCache) error {
+		if err := validMethod(node, method); err != nil {
+			return err
+		}
+		policy := node.Policies[method]
+		for _, c := range cached {
+			if err := validMethod(node, c); err != nil {
+				return err
+			}
+			policy.Invalidates = append(policy.Invalidates, c)
+		}
+		node.Policies[method] = policy
+		return nil
+	}
+}
+
+// [Policies] is an [Option] that sets policies from a string such as "GetCart=ttl:30s;AddItem=invalidates:GetCart";
+// see the [runtime/plugins/responsecache] package for the format.
+//
+// [runtime/plugins/responsecache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/responsecache
+func Policies(policies string) Option {
+	return func(node *ResponseCache) error {
+		parsed, err := runtime.ParsePolicies(policies)
+		if err != nil {
+			return blueprint.Errorf("invalid policies for %v: %s", node.InstanceName, err.Error())
+		}
+		for method, policy := range parsed {
+			node.Policies[method] = policy
+		}
+		return nil
+	}
+}
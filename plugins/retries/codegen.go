@@ -11,7 +11,7 @@ import (
 )
 
 // code generation function called from the ir.go file.
-func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, Max int64) error {
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, node *RetrierClient) error {
 	pkg, err := builder.CreatePackage(outputPackage)
 	if err != nil {
 		return err
@@ -21,11 +21,12 @@ func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterfa
 		Package: pkg,
 		Service: wrapped,
 		Name:    wrapped.BaseName + "_RetrierClient",
-		Max:     Max,
+		Node:    node,
 		Imports: gogen.NewImports(pkg.Name),
 	}
 
 	client.Imports.AddPackages("context")
+	client.Retries = client.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/retries")
 
 	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, wrapped.BaseName+"_RetrierClient"))
 	outputFile := filepath.Join(client.Package.Path, wrapped.BaseName+"_RetrierClient.go")
@@ -36,7 +37,8 @@ type clientArgs struct {
 	Package golang.PackageInfo
 	Service *gocode.ServiceInterface
 	Name    string
-	Max     int64
+	Node    *RetrierClient
+	Retries string // The import name of the runtime retries package
 	Imports *gogen.Imports
 }
 
@@ -47,13 +49,25 @@ package {{.Package.ShortName}}
 
 type {{.Name}} struct {
 	Client {{.Imports.NameOf .Service.UserType}}
-	MaxTries int
+	retrier *{{.Retries}}.Retrier
 }
 
 func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}) (*{{.Name}}, error) {
+	backoff, err := {{.Retries}}.NewBackoff({{printf "%q" .Node.BackoffPolicy}}, {{printf "%q" .Node.BackoffBase}}, {{printf "%q" .Node.BackoffMax}})
+	if err != nil {
+		return nil, err
+	}
+	var budget *{{.Retries}}.Budget
+	{{- if gt .Node.BudgetRatio 0.0}}
+	budget = {{.Retries}}.NewBudget({{.Node.BudgetRatio}}, {{.Node.BudgetBurst}})
+	{{- end}}
+
 	handler := &{{.Name}}{}
 	handler.Client = client
-	handler.MaxTries = {{.Max}}
+	handler.retrier = {{.Retries}}.NewRetrier({{.Node.Max}}, backoff, budget)
+	{{- if gt .Node.Deadline 0}}
+	handler.retrier.Deadline = {{printf "%d" .Node.Deadline}} // {{.Node.Deadline}}
+	{{- end}}
 	return handler, nil
 }
 
@@ -61,12 +75,10 @@ func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserT
 {{$receiver := .Name -}}
 {{ range $_, $f := .Service.Methods }}
 func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
-	for i := 0; i < client.MaxTries; i++ {
+	err = client.retrier.Do(ctx, func(ctx context.Context) error {
 		{{RetVars $f "err"}} = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
-		if err == nil {
-			return
-		}
-	}
+		return err
+	})
 	return
 }
 {{end}}
@@ -3,12 +3,15 @@ package retries
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/retries"
 )
 
 // Blueprint IR node representing a Retrier
@@ -22,6 +25,16 @@ type RetrierClient struct {
 
 	outputPackage string
 	Max           int64
+
+	// Configured by [Option]s; see the [runtime/plugins/retries] package for the available policies.
+	//
+	// [runtime/plugins/retries]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/retries
+	BackoffPolicy string
+	BackoffBase   string
+	BackoffMax    string
+	BudgetRatio   float64 // No retry budget if zero
+	BudgetBurst   int64
+	Deadline      time.Duration // No overall deadline if zero
 }
 
 func (node *RetrierClient) ImplementsGolangNode() {}
@@ -31,7 +44,21 @@ func (node *RetrierClient) Name() string {
 }
 
 func (node *RetrierClient) String() string {
-	return node.Name() + " = Retrier(" + node.Wrapped.Name() + ")"
+	args := []string{node.Wrapped.Name()}
+	switch node.BackoffPolicy {
+	case "", runtime.NoBackoffPolicy:
+	case runtime.ConstantBackoffPolicy:
+		args = append(args, fmt.Sprintf("backoff=%v(%v)", node.BackoffPolicy, node.BackoffBase))
+	default:
+		args = append(args, fmt.Sprintf("backoff=%v(%v, %v)", node.BackoffPolicy, node.BackoffBase, node.BackoffMax))
+	}
+	if node.BudgetRatio > 0 {
+		args = append(args, fmt.Sprintf("budget=%v, burst=%v", node.BudgetRatio, node.BudgetBurst))
+	}
+	if node.Deadline > 0 {
+		args = append(args, fmt.Sprintf("deadline=%v", node.Deadline))
+	}
+	return node.Name() + " = Retrier(" + strings.Join(args, ", ") + ")"
 }
 
 func newRetrierClient(name string, server ir.IRNode, max_clients int64) (*RetrierClient, error) {
@@ -45,6 +72,7 @@ func newRetrierClient(name string, server ir.IRNode, max_clients int64) (*Retrie
 	node.Wrapped = serverNode
 	node.outputPackage = "retries"
 	node.Max = max_clients
+	node.BackoffPolicy = runtime.NoBackoffPolicy
 
 	return node, nil
 }
@@ -67,7 +95,7 @@ func (node *RetrierClient) GenerateFuncs(builder golang.ModuleBuilder) error {
 		return err
 	}
 
-	return generateClient(builder, iface, node.outputPackage, node.Max)
+	return generateClient(builder, iface, node.outputPackage, node)
 }
 
 func (node *RetrierClient) AddInstantiation(builder golang.NamespaceBuilder) error {
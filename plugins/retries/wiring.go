@@ -1,21 +1,38 @@
 // Package retries provides a Blueprint modifier for the client side of service calls.
 //
-// The plugin wraps clients with a retrier using that retries a request until one of the two conditions is met:
-// i)  the requests returns without an error
-// ii) the number of failed tries has reached the maximum number of failures.
+// The plugin wraps clients with a retrier using that retries a request until one of the following conditions is met:
+// i)   the requests returns without an error
+// ii)  the request returns an error that isn't retryable, e.g. an error returned by the service's business logic
+// iii) the number of failed tries has reached the maximum number of failures
+// iv)  the retry budget is exhausted, or the caller's context deadline would expire before the next try.
+//
+// Only transport and timeout errors are retried; see [runtime/plugins/retries] for details.
 // Usage:
 //  import "github.com/blueprint-uservices/blueprint/plugins/retries"
 //  retries.AddRetries(spec, "my_service", 10) // Only adds retries
 //  retries.AddRetriesWithTimeouts(spec, "my_service", 10, "1s") // Adds retries and timeouts
+//
+// By default, requests are retried immediately.  Options configure a backoff policy and a retry budget:
+//  retries.AddRetries(spec, "my_service", 3, retries.DecorrelatedJitter("10ms", "1s"), retries.Budget(0.1, 10))
+//
+// The timeout of each attempt never extends past the caller's deadline, or the overall [Deadline] of a call, so
+// attempts and retries together stay within the caller's budget.  Per-method timeouts and deadline budgets are
+// configured as for the timeouts plugin:
+//  retries.AddRetriesWithTimeouts(spec, "my_service", 3, "*=timeout:100ms;WriteCart=budget:0.5", retries.Deadline("2s"))
+//
+// [runtime/plugins/retries]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/retries
 package retries
 
 import (
+	"time"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/timeouts"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/retries"
 	"golang.org/x/exp/slog"
 )
 
@@ -24,7 +41,7 @@ import (
 // Modifies the given service such that all clients to that service retry `max_retries` number of times on error.
 // Usage:
 //   AddRetries(spec, "my_service", 10)
-func AddRetries(spec wiring.WiringSpec, serviceName string, max_retries int64) {
+func AddRetries(spec wiring.WiringSpec, serviceName string, max_retries int64, options ...Option) {
 	clientWrapper := serviceName + ".client.retrier"
 
 	ptr := pointer.GetPointer(spec, serviceName)
@@ -42,7 +59,16 @@ func AddRetries(spec wiring.WiringSpec, serviceName string, max_retries int64) {
 			return nil, blueprint.Errorf("Retries %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
 		}
 
-		return newRetrierClient(clientWrapper, wrapped, max_retries)
+		node, err := newRetrierClient(clientWrapper, wrapped, max_retries)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
 	})
 }
 
@@ -52,6 +78,8 @@ func AddRetries(spec wiring.WiringSpec, serviceName string, max_retries int64) {
 // (i)  all clients to that service have a user-specified `timeout` for each request.
 // (ii) all clients to that service retry at most `max_retries` number of times on error.
 //
+// `timeout` is a duration such as "1s", or per-method timeouts in the format of [timeouts.Timeouts].
+//
 // Ordering of functionality depicted via example call-chain:
 // Before:
 //   workflow -> plugin grpc
@@ -60,7 +88,67 @@ func AddRetries(spec wiring.WiringSpec, serviceName string, max_retries int64) {
 //
 // Usage:
 //   AddRetriesWithTimeouts(spec, "my_service", 10, "1s")
-func AddRetriesWithTimeouts(spec wiring.WiringSpec, serviceName string, max_retries int64, timeout string) {
-	AddRetries(spec, serviceName, max_retries)
+func AddRetriesWithTimeouts(spec wiring.WiringSpec, serviceName string, max_retries int64, timeout string, options ...Option) {
+	AddRetries(spec, serviceName, max_retries, options...)
 	timeouts.Add(spec, serviceName, timeout)
 }
+
+// An option for [AddRetries] and [AddRetriesWithTimeouts]
+type Option func(*RetrierClient) error
+
+// [ConstantBackoff] is an option that waits a fixed `delay` between tries, e.g. "10ms"
+func ConstantBackoff(delay string) Option {
+	return backoff(runtime.ConstantBackoffPolicy, delay, "")
+}
+
+// [ExponentialBackoff] is an option that waits `base` before the first retry, and doubles the delay for each
+// subsequent retry up to `max`, e.g. ExponentialBackoff("10ms", "1s")
+func ExponentialBackoff(base string, max string) Option {
+	return backoff(runtime.ExponentialBackoffPolicy, base, max)
+}
+
+// [DecorrelatedJitter] is an option that waits a random delay between `base` and three times the previous
+// delay, up to `max`, e.g. DecorrelatedJitter("10ms", "1s").  The randomness prevents clients that fail
+// together from retrying together.
+func DecorrelatedJitter(base string, max string) Option {
+	return backoff(runtime.DecorrelatedJitterPolicy, base, max)
+}
+
+func backoff(policy string, base string, max string) Option {
+	return func(node *RetrierClient) error {
+		if _, err := runtime.NewBackoff(policy, base, max); err != nil {
+			return blueprint.Errorf("invalid backoff for %v: %v", node.InstanceName, err.Error())
+		}
+		node.BackoffPolicy = policy
+		node.BackoffBase = base
+		node.BackoffMax = max
+		return nil
+	}
+}
+
+// [Deadline] is an option that limits the total duration of a call, including all of its attempts and the backoff
+// between them, e.g. "2s".  Attempts that are in progress when the deadline expires are cancelled.
+func Deadline(deadline string) Option {
+	return func(node *RetrierClient) error {
+		d, err := time.ParseDuration(deadline)
+		if err != nil || d <= 0 {
+			return blueprint.Errorf("invalid deadline %q for %v", deadline, node.InstanceName)
+		}
+		node.Deadline = d
+		return nil
+	}
+}
+
+// [Budget] is an option that limits retries to approximately `ratio` retries per request, plus up to `burst`
+// retries in excess of the ratio, e.g. Budget(0.1, 10) allows retries to increase the load on the service by
+// about 10%.  The budget is shared by all callers that use the same client.
+func Budget(ratio float64, burst int64) Option {
+	return func(node *RetrierClient) error {
+		if ratio <= 0 || burst < 1 {
+			return blueprint.Errorf("invalid retry budget for %v: ratio %v and burst %v must be positive", node.InstanceName, ratio, burst)
+		}
+		node.BudgetRatio = ratio
+		node.BudgetBurst = burst
+		return nil
+	}
+}
@@ -2,11 +2,13 @@ package simple
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 	"golang.org/x/exp/slog"
 )
@@ -30,14 +32,22 @@ type SimpleBackend struct {
 	BackendImpl  string // e.g. "SimpleNoSQLDB"
 
 	Spec *workflowspec.Service // The backend's interface and implementation
+
+	// If set, the backend's state is restored from and saved to this file; see [Persist]
+	SnapshotPath     string
+	SnapshotInterval string
+
+	// If positive, the maximum number of entries in a cache; see [MaxEntries]
+	MaxEntries int
 }
 
 // Creates a [SimpleBackend] IR node.
 //   - name should be a name for the instance, e.g. "my_nosql_db"
 //   - BackendIface should be the the interface this backend implements, e.g. "NoSQLDatabase"
 //   - BackendImpl should be the the implementation, e.g. "SimpleNoSQLDB"
-func newSimpleBackend[BackendImpl any](name string) (*SimpleBackend, error) {
-	spec, err := workflowspec.GetService[BackendImpl]()
+func newSimpleBackend[BackendIface any, BackendImpl any](name string) (*SimpleBackend, error) {
+	// Backends implement more than one interface (e.g. snapshot.Snapshottable), so the interface must be explicit
+	spec, err := workflowspec.GetServiceWithInterface[BackendIface, BackendImpl]()
 	if err != nil {
 		return nil, err
 	}
@@ -79,12 +89,65 @@ func (node *SimpleBackend) AddInstantiation(builder golang.NamespaceBuilder) err
 	}
 
 	slog.Info(fmt.Sprintf("Instantiating %v %v in %v/%v", node.BackendImpl, node.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
-	return builder.DeclareConstructor(node.InstanceName, node.Spec.Constructor.AsConstructor(), nil)
+	constructor := node.Spec.Constructor.AsConstructor()
+	if node.SnapshotPath == "" && node.MaxEntries <= 0 {
+		return builder.DeclareConstructor(node.InstanceName, constructor, nil)
+	}
+
+	templateArgs := instantiationTemplateArgs{
+		Constructor: builder.ImportType(&gocode.UserType{Package: constructor.Package, Name: constructor.Name}),
+		MaxEntries:  node.MaxEntries,
+		Path:        node.SnapshotPath,
+		Interval:    node.SnapshotInterval,
+	}
+	if node.SnapshotPath != "" {
+		templateArgs.Snapshot = builder.Import("github.com/blueprint-uservices/blueprint/runtime/plugins/snapshot")
+	}
+	code, err := gogen.ExecuteTemplate("simple.AddInstantiation", instantiationTemplate, templateArgs)
+	if err != nil {
+		return err
+	}
+	return builder.Declare(node.InstanceName, code)
+}
+
+type instantiationTemplateArgs struct {
+	Constructor string
+	MaxEntries  int
+	Snapshot    string
+	Path        string
+	Interval    string
 }
 
+var instantiationTemplate = `func(n *golang.Namespace) (any, error) {
+		// Auto-generated by the simple plugin simple/ir.go
+		instance, err := {{.Constructor}}(n.Context())
+		if err != nil {
+			return nil, err
+		}
+		{{- if gt .MaxEntries 0}}
+		instance.SetMaxEntries({{.MaxEntries}})
+		{{- end}}
+		{{- if .Path}}
+		stop, err := {{.Snapshot}}.Persist(n.Context(), instance, {{printf "%q" .Path}}, {{printf "%q" .Interval}})
+		if err != nil {
+			return nil, err
+		}
+		// The final snapshot is saved before the process exits
+		n.OnShutdown(stop)
+		{{- end}}
+		return instance, nil
+	}`
+
 // Implements ir.IRNode
 func (node *SimpleBackend) String() string {
-	return fmt.Sprintf("%v = %v()", node.InstanceName, node.BackendImpl)
+	var args []string
+	if node.MaxEntries > 0 {
+		args = append(args, fmt.Sprintf("max_entries=%v", node.MaxEntries))
+	}
+	if node.SnapshotPath != "" {
+		args = append(args, fmt.Sprintf("persist=%v, interval=%v", node.SnapshotPath, node.SnapshotInterval))
+	}
+	return fmt.Sprintf("%v = %v(%v)", node.InstanceName, node.BackendImpl, strings.Join(args, ", "))
 }
 
 func (node *SimpleBackend) ImplementsGolangNode()    {}
@@ -19,6 +19,14 @@
 //
 // After instantiating a backend, it can be provided as argument to a workflow service.
 //
+// Optionally, a backend's state can be persisted to a file using [Persist]:
+//
+//	simple.NoSQLDB(spec, "my_nosql_db", simple.Persist("data/my_nosql_db.json", "30s"))
+//
+// A cache can be bounded to a maximum number of entries, evicting the least recently used entries, using [MaxEntries]:
+//
+//	simple.Cache(spec, "my_cache", simple.MaxEntries(1000))
+//
 // # Wiring Spec Example
 //
 // Consider the [SockShop User Service] which makes use of a `backend.NoSQLDatabase`.  The service has the
@@ -41,6 +49,16 @@
 // of a query language), so in some cases they may be insufficient and you might need to resort to testing using
 // proper backends.
 //
+// # Persistence
+//
+// By default the simple backends lose all state when the process exits.  With [Persist], a backend restores
+// its state from a snapshot file when it is instantiated, and periodically saves its state back to the file.
+// This makes it possible to restart a process without re-seeding its backends, and to check fixture datasets
+// into a repository.  Snapshot formats are:
+//   - NoSQLDB: MongoDB Extended JSON
+//   - RelationalDB: an SQLite database file
+//   - Queue and Cache: JSON
+//
 // Implementations of the backends can be found in the following locations:
 //   - NoSQLDB: [runtime/plugins/simplenosqldb]
 //   - RelationalDB: [runtime/plugins/sqlitereldb]
@@ -60,9 +78,13 @@
 package simple
 
 import (
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplecache"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
@@ -73,36 +95,87 @@ import (
 // [NoSQLDB] can be used by wiring specs to create an in-memory [backend.NoSQLDatabase] instance with the specified name.
 // In the compiled application, uses the [simplenosqldb.SimpleNoSQLDB] implementation from the Blueprint runtime package
 // The SimpleNoSQLDB has limited support for query and update operations.
-func NoSQLDB(spec wiring.WiringSpec, name string) string {
-	return define[backend.NoSQLDatabase, simplenosqldb.SimpleNoSQLDB](spec, name)
+func NoSQLDB(spec wiring.WiringSpec, name string, options ...Option) string {
+	return define[backend.NoSQLDatabase, simplenosqldb.SimpleNoSQLDB](spec, name, options)
 }
 
 // [RelationalDB] can be used by wiring specs to create an in-memory [backend.RelationalDB] instance with the specified name.
 // In the compiled application, uses the [sqlitereldb.SqliteRelDB] implementation from the Blueprint runtime package
 // The compiled application might fail to run if gcc is not installed and CGO_ENABLED is not set.
-func RelationalDB(spec wiring.WiringSpec, name string) string {
-	return define[backend.RelationalDB, sqlitereldb.SqliteRelDB](spec, name)
+func RelationalDB(spec wiring.WiringSpec, name string, options ...Option) string {
+	return define[backend.RelationalDB, sqlitereldb.SqliteRelDB](spec, name, options)
 }
 
 // [Queue] can be used by wiring specs to create an in-memory [backend.Queue] instance with the specified name.
 // In the compiled application, uses the [simplequeue.SimpleQueue] implementation from the Blueprint runtime package
-func Queue(spec wiring.WiringSpec, name string) string {
-	return define[backend.Queue, simplequeue.SimpleQueue](spec, name)
+func Queue(spec wiring.WiringSpec, name string, options ...Option) string {
+	return define[backend.Queue, simplequeue.SimpleQueue](spec, name, options)
 }
 
 // [Cache] can be used by wiring specs to create an in-memory [backend.Cache] instance with the specified name.
 // In the compiled application, uses the [simplecache.SimpleCache] implementation from the Blueprint runtime package
-func Cache(spec wiring.WiringSpec, name string) string {
-	return define[backend.Cache, simplecache.SimpleCache](spec, name)
+func Cache(spec wiring.WiringSpec, name string, options ...Option) string {
+	return define[backend.Cache, simplecache.SimpleCache](spec, name, options)
+}
+
+// An option for a simple backend; see [Persist] and [MaxEntries]
+type Option func(*SimpleBackend) error
+
+// [Persist] is an option for [NoSQLDB], [RelationalDB], [Queue], and [Cache] that persists the backend's
+// state to the file at path.
+//
+// When the backend is instantiated, its state is restored from path, if the file exists.  Thereafter the
+// backend's state is saved to path every interval (e.g. "30s"), and once more when the process shuts down.
+// If interval is empty, the backend's state is restored but never saved, which is useful for fixture datasets.
+//
+// Relative paths are relative to the working directory of the process that runs the backend.
+func Persist(path string, interval string) Option {
+	return func(node *SimpleBackend) error {
+		if path == "" {
+			return blueprint.Errorf("a snapshot path must be specified for %v", node.InstanceName)
+		}
+		if interval != "" {
+			if d, err := time.ParseDuration(interval); err != nil || d <= 0 {
+				return blueprint.Errorf("invalid snapshot interval %v for %v", interval, node.InstanceName)
+			}
+		}
+		node.SnapshotPath = path
+		node.SnapshotInterval = interval
+		return nil
+	}
+}
+
+// [MaxEntries] is an option for [Cache] that bounds the cache to at most n entries.  When the bound is
+// exceeded, the least recently used entries are evicted.
+func MaxEntries(n int) Option {
+	return func(node *SimpleBackend) error {
+		if node.BackendImpl != gocode.NameOf[simplecache.SimpleCache]() {
+			return blueprint.Errorf("MaxEntries is only supported by simple.Cache, but %v is a %v", node.InstanceName, node.BackendImpl)
+		}
+		if n <= 0 {
+			return blueprint.Errorf("invalid max entries %v for %v", n, node.InstanceName)
+		}
+		node.MaxEntries = n
+		return nil
+	}
 }
 
-func define[BackendInterface any, BackendImpl any](spec wiring.WiringSpec, name string) string {
+func define[BackendInterface any, BackendImpl any](spec wiring.WiringSpec, name string, options []Option) string {
 	// The nodes that we are defining
 	backendName := name + ".backend"
 
 	// Define the backend instance
 	spec.Define(backendName, &SimpleBackend{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
-		return newSimpleBackend[BackendImpl](name)
+		node, err := newSimpleBackend[BackendInterface, BackendImpl](name)
+		if err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
 	})
 
 	// Create a pointer to the backend instance
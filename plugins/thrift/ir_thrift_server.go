@@ -9,6 +9,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
 	"github.com/blueprint-uservices/blueprint/plugins/thrift/thriftcodegen"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 	"golang.org/x/exp/slog"
 )
 
@@ -23,6 +24,8 @@ type golangThriftServer struct {
 	InstanceName string
 	Bind         *address.BindConfig
 	Wrapped      golang.Service
+	TLSMode      tlscerts.Mode
+	TLS          *tlscerts.Credentials // The credentials of the server, if it uses TLS
 
 	outputPackage string
 }
@@ -49,6 +52,9 @@ func newGolangThriftServer(name string, service golang.Service) (*golangThriftSe
 }
 
 func (n *golangThriftServer) String() string {
+	if n.TLS != nil {
+		return n.InstanceName + " = ThriftServer(" + n.Wrapped.Name() + ", " + n.Bind.Name() + ", " + n.TLS.Mode.String() + ")"
+	}
 	return n.InstanceName + " = ThriftServer(" + n.Wrapped.Name() + ", " + n.Bind.Name() + ")"
 }
 
@@ -97,12 +103,19 @@ func (node *golangThriftServer) AddInstantiation(builder golang.NamespaceBuilder
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "service", Type: iface},
 				{Name: "serverAddr", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "tlsCredentials", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
+	if node.TLS != nil {
+		if err := node.TLS.AddInstantiation(builder); err != nil {
+			return err
+		}
+	}
+
 	slog.Info(fmt.Sprintf("Instantiating ThriftServer %v in %v/%v", node.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Bind})
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Bind, tlscerts.Arg(node.TLS)})
 }
 
 func (node *golangThriftServer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
@@ -36,6 +36,9 @@ func GenerateClient(builder golang.ModuleBuilder, service *gocode.ServiceInterfa
 		"context", "time", "errors",
 		"github.com/apache/thrift/lib/go/thrift",
 		innerPkgPath,
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig",
 	)
 
 	slog.Info(fmt.Sprintf("Generating %v/%v.go", client.Package.PackageName, client.Name))
@@ -64,22 +67,33 @@ type {{.Name}} struct {
 	Address string
 }
 
-func New_{{.Name}}(ctx context.Context, serverAddress string) (*{{.Name}}, error) {
+func New_{{.Name}}(ctx context.Context, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Client(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
 	handler := &{{.Name}}{}
 	handler.Address = serverAddress
 	var protocolFactory thrift.TProtocolFactory
-	protocolFactory = thrift.NewTBinaryProtocolFactory(true, true)
+	protocolFactory = thrift.NewTHeaderProtocolFactoryConf(nil) // THeader carries the deadline and metadata of calls
 	var transportFactory thrift.TTransportFactory
 	transportFactory = thrift.NewTTransportFactory()
 	var transport thrift.TTransport
-	var err error
 	duration, err := time.ParseDuration("1s")
 	if err != nil {
 		return nil, err
 	}
-	transport, err = thrift.NewTSocketTimeout(handler.Address, duration, duration)
-	if err != nil {
-		return nil, err
+	if tlsConfig != nil {
+		transport = thrift.NewTSSLSocketConf(handler.Address, &thrift.TConfiguration{
+			ConnectTimeout: duration,
+			SocketTimeout:  duration,
+			TLSConfig:      tlsConfig,
+		})
+	} else {
+		transport, err = thrift.NewTSocketTimeout(handler.Address, duration, duration)
+		if err != nil {
+			return nil, err
+		}
 	}
 	transport, err = transportFactory.GetTransport(transport)
 	if err != nil {
@@ -89,10 +103,10 @@ func New_{{.Name}}(ctx context.Context, serverAddress string) (*{{.Name}}, error
 	if err != nil {
 		return nil, err
 	}
-	iprot := protocolFactory.GetProtocol(transport)
-	oprot := protocolFactory.GetProtocol(transport)
+	// THeader requests and responses must use the same protocol instance
+	protocol := protocolFactory.GetProtocol(transport)
 
-	client := {{.ImportPrefix}}.New{{.Service.BaseName}}Client(thrift.NewTStandardClient(iprot, oprot))
+	client := {{.ImportPrefix}}.New{{.Service.BaseName}}Client(thrift.NewTStandardClient(protocol, protocol))
 	handler.Client = client
 	handler.Timeout = duration
 	return handler, nil
@@ -110,11 +124,21 @@ func (client *{{$receiver}}) {{SignatureWithRetVars $f}} {
 	ctx, cancel := context.WithTimeout(ctx, client.Timeout)
 	defer cancel()
 
-	rsp, err := client.Client.{{$f.Name}}(ctx, req)
-	if err != nil {
-		err = ctx.Err()
+	// Propagate the deadline and metadata of the call in THeaders
+	headers := propagation.MapCarrier{}
+	propagation.Inject(ctx, headers)
+	for key, value := range headers {
+		ctx = thrift.SetHeader(ctx, key, value)
 	}
+	ctx = thrift.SetWriteHeaderList(ctx, headers.Keys())
+
+	rsp, err := client.Client.{{$f.Name}}(ctx, req)
 	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = rpcerrors.FromMessage(err)
+		}
 		return
 	}
 	if rsp == nil {
@@ -32,7 +32,10 @@ func GenerateServerHandler(builder golang.ModuleBuilder, service *gocode.Service
 
 	innerPkgPath := builder.Info().Name + "/" + outputPackage + "/" + innerPkg
 
-	server.Imports.AddPackages("context", "github.com/apache/thrift/lib/go/thrift", innerPkgPath)
+	server.Imports.AddPackages("context", "crypto/tls", "github.com/apache/thrift/lib/go/thrift", innerPkgPath,
+		"github.com/blueprint-uservices/blueprint/runtime/core/propagation",
+		"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors",
+		"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig")
 
 	slog.Info(fmt.Sprintf("Generating %v/%v_ThriftServer.go", server.Package.PackageName, service.Name))
 	outputFile := filepath.Join(server.Package.Path, service.Name+
@@ -58,24 +61,34 @@ package {{.Package.ShortName}}
 type {{.Name}} struct {
 	Service {{.Imports.NameOf .Service.UserType}}
 	Address string
+	TLSConfig *tls.Config // nil if the server does not use TLS
 }
 
-func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string) (*{{.Name}}, error) {
+func New_{{.Name}}(ctx context.Context, service {{.Imports.NameOf .Service.UserType}}, serverAddress string, tlsCredentials string) (*{{.Name}}, error) {
+	tlsConfig, err := tlsconfig.Server(tlsCredentials)
+	if err != nil {
+		return nil, err
+	}
 	handler := &{{.Name}}{}
 	handler.Service = service
 	handler.Address = serverAddress
+	handler.TLSConfig = tlsConfig
 	return handler, nil
 }
 
 // Blueprint: Run is automatically called in a separate goroutine by runtime/plugins/golang/di.go
 func (handler *{{.Name}}) Run(ctx context.Context) error {
 	var protocolFactory thrift.TProtocolFactory
-	protocolFactory = thrift.NewTBinaryProtocolFactory(true, true)
+	protocolFactory = thrift.NewTHeaderProtocolFactoryConf(nil) // THeader carries the deadline and metadata of calls
 	var transportFactory thrift.TTransportFactory
 	transportFactory = thrift.NewTTransportFactory()
 	var transport thrift.TServerTransport
 	var err error
-	transport, err = thrift.NewTServerSocket(handler.Address)
+	if handler.TLSConfig != nil {
+		transport, err = thrift.NewTSSLServerSocket(handler.Address, handler.TLSConfig)
+	} else {
+		transport, err = thrift.NewTServerSocket(handler.Address)
+	}
 	if err != nil {
 		return err
 	}
@@ -97,10 +110,18 @@ func (handler *{{.Name}}) Run(ctx context.Context) error {
 {{$prefix := .ImportPrefix -}}
 {{ range $_, $f := .Service.Methods }}
 func (handler *{{$receiver}}) {{$f.Name -}}(ctx context.Context, req *{{$prefix}}.{{$service}}_{{$f.Name}}_Request) (*{{$prefix}}.{{$service}}_{{$f.Name}}_Response, error) {
+	headers := propagation.MapCarrier{}
+	for _, key := range thrift.GetReadHeaderList(ctx) {
+		headers[key], _ = thrift.GetHeader(ctx, key)
+	}
+	ctx, cancel := propagation.Extract(ctx, headers)
+	defer cancel()
+
 	{{ArgVarsEquals $f}} unmarshall_{{$f.Name}}_req(req)
 	{{RetVars $f "err"}} := handler.Service.{{$f.Name}}({{ArgVars $f "ctx"}})
 	if err != nil {
-		return nil, err
+		// Thrift only carries the messages of errors, so the message is the envelope of the error
+		return nil, thrift.NewTApplicationException(thrift.INTERNAL_ERROR, rpcerrors.Encode(err).String())
 	}
 	rsp := &{{$prefix}}.{{$service}}_{{$f.Name}}_Response{}
 	marshall_{{$f.Name}}_rsp(rsp, {{RetVars $f}})
@@ -11,8 +11,14 @@
 // and a client-side library that calls the server.
 // This is implemented within the [thriftcodegen] pacakge.
 //
+// To encrypt the traffic between clients and the server, deploy the service with the [TLS] or [MutualTLS] option,
+// e.g. thrift.Deploy(spec, "my_service", thrift.TLS()).  Certificates are generated at compile time; see the
+// [tlscerts] package.
+//
 // To use this plugin, the thrift compiler and version-matching go bindings are required to be installed on the machine that is compiling the Blueprint wiring spec.
 // Installation instructions can be found: https://thrift.apache.org/download
+//
+// [tlscerts]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/tlscerts
 package thrift
 
 import (
@@ -22,6 +28,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/tlscerts"
 	"golang.org/x/exp/slog"
 )
 
@@ -38,7 +45,9 @@ import (
 //
 // Deploying a service with Thrift increases the visibility of the service within the application.
 // By default, any other service running in any other container or namespace can now contact this service.
-func Deploy(spec wiring.WiringSpec, serviceName string) {
+//
+// `options` configure the server, e.g. [TLS].
+func Deploy(spec wiring.WiringSpec, serviceName string, options ...Option) {
 	// The nodes that we are defining
 	thrift_client := serviceName + ".thrift_client"
 	thrift_server := serviceName + ".thrift_server"
@@ -79,8 +88,38 @@ func Deploy(spec wiring.WiringSpec, serviceName string) {
 		if err != nil {
 			return nil, err
 		}
+		for _, option := range options {
+			if err := option(server); err != nil {
+				return nil, err
+			}
+		}
+		if server.TLS, err = tlscerts.IssueServer(spec, thrift_server+".tls", serviceName, server.TLSMode); err != nil {
+			return nil, err
+		}
 
 		err = address.Bind[*golangThriftServer](namespace, thrift_addr, server, &server.Bind)
 		return server, err
 	})
 }
+
+// An option for [Deploy]
+type Option func(*golangThriftServer) error
+
+// [TLS] is an [Option] that serves the service with TLS.  Clients verify that the server presents a certificate for
+// the name of the service, which is issued by the application's development CA at compile time.
+func TLS() Option {
+	return func(node *golangThriftServer) error {
+		node.TLSMode = tlscerts.TLS
+		return nil
+	}
+}
+
+// [MutualTLS] is an [Option] that serves the service with mutual TLS.  In addition to [TLS], the server requires
+// clients to present a certificate issued by the application's development CA, which is issued to each client at
+// compile time.
+func MutualTLS() Option {
+	return func(node *golangThriftServer) error {
+		node.TLSMode = tlscerts.MutualTLS
+		return nil
+	}
+}
@@ -24,7 +24,8 @@ func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterfa
 		Imports: gogen.NewImports(pkg.Name),
 	}
 
-	client.Imports.AddPackages("context", "time", "errors")
+	client.Imports.AddPackages("context")
+	client.Timeouts = client.Imports.AddPackage("github.com/blueprint-uservices/blueprint/runtime/plugins/timeouts")
 	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, wrapped.BaseName+"_TimeoutClient"))
 	outputFile := filepath.Join(client.Package.Path, wrapped.BaseName+"_TimeoutClient.go")
 
@@ -32,10 +33,11 @@ func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterfa
 }
 
 type clientArgs struct {
-	Package golang.PackageInfo
-	Service *gocode.ServiceInterface
-	Name    string
-	Imports *gogen.Imports
+	Package  golang.PackageInfo
+	Service  *gocode.ServiceInterface
+	Name     string
+	Timeouts string // The import name of the runtime timeouts package
+	Imports  *gogen.Imports
 }
 
 var clientTemplate = `// Blueprint: Auto-generated by Timeouts Plugin
@@ -45,40 +47,28 @@ package {{.Package.ShortName}}
 
 type {{.Name}} struct {
 	Client {{.Imports.NameOf .Service.UserType}}
-	Timeout time.Duration
+	timeouts *{{.Timeouts}}.ServiceTimeouts
 }
 
-func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}, timeout string) (*{{.Name}}, error) {
-	handler := &{{.Name}}{}
-	tout, err := time.ParseDuration(timeout)
+func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}, config string) (*{{.Name}}, error) {
+	t, err := {{.Timeouts}}.NewServiceTimeouts(config)
 	if err != nil {
 		return nil, err
 	}
-	handler.Timeout = tout
+	handler := &{{.Name}}{}
 	handler.Client = client
+	handler.timeouts = t
 	return handler, nil
 }
 
-{{$service := .Service.Name -}}
 {{$receiver := .Name -}}
 {{ range $_, $f := .Service.Methods }}
 func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
-	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(client.Timeout))
-	defer cancel()
-	is_complete := make(chan bool)
-	go func() {
+	err = client.timeouts.Call(ctx, "{{$f.Name}}", func(ctx context.Context) error {
 		{{RetVars $f "err"}} = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
-		is_complete <- true
-	}()
-
-	// Wait till we either complete the request or it gets timed out
-	select {
-	case <-ctx.Done():
-		err = errors.New("Request was timed out")
-		return
-	case <-is_complete:
-		return
-	}
+		return err
+	})
+	return
 }
 {{end}}
 `
@@ -9,6 +9,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/timeouts"
 )
 
 // Blueprint IR node representing a Timeout node
@@ -20,11 +21,11 @@ type TimeoutClient struct {
 	InstanceName string
 	Wrapped      golang.Service
 
-	TimeoutValue  *ir.IRValue
+	Timeouts      runtime.Timeouts // Keyed by method name, or "*" for the service
 	outputPackage string
 }
 
-func newTimeoutClient(name string, server ir.IRNode, timeout string) (*TimeoutClient, error) {
+func newTimeoutClient(name string, server ir.IRNode) (*TimeoutClient, error) {
 	serverNode, is_callable := server.(golang.Service)
 	if !is_callable {
 		return nil, blueprint.Errorf("timeout server wrapper requires %s to be a golang service but got %s", server.Name(), reflect.TypeOf(server).String())
@@ -34,7 +35,7 @@ func newTimeoutClient(name string, server ir.IRNode, timeout string) (*TimeoutCl
 	node.InstanceName = name
 	node.Wrapped = serverNode
 	node.outputPackage = "timeouts"
-	node.TimeoutValue = &ir.IRValue{Value: timeout}
+	node.Timeouts = make(runtime.Timeouts)
 	return node, nil
 }
 
@@ -51,7 +52,7 @@ func (node *TimeoutClient) Name() string {
 
 // Implements ir.IRNode
 func (node *TimeoutClient) String() string {
-	return node.Name() + " = TimeoutClient(" + node.Wrapped.Name() + ")"
+	return node.Name() + " = TimeoutClient(" + node.Wrapped.Name() + ", " + node.Timeouts.String() + ")"
 }
 
 // Implements golang.Service
@@ -75,6 +76,12 @@ func (node *TimeoutClient) GenerateFuncs(builder golang.ModuleBuilder) error {
 		return err
 	}
 
+	for method := range node.Timeouts {
+		if _, exists := iface.Methods[method]; !exists && method != "*" {
+			return blueprint.Errorf("unable to add a timeout to %v.%v as the method does not exist", node.Wrapped.Name(), method)
+		}
+	}
+
 	return generateClient(builder, iface, node.outputPackage)
 }
 
@@ -96,10 +103,11 @@ func (node *TimeoutClient) AddInstantiation(builder golang.NamespaceBuilder) err
 			Arguments: []gocode.Variable{
 				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
 				{Name: "client", Type: iface},
-				{Name: "timeout_val", Type: &gocode.BasicType{Name: "string"}},
+				{Name: "timeouts", Type: &gocode.BasicType{Name: "string"}},
 			},
 		},
 	}
 
-	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.TimeoutValue})
+	args := []ir.IRNode{node.Wrapped, &ir.IRValue{Value: node.Timeouts.String()}}
+	return builder.DeclareConstructor(node.InstanceName, constructor, args)
 }
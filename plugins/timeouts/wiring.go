@@ -5,14 +5,28 @@
 //
 // Example Usage to add a "1s" timeout to each request:
 //  timeouts.Add(spec, "my_service", "1s")
+//
+// Timeouts can be overridden for individual methods with [Method].  With a deadline [Budget], a call uses at most a
+// fraction of the time remaining until the caller's own deadline, which leaves time for the caller's other calls and
+// for retries.  Example usage where lookups time out after 100ms, and writes after 1s or half of the caller's
+// remaining time, whichever is sooner:
+//  timeouts.Add(spec, "my_service", "100ms", timeouts.Method("WriteCart", timeouts.Timeout("1s"), timeouts.Budget(0.5)))
+//
+// The plugin utilizes code in the [runtime/plugins/timeouts] package.
+//
+// [runtime/plugins/timeouts]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/timeouts
 package timeouts
 
 import (
+	"strings"
+	"time"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	runtime "github.com/blueprint-uservices/blueprint/runtime/plugins/timeouts"
 	"golang.org/x/exp/slog"
 )
 
@@ -21,15 +35,18 @@ import (
 // Modifies the given service such that all clients to that service have a user-specified `timeout`.
 //
 // The `timeout` string must be a sequence of decimal numbers, each with optional fraction and a unit suffix, such as "300ms", "1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+// Alternatively, `timeout` can be the timeouts of methods in the format of [Timeouts], or empty if `options` configure
+// the timeouts.
 //
 // Usage:
 //   Add(spec, "my_service", "1s")
-func Add(spec wiring.WiringSpec, serviceName string, timeout string) {
+func Add(spec wiring.WiringSpec, serviceName string, timeout string, options ...Option) {
 	clientWrapper := serviceName + ".client.timeout"
 
 	ptr := pointer.GetPointer(spec, serviceName)
 	if ptr == nil {
 		slog.Error("Unable to add timeouts to " + serviceName + " as it is not a pointer")
+		return
 	}
 
 	clientNext := ptr.AddSrcModifier(spec, clientWrapper)
@@ -41,6 +58,94 @@ func Add(spec wiring.WiringSpec, serviceName string, timeout string) {
 			return nil, blueprint.Errorf("Timeouts %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
 		}
 
-		return newTimeoutClient(clientWrapper, wrapped, timeout)
+		node, err := newTimeoutClient(clientWrapper, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		if err := Timeouts(timeout)(node, "*"); err != nil {
+			return nil, err
+		}
+		for _, option := range options {
+			if err := option(node, "*"); err != nil {
+				return nil, err
+			}
+		}
+		if len(node.Timeouts) == 0 {
+			return nil, blueprint.Errorf("no timeouts for %v", clientWrapper)
+		}
+		for method, timeout := range node.Timeouts {
+			if err := timeout.Validate(); err != nil {
+				return nil, blueprint.Errorf("invalid timeout for %v of %v: %s", method, clientWrapper, err.Error())
+			}
+		}
+		return node, nil
 	})
 }
+
+// An option for [Add] that configures the timeout of method, which is "*" for the service as a whole.
+type Option func(node *TimeoutClient, method string) error
+
+// [Timeout] is an [Option] that times out calls after `timeout`, e.g. "1s".
+func Timeout(timeout string) Option {
+	return func(node *TimeoutClient, method string) error {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return blueprint.Errorf("invalid timeout for %v of %v: %s", method, node.InstanceName, err.Error())
+		}
+		t := node.Timeouts[method]
+		t.Duration = d
+		node.Timeouts[method] = t
+		return nil
+	}
+}
+
+// [Budget] is an [Option] that limits calls to `fraction` of the time remaining until the caller's deadline, e.g.
+// 0.5.  The budget has no effect for callers without a deadline.  If there is also a [Timeout], the earlier deadline
+// applies.
+func Budget(fraction float64) Option {
+	return func(node *TimeoutClient, method string) error {
+		t := node.Timeouts[method]
+		t.Budget = fraction
+		node.Timeouts[method] = t
+		return nil
+	}
+}
+
+// [Method] is an [Option] that applies `options` to the specified method instead of the service as a whole.  The
+// options override the corresponding options of the service, and the method otherwise uses the service's options.
+func Method(method string, options ...Option) Option {
+	return func(node *TimeoutClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("timeouts.Method(%v) cannot be nested in timeouts.Method(%v) for %v", method, scope, node.InstanceName)
+		}
+		if method == "" || method == "*" || strings.ContainsAny(method, ",:;= ") {
+			return blueprint.Errorf("invalid method name %q for %v", method, node.InstanceName)
+		}
+		for _, option := range options {
+			if err := option(node, method); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// [Timeouts] is an [Option] that sets timeouts from a string such as "*=timeout:100ms;WriteCart=timeout:1s,budget:0.5",
+// or a duration such as "1s" for all methods; see the [runtime/plugins/timeouts] package for the format.
+//
+// [runtime/plugins/timeouts]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/timeouts
+func Timeouts(timeouts string) Option {
+	return func(node *TimeoutClient, scope string) error {
+		if scope != "*" {
+			return blueprint.Errorf("timeouts.Timeouts cannot be nested in timeouts.Method(%v) for %v", scope, node.InstanceName)
+		}
+		parsed, err := runtime.ParseTimeouts(timeouts)
+		if err != nil {
+			return blueprint.Errorf("invalid timeouts for %v: %s", node.InstanceName, err.Error())
+		}
+		for method, timeout := range parsed {
+			node.Timeouts[method] = timeout
+		}
+		return nil
+	}
+}
@@ -0,0 +1,252 @@
+package tlscerts
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig"
+)
+
+// How long the CA and the certificates that it issues are valid for
+const validity = 10 * 365 * 24 * time.Hour
+
+// The file, in the state directory of the output directory (see ir.StateDir), where the seed of the CA is persisted
+const stateFile = "tlscerts.json"
+
+// The development certificate authority of an application.
+//
+// The keys of the CA and of the certificates that it issues are derived from a random seed, which is generated when
+// the application's artifacts are generated.  With incremental compilation, the seed is persisted in the output
+// directory, so that the same certificates are generated each time the application is compiled, and processes that
+// are not regenerated keep trusting the processes that are.  The keys are not part of the IR, so they do not change
+// the content hash of the nodes that use them.
+type Authority struct {
+	InstanceName string
+}
+
+// The seed of the CA, and the time that it was created, which is the start of the validity of every certificate
+type authoritySeed struct {
+	Seed    []byte    `json:"seed"`
+	Created time.Time `json:"created"`
+}
+
+// The CA for one call to ir.ApplicationNode.GenerateArtifacts
+type issuer struct {
+	authoritySeed
+	cert    *x509.Certificate
+	certPEM []byte
+	key     ed25519.PrivateKey
+}
+
+type issuerKey struct {
+	ca       *Authority
+	stateDir string
+}
+
+var (
+	issuersLock sync.Mutex
+	issuers     = make(map[issuerKey]*issuer)
+)
+
+func newAuthority(name string) *Authority {
+	return &Authority{InstanceName: name}
+}
+
+// Implements ir.IRNode
+func (ca *Authority) Name() string {
+	return ca.InstanceName
+}
+
+// Implements ir.IRNode
+func (ca *Authority) String() string {
+	return ca.InstanceName + " = DevelopmentCA()"
+}
+
+// Implements ir.IRMetadata
+func (ca *Authority) ImplementsIRMetadata() {}
+
+// Returns the issuer of the CA for the artifacts that are being generated, loading or creating its seed the first
+// time that it is needed.
+func (ca *Authority) issuer() (*issuer, error) {
+	stateDir, err := ir.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	issuersLock.Lock()
+	defer issuersLock.Unlock()
+	key := issuerKey{ca, stateDir}
+	if iss, exists := issuers[key]; exists {
+		return iss, nil
+	}
+
+	seed, err := loadSeed(ca.InstanceName, stateDir)
+	if err != nil {
+		return nil, err
+	}
+	iss := &issuer{authoritySeed: seed, key: seed.derive("ca")}
+	template := iss.newTemplate("Blueprint Development CA")
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, iss.key.Public(), iss.key)
+	if err != nil {
+		return nil, blueprint.Errorf("unable to create the certificate of %v: %s", ca.InstanceName, err.Error())
+	}
+	if iss.cert, err = x509.ParseCertificate(der); err != nil {
+		return nil, blueprint.Errorf("unable to parse the certificate of %v: %s", ca.InstanceName, err.Error())
+	}
+	iss.certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	issuers[key] = iss
+	return iss, nil
+}
+
+// Reads the seed of the CA from stateDir, or generates a new seed and saves it to stateDir.  If stateDir is empty,
+// the seed is not saved.
+func loadSeed(name string, stateDir string) (authoritySeed, error) {
+	var seed authoritySeed
+	path := filepath.Join(stateDir, stateFile)
+	if stateDir != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if err := json.Unmarshal(data, &seed); err != nil || len(seed.Seed) != ed25519.SeedSize {
+				return seed, blueprint.Errorf("invalid seed for %v in %v", name, path)
+			}
+			return seed, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return seed, blueprint.Errorf("unable to read the seed of %v from %v: %s", name, path, err.Error())
+		}
+	}
+
+	seed.Seed = make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed.Seed); err != nil {
+		return seed, blueprint.Errorf("unable to generate a seed for %v: %s", name, err.Error())
+	}
+	seed.Created = time.Now().UTC().Truncate(time.Second)
+	if stateDir != "" {
+		data, err := json.Marshal(seed)
+		if err != nil {
+			return seed, blueprint.Errorf("unable to encode the seed of %v: %s", name, err.Error())
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return seed, blueprint.Errorf("unable to write the seed of %v to %v: %s", name, path, err.Error())
+		}
+	}
+	return seed, nil
+}
+
+// Returns bytes that are derived from the seed for the purpose label
+func (seed authoritySeed) hash(label string) []byte {
+	mac := hmac.New(sha256.New, seed.Seed)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// Returns the key that is derived from the seed for label.  Ed25519 keys and signatures are deterministic, so
+// certificates are the same each time they are issued from the same seed.
+func (seed authoritySeed) derive(label string) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(seed.hash("key:" + label))
+}
+
+// Returns the credentials of the server of serviceName.  The server's certificate is valid for serviceName and for
+// localhost, and is issued when the credentials are instantiated.
+func (ca *Authority) issueServer(name string, serviceName string, mode Mode) *Credentials {
+	return &Credentials{
+		CredentialsName: name,
+		Mode:            mode,
+		ServiceName:     serviceName,
+		Authority:       ca,
+	}
+}
+
+// Returns the credentials of a client named `name` of the server with credentials `server`.  The client is issued a
+// certificate if the server uses mutual TLS.
+func (server *Credentials) IssueClient(name string, clientName string) (*Credentials, error) {
+	creds := &Credentials{
+		CredentialsName: name,
+		Mode:            server.Mode,
+		ServiceName:     server.ServiceName,
+		ServerName:      server.ServiceName,
+		Authority:       server.Authority,
+	}
+	if server.Mode == MutualTLS {
+		creds.ClientName = clientName
+	}
+	return creds, nil
+}
+
+// Returns the PEM-encoded files of the credentials, issuing their certificate if they have one
+func (creds *Credentials) files() (map[string][]byte, error) {
+	iss, err := creds.Authority.issuer()
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{tlsconfig.CAFile: iss.certPEM}
+	switch {
+	case creds.ServerName == "":
+		template := iss.newTemplate(creds.ServiceName)
+		template.DNSNames = []string{creds.ServiceName, "localhost"}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		if err := iss.issue(creds.CredentialsName, template, files); err != nil {
+			return nil, err
+		}
+		if creds.Mode == MutualTLS {
+			files[tlsconfig.ClientCAFile] = iss.certPEM
+		}
+	case creds.ClientName != "":
+		template := iss.newTemplate(creds.ClientName)
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		if err := iss.issue(creds.CredentialsName, template, files); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Issues a certificate from template with a key that is derived from name, and adds the certificate and key to files
+func (iss *issuer) issue(name string, template *x509.Certificate, files map[string][]byte) error {
+	key := iss.derive(name)
+	template.SerialNumber = iss.serial(name)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, iss.cert, key.Public(), iss.key)
+	if err != nil {
+		return blueprint.Errorf("unable to issue a certificate for %v: %s", name, err.Error())
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return blueprint.Errorf("unable to encode the key of %v: %s", name, err.Error())
+	}
+	files[tlsconfig.CertFile] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	files[tlsconfig.KeyFile] = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return nil
+}
+
+// Returns a positive 128-bit serial number that is derived from name
+func (iss *issuer) serial(name string) *big.Int {
+	return new(big.Int).SetBytes(iss.hash("serial:" + name)[:16])
+}
+
+func (iss *issuer) newTemplate(commonName string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: iss.serial(commonName),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Blueprint"}},
+		NotBefore:    iss.Created.Add(-time.Hour),
+		NotAfter:     iss.Created.Add(validity),
+	}
+}
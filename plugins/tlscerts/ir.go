@@ -0,0 +1,135 @@
+package tlscerts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// The certificates and key of a server or client.
+//
+// At runtime, the credentials are a string: the name that they are registered with in tlsconfig.Register.
+// Servers and clients pass the name to tlsconfig.Server and tlsconfig.Client.  Credentials are not added to
+// namespaces by the wiring spec; instead, the server or client that uses them calls [Credentials.AddInstantiation].
+//
+// The certificates and keys of the credentials are issued by the [Authority] when the credentials are instantiated,
+// so they are not part of the IR.
+type Credentials struct {
+	CredentialsName string
+	Mode            Mode
+	ServiceName     string // The name of the service whose server the credentials are for, or connect to
+	ServerName      string // For clients, the name that the server's certificate is issued for
+	ClientName      string // For clients of servers that use mutual TLS, the name that the client's certificate is issued for
+
+	Authority *Authority // The CA that issues the certificates
+}
+
+// Returns the constructor argument for creds, which is the empty string for servers and clients without TLS
+func Arg(creds *Credentials) ir.IRNode {
+	if creds == nil {
+		return &ir.IRValue{Value: ""}
+	}
+	return creds
+}
+
+// Implements ir.IRNode
+func (creds *Credentials) Name() string {
+	return creds.CredentialsName
+}
+
+// Implements ir.IRNode
+func (creds *Credentials) String() string {
+	return creds.CredentialsName + " = TLSCredentials(" + creds.Mode.String() + ")"
+}
+
+// Issues the files of the credentials and writes them to a package in the module of builder that embeds them, and
+// declares the credentials in the namespace.
+func (creds *Credentials) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(creds.CredentialsName) {
+		return nil
+	}
+
+	pkg, err := builder.Module().CreatePackage("tlscerts/" + ir.CleanName(creds.CredentialsName))
+	if err != nil {
+		return err
+	}
+	contents, err := creds.files()
+	if err != nil {
+		return err
+	}
+	var files []string
+	for file := range contents {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(pkg.Path, file), contents[file], 0600); err != nil {
+			return blueprint.Errorf("unable to write %v of %v: %s", file, creds.CredentialsName, err.Error())
+		}
+	}
+
+	args := credentialsTemplateArgs{
+		Package:     pkg,
+		Credentials: creds,
+		Files:       files,
+		Imports:     gogen.NewImports(pkg.Name),
+	}
+	args.Imports.AddPackages("embed", "github.com/blueprint-uservices/blueprint/runtime/core/tlsconfig")
+	slog.Info(fmt.Sprintf("Generating %v/credentials.go", pkg.PackageName))
+	if err := gogen.ExecuteTemplateToFile("tlscerts.Credentials", credentialsTemplate, args, filepath.Join(pkg.Path, "credentials.go")); err != nil {
+		return err
+	}
+
+	code, err := gogen.ExecuteTemplate("tlscerts.AddInstantiation", buildFuncTemplate, buildFuncArgs{Package: builder.Import(pkg.PackageName)})
+	if err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Instantiating TLSCredentials %v in %v/%v", creds.CredentialsName, builder.Info().Package.PackageName, builder.Info().FileName))
+	return builder.Declare(creds.CredentialsName, code)
+}
+
+type credentialsTemplateArgs struct {
+	Package     golang.PackageInfo
+	Credentials *Credentials
+	Files       []string
+	Imports     *gogen.Imports
+}
+
+var credentialsTemplate = `// Package {{.Package.ShortName}} is auto-generated by Blueprint's tlscerts plugin (tlscerts/ir.go)
+//
+// It embeds the TLS certificates of {{.Credentials.CredentialsName}}, which were generated when the application was
+// compiled.  The certificates are for development and testing only.
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+{{range $_, $file := .Files -}}
+//go:embed {{$file}}
+{{end -}}
+var files embed.FS
+
+// Registers the credentials with the tlsconfig package, and returns the name that they are registered with
+func Register() string {
+	tlsconfig.Register({{printf "%q" .Credentials.CredentialsName}}, tlsconfig.Credentials{
+		Files:      files,
+		ServerName: {{printf "%q" .Credentials.ServerName}},
+	})
+	return {{printf "%q" .Credentials.CredentialsName}}
+}
+`
+
+type buildFuncArgs struct {
+	Package string
+}
+
+var buildFuncTemplate = `func(n *golang.Namespace) (any, error) {
+		// Auto-generated by the tlscerts plugin tlscerts/ir.go
+		return {{.Package}}.Register(), nil
+	}`
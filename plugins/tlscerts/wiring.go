@@ -0,0 +1,88 @@
+// Package tlscerts generates the certificates that are used by the HTTP, gRPC, and Thrift plugins to serve services
+// with TLS or mutual TLS.
+//
+// This package is not used directly by wiring specs.  Instead, services are deployed with the TLS options of the
+// plugins, e.g.
+//
+//	grpc.Deploy(spec, "user_service", grpc.TLS())
+//	http.Deploy(spec, "cart_service", http.MutualTLS())
+//
+// At compile time, Blueprint generates a development certificate authority (CA) for the application, and uses it to
+// issue a certificate to the server of each such service, for the name of the service.  With mutual TLS, it also
+// issues a certificate to each client of the service.  The certificates and keys are written to a package in each
+// process that runs a server or client (e.g. tlscerts/user_service_grpc_server_tls), which embeds them in the
+// process's binary, so that they are available to processes however they are run, including in containers.
+//
+// Generated clients verify that servers present a certificate issued by the CA for the name of the service,
+// regardless of the address that they dial.  Server certificates are also valid for localhost, so that servers can be
+// called by tools such as curl, with the CA certificate that is in the package of any of the application's clients.
+//
+// The keys of the CA and certificates are derived from a random seed.  With incremental compilation (see
+// ir.SetIncrementalCompilation), the seed is persisted in the output directory, so that recompiling the application
+// generates the same certificates; otherwise, they are regenerated each time the application is compiled.  The CA's
+// private key is not written to any process.  The certificates are intended for development and testing, not for
+// production deployments.
+//
+// The generated code utilizes the [runtime/core/tlsconfig] package.
+//
+// [runtime/core/tlsconfig]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/tlsconfig
+package tlscerts
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+)
+
+// Whether a server uses TLS
+type Mode int
+
+const (
+	Plaintext Mode = iota // The server does not use TLS
+	TLS                   // The server presents a certificate to clients
+	MutualTLS             // The server presents a certificate to clients, and clients present a certificate to the server
+)
+
+func (mode Mode) String() string {
+	switch mode {
+	case TLS:
+		return "TLS"
+	case MutualTLS:
+		return "MutualTLS"
+	default:
+		return "Plaintext"
+	}
+}
+
+// The name of the application's CA in the wiring spec
+const authorityName = "tls.ca"
+
+// Returns the development CA of the application that spec is building, creating it the first time it is needed.
+func GetAuthority(spec wiring.WiringSpec) (*Authority, error) {
+	var ca *Authority
+	if err := spec.GetProperty(authorityName, "authority", &ca); err != nil {
+		return nil, err
+	}
+	if ca != nil {
+		return ca, nil
+	}
+
+	ca = newAuthority(authorityName)
+	spec.Define(authorityName, &Authority{}, func(wiring.Namespace) (ir.IRNode, error) {
+		return ca, nil
+	})
+	spec.SetProperty(authorityName, "authority", ca)
+	return ca, nil
+}
+
+// Issues the credentials of a server of serviceName that uses mode, which are named `name` in the namespace of the
+// server.  Returns nil if mode is [Plaintext].
+func IssueServer(spec wiring.WiringSpec, name string, serviceName string, mode Mode) (*Credentials, error) {
+	if mode == Plaintext {
+		return nil, nil
+	}
+	ca, err := GetAuthority(spec)
+	if err != nil {
+		return nil, err
+	}
+	return ca.issueServer(name, serviceName, mode), nil
+}
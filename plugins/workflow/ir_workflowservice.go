@@ -39,9 +39,9 @@ type workflowHandler struct {
 	Args []ir.IRNode
 }
 
-func initWorkflowNode[ServiceType any](n *workflowNode, name string) (err error) {
+func initWorkflowNode(n *workflowNode, name string, getService serviceGetter) (err error) {
 	n.InstanceName = name
-	n.ServiceInfo, err = workflowspec.GetService[ServiceType]()
+	n.ServiceInfo, err = getService()
 	if err != nil {
 		return err
 	}
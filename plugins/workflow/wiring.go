@@ -46,6 +46,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 )
 
 var strtype = &gocode.BasicType{Name: "string"}
@@ -67,12 +68,31 @@ var strtype = &gocode.BasicType{Name: "string"}
 // After calling [Service], serviceName is an application-level golang service.  Application-level modifiers
 // can be applied to it, or it can be further deployed into e.g. a goproc, a linuxcontainer, etc.
 func Service[ServiceType any](spec wiring.WiringSpec, serviceName string, serviceArgs ...string) string {
+	return defineService(spec, serviceName, workflowspec.GetService[ServiceType], serviceArgs)
+}
+
+// [ServiceByName] is like [Service], but the type of the service is specified by its package and name
+// rather than by a type parameter, e.g.
+//
+//	payment_service := workflow.ServiceByName(spec, "github.com/blueprint-uservices/blueprint/examples/sockshop/workflow/payment", "PaymentService", "payment_service", "500")
+//
+// This is intended for wiring specs that aren't written in Go, such as those loaded from a file.  The
+// named package must be resolvable from the wiring spec's module; see [workflowspec.GetServiceByName].
+func ServiceByName(spec wiring.WiringSpec, servicePackage string, serviceType string, serviceName string, serviceArgs ...string) string {
+	return defineService(spec, serviceName, func() (*workflowspec.Service, error) {
+		return workflowspec.GetServiceByName(servicePackage, serviceType)
+	}, serviceArgs)
+}
+
+type serviceGetter func() (*workflowspec.Service, error)
+
+func defineService(spec wiring.WiringSpec, serviceName string, getService serviceGetter, serviceArgs []string) string {
 	// Define the service
 	handlerName := serviceName + ".handler"
 	spec.Define(handlerName, &workflowHandler{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
 		// Create the IR node for the handler
 		handler := &workflowHandler{}
-		if err := initWorkflowNode[ServiceType](&handler.workflowNode, serviceName); err != nil {
+		if err := initWorkflowNode(&handler.workflowNode, serviceName, getService); err != nil {
 			return nil, err
 		}
 
@@ -98,6 +118,12 @@ func Service[ServiceType any](spec wiring.WiringSpec, serviceName string, servic
 		return handler, nil
 	})
 
+	// String arguments that aren't defined in the wiring spec are treated as hard-coded values, so we
+	// can't know until build time whether a missing argument is an error
+	for _, arg := range serviceArgs {
+		wiring.AddReference(spec, handlerName, wiring.Reference{To: arg, Optional: true})
+	}
+
 	// Create a pointer to the handler
 	ptr := pointer.CreatePointer[*workflowNode](spec, serviceName, handlerName)
 
@@ -106,7 +132,7 @@ func Service[ServiceType any](spec wiring.WiringSpec, serviceName string, servic
 	clientNext := ptr.AddSrcModifier(spec, clientName)
 	spec.Define(clientName, &workflowClient{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
 		client := &workflowClient{}
-		if err := initWorkflowNode[ServiceType](&client.workflowNode, clientName); err != nil {
+		if err := initWorkflowNode(&client.workflowNode, clientName, getService); err != nil {
 			return nil, err
 		}
 		return client, namespace.Get(clientNext, &client.Wrapped)
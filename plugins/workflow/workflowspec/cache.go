@@ -51,6 +51,26 @@ func GetService[T any]() (*Service, error) {
 	return cached.get(t.Package, t.Name, modInfo)
 }
 
+// Gets a [WorkflowSpecService] for the struct Impl, using Iface as the service interface.
+//
+// [GetService] picks one arbitrarily if a struct implements more than one service interface; use
+// this method instead to choose the interface, e.g. for backends that implement several interfaces.
+//
+// # Example Usage
+//
+//	cache := workflowspec.GetServiceWithInterface[backend.Cache, simplecache.SimpleCache]()
+func GetServiceWithInterface[Iface any, Impl any]() (*Service, error) {
+	ifaceModInfo, iface, err := goparser.FindModule[Iface]()
+	if err != nil {
+		return nil, err
+	}
+	modInfo, t, err := goparser.FindModule[Impl]()
+	if err != nil {
+		return nil, err
+	}
+	return cached.getWithInterface(iface.Package, iface.Name, ifaceModInfo, t.Package, t.Name, modInfo)
+}
+
 // Gets a [WorkflowSpecService] for the specified type.
 // pkg and name should be the package and name of a service defined in an application's
 // workflow spec or a plugin's runtime directory.
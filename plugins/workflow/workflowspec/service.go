@@ -79,6 +79,10 @@ func (spec *WorkflowSpec) makeServiceFromStruct(struc *goparser.ParsedStruct) (*
 		slog.Warn(fmt.Sprintf("Warning: struct %v implements more than one service interface; using %v", struc.Name, validIfaces[0]))
 	}
 
+	return spec.makeServiceFromStructWithInterface(struc, validIfaces[0])
+}
+
+func (spec *WorkflowSpec) makeServiceFromStructWithInterface(struc *goparser.ParsedStruct, iface *goparser.ParsedInterface) (*Service, error) {
 	// Find constructors
 	constructors := spec.findConstructorsOfStruct(struc)
 	if len(constructors) == 0 {
@@ -89,10 +93,10 @@ func (spec *WorkflowSpec) makeServiceFromStruct(struc *goparser.ParsedStruct) (*
 	}
 
 	service := &Service{
-		Iface:       validIfaces[0],
+		Iface:       iface,
 		Constructor: constructors[0],
 	}
-	slog.Info(fmt.Sprintf("Located %v (%v) in package %v", struc.Name, constructors[0].Name, validIfaces[0].File.Package.Name))
+	slog.Info(fmt.Sprintf("Located %v (%v) in package %v", struc.Name, constructors[0].Name, iface.File.Package.Name))
 	return service, nil
 }
 
@@ -74,7 +74,7 @@ func (spec *WorkflowSpec) get(pkgName, name string, modInfo *goparser.ModuleInfo
 	// Find the package within the module
 	pkg, pkgExists := mod.Packages[pkgName]
 	if !pkgExists {
-		return nil, err
+		return nil, blueprint.Errorf("unable to find package %v in module %v of workflow spec", pkgName, mod.Name)
 	}
 
 	// Return either the interface or struct definition
@@ -86,3 +86,39 @@ func (spec *WorkflowSpec) get(pkgName, name string, modInfo *goparser.ModuleInfo
 	}
 	return nil, blueprint.Errorf("unable to find service %v in workflow spec", name)
 }
+
+// Looks up the named struct in the workflow spec, as a service that implements the named interface.
+func (spec *WorkflowSpec) getWithInterface(ifacePkgName, ifaceName string, ifaceModInfo *goparser.ModuleInfo, pkgName, name string, modInfo *goparser.ModuleInfo) (*Service, error) {
+	ifaceMod, err := spec.Modules.Add(ifaceModInfo)
+	if err != nil {
+		return nil, err
+	}
+	ifacePkg, pkgExists := ifaceMod.Packages[ifacePkgName]
+	if !pkgExists {
+		return nil, blueprint.Errorf("unable to find package %v in module %v of workflow spec", ifacePkgName, ifaceMod.Name)
+	}
+	iface, hasIface := ifacePkg.Interfaces[ifaceName]
+	if !hasIface {
+		return nil, blueprint.Errorf("unable to find service interface %v in workflow spec", ifaceName)
+	}
+	if valid, err := isInterfaceAValidService(iface); !valid {
+		return nil, blueprint.Errorf("interface %v is not a valid service because %v", iface.Name, err.Error())
+	}
+
+	mod, err := spec.Modules.Add(modInfo)
+	if err != nil {
+		return nil, err
+	}
+	pkg, pkgExists := mod.Packages[pkgName]
+	if !pkgExists {
+		return nil, blueprint.Errorf("unable to find package %v in module %v of workflow spec", pkgName, mod.Name)
+	}
+	struc, hasStruc := pkg.Structs[name]
+	if !hasStruc {
+		return nil, blueprint.Errorf("unable to find struct %v in workflow spec", name)
+	}
+	if _, err := implements(struc, iface); err != nil {
+		return nil, err
+	}
+	return spec.makeServiceFromStructWithInterface(struc, iface)
+}
@@ -40,6 +40,21 @@ func (w *workloadGenerator) String() string {
 	return ir.PrettyPrintNamespace(w.WorkloadName, "WorkloadGenerator", w.ProcNode.Edges, w.ProcNode.Nodes)
 }
 
+// Implements [ir.NamespaceNode]
+func (w *workloadGenerator) GetNamespaceType() string {
+	return "WorkloadGenerator"
+}
+
+// Implements [ir.NamespaceNode]
+func (w *workloadGenerator) GetArgNodes() []ir.IRNode {
+	return w.ProcNode.Edges
+}
+
+// Implements [ir.NamespaceNode]
+func (w *workloadGenerator) GetChildNodes() []ir.IRNode {
+	return w.ProcNode.Nodes
+}
+
 // Implements [ir.ArtifactGenerator]
 func (w *workloadGenerator) GenerateArtifacts(workspaceDir string) error {
 	// Create a subdir for the actual process artifacts
@@ -133,6 +133,7 @@ func Generator[GeneratorType any](spec wiring.WiringSpec, name string, workloadA
 
 		return w, nil
 	})
+	wiring.AddReference(spec, wlgenName, wiring.Reference{To: procName})
 
 	return wlgenName
 }
@@ -109,6 +109,9 @@ func Instrument(spec wiring.WiringSpec, serviceName string) {
 
 		return newXtraceServerWrapper(serverWrapper, wrapped, xtraceClient)
 	})
+
+	wiring.AddReference(spec, clientWrapper, wiring.Reference{To: xtraceServer})
+	wiring.AddReference(spec, serverWrapper, wiring.Reference{To: xtraceServer})
 }
 
 // Adds an xtrace docker container that uses the latest xtrace image to the application
@@ -1,12 +1,21 @@
 package backend
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Represents a key-value cache.
 type Cache interface {
 	// Store a key-value pair in the cache
 	Put(ctx context.Context, key string, value interface{}) error
 
+	// Store a key-value pair in the cache that expires after ttl.
+	// If ttl is zero or negative then the key never expires, as with Put.
+	//
+	// Implementations might round ttl up to a coarser granularity, e.g. whole seconds.
+	PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+
 	// Retrieves a value from the cache.
 	// val should be a pointer in which the value will be stored, e.g.
 	//
@@ -32,6 +41,13 @@ type Cache interface {
 	// Delete from the cache
 	Delete(ctx context.Context, key string) error
 
-	// Treats the value mapped to key as an integer, and increments it
+	// Sets an existing key to expire after ttl, replacing any previous expiry.
+	// If ttl is zero or negative then the key is deleted immediately.
+	//
+	// Reports whether the key existed in the cache
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Treats the value mapped to key as an integer, and increments it.
+	// The key's expiry, if any, is unchanged.
 	Incr(ctx context.Context, key string) (int64, error)
 }
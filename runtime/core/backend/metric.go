@@ -3,8 +3,10 @@ package backend
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
 	"golang.org/x/exp/slog"
 )
 
@@ -55,3 +57,23 @@ func Meter(ctx context.Context, name string, opts ...metric.MeterOption) (metric
 	}
 	return mp.Meter(name, opts...), nil
 }
+
+// Creates metric instruments the first time that they are used.
+//
+// Wrappers generated by plugins might be instantiated before the application's metric collector, so they cannot
+// create their instruments when they are instantiated.  If there is no metric collector when the instruments are
+// created, they are created by a no-op meter and metrics are not recorded.
+type LazyInstruments struct {
+	once sync.Once
+}
+
+// Calls create with the [Meter] named name, the first time that Init is called
+func (l *LazyInstruments) Init(name string, create func(meter metric.Meter)) {
+	l.once.Do(func() {
+		meter, err := Meter(context.Background(), name)
+		if err != nil {
+			meter = noop.NewMeterProvider().Meter(name)
+		}
+		create(meter)
+	})
+}
@@ -28,6 +28,33 @@ type NoSQLCursor interface {
 	All(ctx context.Context, obj interface{}) error //similar logic to Decode, but for multiple documents
 }
 
+// Options for [NoSQLCollection.FindManyWithOptions].  The zero value returns all matching documents
+// in an unspecified order.
+type FindOptions struct {
+	// Optional projection; behaves with mongodb semantics.
+	Projection bson.D
+
+	// The order in which to return documents, as a list of fields, each with 1 for ascending order
+	// or -1 for descending order, e.g. bson.D{{"price", -1}, {"name", 1}}.  Fields can be dotted paths.
+	//
+	// We use the same sort semantics as mongodb
+	// https://www.mongodb.com/docs/manual/reference/method/cursor.sort/
+	//
+	// The relative order of documents that compare equal is unspecified, so to paginate with Skip and
+	// Limit, the sort order should include a unique field such as "_id".
+	Sort bson.D
+
+	// The number of matching documents to skip.  Must not be negative.
+	Skip int64
+
+	// The maximum number of documents to return; 0 means no limit.  Must not be negative.
+	Limit int64
+
+	// The number of documents that the backend fetches per round-trip; 0 uses the backend's default.
+	// This only affects performance and never the documents that are returned.
+	BatchSize int32
+}
+
 type NoSQLCollection interface {
 	// Deletes the first document that matches filter
 	//
@@ -63,6 +90,29 @@ type NoSQLCollection interface {
 	// Projections are optional and behave with mongodb semantics.
 	FindMany(ctx context.Context, filter bson.D, projection ...bson.D) (NoSQLCursor, error) // Result is not a slice -> it is an object we can use to retrieve documents using res.All().
 
+	// Finds all documents that match the filter, sorting, skipping, and limiting the results
+	// as specified by opts.
+	//
+	// We use the same filter semantics as mongodb
+	// https://www.mongodb.com/docs/manual/tutorial/query-documents/
+	//
+	// Returns an error if opts are invalid, e.g. if Skip or Limit are negative.
+	FindManyWithOptions(ctx context.Context, filter bson.D, opts FindOptions) (NoSQLCursor, error)
+
+	// Returns the number of documents that match the filter.
+	//
+	// We use the same filter semantics as mongodb
+	// https://www.mongodb.com/docs/manual/tutorial/query-documents/
+	CountDocuments(ctx context.Context, filter bson.D) (int64, error)
+
+	// Runs an aggregation pipeline over the collection and returns the resulting documents.
+	//
+	// We use the same pipeline semantics as mongodb
+	// https://www.mongodb.com/docs/manual/core/aggregation-pipeline/
+	//
+	// Implementations other than mongodb might only support a subset of stages.
+	Aggregate(ctx context.Context, pipeline []bson.D) (NoSQLCursor, error)
+
 	// Applies the provided update to the first document that matches filter
 	//
 	// We use the same filter semantics as mongodb
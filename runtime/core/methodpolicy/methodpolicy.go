@@ -0,0 +1,77 @@
+// Package methodpolicy parses the per-method settings of the runtime plugins that configure the methods of a
+// service, such as the ratelimit, circuitbreaker, timeouts and hedging plugins.
+//
+// Settings are a semicolon-separated list of method=policy entries, where each policy is a comma-separated list of
+// key:value pairs, e.g. "*=rate:100,burst:10;GetCart=concurrency:5".  Plugins use the entry for "*", if they
+// allow it, for the service as a whole.  Each plugin decodes the keys and values of its own policies.
+package methodpolicy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Returned by the function passed to [ParseFields] for keys that it does not recognise
+var ErrUnknownKey = errors.New("unknown key")
+
+// Parses s, a comma-separated list of key:value pairs, calling set for each pair
+func ParseFields(s string, set func(key string, value string) error) error {
+	for _, field := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(field), ":")
+		if !found {
+			return fmt.Errorf("expected key:value but got %v", field)
+		}
+		if err := set(key, value); errors.Is(err, ErrUnknownKey) {
+			return fmt.Errorf("unknown setting %v", key)
+		} else if err != nil {
+			return fmt.Errorf("invalid %v: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Parses s, a semicolon-separated list of method=policy pairs, calling parse for each policy.  The empty string is
+// no policies.
+func Parse[T any](s string, parse func(policy string) (T, error)) (map[string]T, error) {
+	policies := make(map[string]T)
+	if strings.TrimSpace(s) == "" {
+		return policies, nil
+	}
+	for _, entry := range strings.Split(s, ";") {
+		method, policy, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || method == "" {
+			return nil, fmt.Errorf("expected method=policy but got %v", entry)
+		}
+		if _, exists := policies[method]; exists {
+			return nil, fmt.Errorf("duplicate policy for %v", method)
+		}
+		p, err := parse(policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy for %v: %w", method, err)
+		}
+		policies[method] = p
+	}
+	return policies, nil
+}
+
+// Returns policies in the format parsed by [Parse], with the policy for "*" first and the remaining methods in
+// alphabetical order
+func Format[T fmt.Stringer](policies map[string]T) string {
+	var methods []string
+	for method := range policies {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if methods[i] == "*" || methods[j] == "*" {
+			return methods[i] == "*"
+		}
+		return methods[i] < methods[j]
+	})
+	var entries []string
+	for _, method := range methods {
+		entries = append(entries, method+"="+policies[method].String())
+	}
+	return strings.Join(entries, ";")
+}
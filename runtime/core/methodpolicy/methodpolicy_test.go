@@ -0,0 +1,65 @@
+package methodpolicy
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type limit struct {
+	rate  int
+	burst int
+}
+
+func (l limit) String() string {
+	return "rate:" + strconv.Itoa(l.rate) + ",burst:" + strconv.Itoa(l.burst)
+}
+
+func parseLimit(s string) (limit, error) {
+	var l limit
+	err := ParseFields(s, func(key string, value string) (err error) {
+		switch key {
+		case "rate":
+			l.rate, err = strconv.Atoi(value)
+		case "burst":
+			l.burst, err = strconv.Atoi(value)
+		default:
+			return ErrUnknownKey
+		}
+		return err
+	})
+	return l, err
+}
+
+func TestParse(t *testing.T) {
+	policies, err := Parse("GetCart=rate:5,burst:1; *=rate:100,burst:10;AddItem=rate:1,burst:2", parseLimit)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]limit{"*": {100, 10}, "GetCart": {5, 1}, "AddItem": {1, 2}}, policies)
+	assert.Equal(t, "*=rate:100,burst:10;AddItem=rate:1,burst:2;GetCart=rate:5,burst:1", Format(policies))
+
+	policies, err = Parse(" ", parseLimit)
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+
+	for invalid, message := range map[string]string{
+		"rate:100":            "expected method=policy but got rate:100",
+		"=rate:1":             "expected method=policy but got =rate:1",
+		"*=rate:1;*=rate:2":   "duplicate policy for *",
+		"*=rate":              "invalid policy for *: expected key:value but got rate",
+		"*=speed:1":           "invalid policy for *: unknown setting speed",
+		"GetCart=rate:fast":   "invalid policy for GetCart: invalid rate: strconv.Atoi: parsing \"fast\": invalid syntax",
+		"GetCart=burst:1,x:1": "invalid policy for GetCart: unknown setting x",
+	} {
+		_, err := Parse(invalid, parseLimit)
+		assert.EqualError(t, err, message, invalid)
+	}
+}
+
+func TestParseFieldsErrors(t *testing.T) {
+	failure := errors.New("out of range")
+	err := ParseFields("rate:1", func(key string, value string) error { return failure })
+	assert.True(t, errors.Is(err, failure))
+}
@@ -0,0 +1,221 @@
+// Package propagation carries the deadline and metadata of a context across RPC boundaries.
+//
+// Clients generated by the HTTP, gRPC, and Thrift plugins call [Inject] to write the context of each call to the
+// request's headers, and servers call [Extract] to recreate the context before calling the service.  The following
+// are carried:
+//   - the deadline of the call, as the time remaining until the deadline.  The server's context is cancelled when
+//     the deadline is reached, as well as when the transport detects that the client has gone away.
+//   - the W3C trace context and baggage headers, i.e. the values of the [TraceParent], [TraceState], and [Baggage]
+//     keys
+//   - user-defined keys, which are set with [WithValue] and read with [Value]
+//
+// Plugins such as tracers can [Register] a [Propagator] to write their own state to, and read it from, the
+// headers of every call, instead of adding arguments to the methods of services.
+//
+// This package does not need to be used directly by application workflow specs, other than to set and read
+// metadata.
+package propagation
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys of the W3C trace context and baggage headers, which are carried with their standard names
+const (
+	TraceParent = "traceparent"
+	TraceState  = "tracestate"
+	Baggage     = "baggage"
+)
+
+const (
+	// The header that carries the time remaining until the deadline of a call
+	timeoutHeader = "blueprint-timeout"
+
+	// The prefix of the headers that carry user-defined keys
+	metadataPrefix = "blueprint-md-"
+)
+
+var w3cKeys = map[string]bool{TraceParent: true, TraceState: true, Baggage: true}
+
+// The headers of a request, such as HTTP headers, gRPC metadata, or Thrift headers.
+//
+// Carrier has the same methods as OpenTelemetry's TextMapCarrier.
+type Carrier interface {
+	Get(key string) string
+	Set(key string, value string)
+	Keys() []string
+}
+
+// A hook that writes state from the context of a call to the request's headers, and reads it back on the server.
+//
+// Because every [Carrier] is an OpenTelemetry TextMapCarrier, a TextMapPropagator is easily adapted to a Propagator.
+type Propagator interface {
+	Inject(ctx context.Context, carrier Carrier)
+	Extract(ctx context.Context, carrier Carrier) context.Context
+}
+
+type metadataKey struct{}
+
+var (
+	propagatorsMu sync.RWMutex
+	propagators   []Propagator
+)
+
+// Registers a propagator that is called by [Inject] and [Extract] for every call
+func Register(p Propagator) {
+	propagatorsMu.Lock()
+	defer propagatorsMu.Unlock()
+	propagators = append(propagators, p)
+}
+
+func registered() []Propagator {
+	propagatorsMu.RLock()
+	defer propagatorsMu.RUnlock()
+	return propagators
+}
+
+// Returns a copy of ctx in which key is value.  Keys are case-insensitive.  The value is carried to the services
+// that are called with the returned context, and onwards to the services that they call.
+func WithValue(ctx context.Context, key string, value string) context.Context {
+	md := make(map[string]string)
+	for k, v := range values(ctx) {
+		md[k] = v
+	}
+	md[strings.ToLower(key)] = value
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// Returns the value of key in ctx, or the empty string if ctx does not have key
+func Value(ctx context.Context, key string) string {
+	return values(ctx)[strings.ToLower(key)]
+}
+
+// Returns a copy of the keys and values in ctx
+func Values(ctx context.Context) map[string]string {
+	md := make(map[string]string)
+	for k, v := range values(ctx) {
+		md[k] = v
+	}
+	return md
+}
+
+func values(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(metadataKey{}).(map[string]string)
+	return md
+}
+
+// Writes the deadline and metadata of ctx to the headers of a call that is made with ctx, then calls the
+// registered propagators
+func Inject(ctx context.Context, carrier Carrier) {
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		carrier.Set(timeoutHeader, remaining.String())
+	}
+	for key, value := range values(ctx) {
+		if w3cKeys[key] {
+			carrier.Set(key, value)
+		} else {
+			carrier.Set(metadataPrefix+key, url.PathEscape(value))
+		}
+	}
+	for _, p := range registered() {
+		p.Inject(ctx, carrier)
+	}
+}
+
+// Returns a context for a call that is received with the headers in carrier, with the deadline and metadata
+// that the headers carry.  Registered propagators are then called with the context.
+//
+// The returned cancel func must be called when the call completes.
+func Extract(ctx context.Context, carrier Carrier) (context.Context, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if timeout, err := time.ParseDuration(carrier.Get(timeoutHeader)); err == nil {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	md := make(map[string]string)
+	for _, header := range carrier.Keys() {
+		key := strings.ToLower(header)
+		if w3cKeys[key] {
+			md[key] = carrier.Get(header)
+		} else if strings.HasPrefix(key, metadataPrefix) {
+			if value, err := url.PathUnescape(carrier.Get(header)); err == nil {
+				md[strings.TrimPrefix(key, metadataPrefix)] = value
+			}
+		}
+	}
+	if len(md) > 0 {
+		ctx = context.WithValue(ctx, metadataKey{}, md)
+	}
+
+	for _, p := range registered() {
+		ctx = p.Extract(ctx, carrier)
+	}
+	return ctx, cancel
+}
+
+// A [Carrier] for HTTP headers
+type HeaderCarrier map[string][]string
+
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c HeaderCarrier) Set(key string, value string) {
+	http.Header(c).Set(key, value)
+}
+
+func (c HeaderCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// A [Carrier] for multi-valued headers with lowercase keys, such as gRPC metadata
+type MultiMapCarrier map[string][]string
+
+func (c MultiMapCarrier) Get(key string) string {
+	if values := c[strings.ToLower(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c MultiMapCarrier) Set(key string, value string) {
+	c[strings.ToLower(key)] = []string{value}
+}
+
+func (c MultiMapCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// A [Carrier] for single-valued headers, such as Thrift headers
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c MapCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+func (c MapCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
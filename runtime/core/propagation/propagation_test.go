@@ -0,0 +1,85 @@
+package propagation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValues(t *testing.T) {
+	ctx := WithValue(context.Background(), "Tenant", "acme")
+	child := WithValue(ctx, "user", "alice")
+	assert.Equal(t, "acme", Value(child, "tenant"))
+	assert.Equal(t, "alice", Value(child, "User"))
+	assert.Equal(t, "", Value(ctx, "user"))
+	assert.Equal(t, map[string]string{"tenant": "acme", "user": "alice"}, Values(child))
+}
+
+func TestInjectExtract(t *testing.T) {
+	ctx := WithValue(context.Background(), "tenant", "acme corp/eu")
+	ctx = WithValue(ctx, TraceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	for _, carrier := range []Carrier{HeaderCarrier(http.Header{}), MultiMapCarrier{}, MapCarrier{}} {
+		Inject(ctx, carrier)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", carrier.Get("traceparent"))
+
+		server, cancel := Extract(context.Background(), carrier)
+		assert.Equal(t, Values(ctx), Values(server))
+		deadline, hasDeadline := server.Deadline()
+		require.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		cancel()
+		assert.Error(t, server.Err())
+	}
+
+	// Calls without a deadline or metadata
+	server, cancel := Extract(context.Background(), MapCarrier{"content-type": "json"})
+	defer cancel()
+	_, hasDeadline := server.Deadline()
+	assert.False(t, hasDeadline)
+	assert.Empty(t, Values(server))
+}
+
+func TestExpiredDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	carrier := MapCarrier{}
+	Inject(ctx, carrier)
+	server, cancel := Extract(context.Background(), carrier)
+	defer cancel()
+	assert.ErrorIs(t, server.Err(), context.DeadlineExceeded)
+}
+
+type spanKey struct{}
+
+// Propagates a span ID in the span header
+type spanPropagator struct{}
+
+func (spanPropagator) Inject(ctx context.Context, carrier Carrier) {
+	if span, ok := ctx.Value(spanKey{}).(string); ok {
+		carrier.Set("span", span)
+	}
+}
+
+func (spanPropagator) Extract(ctx context.Context, carrier Carrier) context.Context {
+	return context.WithValue(ctx, spanKey{}, carrier.Get("span"))
+}
+
+func TestRegister(t *testing.T) {
+	Register(spanPropagator{})
+	defer func() { propagators = nil }()
+
+	carrier := MultiMapCarrier{}
+	Inject(context.WithValue(context.Background(), spanKey{}, "span-1"), carrier)
+	assert.Equal(t, []string{"span-1"}, carrier["span"])
+
+	server, cancel := Extract(context.Background(), carrier)
+	defer cancel()
+	assert.Equal(t, "span-1", server.Value(spanKey{}))
+}
@@ -0,0 +1,282 @@
+// Package rpcerrors carries the errors returned by services across RPC boundaries, so that callers can use
+// [errors.Is] and [errors.As] with the errors that a remote service returns.
+//
+// Servers generated by the HTTP, gRPC, and Thrift plugins [Encode] each error in an [Envelope], which has a
+// [Code], the error's message, and, if the error is of a registered type, the type's name and the error's JSON
+// encoding.  Codes are the same as gRPC status codes, and are mapped to HTTP statuses by [Code.HTTPStatus].
+// Clients reconstruct the error as an [*Error], which unwraps to the registered error.
+//
+// Workflow packages register their errors when they are initialized, e.g.
+//
+//	var ErrNotFound = errors.New("cart not found")
+//
+//	type OutOfStockError struct {
+//		ItemID string
+//	}
+//
+//	func init() {
+//		rpcerrors.RegisterSentinel("cart.NotFound", ErrNotFound, rpcerrors.NotFound)
+//		rpcerrors.Register[*OutOfStockError]("cart.OutOfStock", rpcerrors.FailedPrecondition)
+//	}
+//
+// Errors that are not registered have the code of an [*Error] that they wrap, or else [DeadlineExceeded] or
+// [Canceled] for context errors, or else [Unknown].
+package rpcerrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The code of an error, which has the same value as the corresponding gRPC status code
+type Code uint32
+
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+)
+
+var codeNames = []string{"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition", "Aborted", "OutOfRange",
+	"Unimplemented", "Internal", "Unavailable", "DataLoss", "Unauthenticated"}
+
+// Returns the names of the codes, in order of their values
+func CodeNames() []string {
+	return append([]string(nil), codeNames...)
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// Codes are encoded in JSON by name
+func (c Code) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Code) UnmarshalText(text []byte) error {
+	for i, name := range codeNames {
+		if name == string(text) {
+			*c = Code(i)
+			return nil
+		}
+	}
+	if n, found := strings.CutPrefix(string(text), "Code("); found {
+		if v, err := strconv.ParseUint(strings.TrimSuffix(n, ")"), 10, 32); err == nil {
+			*c = Code(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error code %q", text)
+}
+
+// An error with a code.  Errors that are received from remote services are of this type, and unwrap to the
+// registered error that was returned by the remote service, if any.
+type Error struct {
+	Code    Code
+	Message string
+	err     error
+}
+
+// Returns an error with the provided code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Returns an error with the provided code and a formatted message
+func Errorf(code Code, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// The serialized form of an error
+type Envelope struct {
+	Code    Code            `json:"code"`
+	Message string          `json:"message"`
+	Type    string          `json:"type,omitempty"`    // The registered name of the error's type
+	Details json.RawMessage `json:"details,omitempty"` // The JSON encoding of the error, for registered types
+}
+
+// A registered error type
+type registration struct {
+	name     string
+	code     Code
+	sentinel bool // Sentinel errors are sent without details
+	match    func(err error) (error, bool)
+	decode   func(details []byte) (error, error)
+}
+
+var (
+	registrationsMu sync.RWMutex
+	registrations   []*registration
+	registeredNames = make(map[string]*registration)
+)
+
+func register(r *registration) {
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+	if _, exists := registeredNames[r.name]; exists {
+		panic("rpcerrors: duplicate registration of " + r.name)
+	}
+	registrations = append(registrations, r)
+	registeredNames[r.name] = r
+}
+
+// Registers the error type T with the provided name and code.  Errors of type T are sent with their JSON
+// encoding, and are decoded into a T by callers.
+//
+// name must be unique, and the same in every process that sends or receives the errors.  Register panics if name
+// is already registered.
+func Register[T error](name string, code Code) {
+	register(&registration{
+		name: name,
+		code: code,
+		match: func(err error) (error, bool) {
+			var t T
+			if errors.As(err, &t) {
+				return t, true
+			}
+			return nil, false
+		},
+		decode: func(details []byte) (error, error) {
+			var t T
+			err := json.Unmarshal(details, &t)
+			return t, err
+		},
+	})
+}
+
+// Registers a sentinel error with the provided name and code.  Callers receive errors that match target with
+// [errors.Is].
+//
+// name must be unique, and the same in every process that sends or receives the errors.  RegisterSentinel panics
+// if name is already registered.
+func RegisterSentinel(name string, target error, code Code) {
+	register(&registration{
+		name:     name,
+		code:     code,
+		sentinel: true,
+		match: func(err error) (error, bool) {
+			return target, errors.Is(err, target)
+		},
+		decode: func(details []byte) (error, error) {
+			return target, nil
+		},
+	})
+}
+
+// Returns the envelope of err
+func Encode(err error) Envelope {
+	envelope := Envelope{Code: Unknown, Message: err.Error()}
+
+	registrationsMu.RLock()
+	defer registrationsMu.RUnlock()
+	for _, r := range registrations {
+		if match, matches := r.match(err); matches {
+			envelope.Code = r.code
+			envelope.Type = r.name
+			if !r.sentinel {
+				envelope.Details, _ = json.Marshal(match)
+			}
+			return envelope
+		}
+	}
+
+	var coded *Error
+	switch {
+	case errors.As(err, &coded):
+		envelope.Code = coded.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		envelope.Code = DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		envelope.Code = Canceled
+	}
+	return envelope
+}
+
+// Returns the code of err
+func CodeOf(err error) Code {
+	if err == nil {
+		return OK
+	}
+	return Encode(err).Code
+}
+
+// Reconstructs the error of the envelope.  If the envelope has a registered type, the error unwraps to the
+// registered error.  Otherwise, errors with code [DeadlineExceeded] or [Canceled] unwrap to the corresponding
+// context error.
+func (envelope Envelope) Err() error {
+	e := &Error{Code: envelope.Code, Message: envelope.Message}
+	registrationsMu.RLock()
+	r, registered := registeredNames[envelope.Type]
+	registrationsMu.RUnlock()
+	if registered && (r.sentinel || len(envelope.Details) > 0) {
+		if err, decodeErr := r.decode(envelope.Details); decodeErr == nil {
+			e.err = err
+		}
+	}
+	if e.err == nil {
+		switch envelope.Code {
+		case DeadlineExceeded:
+			e.err = context.DeadlineExceeded
+		case Canceled:
+			e.err = context.Canceled
+		}
+	}
+	return e
+}
+
+// Returns the JSON encoding of the envelope
+func (envelope Envelope) String() string {
+	b, _ := json.Marshal(envelope)
+	return string(b)
+}
+
+// Parses the JSON encoding of an envelope at the end of message, which might have a prefix that was added by a
+// transport, e.g. "Internal error processing GetCart: {...}"
+func ParseEnvelope(message string) (Envelope, bool) {
+	var envelope Envelope
+	for i := strings.Index(message, "{"); i >= 0; i = indexFrom(message, "{", i+1) {
+		if err := json.Unmarshal([]byte(message[i:]), &envelope); err == nil && envelope.Message != "" {
+			return envelope, true
+		}
+	}
+	return Envelope{}, false
+}
+
+func indexFrom(s string, substr string, from int) int {
+	if i := strings.Index(s[from:], substr); i >= 0 {
+		return from + i
+	}
+	return -1
+}
@@ -0,0 +1,109 @@
+package rpcerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("cart not found")
+
+type outOfStockError struct {
+	ItemID string
+}
+
+func (e *outOfStockError) Error() string {
+	return "out of stock: " + e.ItemID
+}
+
+func init() {
+	RegisterSentinel("cart.NotFound", errNotFound, NotFound)
+	Register[*outOfStockError]("cart.OutOfStock", FailedPrecondition)
+}
+
+func TestEncode(t *testing.T) {
+	envelope := Encode(fmt.Errorf("GetCart: %w", errNotFound))
+	assert.Equal(t, Envelope{Code: NotFound, Message: "GetCart: cart not found", Type: "cart.NotFound"}, envelope)
+	assert.Equal(t, `{"code":"NotFound","message":"GetCart: cart not found","type":"cart.NotFound"}`, envelope.String())
+
+	envelope = Encode(fmt.Errorf("AddItem: %w", &outOfStockError{ItemID: "sock"}))
+	assert.Equal(t, FailedPrecondition, envelope.Code)
+	assert.JSONEq(t, `{"ItemID":"sock"}`, string(envelope.Details))
+
+	assert.Equal(t, Unauthenticated, CodeOf(fmt.Errorf("login: %w", New(Unauthenticated, "bad token"))))
+	assert.Equal(t, DeadlineExceeded, CodeOf(fmt.Errorf("call: %w", context.DeadlineExceeded)))
+	assert.Equal(t, Canceled, CodeOf(context.Canceled))
+	assert.Equal(t, Unknown, CodeOf(errors.New("oops")))
+	assert.Equal(t, OK, CodeOf(nil))
+
+	assert.Panics(t, func() { RegisterSentinel("cart.NotFound", errors.New("other"), NotFound) })
+}
+
+func TestErr(t *testing.T) {
+	err := Encode(fmt.Errorf("GetCart: %w", errNotFound)).Err()
+	assert.ErrorIs(t, err, errNotFound)
+	assert.EqualError(t, err, "GetCart: cart not found")
+	assert.Equal(t, NotFound, CodeOf(err))
+
+	err = Encode(&outOfStockError{ItemID: "sock"}).Err()
+	var outOfStock *outOfStockError
+	require.ErrorAs(t, err, &outOfStock)
+	assert.Equal(t, "sock", outOfStock.ItemID)
+
+	// Errors are encoded again when they are returned onwards
+	assert.Equal(t, Encode(&outOfStockError{ItemID: "sock"}), Encode(err))
+	assert.Equal(t, PermissionDenied, CodeOf(Encode(New(PermissionDenied, "no")).Err()))
+
+	assert.ErrorIs(t, Encode(context.DeadlineExceeded).Err(), context.DeadlineExceeded)
+
+	// Unregistered types only have a code and message
+	err = Envelope{Code: Aborted, Message: "conflict", Type: "other.Conflict", Details: []byte(`{}`)}.Err()
+	assert.Nil(t, errors.Unwrap(err))
+	assert.Equal(t, Aborted, CodeOf(err))
+}
+
+func TestCodeText(t *testing.T) {
+	for _, code := range []Code{OK, NotFound, Unauthenticated, Code(40)} {
+		text, err := code.MarshalText()
+		require.NoError(t, err)
+		var decoded Code
+		require.NoError(t, decoded.UnmarshalText(text))
+		assert.Equal(t, code, decoded)
+	}
+	var code Code
+	assert.Error(t, code.UnmarshalText([]byte("Missing")))
+}
+
+func TestHTTP(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteHTTP(w, fmt.Errorf("GetCart: %w", errNotFound))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	err := FromHTTP(w.Code, w.Body.Bytes())
+	assert.ErrorIs(t, err, errNotFound)
+
+	// Responses that are not envelopes
+	err = FromHTTP(http.StatusServiceUnavailable, []byte("overloaded\n"))
+	assert.EqualError(t, err, "overloaded (HTTP status 503)")
+	assert.Equal(t, Unavailable, CodeOf(err))
+	assert.Equal(t, Unknown, CodeOf(FromHTTP(http.StatusInternalServerError, nil)))
+}
+
+func TestStatusAndMessage(t *testing.T) {
+	envelope := Encode(&outOfStockError{ItemID: "hat"})
+	var outOfStock *outOfStockError
+	assert.ErrorAs(t, FromStatus(FailedPrecondition, "out of stock: hat", []string{envelope.String()}), &outOfStock)
+	assert.Equal(t, Unavailable, CodeOf(FromStatus(Unavailable, "connection refused", nil)))
+
+	err := FromMessage(errors.New("Internal error processing AddItem: " + envelope.String()))
+	assert.ErrorAs(t, err, &outOfStock)
+	assert.EqualError(t, err, "out of stock: hat")
+	plain := errors.New("connection reset")
+	assert.Equal(t, plain, FromMessage(plain))
+}
@@ -0,0 +1,108 @@
+package rpcerrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// The gRPC trailer that carries the envelope of an error
+const Header = "blueprint-error"
+
+var httpStatuses = map[Code]int{
+	OK:                 http.StatusOK,
+	Canceled:           499, // Client Closed Request
+	Unknown:            http.StatusInternalServerError,
+	InvalidArgument:    http.StatusBadRequest,
+	DeadlineExceeded:   http.StatusGatewayTimeout,
+	NotFound:           http.StatusNotFound,
+	AlreadyExists:      http.StatusConflict,
+	PermissionDenied:   http.StatusForbidden,
+	ResourceExhausted:  http.StatusTooManyRequests,
+	FailedPrecondition: http.StatusBadRequest,
+	Aborted:            http.StatusConflict,
+	OutOfRange:         http.StatusBadRequest,
+	Unimplemented:      http.StatusNotImplemented,
+	Internal:           http.StatusInternalServerError,
+	Unavailable:        http.StatusServiceUnavailable,
+	DataLoss:           http.StatusInternalServerError,
+	Unauthenticated:    http.StatusUnauthorized,
+}
+
+// Returns the HTTP status of responses with errors of code c
+func (c Code) HTTPStatus() int {
+	if status, exists := httpStatuses[c]; exists {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Returns the code of an HTTP status, for responses that do not have an envelope
+func CodeOfHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return InvalidArgument
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return PermissionDenied
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusConflict:
+		return Aborted
+	case http.StatusTooManyRequests:
+		return ResourceExhausted
+	case 499:
+		return Canceled
+	case http.StatusNotImplemented:
+		return Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return Unavailable
+	case http.StatusGatewayTimeout:
+		return DeadlineExceeded
+	}
+	return Unknown
+}
+
+// Writes the envelope of err as a JSON response, with the HTTP status of its code
+func WriteHTTP(w http.ResponseWriter, err error) {
+	envelope := Encode(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(envelope.Code.HTTPStatus())
+	json.NewEncoder(w).Encode(envelope)
+}
+
+// Returns the error of an HTTP response with status and body.  If the body is not an envelope, the error has the
+// code of the status and the body as its message.
+func FromHTTP(status int, body []byte) error {
+	var envelope Envelope
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Message != "" {
+		return envelope.Err()
+	}
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return Errorf(CodeOfHTTPStatus(status), "%v (HTTP status %d)", message, status)
+}
+
+// Returns the error of a gRPC status with code and message, where envelopes are the values of the [Header]
+// trailer.  If there is no envelope, the error has the code and message of the status.
+func FromStatus(code Code, message string, envelopes []string) error {
+	for _, s := range envelopes {
+		var envelope Envelope
+		if err := json.Unmarshal([]byte(s), &envelope); err == nil {
+			return envelope.Err()
+		}
+	}
+	return Envelope{Code: code, Message: message}.Err()
+}
+
+// Returns the error that was received from a transport that only carries error messages, such as Thrift.  If the
+// message of err ends with an envelope, the envelope's error is returned; otherwise err is returned.
+func FromMessage(err error) error {
+	if envelope, ok := ParseEnvelope(err.Error()); ok {
+		return envelope.Err()
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+// Package tlsconfig provides the TLS configuration of the servers and clients that are generated by the HTTP, gRPC,
+// and Thrift plugins.
+//
+// When a service is deployed with TLS or mutual TLS, Blueprint generates a development certificate authority and
+// the certificates of the service's server and clients at compile time.  The certificates are written to a
+// package in each process that runs the server or a client, which embeds them and calls [Register] when the
+// process starts.  Generated servers and clients then call [Server] and [Client] with the name that the
+// certificates were registered with.
+//
+// Clients verify that servers present a certificate that was issued by the certificate authority for the name of
+// the server's service, regardless of the address that they dial.  With mutual TLS, servers also require clients to
+// present a certificate that was issued by the certificate authority.
+//
+// This package does not need to be used directly by application workflow specs.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+// The names of the PEM-encoded files of [Credentials]
+const (
+	CAFile       = "ca.pem"        // The certificate of the CA that issued the server's certificate, for clients
+	CertFile     = "cert.pem"      // The certificate of the server or client
+	KeyFile      = "key.pem"       // The private key of CertFile
+	ClientCAFile = "client_ca.pem" // The certificate of the CA that issues the certificates of clients, for servers with mutual TLS
+)
+
+// The certificates and key of a server or client
+type Credentials struct {
+	// Contains the [CAFile], [CertFile], [KeyFile], and [ClientCAFile] files of the server or client.  Clients
+	// without mutual TLS do not have [CertFile] and [KeyFile], and only servers with mutual TLS have [ClientCAFile].
+	Files fs.FS
+
+	// For clients, the name that the server's certificate must be issued for
+	ServerName string
+}
+
+var (
+	credentialsMu sync.RWMutex
+	credentials   = make(map[string]Credentials)
+)
+
+// Registers the credentials of a server or client with the provided name.  Registering a name again replaces its
+// credentials.
+func Register(name string, creds Credentials) {
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	credentials[name] = creds
+}
+
+func get(name string) (Credentials, error) {
+	credentialsMu.RLock()
+	defer credentialsMu.RUnlock()
+	creds, exists := credentials[name]
+	if !exists {
+		return Credentials{}, fmt.Errorf("no TLS credentials are registered for %v", name)
+	}
+	return creds, nil
+}
+
+// Returns the TLS configuration of a server with the credentials registered as name, or nil if name is empty.
+//
+// If the credentials have a [ClientCAFile], clients must present a certificate that was issued by it.
+func Server(name string) (*tls.Config, error) {
+	if name == "" {
+		return nil, nil
+	}
+	creds, err := get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := loadCertificate(creds.Files)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS credentials for %v: %w", name, err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if _, err := fs.Stat(creds.Files, ClientCAFile); err == nil {
+		config.ClientCAs, err = loadPool(creds.Files, ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS credentials for %v: %w", name, err)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Returns the TLS configuration of a client with the credentials registered as name, or nil if name is empty.
+//
+// The client verifies that the server presents a certificate that was issued by the [CAFile] for
+// [Credentials.ServerName].  If the credentials have a [CertFile], the client presents it to the server.
+func Client(name string) (*tls.Config, error) {
+	if name == "" {
+		return nil, nil
+	}
+	creds, err := get(name)
+	if err != nil {
+		return nil, err
+	}
+	if creds.ServerName == "" {
+		return nil, fmt.Errorf("invalid TLS credentials for %v: no server name", name)
+	}
+
+	config := &tls.Config{
+		ServerName: creds.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	config.RootCAs, err = loadPool(creds.Files, CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS credentials for %v: %w", name, err)
+	}
+
+	if _, err := fs.Stat(creds.Files, CertFile); err == nil {
+		cert, err := loadCertificate(creds.Files)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS credentials for %v: %w", name, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadCertificate(files fs.FS) (tls.Certificate, error) {
+	certPEM, err := fs.ReadFile(files, CertFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := fs.ReadFile(files, KeyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func loadPool(files fs.FS, file string) (*x509.CertPool, error) {
+	pem, err := fs.ReadFile(files, file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New(file + " does not contain any certificates")
+	}
+	return pool, nil
+}
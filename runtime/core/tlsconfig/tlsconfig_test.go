@@ -0,0 +1,183 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type authority struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+}
+
+var serial int64
+
+func newCertificate(t *testing.T, template *x509.Certificate, parent *authority) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial++
+	template.SerialNumber = big.NewInt(serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func newAuthority(t *testing.T) *authority {
+	cert, key, certPEM, _ := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	return &authority{cert: cert, key: key, certPEM: certPEM}
+}
+
+func (ca *authority) server(t *testing.T, serviceName string, clientCA *authority) fstest.MapFS {
+	_, _, certPEM, keyPEM := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: serviceName},
+		DNSNames:    []string{serviceName},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	files := fstest.MapFS{
+		CertFile: {Data: certPEM},
+		KeyFile:  {Data: keyPEM},
+	}
+	if clientCA != nil {
+		files[ClientCAFile] = &fstest.MapFile{Data: clientCA.certPEM}
+	}
+	return files
+}
+
+func (ca *authority) client(t *testing.T, clientName string) fstest.MapFS {
+	_, _, certPEM, keyPEM := newCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: clientName},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	return fstest.MapFS{
+		CAFile:   {Data: ca.certPEM},
+		CertFile: {Data: certPEM},
+		KeyFile:  {Data: keyPEM},
+	}
+}
+
+// Starts a server with the credentials registered as name, which responds with the name of the client's certificate
+func serve(t *testing.T, name string) *httptest.Server {
+	config, err := Server(name)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	server.TLS = config
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func call(t *testing.T, server *httptest.Server, name string) (string, error) {
+	config, err := Client(name)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestTLS(t *testing.T) {
+	ca := newAuthority(t)
+	Register("cart.server", Credentials{Files: ca.server(t, "cart", nil)})
+	Register("cart.client", Credentials{Files: fstest.MapFS{CAFile: {Data: ca.certPEM}}, ServerName: "cart"})
+	Register("cart.wrongname", Credentials{Files: fstest.MapFS{CAFile: {Data: ca.certPEM}}, ServerName: "user"})
+	Register("cart.wrongca", Credentials{Files: fstest.MapFS{CAFile: {Data: newAuthority(t).certPEM}}, ServerName: "cart"})
+
+	server := serve(t, "cart.server")
+
+	body, err := call(t, server, "cart.client")
+	require.NoError(t, err)
+	assert.Equal(t, "", body)
+
+	_, err = call(t, server, "cart.wrongname")
+	assert.ErrorContains(t, err, "certificate is valid for cart, not user")
+
+	_, err = call(t, server, "cart.wrongca")
+	assert.ErrorContains(t, err, "certificate signed by unknown authority")
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newAuthority(t)
+	Register("user.server", Credentials{Files: ca.server(t, "user", ca)})
+	Register("user.client", Credentials{Files: ca.client(t, "frontend"), ServerName: "user"})
+	Register("user.nocert", Credentials{Files: fstest.MapFS{CAFile: {Data: ca.certPEM}}, ServerName: "user"})
+	Register("user.wrongca", Credentials{Files: newAuthority(t).client(t, "frontend"), ServerName: "user"})
+
+	server := serve(t, "user.server")
+
+	body, err := call(t, server, "user.client")
+	require.NoError(t, err)
+	assert.Equal(t, "frontend", body)
+
+	// The server rejects clients without a certificate, or with a certificate from a different CA
+	_, err = call(t, server, "user.nocert")
+	assert.Error(t, err)
+	_, err = call(t, server, "user.wrongca")
+	assert.Error(t, err)
+}
+
+func TestCredentials(t *testing.T) {
+	config, err := Server("")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+	config, err = Client("")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+
+	_, err = Server("unregistered")
+	assert.ErrorContains(t, err, "no TLS credentials are registered for unregistered")
+
+	Register("noservername", Credentials{Files: fstest.MapFS{CAFile: {Data: newAuthority(t).certPEM}}})
+	_, err = Client("noservername")
+	assert.ErrorContains(t, err, "no server name")
+
+	Register("nocert", Credentials{Files: fstest.MapFS{}})
+	_, err = Server("nocert")
+	assert.ErrorContains(t, err, "invalid TLS credentials for nocert")
+
+	Register("badca", Credentials{Files: fstest.MapFS{CAFile: {Data: []byte("not a certificate")}}, ServerName: "cart"})
+	_, err = Client("badca")
+	assert.ErrorContains(t, err, "ca.pem does not contain any certificates")
+}
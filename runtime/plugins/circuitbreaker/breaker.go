@@ -0,0 +1,254 @@
+// Package circuitbreaker implements the circuit breakers used by client wrappers generated by the Blueprint
+// circuitbreaker plugin.
+//
+// A [Breaker] is closed while calls succeed.  It trips open when the conditions of its [Policy] are met, after which
+// calls are rejected with an [OpenError] without being made.  After the policy's open timeout, the breaker is half
+// open and admits a number of probe calls.  If the probes succeed the breaker closes; if any probe fails or is slow,
+// the breaker opens again.
+//
+// A [ServiceBreaker] has a separate breaker for each method of a service, so that a misbehaving method does not trip
+// the breaker for the service's other methods.  It reports state changes to listeners and the log, and records
+// metrics using the meter from [backend.Meter]:
+//   - circuitbreaker.calls counts calls by outcome: success, failure, ignored, or open
+//   - circuitbreaker.state_changes counts state changes
+//   - circuitbreaker.state is the state of each breaker: 0 closed, 1 half open, 2 open
+//
+// Calls that return an error are failures, except for rejections by a server-side rate limiter or load shedder
+// (see [ratelimit.IsRejected]), which are ignored.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the circuitbreaker plugin is used in a wiring spec.
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The state of a [Breaker]
+type State int
+
+const (
+	Closed State = iota
+	HalfOpen
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// The outcome of a call admitted by a [Breaker]
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	Ignored // Not counted by the breaker, e.g. calls rejected by a rate limiter
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Success:
+		return "success"
+	case Failure:
+		return "failure"
+	case Ignored:
+		return "ignored"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// Matched by [OpenError] using [errors.Is]
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Returned for calls that are rejected by an open breaker
+type OpenError struct {
+	Service string
+	Method  string
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for %v.%v is open", e.Service, e.Method)
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrOpen
+}
+
+type transition struct {
+	from, to State
+}
+
+// A circuit breaker for calls, following a [Policy]; see the package documentation.
+type Breaker struct {
+	lock       sync.Mutex
+	policy     Policy
+	state      State
+	generation uint64 // Incremented by state changes, so that calls admitted before a change are not counted after it
+	now        func() time.Time
+	onChange   func(from, to State)
+	changes    []transition // Changes to report once the lock is released
+
+	// Counts while closed
+	windowStart time.Time
+	requests    int
+	failures    int
+	slow        int
+	consecutive int
+
+	// While open or half open
+	openedAt  time.Time
+	probes    int // Probes in flight
+	successes int // Successful probes
+}
+
+// Instantiates a closed [Breaker].  onChange, if not nil, is called after the state of the breaker changes.
+func NewBreaker(policy Policy, onChange func(from, to State)) *Breaker {
+	return &Breaker{
+		policy:   policy.withDefaults(),
+		onChange: onChange,
+		now:      time.Now,
+	}
+}
+
+// Returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.lock.Lock()
+	defer b.unlock()
+	b.advance(b.now())
+	return b.state
+}
+
+// Returns whether a call may be made.  If so, done must be called with the outcome of the call and how long it took.
+func (b *Breaker) Allow() (done func(outcome Outcome, elapsed time.Duration), ok bool) {
+	b.lock.Lock()
+	defer b.unlock()
+	b.advance(b.now())
+	switch b.state {
+	case Open:
+		return nil, false
+	case HalfOpen:
+		if b.probes+b.successes >= b.policy.HalfOpenProbes {
+			return nil, false
+		}
+		b.probes++
+	}
+	generation := b.generation
+	return func(outcome Outcome, elapsed time.Duration) { b.done(generation, outcome, elapsed) }, true
+}
+
+func (b *Breaker) done(generation uint64, outcome Outcome, elapsed time.Duration) {
+	b.lock.Lock()
+	defer b.unlock()
+	if generation != b.generation {
+		return
+	}
+	now := b.now()
+	b.advance(now)
+	failed := outcome == Failure
+	slow := b.policy.SlowCall > 0 && elapsed > b.policy.SlowCall
+	switch b.state {
+	case Closed:
+		if outcome == Ignored {
+			return
+		}
+		b.requests++
+		if failed {
+			b.failures++
+			b.consecutive++
+		} else {
+			b.consecutive = 0
+		}
+		if slow {
+			b.slow++
+		}
+		if b.tripped() {
+			b.open(now)
+		}
+	case HalfOpen:
+		b.probes--
+		if outcome == Ignored {
+			return
+		}
+		if failed || slow {
+			b.open(now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.policy.HalfOpenProbes {
+			b.setState(Closed)
+			b.reset(now)
+			b.consecutive = 0
+		}
+	}
+}
+
+// Returns whether the counts of the current interval meet any of the policy's trip conditions
+func (b *Breaker) tripped() bool {
+	p := b.policy
+	if p.ConsecutiveFailures > 0 && b.consecutive >= p.ConsecutiveFailures {
+		return true
+	}
+	if b.requests < max(p.MinRequests, 1) {
+		return false
+	}
+	if p.FailureRate > 0 && float64(b.failures) >= p.FailureRate*float64(b.requests) {
+		return true
+	}
+	return p.SlowCallRate > 0 && float64(b.slow) >= p.SlowCallRate*float64(b.requests)
+}
+
+// Moves from open to half open after the open timeout, and resets the counts when an interval ends
+func (b *Breaker) advance(now time.Time) {
+	switch b.state {
+	case Open:
+		if now.Sub(b.openedAt) >= b.policy.OpenTimeout {
+			b.setState(HalfOpen)
+			b.probes, b.successes = 0, 0
+		}
+	case Closed:
+		if b.windowStart.IsZero() || now.Sub(b.windowStart) >= b.policy.Interval {
+			b.reset(now)
+		}
+	}
+}
+
+func (b *Breaker) open(now time.Time) {
+	b.setState(Open)
+	b.openedAt = now
+}
+
+// Starts a new interval.  Consecutive failures are counted across intervals.
+func (b *Breaker) reset(now time.Time) {
+	b.windowStart = now
+	b.requests, b.failures, b.slow = 0, 0, 0
+}
+
+func (b *Breaker) setState(to State) {
+	b.changes = append(b.changes, transition{b.state, to})
+	b.state = to
+	b.generation++
+}
+
+// Releases the lock, then reports any state changes
+func (b *Breaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.lock.Unlock()
+	if b.onChange != nil {
+		for _, change := range changes {
+			b.onChange(change.from, change.to)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies("GetCart=consecutive:5,open:1s; *=failurerate:0.5,minreqs:100,slowcall:200ms,slowrate:0.25,interval:10s,probes:3")
+	require.NoError(t, err)
+	assert.Equal(t, Policies{
+		"*":       {FailureRate: 0.5, MinRequests: 100, SlowCall: 200 * time.Millisecond, SlowCallRate: 0.25, Interval: 10 * time.Second, HalfOpenProbes: 3},
+		"GetCart": {ConsecutiveFailures: 5, OpenTimeout: time.Second},
+	}, policies)
+	assert.Equal(t, "*=failurerate:0.5,slowcall:200ms,slowrate:0.25,minreqs:100,interval:10s,probes:3;GetCart=consecutive:5,open:1s", policies.String())
+
+	for _, invalid := range []string{"*", "*=", "*=minreqs:10", "*=failurerate:2", "*=slowcall:1s", "*=consecutive:-1", "*=speed:1", "*=consecutive:1;*=consecutive:2"} {
+		_, err := ParsePolicies(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestBreaker(policy Policy) (*Breaker, *clock, *[]transition) {
+	c := &clock{now: time.Now()}
+	var changes []transition
+	b := NewBreaker(policy, func(from, to State) { changes = append(changes, transition{from, to}) })
+	b.now = c.Now
+	return b, c, &changes
+}
+
+func call(t *testing.T, b *Breaker, outcome Outcome, elapsed time.Duration) {
+	done, ok := b.Allow()
+	require.True(t, ok)
+	done(outcome, elapsed)
+}
+
+func TestFailureRate(t *testing.T) {
+	b, c, changes := newTestBreaker(Policy{FailureRate: 0.5, MinRequests: 4, Interval: time.Second})
+
+	// Counts are reset after each interval
+	call(t, b, Failure, 0)
+	call(t, b, Failure, 0)
+	call(t, b, Success, 0)
+	c.now = c.now.Add(time.Second)
+	call(t, b, Failure, 0)
+	call(t, b, Success, 0)
+	call(t, b, Success, 0)
+	call(t, b, Ignored, 0)
+	call(t, b, Success, 0)
+	assert.Equal(t, Closed, b.State())
+
+	call(t, b, Failure, 0)
+	call(t, b, Failure, 0)
+	assert.Equal(t, Open, b.State())
+	_, ok := b.Allow()
+	assert.False(t, ok)
+	assert.Equal(t, []transition{{Closed, Open}}, *changes)
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	b, c, _ := newTestBreaker(Policy{ConsecutiveFailures: 3, Interval: time.Second})
+
+	call(t, b, Failure, 0)
+	call(t, b, Failure, 0)
+	call(t, b, Success, 0)
+	call(t, b, Failure, 0)
+	call(t, b, Failure, 0)
+	assert.Equal(t, Closed, b.State())
+
+	// Consecutive failures are counted across intervals
+	c.now = c.now.Add(time.Second)
+	call(t, b, Failure, 0)
+	assert.Equal(t, Open, b.State())
+}
+
+func TestSlowCallRate(t *testing.T) {
+	b, _, _ := newTestBreaker(Policy{SlowCall: 100 * time.Millisecond, SlowCallRate: 0.5, MinRequests: 2})
+
+	call(t, b, Success, 200*time.Millisecond)
+	assert.Equal(t, Closed, b.State())
+	call(t, b, Success, 200*time.Millisecond)
+	assert.Equal(t, Open, b.State())
+}
+
+func TestHalfOpen(t *testing.T) {
+	b, c, changes := newTestBreaker(Policy{ConsecutiveFailures: 1, OpenTimeout: time.Second, HalfOpenProbes: 2})
+
+	call(t, b, Failure, 0)
+	assert.Equal(t, Open, b.State())
+	c.now = c.now.Add(time.Second)
+	assert.Equal(t, HalfOpen, b.State())
+
+	// Only the probes are admitted, and a failed probe re-opens the breaker
+	first, ok := b.Allow()
+	require.True(t, ok)
+	second, ok := b.Allow()
+	require.True(t, ok)
+	_, ok = b.Allow()
+	assert.False(t, ok)
+	first(Success, 0)
+	second(Failure, 0)
+	assert.Equal(t, Open, b.State())
+
+	// Ignored probes are replaced, and the breaker closes once the probes succeed
+	c.now = c.now.Add(time.Second)
+	call(t, b, Ignored, 0)
+	call(t, b, Success, 0)
+	assert.Equal(t, HalfOpen, b.State())
+	call(t, b, Success, 0)
+	assert.Equal(t, Closed, b.State())
+
+	assert.Equal(t, []transition{{Closed, Open}, {Open, HalfOpen}, {HalfOpen, Open}, {Open, HalfOpen}, {HalfOpen, Closed}}, *changes)
+}
+
+func TestStaleCalls(t *testing.T) {
+	b, c, _ := newTestBreaker(Policy{ConsecutiveFailures: 1, OpenTimeout: time.Second})
+
+	// A call admitted before the breaker opened doesn't count as a probe
+	stale, ok := b.Allow()
+	require.True(t, ok)
+	call(t, b, Failure, 0)
+	c.now = c.now.Add(time.Second)
+	stale(Success, 0)
+	assert.Equal(t, HalfOpen, b.State())
+	call(t, b, Success, 0)
+	assert.Equal(t, Closed, b.State())
+}
+
+type testMetricCollector struct {
+	provider metric.MeterProvider
+}
+
+func (c *testMetricCollector) GetMetricProvider(ctx context.Context) (metric.MeterProvider, error) {
+	return c.provider, nil
+}
+
+func TestServiceBreaker(t *testing.T) {
+	reader := metricsdk.NewManualReader()
+	backend.SetDefaultMetricCollector(&testMetricCollector{metricsdk.NewMeterProvider(metricsdk.WithReader(reader))})
+
+	ctx := context.Background()
+	s, err := NewServiceBreaker(ctx, "cart", "frontend", "*=consecutive:2,open:1h;ListItems=consecutive:1,open:1h")
+	require.NoError(t, err)
+	var changes []StateChange
+	s.OnStateChange(func(change StateChange) { changes = append(changes, change) })
+
+	failure := errors.New("failure")
+	fail := func(ctx context.Context) error { return failure }
+	succeed := func(ctx context.Context) error { return nil }
+
+	// Each method has its own breaker
+	assert.Equal(t, failure, s.Call(ctx, "ListItems", fail))
+	assert.Equal(t, Open, s.State("ListItems"))
+	assert.Equal(t, Closed, s.State("GetCart"))
+	assert.NoError(t, s.Call(ctx, "GetCart", succeed))
+
+	err = s.Call(ctx, "ListItems", succeed)
+	assert.True(t, errors.Is(err, ErrOpen))
+	assert.Equal(t, "circuit breaker for cart.ListItems is open", err.Error())
+
+	// Rejections by rate limiters aren't failures
+	for i := 0; i < 3; i++ {
+		s.Call(ctx, "GetCart", func(ctx context.Context) error { return &ratelimit.RejectedError{Method: "GetCart"} })
+	}
+	assert.Equal(t, Closed, s.State("GetCart"))
+
+	// Panics are failures
+	assert.Panics(t, func() { s.Call(ctx, "GetCart", func(ctx context.Context) error { panic("oops") }) })
+	s.Call(ctx, "GetCart", fail)
+	assert.Equal(t, Open, s.State("GetCart"))
+
+	require.Len(t, changes, 2)
+	assert.Equal(t, StateChange{Service: "cart", Caller: "frontend", Method: "ListItems", From: Closed, To: Open, Time: changes[0].Time}, changes[0])
+	assert.Equal(t, "GetCart", changes[1].Method)
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &data))
+	sums := make(map[string]int64)
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		switch d := m.Data.(type) {
+		case metricdata.Sum[int64]:
+			for _, point := range d.DataPoints {
+				sums[m.Name] += point.Value
+			}
+		case metricdata.Gauge[int64]:
+			for _, point := range d.DataPoints {
+				sums[m.Name] += point.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"circuitbreaker.calls": 8, "circuitbreaker.state_changes": 2, "circuitbreaker.state": 4}, sums)
+}
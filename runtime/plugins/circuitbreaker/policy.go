@@ -0,0 +1,141 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/methodpolicy"
+)
+
+// Defaults for policies that omit them
+const (
+	DefaultInterval       = 10 * time.Second
+	DefaultOpenTimeout    = 5 * time.Second
+	DefaultHalfOpenProbes = 1
+)
+
+// The conditions under which a breaker trips, and how it recovers.  The breaker trips if any of its trip conditions
+// is met; a condition with a zero value is disabled.
+type Policy struct {
+	FailureRate         float64       // Trips when this fraction of the calls in an interval fail
+	ConsecutiveFailures int           // Trips after this many consecutive failures
+	SlowCall            time.Duration // Calls that take longer than this are slow
+	SlowCallRate        float64       // Trips when this fraction of the calls in an interval are slow
+	MinRequests         int           // The rate conditions only apply once this many calls are made in an interval
+	Interval            time.Duration // How often the counts of calls are reset; defaults to DefaultInterval
+	OpenTimeout         time.Duration // How long the breaker stays open before probing; defaults to DefaultOpenTimeout
+	HalfOpenProbes      int           // The number of probe calls that must succeed to close the breaker; defaults to DefaultHalfOpenProbes
+}
+
+// Policies for a service, keyed by method name.  Each method has its own breaker.  The policy for "*" applies to the
+// methods that do not have their own policy.
+type Policies map[string]Policy
+
+// Parses a policy from a comma-separated list of key:value pairs, e.g.
+// "failurerate:0.5,minreqs:100,consecutive:5,slowcall:200ms,slowrate:0.5,interval:10s,open:5s,probes:3".
+// Omitted keys are 0, or the default.
+func ParsePolicy(s string) (Policy, error) {
+	var policy Policy
+	err := methodpolicy.ParseFields(s, func(key string, value string) (err error) {
+		switch key {
+		case "failurerate":
+			policy.FailureRate, err = strconv.ParseFloat(value, 64)
+		case "consecutive":
+			policy.ConsecutiveFailures, err = strconv.Atoi(value)
+		case "slowcall":
+			policy.SlowCall, err = time.ParseDuration(value)
+		case "slowrate":
+			policy.SlowCallRate, err = strconv.ParseFloat(value, 64)
+		case "minreqs":
+			policy.MinRequests, err = strconv.Atoi(value)
+		case "interval":
+			policy.Interval, err = time.ParseDuration(value)
+		case "open":
+			policy.OpenTimeout, err = time.ParseDuration(value)
+		case "probes":
+			policy.HalfOpenProbes, err = strconv.Atoi(value)
+		default:
+			return methodpolicy.ErrUnknownKey
+		}
+		return err
+	})
+	if err != nil {
+		return Policy{}, err
+	}
+	return policy, policy.Validate()
+}
+
+// Returns an error if the policy has no trip conditions or any of its values are out of range
+func (p Policy) Validate() error {
+	if p.FailureRate == 0 && p.ConsecutiveFailures == 0 && p.SlowCallRate == 0 {
+		return fmt.Errorf("invalid policy %v; expected a failure rate, consecutive failures, or slow call rate", p)
+	}
+	if p.FailureRate < 0 || p.FailureRate > 1 || p.SlowCallRate < 0 || p.SlowCallRate > 1 {
+		return fmt.Errorf("invalid policy %v; rates must be between 0 and 1", p)
+	}
+	if (p.SlowCall > 0) != (p.SlowCallRate > 0) {
+		return fmt.Errorf("invalid policy %v; slow calls require both a duration and a rate", p)
+	}
+	if p.ConsecutiveFailures < 0 || p.SlowCall < 0 || p.MinRequests < 0 || p.Interval < 0 || p.OpenTimeout < 0 || p.HalfOpenProbes < 0 {
+		return fmt.Errorf("invalid policy %v; values must not be negative", p)
+	}
+	return nil
+}
+
+// Returns the policy in the format parsed by [ParsePolicy]
+func (p Policy) String() string {
+	var fields []string
+	if p.FailureRate > 0 {
+		fields = append(fields, "failurerate:"+strconv.FormatFloat(p.FailureRate, 'g', -1, 64))
+	}
+	if p.ConsecutiveFailures > 0 {
+		fields = append(fields, "consecutive:"+strconv.Itoa(p.ConsecutiveFailures))
+	}
+	if p.SlowCall > 0 {
+		fields = append(fields, "slowcall:"+p.SlowCall.String())
+	}
+	if p.SlowCallRate > 0 {
+		fields = append(fields, "slowrate:"+strconv.FormatFloat(p.SlowCallRate, 'g', -1, 64))
+	}
+	if p.MinRequests > 0 {
+		fields = append(fields, "minreqs:"+strconv.Itoa(p.MinRequests))
+	}
+	if p.Interval > 0 {
+		fields = append(fields, "interval:"+p.Interval.String())
+	}
+	if p.OpenTimeout > 0 {
+		fields = append(fields, "open:"+p.OpenTimeout.String())
+	}
+	if p.HalfOpenProbes > 0 {
+		fields = append(fields, "probes:"+strconv.Itoa(p.HalfOpenProbes))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Returns the policy with defaults in place of omitted values
+func (p Policy) withDefaults() Policy {
+	if p.Interval == 0 {
+		p.Interval = DefaultInterval
+	}
+	if p.OpenTimeout == 0 {
+		p.OpenTimeout = DefaultOpenTimeout
+	}
+	if p.HalfOpenProbes == 0 {
+		p.HalfOpenProbes = DefaultHalfOpenProbes
+	}
+	return p
+}
+
+// Parses policies from a semicolon-separated list of method=policy pairs, where each policy is in the format parsed
+// by [ParsePolicy], e.g. "*=failurerate:0.5,minreqs:100;GetCart=consecutive:5".  The empty string is no policies.
+func ParsePolicies(s string) (Policies, error) {
+	return methodpolicy.Parse(s, ParsePolicy)
+}
+
+// Returns the policies in the format parsed by [ParsePolicies], with the policy for "*" first and the remaining
+// methods in alphabetical order.
+func (p Policies) String() string {
+	return methodpolicy.Format(p)
+}
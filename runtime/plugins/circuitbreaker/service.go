@@ -0,0 +1,179 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/exp/slog"
+)
+
+// A change of the state of the breaker of a method
+type StateChange struct {
+	Service string
+	Caller  string
+	Method  string
+	From    State
+	To      State
+	Time    time.Time
+}
+
+// The breakers of the methods of a service, used by the client wrapper of one caller; see the package documentation.
+type ServiceBreaker struct {
+	service  string
+	caller   string
+	policies Policies
+
+	lock      sync.Mutex
+	breakers  map[string]*Breaker // Created on the first call to each method that has a policy
+	listeners []func(StateChange)
+
+	metrics      backend.LazyInstruments
+	calls        metric.Int64Counter
+	stateChanges metric.Int64Counter
+	stateGauge   metric.Int64ObservableGauge
+}
+
+// Instantiates a [ServiceBreaker] for calls from `caller` to `service`, with the policies parsed by [ParsePolicies].
+// Methods without a policy, when there is no policy for "*", do not have a breaker.
+func NewServiceBreaker(ctx context.Context, service string, caller string, policies string) (*ServiceBreaker, error) {
+	parsed, err := ParsePolicies(policies)
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceBreaker{
+		service:  service,
+		caller:   caller,
+		policies: parsed,
+		breakers: make(map[string]*Breaker),
+	}, nil
+}
+
+// Registers a listener that is called after the breaker of any method changes state
+func (s *ServiceBreaker) OnStateChange(listener func(StateChange)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Returns the state of the breaker of method.  Methods without a breaker are always [Closed].
+func (s *ServiceBreaker) State(method string) State {
+	if breaker := s.breaker(method); breaker != nil {
+		return breaker.State()
+	}
+	return Closed
+}
+
+// Calls call if the breaker of method allows it; otherwise returns an [OpenError].
+func (s *ServiceBreaker) Call(ctx context.Context, method string, call func(ctx context.Context) error) (err error) {
+	s.initMetrics()
+	breaker := s.breaker(method)
+	if breaker == nil {
+		return call(ctx)
+	}
+
+	done, ok := breaker.Allow()
+	if !ok {
+		s.record(ctx, method, "open")
+		return &OpenError{Service: s.service, Method: method}
+	}
+
+	start := time.Now()
+	outcome := Failure // If call panics
+	defer func() {
+		done(outcome, time.Since(start))
+		s.record(ctx, method, outcome.String())
+	}()
+	err = call(ctx)
+	outcome = classify(err)
+	return err
+}
+
+// Calls rejected by the server's rate limiter or load shedder are load shedding rather than failures
+func classify(err error) Outcome {
+	if err == nil {
+		return Success
+	}
+	if ratelimit.IsRejected(err) {
+		return Ignored
+	}
+	return Failure
+}
+
+// Returns the breaker of method, or nil if method has no policy
+func (s *ServiceBreaker) breaker(method string) *Breaker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if breaker, exists := s.breakers[method]; exists {
+		return breaker
+	}
+	policy, exists := s.policies[method]
+	if !exists {
+		if policy, exists = s.policies["*"]; !exists {
+			return nil
+		}
+	}
+	breaker := NewBreaker(policy, func(from, to State) { s.changed(method, from, to) })
+	s.breakers[method] = breaker
+	return breaker
+}
+
+func (s *ServiceBreaker) changed(method string, from State, to State) {
+	change := StateChange{Service: s.service, Caller: s.caller, Method: method, From: from, To: to, Time: time.Now()}
+	slog.Info(fmt.Sprintf("circuit breaker for %v.%v from %v changed from %v to %v", s.service, method, s.caller, from, to))
+	s.initMetrics()
+	if s.stateChanges != nil {
+		s.stateChanges.Add(context.Background(), 1, metric.WithAttributes(s.attributes(method,
+			attribute.String("from", from.String()), attribute.String("to", to.String()))...))
+	}
+
+	s.lock.Lock()
+	listeners := append([]func(StateChange){}, s.listeners...)
+	s.lock.Unlock()
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+func (s *ServiceBreaker) initMetrics() {
+	s.metrics.Init("github.com/blueprint-uservices/blueprint/runtime/plugins/circuitbreaker", func(meter metric.Meter) {
+		s.calls, _ = meter.Int64Counter("circuitbreaker.calls", metric.WithDescription("Calls by outcome: success, failure, ignored, or open"))
+		s.stateChanges, _ = meter.Int64Counter("circuitbreaker.state_changes", metric.WithDescription("Changes of state of circuit breakers"))
+		s.stateGauge, _ = meter.Int64ObservableGauge("circuitbreaker.state", metric.WithDescription("The state of circuit breakers: 0 closed, 1 half open, 2 open"))
+		if s.stateGauge != nil {
+			meter.RegisterCallback(s.observe, s.stateGauge)
+		}
+	})
+}
+
+func (s *ServiceBreaker) observe(ctx context.Context, observer metric.Observer) error {
+	s.lock.Lock()
+	breakers := make(map[string]*Breaker, len(s.breakers))
+	for method, breaker := range s.breakers {
+		breakers[method] = breaker
+	}
+	s.lock.Unlock()
+	for method, breaker := range breakers {
+		observer.ObserveInt64(s.stateGauge, int64(breaker.State()), metric.WithAttributes(s.attributes(method)...))
+	}
+	return nil
+}
+
+func (s *ServiceBreaker) record(ctx context.Context, method string, outcome string) {
+	if s.calls != nil {
+		s.calls.Add(ctx, 1, metric.WithAttributes(s.attributes(method, attribute.String("outcome", outcome))...))
+	}
+}
+
+func (s *ServiceBreaker) attributes(method string, extra ...attribute.KeyValue) []attribute.KeyValue {
+	return append([]attribute.KeyValue{
+		attribute.String("service", s.service),
+		attribute.String("caller", s.caller),
+		attribute.String("method", method),
+	}, extra...)
+}
@@ -0,0 +1,73 @@
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"golang.org/x/exp/slog"
+)
+
+// Serves the control endpoint for the injector on addr until ctx is done.  Returns once the endpoint is
+// listening.  The endpoint has the following API:
+//   - GET /faults returns the current [Schedule] as JSON
+//   - PUT /faults replaces the current schedule with the JSON schedule in the request body
+//   - DELETE /faults clears the current schedule
+//
+// For example:
+//
+//	curl -X PUT localhost:9000/faults -d '{"rules": [{"method": "GetCart", "kind": "error", "probability": 0.5}]}'
+func (i *Injector) Serve(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("unable to serve fault injection control endpoint on %v: %w", addr, err)
+	}
+	server := &http.Server{Handler: i.Handler()}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	go func() {
+		if err := server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error(fmt.Sprintf("fault injection control endpoint on %v failed: %v", addr, err))
+		}
+	}()
+	return nil
+}
+
+// Returns the HTTP handler for the control endpoint; see [Injector.Serve]
+func (i *Injector) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /faults", func(w http.ResponseWriter, r *http.Request) {
+		i.writeSchedule(w)
+	})
+	mux.HandleFunc("PUT /faults", func(w http.ResponseWriter, r *http.Request) {
+		var schedule Schedule
+		if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := i.SetSchedule(schedule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		i.writeSchedule(w)
+	})
+	mux.HandleFunc("DELETE /faults", func(w http.ResponseWriter, r *http.Request) {
+		i.SetSchedule(Schedule{})
+		i.writeSchedule(w)
+	})
+	return mux
+}
+
+func (i *Injector) writeSchedule(w http.ResponseWriter) {
+	schedule := i.Schedule()
+	if schedule.Rules == nil {
+		schedule.Rules = []Rule{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
@@ -0,0 +1,169 @@
+// Package faults implements the fault injection used by wrappers generated by the Blueprint faults plugin.
+//
+// An [Injector] injects faults into calls according to a [Schedule] of rules.  Each rule applies a kind of
+// fault to a method with a probability.  The supported kinds of faults are:
+//   - [Error]: the call is not made and an error wrapping [ErrInjected] is returned
+//   - [Panic]: the call is not made and the injector panics
+//   - [Hang]: the call is not made and the injector blocks until the caller's context is done
+//   - [Drop]: the call is made but its response is dropped, and an error wrapping [ErrDropped] is returned
+//
+// The schedule can be changed at runtime through an optional HTTP control endpoint; see [Injector.Serve].
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the faults plugin is used in a wiring spec.
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A kind of fault
+type Kind string
+
+const (
+	Error Kind = "error"
+	Panic Kind = "panic"
+	Hang  Kind = "hang"
+	Drop  Kind = "drop"
+)
+
+var (
+	// Wrapped by the errors returned for [Error] faults
+	ErrInjected = errors.New("injected error")
+
+	// Wrapped by the errors returned for [Drop] faults
+	ErrDropped = errors.New("injected dropped response")
+)
+
+// Injects a kind of fault into calls to a method with a probability
+type Rule struct {
+	Method      string  `json:"method"` // A method name, or * for all methods
+	Kind        Kind    `json:"kind"`
+	Probability float64 `json:"probability"` // Between 0 and 1
+}
+
+// A list of rules.  When a call matches multiple rules, each rule is considered in order, and at most one
+// fault is injected.
+type Schedule struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Injects faults into calls according to a [Schedule] that can be changed at runtime
+type Injector struct {
+	lock     sync.RWMutex
+	schedule Schedule
+}
+
+// Instantiates an [Injector] with the schedule parsed by [ParseSchedule].
+func NewInjector(schedule string) (*Injector, error) {
+	s, err := ParseSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &Injector{schedule: s}, nil
+}
+
+// Parses a schedule from a comma-separated list of method:kind:probability rules, e.g.
+// "GetCart:error:0.1,*:hang:0.01".  The empty string is an empty schedule.
+func ParseSchedule(s string) (Schedule, error) {
+	var schedule Schedule
+	if strings.TrimSpace(s) == "" {
+		return schedule, nil
+	}
+	for _, rule := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		if len(parts) != 3 {
+			return Schedule{}, fmt.Errorf("expected method:kind:probability but got %v", rule)
+		}
+		probability, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid probability in %v: %w", rule, err)
+		}
+		schedule.Rules = append(schedule.Rules, Rule{Method: parts[0], Kind: Kind(parts[1]), Probability: probability})
+	}
+	return schedule, schedule.Validate()
+}
+
+// Returns an error if any of the schedule's rules are invalid
+func (s Schedule) Validate() error {
+	for _, rule := range s.Rules {
+		if rule.Method == "" {
+			return fmt.Errorf("a method must be specified; use * for all methods")
+		}
+		switch rule.Kind {
+		case Error, Panic, Hang, Drop:
+		default:
+			return fmt.Errorf("unknown kind of fault %v", rule.Kind)
+		}
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return fmt.Errorf("probability %v of %v %v is not between 0 and 1", rule.Probability, rule.Method, rule.Kind)
+		}
+	}
+	return nil
+}
+
+// Returns the schedule in the format parsed by [ParseSchedule]
+func (s Schedule) String() string {
+	var rules []string
+	for _, rule := range s.Rules {
+		rules = append(rules, fmt.Sprintf("%v:%v:%v", rule.Method, rule.Kind, rule.Probability))
+	}
+	return strings.Join(rules, ",")
+}
+
+// Returns the current schedule
+func (i *Injector) Schedule() Schedule {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return Schedule{Rules: append([]Rule(nil), i.schedule.Rules...)}
+}
+
+// Replaces the current schedule
+func (i *Injector) SetSchedule(s Schedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.schedule = Schedule{Rules: append([]Rule(nil), s.Rules...)}
+	return nil
+}
+
+// Returns the fault to inject into a call to method, if any
+func (i *Injector) choose(method string) (Kind, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	for _, rule := range i.schedule.Rules {
+		if (rule.Method == "*" || rule.Method == method) && rand.Float64() < rule.Probability {
+			return rule.Kind, true
+		}
+	}
+	return "", false
+}
+
+// Calls call, unless the schedule injects a fault into this call of method.
+func (i *Injector) Call(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	kind, inject := i.choose(method)
+	if !inject {
+		return call(ctx)
+	}
+	switch kind {
+	case Error:
+		return fmt.Errorf("%v: %w", method, ErrInjected)
+	case Panic:
+		panic(fmt.Sprintf("injected panic in %v", method))
+	case Hang:
+		<-ctx.Done()
+		return ctx.Err()
+	case Drop:
+		call(ctx)
+		return fmt.Errorf("%v: %w", method, ErrDropped)
+	}
+	return call(ctx)
+}
@@ -0,0 +1,109 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	s, err := ParseSchedule("GetCart:error:0.1, *:hang:0.01")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{"GetCart", Error, 0.1}, {"*", Hang, 0.01}}, s.Rules)
+	assert.Equal(t, "GetCart:error:0.1,*:hang:0.01", s.String())
+
+	s, err = ParseSchedule("")
+	require.NoError(t, err)
+	assert.Empty(t, s.Rules)
+
+	for _, invalid := range []string{"GetCart:error", "GetCart:crash:0.1", "GetCart:error:2", ":error:0.1", "GetCart:error:x"} {
+		_, err := ParseSchedule(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestInjectFaults(t *testing.T) {
+	calls := 0
+	call := func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+
+	injector, err := NewInjector("Other:error:1")
+	require.NoError(t, err)
+	assert.NoError(t, injector.Call(context.Background(), "GetCart", call))
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, injector.SetSchedule(Schedule{Rules: []Rule{{"GetCart", Error, 1}}}))
+	err = injector.Call(context.Background(), "GetCart", call)
+	assert.True(t, errors.Is(err, ErrInjected))
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, injector.SetSchedule(Schedule{Rules: []Rule{{"*", Drop, 1}}}))
+	err = injector.Call(context.Background(), "GetCart", call)
+	assert.True(t, errors.Is(err, ErrDropped))
+	assert.Equal(t, 2, calls)
+
+	require.NoError(t, injector.SetSchedule(Schedule{Rules: []Rule{{"GetCart", Panic, 1}}}))
+	assert.Panics(t, func() { injector.Call(context.Background(), "GetCart", call) })
+	assert.Equal(t, 2, calls)
+
+	require.NoError(t, injector.SetSchedule(Schedule{Rules: []Rule{{"GetCart", Hang, 1}}}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = injector.Call(ctx, "GetCart", call)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 2, calls)
+
+	assert.Error(t, injector.SetSchedule(Schedule{Rules: []Rule{{"GetCart", Hang, -1}}}))
+}
+
+func TestControlEndpoint(t *testing.T) {
+	injector, err := NewInjector("GetCart:error:0.5")
+	require.NoError(t, err)
+	server := httptest.NewServer(injector.Handler())
+	defer server.Close()
+
+	do := func(method string, body string) (int, string) {
+		req, err := http.NewRequest(method, server.URL+"/faults", strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	status, body := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"rules":[{"method":"GetCart","kind":"error","probability":0.5}]}`, body)
+
+	status, _ = do(http.MethodPut, `{"rules":[{"method":"*","kind":"drop","probability":0.25}]}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "*:drop:0.25", injector.Schedule().String())
+
+	status, _ = do(http.MethodPut, `{"rules":[{"method":"*","kind":"crash","probability":0.25}]}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "*:drop:0.25", injector.Schedule().String())
+
+	status, body = do(http.MethodDelete, "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"rules":[]}`, body)
+}
+
+func TestServe(t *testing.T) {
+	injector, err := NewInjector("")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, injector.Serve(ctx, "localhost:0"))
+}
@@ -85,6 +85,7 @@ type Namespace struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     *sync.WaitGroup
+	hooks  []func() // Called by Await; see OnShutdown
 
 	parent *Namespace
 }
@@ -432,7 +433,23 @@ func (n *Namespace) Shutdown(awaitCompletion bool) {
 	}
 }
 
-// If any nodes in this namespace are running goroutines, waits for them to finish
+// If any nodes in this namespace are running goroutines, waits for them to finish, and then calls
+// any functions registered with [Namespace.OnShutdown]
 func (n *Namespace) Await() {
 	n.wg.Wait()
+	for i := len(n.hooks) - 1; i >= 0; i-- {
+		n.hooks[i]()
+	}
+}
+
+// Registers a function to be called by [Namespace.Await] after any running nodes have finished, e.g. to
+// save a node's state before the process exits.  Functions are called in the reverse order that they were
+// registered, and a function registered in a child namespace is also called when awaiting its parent.
+// Each function is called at most once.
+func (n *Namespace) OnShutdown(hook func()) {
+	var once sync.Once
+	wrapped := func() { once.Do(hook) }
+	for ns := n; ns != nil; ns = ns.parent {
+		ns.hooks = append(ns.hooks, wrapped)
+	}
 }
@@ -278,3 +278,27 @@ func TestNestedRun(t *testing.T) {
 	assert.True(t, tester1.done)
 	assert.True(t, tester2.done)
 }
+
+func TestOnShutdown(t *testing.T) {
+	pb := golang.NewNamespaceBuilder("TestOnShutdown-Parent")
+	p, err := pb.Build(context.Background())
+	assert.NoError(t, err)
+
+	var calls []string
+	cb := golang.NewNamespaceBuilder("TestOnShutdown-Child")
+	cb.Define("something13", func(n *golang.Namespace) (any, error) {
+		n.OnShutdown(func() { calls = append(calls, "first") })
+		n.OnShutdown(func() { calls = append(calls, "second") })
+		return &runtester{}, nil
+	})
+	cb.Instantiate("something13")
+	c, err := cb.BuildWithParent(p)
+	assert.NoError(t, err)
+	assert.Empty(t, calls)
+
+	// Hooks are called in reverse order, after running nodes have finished, and only once
+	p.Shutdown(true)
+	assert.Equal(t, []string{"second", "first"}, calls)
+	c.Shutdown(true)
+	assert.Equal(t, []string{"second", "first"}, calls)
+}
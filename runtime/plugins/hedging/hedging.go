@@ -0,0 +1,239 @@
+// Package hedging implements the request hedging used by clients generated by the Blueprint hedging plugin.
+//
+// A hedged call sends a duplicate request, a hedge, when the original request has not completed after a delay.  The
+// first successful response is used and the other requests are cancelled, which cuts tail latency at the cost of
+// extra load.  The delay is either fixed or a percentile of the latencies observed by the [Hedger], so that only the
+// slowest calls are hedged.  To cap the extra load, each call earns a fraction of a hedge, and hedges are only sent
+// while enough have been earned; see [Policy].
+//
+// Only idempotent methods may be hedged, because the server may process a call more than once.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the hedging plugin is used in a wiring spec.
+package hedging
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	latencyWindow = 1000 // The number of recent latencies that percentiles are estimated from
+	minSamples    = 20   // The number of latencies needed to estimate a percentile
+	reestimate    = 50   // How many latencies are observed between estimates
+	maxTokens     = 10   // The maximum number of hedges that can be earned in advance, for bursts of slow calls
+)
+
+// An attempt of a hedged call.  An attempt returns its error, and a function that stores its results; only the
+// results of the attempt that is used are stored, so attempts must not modify shared state until then.
+type Attempt func(ctx context.Context) (commit func(), err error)
+
+// Counts of the calls made by a [Hedger]
+type Stats struct {
+	Calls     int64 // Calls made
+	Hedges    int64 // Hedges sent
+	Throttled int64 // Hedges not sent because of the policy's maximum rate
+	Wins      int64 // Calls where the response of a hedge was used
+}
+
+// Returns the ratio of hedges to calls
+func (s Stats) HedgeRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Hedges) / float64(s.Calls)
+}
+
+// Hedges the calls of one method according to a [Policy]
+type Hedger struct {
+	policy Policy
+
+	lock      sync.Mutex
+	latencies []time.Duration // A ring buffer of the latencies of successful calls
+	next      int             // The next index in latencies
+	observed  int             // Latencies observed since the last estimate
+	estimate  time.Duration   // The estimated percentile, or 0 if not yet estimated
+	tokens    float64
+	stats     Stats
+}
+
+// The hedges of a single call
+type hedges struct {
+	sent      int
+	throttled bool
+	won       bool
+}
+
+type result struct {
+	commit   func()
+	err      error
+	hedge    bool
+	panicked bool
+	panic    any
+}
+
+// Instantiates a [Hedger] with policy; omitted values of the policy are defaults.
+func NewHedger(policy Policy) *Hedger {
+	return &Hedger{
+		policy: policy.withDefaults(),
+		tokens: maxTokens,
+	}
+}
+
+// Returns the counts of calls made so far
+func (h *Hedger) Stats() Stats {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.stats
+}
+
+// Returns the delay after which calls are hedged, and false if calls are not yet hedged because the policy only has
+// a percentile and too few latencies have been observed.
+func (h *Hedger) Delay() (time.Duration, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.delay()
+}
+
+func (h *Hedger) delay() (time.Duration, bool) {
+	if h.policy.Percentile > 0 && h.estimate > 0 {
+		return h.estimate, true
+	}
+	return h.policy.Delay, h.policy.Delay > 0
+}
+
+// Makes an attempt, and hedges it if it does not complete within the delay.  Returns the error of the first
+// successful attempt, or of the last attempt if all fail.  If an attempt panics, Do panics.
+func (h *Hedger) Do(ctx context.Context, call Attempt) error {
+	_, err := h.do(ctx, call)
+	return err
+}
+
+func (h *Hedger) do(ctx context.Context, call Attempt) (hedges, error) {
+	// Latencies are measured from the start of the call, including the delay before a hedge that wins
+	var hedged hedges
+	start := time.Now()
+	delay, ok := h.begin()
+	if !ok {
+		commit, err := call(ctx)
+		h.complete(result{err: err}, time.Since(start), &hedged)
+		if commit != nil {
+			commit()
+		}
+		return hedged, err
+	}
+
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, 1+h.policy.MaxHedges) // Attempts that complete after Do returns don't block
+	launch := func(hedge bool) {
+		go func() {
+			r := result{hedge: hedge}
+			defer func() {
+				if p := recover(); p != nil {
+					r.panicked, r.panic = true, p
+				}
+				results <- r
+			}()
+			r.commit, r.err = call(attemptCtx)
+		}()
+	}
+
+	launch(false)
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.panicked {
+				panic(r.panic)
+			}
+			if r.err != nil && pending > 0 {
+				continue
+			}
+			h.complete(r, time.Since(start), &hedged)
+			if r.commit != nil {
+				r.commit()
+			}
+			return hedged, r.err
+		case <-timer.C:
+			if !h.allow() {
+				hedged.throttled = true
+				continue
+			}
+			launch(true)
+			pending++
+			hedged.sent++
+			if hedged.sent < h.policy.MaxHedges {
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			return hedged, ctx.Err()
+		}
+	}
+}
+
+// Counts a call and earns a fraction of a hedge.  Returns the delay before hedging the call.
+func (h *Hedger) begin() (time.Duration, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.stats.Calls++
+	h.tokens = math.Min(h.tokens+h.policy.MaxRate, maxTokens)
+	return h.delay()
+}
+
+// Reports whether a hedge may be sent without exceeding the policy's maximum rate
+func (h *Hedger) allow() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.tokens < 1 {
+		h.stats.Throttled++
+		return false
+	}
+	h.tokens--
+	h.stats.Hedges++
+	return true
+}
+
+// Records the attempt whose response is used, and the latency of the call
+func (h *Hedger) complete(r result, elapsed time.Duration, hedged *hedges) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.hedge {
+		h.stats.Wins++
+		hedged.won = true
+	}
+	if h.policy.Percentile == 0 {
+		return
+	}
+	if len(h.latencies) < latencyWindow {
+		h.latencies = append(h.latencies, elapsed)
+	} else {
+		h.latencies[h.next] = elapsed
+	}
+	h.next = (h.next + 1) % latencyWindow
+	h.observed++
+	if len(h.latencies) >= minSamples && (h.estimate == 0 || h.observed >= reestimate) {
+		h.estimate = percentile(h.latencies, h.policy.Percentile)
+		h.observed = 0
+	}
+}
+
+// Returns the pth percentile of latencies
+func percentile(latencies []time.Duration, p float64) time.Duration {
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,178 @@
+package hedging
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies("Lookup=percentile:0.95,hedges:2; GetCart=delay:50ms,rate:0.2")
+	require.NoError(t, err)
+	assert.Equal(t, Policies{
+		"GetCart": {Delay: 50 * time.Millisecond, MaxRate: 0.2},
+		"Lookup":  {Percentile: 0.95, MaxHedges: 2},
+	}, policies)
+	assert.Equal(t, "GetCart=delay:50ms,rate:0.2;Lookup=percentile:0.95,hedges:2", policies.String())
+
+	for _, invalid := range []string{"GetCart", "GetCart=", "*=delay:1s", "GetCart=hedges:2", "GetCart=percentile:1",
+		"GetCart=delay:-1s", "GetCart=delay:1s,speed:2", "GetCart=delay:1s;GetCart=delay:2s"} {
+		_, err := ParsePolicies(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// Returns an attempt whose first call takes slow and other calls take fast, storing the index of the call in v
+func slowFirst(slow, fast time.Duration, v *int64) (Attempt, *atomic.Int64) {
+	var calls atomic.Int64
+	return func(ctx context.Context) (func(), error) {
+		i := calls.Add(1)
+		d := fast
+		if i == 1 {
+			d = slow
+		}
+		select {
+		case <-time.After(d):
+			return func() { *v = i }, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}, &calls
+}
+
+func TestHedge(t *testing.T) {
+	h := NewHedger(Policy{Delay: 10 * time.Millisecond})
+
+	var v int64
+	attempt, calls := slowFirst(time.Second, time.Millisecond, &v)
+	start := time.Now()
+	require.NoError(t, h.Do(context.Background(), attempt))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int64(2), calls.Load())
+	assert.Equal(t, int64(2), v, "the results of the hedge are used")
+	assert.Equal(t, Stats{Calls: 1, Hedges: 1, Wins: 1}, h.Stats())
+
+	// Calls that complete before the delay are not hedged
+	attempt, calls = slowFirst(time.Millisecond, time.Millisecond, &v)
+	require.NoError(t, h.Do(context.Background(), attempt))
+	assert.Equal(t, int64(1), calls.Load())
+	assert.Equal(t, Stats{Calls: 2, Hedges: 1, Wins: 1}, h.Stats())
+	assert.Equal(t, 0.5, h.Stats().HedgeRate())
+}
+
+func TestHedgeFailures(t *testing.T) {
+	h := NewHedger(Policy{Delay: 5 * time.Millisecond, MaxHedges: 2, MaxRate: 2})
+
+	// A failed attempt waits for the others
+	var calls atomic.Int64
+	failure := errors.New("failure")
+	var v int64
+	err := h.Do(context.Background(), func(ctx context.Context) (func(), error) {
+		i := calls.Add(1)
+		if i < 3 {
+			time.Sleep(20 * time.Millisecond)
+			return func() { v = -1 }, failure
+		}
+		return func() { v = i }, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), v)
+
+	// If all attempts fail, the error of the last is returned
+	calls.Store(0)
+	err = h.Do(context.Background(), func(ctx context.Context) (func(), error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, failure
+	})
+	assert.Equal(t, failure, err)
+
+	// Panics are propagated to the caller
+	assert.Panics(t, func() {
+		h.Do(context.Background(), func(ctx context.Context) (func(), error) { panic("attempt") })
+	})
+}
+
+func TestHedgeRate(t *testing.T) {
+	h := NewHedger(Policy{Delay: time.Millisecond, MaxRate: 0.1})
+
+	var v int64
+	for i := 0; i < 100; i++ {
+		attempt, _ := slowFirst(5*time.Millisecond, 5*time.Millisecond, &v)
+		require.NoError(t, h.Do(context.Background(), attempt))
+	}
+	stats := h.Stats()
+	assert.Equal(t, int64(100), stats.Calls)
+	assert.InDelta(t, maxTokens+10, stats.Hedges, 1, "hedges are limited to the burst plus 10% of calls")
+	assert.Equal(t, int64(100), stats.Hedges+stats.Throttled)
+}
+
+func TestPercentileDelay(t *testing.T) {
+	h := NewHedger(Policy{Percentile: 0.9})
+	_, ok := h.Delay()
+	assert.False(t, ok, "calls are not hedged until enough latencies are observed")
+
+	for i := 1; i <= minSamples; i++ {
+		d := time.Duration(i) * time.Millisecond
+		require.NoError(t, h.Do(context.Background(), func(ctx context.Context) (func(), error) {
+			time.Sleep(d)
+			return nil, nil
+		}))
+	}
+	delay, ok := h.Delay()
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, delay, 18*time.Millisecond)
+	assert.Less(t, delay, 100*time.Millisecond)
+	assert.Equal(t, int64(0), h.Stats().Hedges)
+
+	// A delay is used until enough latencies are observed
+	h = NewHedger(Policy{Delay: time.Second, Percentile: 0.9})
+	delay, _ = h.Delay()
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestPercentileDelayHedged(t *testing.T) {
+	h := NewHedger(Policy{Delay: 20 * time.Millisecond, Percentile: 0.5, MaxRate: 1})
+
+	// The latency of a call that is won by a hedge includes the delay before the hedge
+	var v int64
+	for i := 0; i < minSamples; i++ {
+		attempt, _ := slowFirst(time.Second, time.Millisecond, &v)
+		require.NoError(t, h.Do(context.Background(), attempt))
+	}
+	assert.Equal(t, int64(minSamples), h.Stats().Wins)
+	delay, ok := h.Delay()
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, delay, 20*time.Millisecond)
+}
+
+func TestServiceHedger(t *testing.T) {
+	s, err := NewServiceHedger(context.Background(), "cart", "GetCart=delay:5ms")
+	require.NoError(t, err)
+	assert.Nil(t, s.Hedger("AddItem"))
+
+	// Methods without a policy are not hedged
+	var v int64
+	attempt, calls := slowFirst(20*time.Millisecond, time.Millisecond, &v)
+	require.NoError(t, s.Call(context.Background(), "AddItem", attempt))
+	assert.Equal(t, int64(1), calls.Load())
+	assert.Equal(t, int64(1), v)
+
+	attempt, calls = slowFirst(time.Second, time.Millisecond, &v)
+	require.NoError(t, s.Call(context.Background(), "GetCart", attempt))
+	assert.Equal(t, int64(2), calls.Load())
+	assert.Equal(t, Stats{Calls: 1, Hedges: 1, Wins: 1}, s.Hedger("GetCart").Stats())
+
+	// The caller's context is respected
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = s.Call(ctx, "GetCart", func(ctx context.Context) (func(), error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
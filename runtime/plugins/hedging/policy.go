@@ -0,0 +1,129 @@
+package hedging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/methodpolicy"
+)
+
+// Defaults for policies that omit them
+const (
+	DefaultMaxHedges = 1
+	DefaultMaxRate   = 0.1
+)
+
+// When a method's calls are hedged.  A policy has a delay, a percentile, or both; with both, the delay is used
+// until enough latencies have been observed to estimate the percentile.
+type Policy struct {
+	Delay      time.Duration // Sends a hedge when a call has not completed after this delay
+	Percentile float64       // Sends a hedge when a call has not completed after this percentile of observed latencies, e.g. 0.95
+	MaxHedges  int           // The maximum number of hedges for a call; defaults to DefaultMaxHedges
+	MaxRate    float64       // The maximum ratio of hedges to calls; defaults to DefaultMaxRate
+}
+
+// Hedging policies for a service, keyed by method name.  Only the methods with a policy are hedged, and there is no
+// policy for "*", because only idempotent methods may be hedged.
+type Policies map[string]Policy
+
+// Parses a policy from a comma-separated list of key:value pairs, e.g. "delay:50ms,percentile:0.95,hedges:2,rate:0.2".
+// Omitted keys are 0, or the default.
+func ParsePolicy(s string) (Policy, error) {
+	var policy Policy
+	err := methodpolicy.ParseFields(s, func(key string, value string) (err error) {
+		switch key {
+		case "delay":
+			policy.Delay, err = time.ParseDuration(value)
+		case "percentile":
+			policy.Percentile, err = strconv.ParseFloat(value, 64)
+		case "hedges":
+			policy.MaxHedges, err = strconv.Atoi(value)
+		case "rate":
+			policy.MaxRate, err = strconv.ParseFloat(value, 64)
+		default:
+			return methodpolicy.ErrUnknownKey
+		}
+		return err
+	})
+	if err != nil {
+		return Policy{}, err
+	}
+	return policy, policy.Validate()
+}
+
+// Returns an error if the policy has neither a delay nor a percentile, or any of its values are out of range
+func (p Policy) Validate() error {
+	if p.Delay == 0 && p.Percentile == 0 {
+		return fmt.Errorf("invalid policy %v; expected a delay or a percentile", p)
+	}
+	if p.Delay < 0 || p.Percentile < 0 || p.Percentile >= 1 || p.MaxHedges < 0 || p.MaxRate < 0 {
+		return fmt.Errorf("invalid policy %v; the percentile must be between 0 and 1, and other values must not be negative", p)
+	}
+	return nil
+}
+
+// Returns p with the fields that are set in override replaced
+func (p Policy) Merge(override Policy) Policy {
+	if override.Delay != 0 {
+		p.Delay = override.Delay
+	}
+	if override.Percentile != 0 {
+		p.Percentile = override.Percentile
+	}
+	if override.MaxHedges != 0 {
+		p.MaxHedges = override.MaxHedges
+	}
+	if override.MaxRate != 0 {
+		p.MaxRate = override.MaxRate
+	}
+	return p
+}
+
+// Returns p with defaults for the omitted values
+func (p Policy) withDefaults() Policy {
+	if p.MaxHedges == 0 {
+		p.MaxHedges = DefaultMaxHedges
+	}
+	if p.MaxRate == 0 {
+		p.MaxRate = DefaultMaxRate
+	}
+	return p
+}
+
+// Returns the policy in the format parsed by [ParsePolicy]
+func (p Policy) String() string {
+	var fields []string
+	if p.Delay != 0 {
+		fields = append(fields, "delay:"+p.Delay.String())
+	}
+	if p.Percentile != 0 {
+		fields = append(fields, "percentile:"+strconv.FormatFloat(p.Percentile, 'g', -1, 64))
+	}
+	if p.MaxHedges != 0 {
+		fields = append(fields, "hedges:"+strconv.Itoa(p.MaxHedges))
+	}
+	if p.MaxRate != 0 {
+		fields = append(fields, "rate:"+strconv.FormatFloat(p.MaxRate, 'g', -1, 64))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Parses policies from a semicolon-separated list of method=policy pairs, where each policy is in the format parsed
+// by [ParsePolicy], e.g. "GetCart=delay:50ms;GetProduct=percentile:0.95,hedges:2".
+func ParsePolicies(s string) (Policies, error) {
+	policies, err := methodpolicy.Parse(s, ParsePolicy)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := policies["*"]; exists {
+		return nil, fmt.Errorf("hedging policies must name each method, as only idempotent methods may be hedged")
+	}
+	return policies, nil
+}
+
+// Returns the policies in the format parsed by [ParsePolicies], with methods in alphabetical order
+func (p Policies) String() string {
+	return methodpolicy.Format(p)
+}
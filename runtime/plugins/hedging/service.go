@@ -0,0 +1,78 @@
+package hedging
+
+import (
+	"context"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// The hedgers of the idempotent methods of a service, used by a client wrapper
+type ServiceHedger struct {
+	service string
+	hedgers map[string]*Hedger
+
+	metrics backend.LazyInstruments
+	calls   metric.Int64Counter
+	hedges  metric.Int64Counter
+}
+
+// Instantiates a [ServiceHedger] for calls to `service`, with the policies parsed by [ParsePolicies].  Methods
+// without a policy are not hedged.
+func NewServiceHedger(ctx context.Context, service string, policies string) (*ServiceHedger, error) {
+	parsed, err := ParsePolicies(policies)
+	if err != nil {
+		return nil, err
+	}
+	s := &ServiceHedger{service: service, hedgers: make(map[string]*Hedger)}
+	for method, policy := range parsed {
+		s.hedgers[method] = NewHedger(policy)
+	}
+	return s, nil
+}
+
+// Returns the hedger of method, or nil if method is not hedged
+func (s *ServiceHedger) Hedger(method string) *Hedger {
+	return s.hedgers[method]
+}
+
+// Calls call, and hedges it if method has a policy.  See [Hedger.Do].
+func (s *ServiceHedger) Call(ctx context.Context, method string, call Attempt) error {
+	hedger, exists := s.hedgers[method]
+	if !exists {
+		commit, err := call(ctx)
+		if commit != nil {
+			commit()
+		}
+		return err
+	}
+
+	s.initMetrics()
+	hedged, err := hedger.do(ctx, call)
+	attributes := []attribute.KeyValue{attribute.String("service", s.service), attribute.String("method", method)}
+	if s.calls != nil {
+		s.calls.Add(ctx, 1, metric.WithAttributes(attributes...))
+	}
+	s.record(ctx, int64(hedged.sent), "sent", attributes)
+	if hedged.throttled {
+		s.record(ctx, 1, "throttled", attributes)
+	}
+	if hedged.won {
+		s.record(ctx, 1, "won", attributes)
+	}
+	return err
+}
+
+func (s *ServiceHedger) initMetrics() {
+	s.metrics.Init("github.com/blueprint-uservices/blueprint/runtime/plugins/hedging", func(meter metric.Meter) {
+		s.calls, _ = meter.Int64Counter("hedging.calls", metric.WithDescription("Calls to hedged methods"))
+		s.hedges, _ = meter.Int64Counter("hedging.hedges", metric.WithDescription("Hedges by outcome: sent, throttled by the maximum rate, or won"))
+	})
+}
+
+func (s *ServiceHedger) record(ctx context.Context, n int64, outcome string, attributes []attribute.KeyValue) {
+	if n > 0 && s.hedges != nil {
+		s.hedges.Add(ctx, n, metric.WithAttributes(append(attributes, attribute.String("outcome", outcome))...))
+	}
+}
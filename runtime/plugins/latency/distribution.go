@@ -0,0 +1,329 @@
+package latency
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A distribution of latencies to inject
+type Distribution interface {
+	// Returns a random latency drawn from the distribution
+	Sample() time.Duration
+
+	// Returns the distribution in the format accepted by [ParseDistribution]
+	String() string
+}
+
+type (
+	fixed struct {
+		latency time.Duration
+	}
+
+	normal struct {
+		mean   time.Duration
+		stddev time.Duration
+	}
+
+	exponential struct {
+		mean time.Duration
+	}
+
+	// The logarithm of the latency is normally distributed; median is e^mu of the underlying normal distribution
+	logNormal struct {
+		median time.Duration
+		sigma  float64
+	}
+
+	// scale is the minimum latency and shape determines how heavy the tail is; smaller is heavier
+	pareto struct {
+		scale time.Duration
+		shape float64
+	}
+
+	empirical struct {
+		points []CDFPoint
+	}
+)
+
+// A point on an empirical cumulative distribution function: Probability is the fraction of latencies
+// that are less than or equal to Latency
+type CDFPoint struct {
+	Latency     time.Duration
+	Probability float64
+}
+
+// Parses a distribution from a string such as "normal(100ms, 20ms)".  Supported distributions are:
+//   - fixed(latency), or just latency, e.g. "100ms"; negative latencies are injected as 0
+//   - normal(mean, stddev); negative samples are injected as 0
+//   - exponential(mean)
+//   - lognormal(median, sigma), where sigma is the standard deviation of the latency's logarithm
+//   - pareto(scale, shape), where scale is the minimum latency and shape is the tail index
+//   - empirical(latency:probability, ...), a cumulative distribution function whose probabilities
+//     increase to 1; latencies are interpolated between points
+func ParseDistribution(spec string) (Distribution, error) {
+	spec = strings.TrimSpace(spec)
+	kind, args, hasArgs := strings.Cut(spec, "(")
+	if !hasArgs {
+		d, err := time.ParseDuration(spec)
+		return fixed{d}, err
+	}
+	if !strings.HasSuffix(args, ")") {
+		return nil, fmt.Errorf("invalid distribution %v", spec)
+	}
+	var params []string
+	for _, arg := range strings.Split(strings.TrimSuffix(args, ")"), ",") {
+		params = append(params, strings.TrimSpace(arg))
+	}
+	dist, err := newDistribution(strings.TrimSpace(kind), params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distribution %v: %w", spec, err)
+	}
+	return dist, nil
+}
+
+func newDistribution(kind string, params []string) (Distribution, error) {
+	expect := func(n int) error {
+		if len(params) != n {
+			return fmt.Errorf("%v expects %v arguments but got %v", kind, n, len(params))
+		}
+		return nil
+	}
+	switch kind {
+	case "fixed":
+		if err := expect(1); err != nil {
+			return nil, err
+		}
+		d, err := time.ParseDuration(params[0])
+		return fixed{d}, err
+	case "normal":
+		if err := expect(2); err != nil {
+			return nil, err
+		}
+		mean, err := parseLatency(params[0])
+		if err != nil {
+			return nil, err
+		}
+		stddev, err := parseLatency(params[1])
+		return normal{mean, stddev}, err
+	case "exponential":
+		if err := expect(1); err != nil {
+			return nil, err
+		}
+		mean, err := parseLatency(params[0])
+		return exponential{mean}, err
+	case "lognormal":
+		if err := expect(2); err != nil {
+			return nil, err
+		}
+		median, err := parseLatency(params[0])
+		if err != nil {
+			return nil, err
+		}
+		sigma, err := parsePositive(params[1])
+		return logNormal{median, sigma}, err
+	case "pareto":
+		if err := expect(2); err != nil {
+			return nil, err
+		}
+		scale, err := parseLatency(params[0])
+		if err != nil {
+			return nil, err
+		}
+		shape, err := parsePositive(params[1])
+		return pareto{scale, shape}, err
+	case "empirical":
+		var points []CDFPoint
+		for _, param := range params {
+			latency, probability, found := strings.Cut(param, ":")
+			if !found {
+				return nil, fmt.Errorf("expected latency:probability but got %v", param)
+			}
+			point, err := parseCDFPoint(latency, probability)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, point)
+		}
+		return NewEmpirical(points)
+	}
+	return nil, fmt.Errorf("unknown distribution %v", kind)
+}
+
+func parseLatency(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("latency %v is negative", s)
+	}
+	return d, nil
+}
+
+func parsePositive(s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, fmt.Errorf("%v must be positive", s)
+	}
+	return f, nil
+}
+
+func parseCDFPoint(latency string, probability string) (CDFPoint, error) {
+	d, err := parseLatency(strings.TrimSpace(latency))
+	if err != nil {
+		return CDFPoint{}, err
+	}
+	p, err := strconv.ParseFloat(strings.TrimSpace(probability), 64)
+	if err != nil {
+		return CDFPoint{}, err
+	}
+	return CDFPoint{d, p}, nil
+}
+
+// Instantiates an empirical [Distribution] from the points of a cumulative distribution function.  Latencies and
+// probabilities must be non-decreasing, and the probability of the last point must be 1.
+func NewEmpirical(points []CDFPoint) (Distribution, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("empirical distribution has no points")
+	}
+	for i, point := range points {
+		if point.Probability < 0 || point.Probability > 1 {
+			return nil, fmt.Errorf("probability %v is not between 0 and 1", point.Probability)
+		}
+		if i > 0 && (point.Latency < points[i-1].Latency || point.Probability < points[i-1].Probability) {
+			return nil, fmt.Errorf("empirical distribution is not non-decreasing at %v", point.Latency)
+		}
+	}
+	if last := points[len(points)-1].Probability; last != 1 {
+		return nil, fmt.Errorf("the last probability of an empirical distribution must be 1 but got %v", last)
+	}
+	return empirical{points}, nil
+}
+
+// Reads the points of a cumulative distribution function, one "latency,probability" pair per line, e.g.
+//
+//	# latency, probability
+//	5ms, 0.5
+//	20ms, 0.99
+//	250ms, 1
+//
+// Blank lines and lines starting with # are ignored.
+func ReadCDF(r io.Reader) ([]CDFPoint, error) {
+	var points []CDFPoint
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		latency, probability, found := strings.Cut(text, ",")
+		if !found {
+			return nil, fmt.Errorf("line %v: expected latency,probability but got %v", line, text)
+		}
+		point, err := parseCDFPoint(latency, probability)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+		points = append(points, point)
+	}
+	return points, scanner.Err()
+}
+
+// Loads an empirical [Distribution] from a file in the format read by [ReadCDF]
+func LoadEmpirical(path string) (Distribution, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	points, err := ReadCDF(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %v: %w", path, err)
+	}
+	return NewEmpirical(points)
+}
+
+func (d fixed) Sample() time.Duration {
+	return d.latency
+}
+
+func (d fixed) String() string {
+	return fmt.Sprintf("fixed(%v)", d.latency)
+}
+
+func (d normal) Sample() time.Duration {
+	return toDuration(float64(d.mean) + rand.NormFloat64()*float64(d.stddev))
+}
+
+func (d normal) String() string {
+	return fmt.Sprintf("normal(%v, %v)", d.mean, d.stddev)
+}
+
+func (d exponential) Sample() time.Duration {
+	return toDuration(rand.ExpFloat64() * float64(d.mean))
+}
+
+func (d exponential) String() string {
+	return fmt.Sprintf("exponential(%v)", d.mean)
+}
+
+func (d logNormal) Sample() time.Duration {
+	return toDuration(float64(d.median) * math.Exp(d.sigma*rand.NormFloat64()))
+}
+
+func (d logNormal) String() string {
+	return fmt.Sprintf("lognormal(%v, %v)", d.median, d.sigma)
+}
+
+func (d pareto) Sample() time.Duration {
+	// Inverse transform sampling; 1 - rand.Float64() is in (0, 1]
+	return toDuration(float64(d.scale) / math.Pow(1-rand.Float64(), 1/d.shape))
+}
+
+func (d pareto) String() string {
+	return fmt.Sprintf("pareto(%v, %v)", d.scale, d.shape)
+}
+
+// Converts a sample in nanoseconds to a duration, clamping negative samples to 0 and samples that overflow a
+// duration to the maximum duration
+func toDuration(sample float64) time.Duration {
+	if sample >= math.MaxInt64 {
+		return math.MaxInt64
+	} else if sample > 0 {
+		return time.Duration(sample)
+	}
+	return 0
+}
+
+func (d empirical) Sample() time.Duration {
+	p := rand.Float64()
+	i := sort.Search(len(d.points), func(i int) bool { return d.points[i].Probability >= p })
+	if i == 0 {
+		return d.points[0].Latency
+	}
+	lo, hi := d.points[i-1], d.points[i]
+	if hi.Probability == lo.Probability {
+		return hi.Latency
+	}
+	fraction := (p - lo.Probability) / (hi.Probability - lo.Probability)
+	return lo.Latency + time.Duration(fraction*float64(hi.Latency-lo.Latency))
+}
+
+func (d empirical) String() string {
+	var points []string
+	for _, point := range d.points {
+		points = append(points, fmt.Sprintf("%v:%v", point.Latency, point.Probability))
+	}
+	return fmt.Sprintf("empirical(%v)", strings.Join(points, ", "))
+}
@@ -0,0 +1,178 @@
+package latency
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the sorted samples of n draws from d
+func samples(d Distribution, n int) []time.Duration {
+	s := make([]time.Duration, n)
+	for i := range s {
+		s[i] = d.Sample()
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
+func median(s []time.Duration) time.Duration {
+	return s[len(s)/2]
+}
+
+func mean(s []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range s {
+		total += d
+	}
+	return total / time.Duration(len(s))
+}
+
+func TestParseDistribution(t *testing.T) {
+	for spec, expected := range map[string]string{
+		"100ms":                               "fixed(100ms)",
+		"fixed(1s)":                           "fixed(1s)",
+		"normal(100ms, 20ms)":                 "normal(100ms, 20ms)",
+		"exponential(50ms)":                   "exponential(50ms)",
+		"lognormal(50ms, 0.5)":                "lognormal(50ms, 0.5)",
+		"pareto(10ms,1.5)":                    "pareto(10ms, 1.5)",
+		"empirical(5ms:0.5, 20ms:0.99, 1s:1)": "empirical(5ms:0.5, 20ms:0.99, 1s:1)",
+		" empirical( 5ms : 0.5 , 20ms : 1 ) ": "empirical(5ms:0.5, 20ms:1)",
+	} {
+		d, err := ParseDistribution(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expected, d.String())
+
+		// Distributions round-trip
+		roundtrip, err := ParseDistribution(d.String())
+		require.NoError(t, err, spec)
+		assert.Equal(t, d, roundtrip)
+	}
+
+	for _, spec := range []string{
+		"",
+		"often",
+		"normal(100ms)",
+		"normal(100ms, 20ms",
+		"exponential(-1s)",
+		"lognormal(50ms, 0)",
+		"pareto(10ms, fat)",
+		"gamma(1s, 2)",
+		"empirical()",
+		"empirical(5ms:0.5)",
+		"empirical(5ms:0.5, 1ms:1)",
+		"empirical(5ms:0.5, 10ms:0.4, 20ms:1)",
+		"empirical(5ms)",
+	} {
+		_, err := ParseDistribution(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSample(t *testing.T) {
+	const n = 10000
+
+	s := samples(&fixed{10 * time.Millisecond}, 10)
+	assert.Equal(t, 10*time.Millisecond, s[0])
+	assert.Equal(t, 10*time.Millisecond, s[9])
+
+	s = samples(&normal{100 * time.Millisecond, 10 * time.Millisecond}, n)
+	assert.InDelta(t, 100*time.Millisecond, mean(s), float64(time.Millisecond))
+	assert.GreaterOrEqual(t, s[0], time.Duration(0))
+
+	s = samples(&exponential{50 * time.Millisecond}, n)
+	assert.InDelta(t, 50*time.Millisecond, mean(s), float64(3*time.Millisecond))
+
+	s = samples(&logNormal{50 * time.Millisecond, 0.5}, n)
+	assert.InDelta(t, 50*time.Millisecond, median(s), float64(3*time.Millisecond))
+
+	s = samples(&pareto{10 * time.Millisecond, 2}, n)
+	assert.GreaterOrEqual(t, s[0], 10*time.Millisecond)
+	// The median of a Pareto distribution is scale * 2^(1/shape)
+	assert.InDelta(t, 14142*time.Microsecond, median(s), float64(time.Millisecond))
+
+	d, err := NewEmpirical([]CDFPoint{{10 * time.Millisecond, 0.5}, {20 * time.Millisecond, 0.9}, {100 * time.Millisecond, 1}})
+	require.NoError(t, err)
+	s = samples(d, n)
+	assert.Equal(t, 10*time.Millisecond, s[0])
+	assert.LessOrEqual(t, s[n-1], 100*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, s[n/2-n/20])
+	assert.InDelta(t, 20*time.Millisecond, s[n*9/10], float64(2*time.Millisecond))
+}
+
+func TestSampleOverflow(t *testing.T) {
+	// Samples that overflow a duration are clamped rather than wrapping around to negative delays
+	for _, d := range []Distribution{
+		&normal{time.Duration(math.MaxInt64 / 2), time.Duration(math.MaxInt64 / 2)},
+		&exponential{time.Duration(math.MaxInt64 / 2)},
+		&logNormal{time.Hour, 100},
+		&logNormal{0, 1000},
+		&pareto{time.Hour, 0.01},
+	} {
+		s := samples(d, 1000)
+		assert.GreaterOrEqual(t, s[0], time.Duration(0), d.String())
+	}
+	s := samples(&logNormal{time.Hour, 100}, 1000)
+	assert.Equal(t, time.Duration(math.MaxInt64), s[len(s)-1])
+}
+
+func TestLoadEmpirical(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cdf.csv")
+	cdf := strings.Join([]string{
+		"# latency, probability",
+		"5ms, 0.5",
+		"",
+		"20ms, 0.99",
+		"250ms, 1",
+	}, "\n")
+	require.NoError(t, os.WriteFile(path, []byte(cdf), 0644))
+
+	d, err := LoadEmpirical(path)
+	require.NoError(t, err)
+	assert.Equal(t, "empirical(5ms:0.5, 20ms:0.99, 250ms:1)", d.String())
+
+	require.NoError(t, os.WriteFile(path, []byte("5ms 0.5\n"), 0644))
+	_, err = LoadEmpirical(path)
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = LoadEmpirical(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestInjector(t *testing.T) {
+	ctx := context.Background()
+
+	injector, err := NewInjector("20ms", "", "1")
+	require.NoError(t, err)
+	start := time.Now()
+	assert.NoError(t, injector.Inject(ctx, "Anything"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Only the allowlisted methods are delayed
+	injector, err = NewInjector("1h", "GetCart, AddItem", "1")
+	require.NoError(t, err)
+	assert.NoError(t, injector.Inject(ctx, "DeleteCart"))
+
+	// The caller's context is respected
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, injector.Inject(timeout, "AddItem"), context.DeadlineExceeded)
+
+	// No requests are sampled
+	injector, err = NewInjector("1h", "", "0")
+	require.NoError(t, err)
+	assert.NoError(t, injector.Inject(ctx, "Anything"))
+
+	_, err = NewInjector("1h", "", "1.5")
+	assert.Error(t, err)
+	_, err = NewInjector("normal(1h)", "", "1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+// Package latency implements the latency injection used by server wrappers generated by the Blueprint
+// latency plugin.
+//
+// An [Injector] delays requests by a latency sampled from a [Distribution].  Injection can be restricted to
+// a subset of a service's methods and to a random fraction of requests.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the latency plugin is used in a wiring spec.
+package latency
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Injects latency into requests
+type Injector struct {
+	Distribution Distribution
+	Methods      map[string]bool // If non-empty, latency is only injected into these methods
+	Fraction     float64         // The fraction of requests into which latency is injected
+}
+
+// Instantiates an [Injector] from string arguments, as used by generated code.
+//   - distribution is parsed by [ParseDistribution]
+//   - methods is a comma-separated list of method names; if empty, latency is injected into all methods
+//   - fraction is the fraction of requests to inject latency into, between 0 and 1
+func NewInjector(distribution string, methods string, fraction string) (*Injector, error) {
+	dist, err := ParseDistribution(distribution)
+	if err != nil {
+		return nil, err
+	}
+	f, err := strconv.ParseFloat(fraction, 64)
+	if err != nil {
+		return nil, err
+	}
+	if f < 0 || f > 1 {
+		return nil, fmt.Errorf("latency injection fraction %v is not between 0 and 1", fraction)
+	}
+	injector := &Injector{Distribution: dist, Fraction: f}
+	if methods != "" {
+		injector.Methods = make(map[string]bool)
+		for _, method := range strings.Split(methods, ",") {
+			injector.Methods[strings.TrimSpace(method)] = true
+		}
+	}
+	return injector, nil
+}
+
+// Sleeps for a sampled latency if latency should be injected into this call of method.  Returns early
+// with ctx's error if ctx is done while sleeping.
+func (i *Injector) Inject(ctx context.Context, method string) error {
+	if len(i.Methods) > 0 && !i.Methods[method] {
+		return nil
+	}
+	if i.Fraction < 1 && rand.Float64() >= i.Fraction {
+		return nil
+	}
+	latency := i.Distribution.Sample()
+	if latency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,114 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How a [Balancer] picks the replica for a call
+type Policy string
+
+const (
+	RoundRobin       Policy = "roundrobin"       // Each replica in turn
+	Random           Policy = "random"           // A replica chosen uniformly at random
+	LeastOutstanding Policy = "leastoutstanding" // The replica with the fewest calls in progress
+	PowerOfTwo       Policy = "p2c"              // The replica with fewer calls in progress of two chosen at random
+)
+
+// Defaults for configs that omit them
+const (
+	DefaultPolicy      = RoundRobin
+	DefaultFailures    = 5
+	DefaultEjection    = 30 * time.Second
+	DefaultHealthCheck = 10 * time.Second
+)
+
+// The configuration of a [Balancer].  Omitted values are defaults.
+type Config struct {
+	Policy      Policy        // How replicas are picked
+	Failures    int           // A replica is ejected after this many consecutive failed calls
+	Ejection    time.Duration // How long a replica is ejected for after failed calls
+	HealthCheck time.Duration // How often replicas with a Health method are checked; failed replicas are ejected until a check succeeds
+}
+
+// Parses a config from a comma-separated list of key:value pairs, e.g.
+// "policy:p2c,failures:5,eject:30s,healthcheck:10s".  Omitted keys are defaults.
+func ParseConfig(s string) (Config, error) {
+	var config Config
+	if strings.TrimSpace(s) == "" {
+		return config, nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(field), ":")
+		if !found {
+			return Config{}, fmt.Errorf("expected key:value but got %v", field)
+		}
+		var err error
+		switch key {
+		case "policy":
+			config.Policy = Policy(value)
+		case "failures":
+			config.Failures, err = strconv.Atoi(value)
+		case "eject":
+			config.Ejection, err = time.ParseDuration(value)
+		case "healthcheck":
+			config.HealthCheck, err = time.ParseDuration(value)
+		default:
+			return Config{}, fmt.Errorf("unknown load balancer setting %v", key)
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid %v: %w", key, err)
+		}
+	}
+	return config, config.Validate()
+}
+
+// Returns an error if the policy is unknown or any of the values are negative
+func (c Config) Validate() error {
+	switch c.Policy {
+	case "", RoundRobin, Random, LeastOutstanding, PowerOfTwo:
+	default:
+		return fmt.Errorf("unknown load balancing policy %v; expected %v, %v, %v, or %v", c.Policy, RoundRobin, Random, LeastOutstanding, PowerOfTwo)
+	}
+	if c.Failures < 0 || c.Ejection < 0 || c.HealthCheck < 0 {
+		return fmt.Errorf("invalid load balancer config %v; values must not be negative", c)
+	}
+	return nil
+}
+
+// Returns c with defaults for the omitted values
+func (c Config) withDefaults() Config {
+	if c.Policy == "" {
+		c.Policy = DefaultPolicy
+	}
+	if c.Failures == 0 {
+		c.Failures = DefaultFailures
+	}
+	if c.Ejection == 0 {
+		c.Ejection = DefaultEjection
+	}
+	if c.HealthCheck == 0 {
+		c.HealthCheck = DefaultHealthCheck
+	}
+	return c
+}
+
+// Returns the config in the format parsed by [ParseConfig]
+func (c Config) String() string {
+	var fields []string
+	if c.Policy != "" {
+		fields = append(fields, "policy:"+string(c.Policy))
+	}
+	if c.Failures != 0 {
+		fields = append(fields, "failures:"+strconv.Itoa(c.Failures))
+	}
+	if c.Ejection != 0 {
+		fields = append(fields, "eject:"+c.Ejection.String())
+	}
+	if c.HealthCheck != 0 {
+		fields = append(fields, "healthcheck:"+c.HealthCheck.String())
+	}
+	return strings.Join(fields, ",")
+}
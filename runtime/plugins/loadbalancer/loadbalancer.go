@@ -0,0 +1,263 @@
+// Package loadbalancer implements the client-side load balancing used by clients generated by the Blueprint
+// loadbalancer plugin.
+//
+// A [Balancer] picks one of the replicas of a service for each call according to a [Policy].  Replicas are ejected,
+// i.e. not picked, while they are failing:
+//   - after a number of consecutive calls fail with an error that [retries.IsRetryable] classifies as a transport
+//     or timeout error, a replica is ejected for a fixed duration
+//   - replicas with a Health method, as added by the healthchecker plugin, are periodically checked, and a replica
+//     that fails a check is ejected until a check succeeds
+//
+// If all of the replicas are ejected, calls are balanced across all of them.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the loadbalancer plugin is used in a wiring spec.
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/retries"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/exp/slog"
+)
+
+// A replica of a service
+type Replica struct {
+	Name   string
+	Client any // If the client has a Health method, the replica is health checked
+}
+
+// The state of a replica, as reported by [Balancer.Status]
+type ReplicaStatus struct {
+	Name        string
+	Healthy     bool  // False if the replica failed its last health check
+	Ejected     bool  // True if the replica is currently not picked, because of failed calls or health checks
+	Outstanding int64 // Calls in progress
+	Calls       int64 // Calls made
+}
+
+type healthChecker interface {
+	Health(ctx context.Context) (string, error)
+}
+
+type replica struct {
+	Replica
+	index       int
+	outstanding atomic.Int64
+	calls       atomic.Int64
+
+	// Guarded by Balancer.lock
+	failures     int
+	ejectedUntil time.Time
+	unhealthy    bool
+}
+
+// Balances calls across the replicas of a service; see the package documentation.
+type Balancer struct {
+	service  string
+	config   Config
+	replicas []*replica
+	next     atomic.Uint64 // For round robin
+
+	lock sync.Mutex
+	rand *rand.Rand
+
+	metrics   backend.LazyInstruments
+	calls     metric.Int64Counter
+	ejections metric.Int64Counter
+}
+
+// Instantiates a [Balancer] for calls to `service`, with the config parsed by [ParseConfig].  If any replica has a
+// Health method, the replicas are health checked until ctx is done.
+func NewBalancer(ctx context.Context, service string, config string, replicas []Replica) (*Balancer, error) {
+	parsed, err := ParseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("no replicas of %v to balance calls across", service)
+	}
+	b := &Balancer{
+		service: service,
+		config:  parsed.withDefaults(),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	checked := false
+	for i, r := range replicas {
+		b.replicas = append(b.replicas, &replica{Replica: r, index: i})
+		_, hasHealth := r.Client.(healthChecker)
+		checked = checked || hasHealth
+	}
+	if checked {
+		go b.healthCheck(ctx)
+	}
+	return b, nil
+}
+
+// Returns the state of each replica
+func (b *Balancer) Status() []ReplicaStatus {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := time.Now()
+	var status []ReplicaStatus
+	for _, r := range b.replicas {
+		status = append(status, ReplicaStatus{
+			Name:        r.Name,
+			Healthy:     !r.unhealthy,
+			Ejected:     !b.available(r, now),
+			Outstanding: r.outstanding.Load(),
+			Calls:       r.calls.Load(),
+		})
+	}
+	return status
+}
+
+// Calls call with the index of the replica that is picked for the call
+func (b *Balancer) Call(ctx context.Context, call func(ctx context.Context, replica int) error) (err error) {
+	b.initMetrics()
+	r := b.pick()
+	r.outstanding.Add(1)
+	r.calls.Add(1)
+	if b.calls != nil {
+		b.calls.Add(ctx, 1, metric.WithAttributes(attribute.String("service", b.service), attribute.String("replica", r.Name)))
+	}
+
+	failed := true // If call panics
+	defer func() {
+		r.outstanding.Add(-1)
+		b.observe(r, failed)
+	}()
+	err = call(ctx, r.index)
+	failed = retries.IsRetryable(err)
+	return err
+}
+
+// Picks a replica according to the policy, from those that are not ejected
+func (b *Balancer) pick() *replica {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := time.Now()
+	var candidates []*replica
+	for _, r := range b.replicas {
+		if b.available(r, now) {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.replicas
+	}
+
+	switch b.config.Policy {
+	case Random:
+		return candidates[b.rand.Intn(len(candidates))]
+	case LeastOutstanding:
+		// Start at a rotating offset so that ties are spread across replicas
+		start := int(b.next.Add(1) % uint64(len(candidates)))
+		least := candidates[start]
+		for i := 1; i < len(candidates); i++ {
+			if r := candidates[(start+i)%len(candidates)]; r.outstanding.Load() < least.outstanding.Load() {
+				least = r
+			}
+		}
+		return least
+	case PowerOfTwo:
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+		i := b.rand.Intn(len(candidates))
+		j := b.rand.Intn(len(candidates) - 1)
+		if j >= i {
+			j++
+		}
+		if candidates[j].outstanding.Load() < candidates[i].outstanding.Load() {
+			return candidates[j]
+		}
+		return candidates[i]
+	default:
+		return candidates[(b.next.Add(1)-1)%uint64(len(candidates))]
+	}
+}
+
+func (b *Balancer) available(r *replica, now time.Time) bool {
+	return !r.unhealthy && !now.Before(r.ejectedUntil)
+}
+
+// Ejects a replica after consecutive failed calls
+func (b *Balancer) observe(r *replica, failed bool) {
+	b.lock.Lock()
+	if !failed {
+		r.failures = 0
+		b.lock.Unlock()
+		return
+	}
+	r.failures++
+	eject := r.failures >= b.config.Failures
+	if eject {
+		r.failures = 0
+		r.ejectedUntil = time.Now().Add(b.config.Ejection)
+	}
+	b.lock.Unlock()
+
+	if eject {
+		b.ejected(r, "failures")
+	}
+}
+
+// Checks the health of the replicas with a Health method every interval until ctx is done
+func (b *Balancer) healthCheck(ctx context.Context) {
+	ticker := time.NewTicker(b.config.HealthCheck)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range b.replicas {
+				if checker, hasHealth := r.Client.(healthChecker); hasHealth {
+					b.check(ctx, r, checker)
+				}
+			}
+		}
+	}
+}
+
+func (b *Balancer) check(ctx context.Context, r *replica, checker healthChecker) {
+	ctx, cancel := context.WithTimeout(ctx, b.config.HealthCheck)
+	defer cancel()
+	_, err := checker.Health(ctx)
+
+	b.lock.Lock()
+	wasUnhealthy := r.unhealthy
+	r.unhealthy = err != nil
+	b.lock.Unlock()
+
+	if err != nil && !wasUnhealthy {
+		b.ejected(r, "healthcheck")
+	} else if err == nil && wasUnhealthy {
+		slog.Info(fmt.Sprintf("replica %v of %v passed its health check", r.Name, b.service))
+	}
+}
+
+func (b *Balancer) ejected(r *replica, reason string) {
+	slog.Info(fmt.Sprintf("ejected replica %v of %v because of %v", r.Name, b.service, reason))
+	b.initMetrics()
+	if b.ejections != nil {
+		b.ejections.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("service", b.service), attribute.String("replica", r.Name), attribute.String("reason", reason)))
+	}
+}
+
+func (b *Balancer) initMetrics() {
+	b.metrics.Init("github.com/blueprint-uservices/blueprint/runtime/plugins/loadbalancer", func(meter metric.Meter) {
+		b.calls, _ = meter.Int64Counter("loadbalancer.calls", metric.WithDescription("Calls by replica"))
+		b.ejections, _ = meter.Int64Counter("loadbalancer.ejections", metric.WithDescription("Ejections of replicas by reason: failures or healthcheck"))
+	})
+}
@@ -0,0 +1,161 @@
+package loadbalancer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig("policy:p2c, failures:3,eject:1m,healthcheck:5s")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Policy: PowerOfTwo, Failures: 3, Ejection: time.Minute, HealthCheck: 5 * time.Second}, config)
+	assert.Equal(t, "policy:p2c,failures:3,eject:1m0s,healthcheck:5s", config.String())
+
+	config, err = ParseConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, Config{}, config)
+
+	for _, invalid := range []string{"p2c", "policy:fastest", "failures:-1", "eject:soon", "speed:1"} {
+		_, err := ParseConfig(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func newReplicas(clients ...any) []Replica {
+	var replicas []Replica
+	for i, client := range clients {
+		replicas = append(replicas, Replica{Name: string(rune('a' + i)), Client: client})
+	}
+	return replicas
+}
+
+// Makes n calls, and returns the number of calls to each replica
+func spread(t *testing.T, b *Balancer, n int) []int {
+	counts := make([]int, len(b.replicas))
+	for i := 0; i < n; i++ {
+		require.NoError(t, b.Call(context.Background(), func(ctx context.Context, replica int) error {
+			counts[replica]++
+			return nil
+		}))
+	}
+	return counts
+}
+
+func TestPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	b, err := NewBalancer(ctx, "svc", "", newReplicas(nil, nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 10, 10}, spread(t, b, 30))
+
+	for _, policy := range []Policy{Random, LeastOutstanding, PowerOfTwo} {
+		b, err := NewBalancer(ctx, "svc", "policy:"+string(policy), newReplicas(nil, nil, nil))
+		require.NoError(t, err)
+		for replica, count := range spread(t, b, 300) {
+			assert.Greater(t, count, 50, "%v replica %v", policy, replica)
+		}
+	}
+
+	_, err = NewBalancer(ctx, "svc", "", nil)
+	assert.Error(t, err)
+}
+
+func TestLeastOutstanding(t *testing.T) {
+	for _, policy := range []Policy{LeastOutstanding, PowerOfTwo} {
+		b, err := NewBalancer(context.Background(), "svc", "policy:"+string(policy), newReplicas(nil, nil))
+		require.NoError(t, err)
+
+		// Replica 0 is slow, so calls go to replica 1 while replica 0 is busy
+		release := make(chan struct{})
+		started := make(chan int)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Call(context.Background(), func(ctx context.Context, replica int) error {
+				started <- replica
+				<-release
+				return nil
+			})
+		}()
+		busy := <-started
+
+		counts := spread(t, b, 20)
+		assert.Equal(t, 0, counts[busy], string(policy))
+		close(release)
+		wg.Wait()
+	}
+}
+
+func TestEjectFailures(t *testing.T) {
+	b, err := NewBalancer(context.Background(), "svc", "failures:2,eject:50ms", newReplicas(nil, nil))
+	require.NoError(t, err)
+
+	// Business logic errors don't eject replicas
+	failure := errors.New("failure")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, failure, b.Call(context.Background(), func(ctx context.Context, replica int) error { return failure }))
+	}
+	assert.False(t, b.Status()[0].Ejected)
+
+	// Replicas that are unavailable are ejected, as reported by gRPC clients
+	call := func(ctx context.Context, replica int) error {
+		if replica == 0 {
+			return rpcerrors.FromStatus(rpcerrors.Unavailable, "connection refused", nil)
+		}
+		return nil
+	}
+	for i := 0; i < 4; i++ {
+		b.Call(context.Background(), call)
+	}
+	status := b.Status()
+	assert.True(t, status[0].Ejected)
+	assert.True(t, status[0].Healthy)
+	assert.Equal(t, []int{0, 10}, spread(t, b, 10))
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, b.Status()[0].Ejected)
+}
+
+type checked struct {
+	healthy atomic.Bool
+}
+
+func (c *checked) Health(ctx context.Context) (string, error) {
+	if c.healthy.Load() {
+		return "Healthy", nil
+	}
+	return "", errors.New("unhealthy")
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	replica := &checked{}
+	b, err := NewBalancer(ctx, "svc", "healthcheck:5ms", newReplicas(replica, nil))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return b.Status()[0].Ejected }, time.Second, time.Millisecond)
+	assert.False(t, b.Status()[0].Healthy)
+	assert.Equal(t, []int{0, 10}, spread(t, b, 10))
+
+	replica.healthy.Store(true)
+	require.Eventually(t, func() bool { return !b.Status()[0].Ejected }, time.Second, time.Millisecond)
+
+	// If all replicas are ejected, all are used
+	replica.healthy.Store(false)
+	require.Eventually(t, func() bool { return b.Status()[0].Ejected }, time.Second, time.Millisecond)
+	b, err = NewBalancer(ctx, "svc", "healthcheck:5ms", newReplicas(replica))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return b.Status()[0].Ejected }, time.Second, time.Millisecond)
+	assert.Equal(t, []int{3}, spread(t, b, 3))
+}
@@ -0,0 +1,217 @@
+// Package loadshedding implements the adaptive admission control used by wrappers generated by the Blueprint
+// loadshedding plugin.
+//
+// A [Shedder] admits up to a limit of concurrent calls.  Calls in excess of the limit wait in a queue, ordered by
+// the priority of the called method and then by arrival, and calls that wait too long are shed.  The limit and how
+// long calls may wait are determined by a policy:
+//   - codel(target, interval, concurrency) has a fixed limit of concurrency calls.  Calls may normally wait for up to
+//     interval.  If the minimum queueing delay over an interval exceeds target, the queue is considered overloaded,
+//     and calls may only wait for up to target until the queue recovers.  This is the variant of CoDel that is used
+//     by Facebook's RPC servers.
+//   - gradient(maxWait, maxLimit) adapts the limit between 1 and maxLimit by comparing short-term and long-term
+//     averages of the latency of calls, as in Netflix's Gradient2 concurrency limiter.  Calls may wait for up to
+//     maxWait; unlike the target of codel, maxWait does not affect the limit.
+//
+// Because higher-priority calls are admitted first, the lowest-priority calls are the first to be shed.  Shed calls
+// return a [ratelimit.RejectedError], so they are recognised by the retries and circuitbreaker plugins in the same
+// way as rejections by a rate limiter, and are returned to callers over RPC with code ResourceExhausted.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the loadshedding plugin is used in a wiring spec.
+package loadshedding
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+)
+
+// A load shedding policy, parsed by [ParsePolicy].  Determines the concurrency limit of a [Shedder] and how long
+// calls may wait for admission.  Methods are called with the Shedder's lock held.
+type Policy interface {
+	// Returns the initial concurrency limit
+	initialLimit() float64
+
+	// Returns how long a call that arrives at now may wait to be admitted
+	timeout(now time.Time) time.Duration
+
+	// Called when a call stops waiting at now, after waiting for delay, whether or not it was admitted
+	observe(now time.Time, delay time.Duration)
+
+	// Called when an admitted call completes after rtt; returns the new concurrency limit
+	completed(rtt time.Duration, inFlight int, limit float64) float64
+
+	String() string
+}
+
+// Sheds calls to a service when it is overloaded; see the package documentation.
+type Shedder struct {
+	lock       sync.Mutex
+	policy     Policy
+	priorities map[string]int
+	limit      float64
+	inFlight   int
+	waiting    waiters
+	arrivals   uint64
+	now        func() time.Time
+}
+
+// Instantiates a [Shedder] from a policy string such as "codel(5ms, 100ms, 100)" or "gradient(50ms, 1000)", and
+// the priorities of methods, e.g. "GetCart:1,ListItems:-1".  Methods without a priority have priority 0, and
+// higher-priority calls are admitted first.
+func NewShedder(policy string, priorities string) (*Shedder, error) {
+	p, err := ParsePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	prio, err := ParsePriorities(priorities)
+	if err != nil {
+		return nil, err
+	}
+	return &Shedder{
+		policy:     p,
+		priorities: prio,
+		limit:      p.initialLimit(),
+		now:        time.Now,
+	}, nil
+}
+
+// Calls call once it is admitted.  Returns a [ratelimit.RejectedError] if the call is shed, or the context's error
+// if the caller's context is done while waiting.
+func (s *Shedder) Call(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	release, err := s.acquire(ctx, method)
+	if err != nil {
+		return err
+	}
+	start := s.now()
+	defer func() { release(s.now().Sub(start)) }()
+	return call(ctx)
+}
+
+// Returns the current concurrency limit
+func (s *Shedder) Limit() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return int(s.limit)
+}
+
+func (s *Shedder) acquire(ctx context.Context, method string) (func(rtt time.Duration), error) {
+	s.lock.Lock()
+	now := s.now()
+	if s.inFlight < int(s.limit) && len(s.waiting) == 0 {
+		s.inFlight++
+		s.policy.observe(now, 0)
+		s.lock.Unlock()
+		return s.release, nil
+	}
+	timeout := s.policy.timeout(now)
+	s.arrivals++
+	w := &waiter{priority: s.priorities[method], arrival: s.arrivals, enqueued: now, ready: make(chan struct{})}
+	heap.Push(&s.waiting, w)
+	s.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.ready:
+		return s.release, nil
+	case <-timer.C:
+		err = &ratelimit.RejectedError{Method: method, Reason: fmt.Sprintf("shed by %v after waiting %v", s.policy, timeout)}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if w.admitted {
+		// Admitted concurrently with the timeout
+		return s.release, nil
+	}
+	heap.Remove(&s.waiting, w.index)
+	s.policy.observe(s.now(), s.now().Sub(w.enqueued))
+	return nil, err
+}
+
+func (s *Shedder) release(rtt time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.inFlight--
+	s.limit = s.policy.completed(rtt, s.inFlight, s.limit)
+	now := s.now()
+	for s.inFlight < int(s.limit) && len(s.waiting) > 0 {
+		w := heap.Pop(&s.waiting).(*waiter)
+		w.admitted = true
+		s.inFlight++
+		s.policy.observe(now, now.Sub(w.enqueued))
+		close(w.ready)
+	}
+}
+
+// A call waiting to be admitted
+type waiter struct {
+	priority int
+	arrival  uint64
+	enqueued time.Time
+	ready    chan struct{} // closed when the call is admitted
+	admitted bool
+	index    int
+}
+
+// Implements [heap.Interface], ordered by priority and then by arrival
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+
+func (w waiters) Less(i, j int) bool {
+	if w[i].priority != w[j].priority {
+		return w[i].priority > w[j].priority
+	}
+	return w[i].arrival < w[j].arrival
+}
+
+func (w waiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+
+func (w *waiters) Push(x any) {
+	item := x.(*waiter)
+	item.index = len(*w)
+	*w = append(*w, item)
+}
+
+func (w *waiters) Pop() any {
+	old := *w
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*w = old[:len(old)-1]
+	return item
+}
+
+// Parses the priorities of methods from a comma-separated list of method:priority pairs, e.g. "GetCart:1,ListItems:-1".
+func ParsePriorities(s string) (map[string]int, error) {
+	priorities := make(map[string]int)
+	if strings.TrimSpace(s) == "" {
+		return priorities, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		method, priority, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || method == "" {
+			return nil, fmt.Errorf("expected method:priority but got %v", entry)
+		}
+		p, err := strconv.Atoi(priority)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority for %v: %w", method, err)
+		}
+		priorities[method] = p
+	}
+	return priorities, nil
+}
@@ -0,0 +1,161 @@
+package loadshedding
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("codel(5ms, 100ms, 10)")
+	require.NoError(t, err)
+	assert.Equal(t, "codel(5ms, 100ms, 10)", p.String())
+
+	p, err = ParsePolicy(" gradient(50ms,1000) ")
+	require.NoError(t, err)
+	assert.Equal(t, "gradient(50ms, 1000)", p.String())
+
+	for _, invalid := range []string{"codel", "codel(5ms, 100ms)", "codel(100ms, 5ms, 10)", "codel(5ms, 100ms, 0)", "gradient(0s, 10)", "gradient(5ms, x)", "fifo(10)"} {
+		_, err := ParsePolicy(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	priorities, err := ParsePriorities("GetCart:1, ListItems:-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"GetCart": 1, "ListItems": -1}, priorities)
+	_, err = ParsePriorities("GetCart")
+	assert.Error(t, err)
+}
+
+func TestCoDel(t *testing.T) {
+	p := &codel{target: 5 * time.Millisecond, interval: 100 * time.Millisecond, concurrency: 1}
+	start := time.Now()
+	assert.Equal(t, p.interval, p.timeout(start))
+
+	// The minimum delay over the interval exceeds the target
+	p.observe(start.Add(10*time.Millisecond), 20*time.Millisecond)
+	p.observe(start.Add(20*time.Millisecond), 10*time.Millisecond)
+	assert.Equal(t, p.interval, p.timeout(start.Add(50*time.Millisecond)))
+	assert.Equal(t, p.target, p.timeout(start.Add(100*time.Millisecond)))
+
+	// A single call without queueing delay is enough to recover
+	p.observe(start.Add(150*time.Millisecond), 0)
+	assert.Equal(t, p.target, p.timeout(start.Add(150*time.Millisecond)))
+	assert.Equal(t, p.interval, p.timeout(start.Add(200*time.Millisecond)))
+
+	// As is an interval without calls
+	p.observe(start.Add(250*time.Millisecond), 20*time.Millisecond)
+	assert.Equal(t, p.target, p.timeout(start.Add(300*time.Millisecond)))
+	assert.Equal(t, p.interval, p.timeout(start.Add(400*time.Millisecond)))
+}
+
+func TestGradient(t *testing.T) {
+	p := &gradient{maxWait: 10 * time.Millisecond, maxLimit: 100}
+	limit := p.initialLimit()
+	assert.Equal(t, 20.0, limit)
+
+	// The limit grows while latency is stable and the limit is being used
+	for i := 0; i < 100; i++ {
+		limit = p.completed(time.Millisecond, int(limit), limit)
+	}
+	assert.Equal(t, 100.0, limit)
+
+	// The limit isn't changed if it isn't being used
+	assert.Equal(t, limit, p.completed(time.Millisecond, 0, limit))
+
+	// The limit shrinks when latency increases
+	for i := 0; i < 100; i++ {
+		limit = p.completed(20*time.Millisecond, int(limit), limit)
+	}
+	assert.Less(t, limit, 20.0)
+	assert.GreaterOrEqual(t, limit, 1.0)
+}
+
+func TestShed(t *testing.T) {
+	shedder, err := NewShedder("codel(5ms, 20ms, 1)", "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, shedder.Call(ctx, "GetCart", func(ctx context.Context) error {
+			close(started)
+			<-block
+			return nil
+		}))
+	}()
+	<-started
+
+	// The call waits for the interval and is shed
+	begin := time.Now()
+	err = shedder.Call(ctx, "GetCart", func(ctx context.Context) error { return nil })
+	assert.True(t, ratelimit.IsRejected(err))
+	assert.Equal(t, rpcerrors.ResourceExhausted, rpcerrors.CodeOf(err))
+	assert.GreaterOrEqual(t, time.Since(begin), 20*time.Millisecond)
+
+	// Calls return if the caller's context is done while waiting
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = shedder.Call(cancelled, "GetCart", func(ctx context.Context) error { return nil })
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	close(block)
+	wg.Wait()
+	assert.NoError(t, shedder.Call(ctx, "GetCart", func(ctx context.Context) error { return nil }))
+}
+
+func TestPriority(t *testing.T) {
+	shedder, err := NewShedder("codel(1s, 1s, 1)", "GetCart:1,ListItems:-1")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		shedder.Call(ctx, "Other", func(ctx context.Context) error {
+			close(started)
+			<-block
+			return nil
+		})
+	}()
+	<-started
+
+	var lock sync.Mutex
+	var order []string
+	for i, method := range []string{"ListItems", "Other", "GetCart"} {
+		wg.Add(1)
+		go func(method string) {
+			defer wg.Done()
+			assert.NoError(t, shedder.Call(ctx, method, func(ctx context.Context) error {
+				lock.Lock()
+				defer lock.Unlock()
+				order = append(order, method)
+				return nil
+			}))
+		}(method)
+		// Wait for the call to be queued before making the next call
+		assert.Eventually(t, func() bool {
+			shedder.lock.Lock()
+			defer shedder.lock.Unlock()
+			return len(shedder.waiting) == i+1
+		}, time.Second, time.Millisecond)
+	}
+
+	close(block)
+	wg.Wait()
+	assert.Equal(t, []string{"GetCart", "Other", "ListItems"}, order)
+}
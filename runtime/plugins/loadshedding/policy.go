@@ -0,0 +1,200 @@
+package loadshedding
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parses a policy such as "codel(5ms, 100ms, 100)" or "gradient(50ms, 1000)"; see the package documentation.
+func ParsePolicy(spec string) (Policy, error) {
+	kind, args, hasArgs := strings.Cut(strings.TrimSpace(spec), "(")
+	if !hasArgs || !strings.HasSuffix(args, ")") {
+		return nil, fmt.Errorf("invalid load shedding policy %v", spec)
+	}
+	var params []string
+	for _, arg := range strings.Split(strings.TrimSuffix(args, ")"), ",") {
+		params = append(params, strings.TrimSpace(arg))
+	}
+	p, err := newPolicy(strings.TrimSpace(kind), params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid load shedding policy %v: %w", spec, err)
+	}
+	return p, nil
+}
+
+func newPolicy(kind string, params []string) (Policy, error) {
+	switch kind {
+	case "codel":
+		if len(params) != 3 {
+			return nil, fmt.Errorf("codel expects target, interval, and concurrency but got %v", params)
+		}
+		target, err := parsePositiveDuration(params[0])
+		if err != nil {
+			return nil, err
+		}
+		interval, err := parsePositiveDuration(params[1])
+		if err != nil {
+			return nil, err
+		}
+		if interval < target {
+			return nil, fmt.Errorf("interval %v is less than target %v", interval, target)
+		}
+		concurrency, err := parsePositiveInt(params[2])
+		if err != nil {
+			return nil, err
+		}
+		return &codel{target: target, interval: interval, concurrency: concurrency}, nil
+	case "gradient":
+		if len(params) != 2 {
+			return nil, fmt.Errorf("gradient expects maxWait and maxLimit but got %v", params)
+		}
+		maxWait, err := parsePositiveDuration(params[0])
+		if err != nil {
+			return nil, err
+		}
+		maxLimit, err := parsePositiveInt(params[1])
+		if err != nil {
+			return nil, err
+		}
+		return &gradient{maxWait: maxWait, maxLimit: maxLimit}, nil
+	}
+	return nil, fmt.Errorf("unknown policy %v", kind)
+}
+
+func parsePositiveDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%v must be positive", s)
+	}
+	return d, nil
+}
+
+func parsePositiveInt(s string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if i <= 0 {
+		return 0, fmt.Errorf("%v must be positive", s)
+	}
+	return i, nil
+}
+
+// Facebook's variant of CoDel: calls wait for up to interval, or up to target while the queue is overloaded.  The
+// queue is overloaded if the minimum queueing delay over the previous interval exceeded target.
+type codel struct {
+	target      time.Duration
+	interval    time.Duration
+	concurrency int
+
+	intervalStart time.Time
+	minDelay      time.Duration
+	observed      bool // Whether minDelay has been observed in the current interval
+	overloaded    bool
+}
+
+func (p *codel) initialLimit() float64 {
+	return float64(p.concurrency)
+}
+
+func (p *codel) timeout(now time.Time) time.Duration {
+	p.advance(now)
+	if p.overloaded {
+		return p.target
+	}
+	return p.interval
+}
+
+func (p *codel) observe(now time.Time, delay time.Duration) {
+	p.advance(now)
+	if !p.observed || delay < p.minDelay {
+		p.minDelay = delay
+		p.observed = true
+	}
+}
+
+// Starts a new interval if the current interval has elapsed
+func (p *codel) advance(now time.Time) {
+	if p.intervalStart.IsZero() {
+		p.intervalStart = now
+	}
+	if now.Sub(p.intervalStart) < p.interval {
+		return
+	}
+	// An interval without any calls is not overloaded
+	p.overloaded = p.observed && p.minDelay > p.target
+	p.intervalStart = now
+	p.observed = false
+}
+
+func (p *codel) completed(rtt time.Duration, inFlight int, limit float64) float64 {
+	return limit
+}
+
+func (p *codel) String() string {
+	return fmt.Sprintf("codel(%v, %v, %v)", p.target, p.interval, p.concurrency)
+}
+
+// Parameters of the gradient policy, from Netflix's Gradient2 concurrency limiter
+const (
+	gradientInitialLimit = 20
+	gradientTolerance    = 1.5 // How much the short-term latency can exceed the long-term latency before the limit decreases
+	gradientSmoothing    = 0.2 // How quickly the limit changes
+	gradientLongWindow   = 600 // The number of calls in the long-term average
+	gradientShortWindow  = 10  // The number of calls in the short-term average
+)
+
+// Adapts the limit by comparing the short-term and long-term average latency of calls.  Calls wait in the queue for
+// up to maxWait, which is independent of the limit.
+type gradient struct {
+	maxWait  time.Duration
+	maxLimit int
+
+	shortRTT float64 // Exponential moving averages, in nanoseconds
+	longRTT  float64
+}
+
+func (p *gradient) initialLimit() float64 {
+	return float64(min(gradientInitialLimit, p.maxLimit))
+}
+
+func (p *gradient) timeout(now time.Time) time.Duration {
+	return p.maxWait
+}
+
+func (p *gradient) observe(now time.Time, delay time.Duration) {}
+
+func (p *gradient) completed(rtt time.Duration, inFlight int, limit float64) float64 {
+	sample := float64(max(rtt, time.Nanosecond))
+	if p.longRTT == 0 {
+		p.shortRTT, p.longRTT = sample, sample
+	} else {
+		p.shortRTT += (sample - p.shortRTT) * 2 / (gradientShortWindow + 1)
+		p.longRTT += (sample - p.longRTT) * 2 / (gradientLongWindow + 1)
+	}
+
+	// Recover more quickly if latency has dropped, e.g. after a period of overload
+	if p.longRTT/p.shortRTT > 2 {
+		p.longRTT *= 0.95
+	}
+
+	// Don't grow the limit if it isn't being used
+	if float64(inFlight) < limit/2 {
+		return limit
+	}
+
+	gradient := max(0.5, min(1.0, gradientTolerance*p.longRTT/p.shortRTT))
+	next := limit*gradient + math.Sqrt(limit)
+	next = limit*(1-gradientSmoothing) + next*gradientSmoothing
+	return max(1, min(float64(p.maxLimit), next))
+}
+
+func (p *gradient) String() string {
+	return fmt.Sprintf("gradient(%v, %v)", p.maxWait, p.maxLimit)
+}
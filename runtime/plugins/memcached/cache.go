@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"github.com/bradfitz/gomemcache/memcache"
@@ -34,6 +35,17 @@ func (m *Memcached) Put(ctx context.Context, key string, value interface{}) erro
 	return m.Client.Set(&memcache.Item{Key: key, Value: marshaled_val})
 }
 
+// Implements the backend.Cache interface
+//
+// Memcached expiry times have a granularity of one second, so ttl is rounded up to a whole number of seconds.
+func (m *Memcached) PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	marshaled_val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.Client.Set(&memcache.Item{Key: key, Value: marshaled_val, Expiration: expiration(ttl)})
+}
+
 // Implements the backend.Cache interface
 func (m *Memcached) Get(ctx context.Context, key string, value interface{}) (bool, error) {
 	it, err := m.Client.Get(key)
@@ -57,6 +69,36 @@ func (m *Memcached) Delete(ctx context.Context, key string) error {
 	return m.Client.Delete(key)
 }
 
+// Implements the backend.Cache interface
+func (m *Memcached) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	var err error
+	if ttl <= 0 {
+		err = m.Client.Delete(key)
+	} else {
+		err = m.Client.Touch(key, expiration(ttl))
+	}
+	if err == memcache.ErrCacheMiss {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Memcached interprets expirations of more than 30 days as absolute unix timestamps
+const maxRelativeExpiration = 60 * 60 * 24 * 30
+
+// Converts ttl to a memcached expiration, rounding up to a whole number of seconds.  Returns 0, meaning
+// no expiration, if ttl is zero or negative.
+func expiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	if seconds > maxRelativeExpiration {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	return int32(seconds)
+}
+
 // Implements the backend.Cache interface
 func (m *Memcached) Mget(ctx context.Context, keys []string, values []interface{}) error {
 	val_map, err := m.Client.GetMulti(keys)
@@ -3,6 +3,7 @@ package memcached
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -71,6 +72,38 @@ func TestMemcachedIncr(t *testing.T) {
 	}
 }
 
+func TestMemcachedExpire(t *testing.T) {
+	ctx := context.Background()
+	memcached, err := NewMemcachedClient(ctx, "localhost:11211")
+	if err != nil {
+		t.Error(err)
+	}
+	err = memcached.PutWithTTL(ctx, "ttlKey", 7, time.Second)
+	assert.NoError(t, err)
+	err = memcached.Put(ctx, "expireKey", 8)
+	assert.NoError(t, err)
+	exists, err := memcached.Expire(ctx, "expireKey", time.Second)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	var val int
+	exists, err = memcached.Get(ctx, "ttlKey", &val)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	time.Sleep(2100 * time.Millisecond)
+	exists, err = memcached.Get(ctx, "ttlKey", &val)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = memcached.Get(ctx, "expireKey", &val)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = memcached.Expire(ctx, "expireKey", time.Second)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestMemcachedDelete(t *testing.T) {
 	ctx := context.Background()
 	memcached, err := NewMemcachedClient(ctx, "localhost:11211")
@@ -4,6 +4,7 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"go.mongodb.org/mongo-driver/bson"
@@ -131,6 +132,54 @@ func (mc *MongoCollection) FindMany(ctx context.Context, filter bson.D, projecti
 	return &MongoCursor{underlyingResult: cursor}, nil
 }
 
+// Implements the [backend.NoSQLCollection] interface
+func (mc *MongoCollection) FindManyWithOptions(ctx context.Context, filter bson.D, opts backend.FindOptions) (backend.NoSQLCursor, error) {
+	if opts.Skip < 0 || opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid find options: skip (%v) and limit (%v) must not be negative", opts.Skip, opts.Limit)
+	}
+
+	findOpts := options.Find()
+	if opts.Projection != nil {
+		findOpts.SetProjection(opts.Projection)
+	}
+	if len(opts.Sort) > 0 {
+		findOpts.SetSort(opts.Sort)
+	}
+	if opts.Skip > 0 {
+		findOpts.SetSkip(opts.Skip)
+	}
+	if opts.Limit > 0 {
+		findOpts.SetLimit(opts.Limit)
+	}
+	if opts.BatchSize > 0 {
+		findOpts.SetBatchSize(opts.BatchSize)
+	}
+
+	cursor, err := mc.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Err() != nil {
+		return nil, cursor.Err()
+	}
+
+	return &MongoCursor{underlyingResult: cursor}, nil
+}
+
+// Implements the [backend.NoSQLCollection] interface
+func (mc *MongoCollection) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+	return mc.collection.CountDocuments(ctx, filter)
+}
+
+// Implements the [backend.NoSQLCollection] interface
+func (mc *MongoCollection) Aggregate(ctx context.Context, pipeline []bson.D) (backend.NoSQLCursor, error) {
+	cursor, err := mc.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoCursor{underlyingResult: cursor}, nil
+}
+
 // Implements the [backend.NoSQLCollection] interface
 func (mc *MongoCollection) UpdateOne(ctx context.Context, filter bson.D, update bson.D) (int, error) {
 	result, err := mc.collection.UpdateOne(ctx, filter, update)
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/methodpolicy"
+)
+
+// The limits that apply to calls
+type Limit struct {
+	Rate        float64 // Calls per second; 0 is unlimited
+	Burst       int     // The size of the token bucket, i.e. the number of calls that can be made at once; defaults to 1
+	Concurrency int     // The maximum number of calls in flight; 0 is unlimited
+	Queue       int     // The maximum number of calls waiting for Rate or Concurrency; 0 rejects calls instead of waiting
+}
+
+// Limits for a service, keyed by method name.  The limit for "*" applies to all calls to the service in aggregate,
+// in addition to any limit for the called method.
+type Limits map[string]Limit
+
+// Parses a limit from a comma-separated list of key:value pairs, e.g. "rate:100,burst:10,concurrency:50,queue:100".
+// Omitted keys are 0.
+func ParseLimit(s string) (Limit, error) {
+	var limit Limit
+	if strings.TrimSpace(s) == "" {
+		return limit, nil
+	}
+	err := methodpolicy.ParseFields(s, func(key string, value string) (err error) {
+		switch key {
+		case "rate":
+			limit.Rate, err = strconv.ParseFloat(value, 64)
+		case "burst":
+			limit.Burst, err = strconv.Atoi(value)
+		case "concurrency":
+			limit.Concurrency, err = strconv.Atoi(value)
+		case "queue":
+			limit.Queue, err = strconv.Atoi(value)
+		default:
+			return methodpolicy.ErrUnknownKey
+		}
+		return err
+	})
+	if err != nil {
+		return Limit{}, err
+	}
+	return limit, limit.Validate()
+}
+
+// Returns an error if any of the limit's values are negative
+func (l Limit) Validate() error {
+	if l.Rate < 0 || l.Burst < 0 || l.Concurrency < 0 || l.Queue < 0 {
+		return fmt.Errorf("invalid limit %v; values must not be negative", l)
+	}
+	return nil
+}
+
+// Returns the limit in the format parsed by [ParseLimit]
+func (l Limit) String() string {
+	var fields []string
+	if l.Rate > 0 {
+		fields = append(fields, "rate:"+strconv.FormatFloat(l.Rate, 'g', -1, 64))
+	}
+	if l.Burst > 0 {
+		fields = append(fields, "burst:"+strconv.Itoa(l.Burst))
+	}
+	if l.Concurrency > 0 {
+		fields = append(fields, "concurrency:"+strconv.Itoa(l.Concurrency))
+	}
+	if l.Queue > 0 {
+		fields = append(fields, "queue:"+strconv.Itoa(l.Queue))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Parses limits from a semicolon-separated list of method=limit pairs, where each limit is in the format parsed by
+// [ParseLimit], e.g. "*=rate:100,burst:10;GetCart=concurrency:5".  The empty string is no limits.
+func ParseLimits(s string) (Limits, error) {
+	return methodpolicy.Parse(s, ParseLimit)
+}
+
+// Returns the limits in the format parsed by [ParseLimits], with the limit for "*" first and the remaining methods
+// in alphabetical order.
+func (l Limits) String() string {
+	return methodpolicy.Format(l)
+}
@@ -0,0 +1,219 @@
+// Package ratelimit implements the server-side admission control used by wrappers generated by the Blueprint
+// ratelimit plugin.
+//
+// A [Limiter] enforces a [Limit]: a token-bucket rate limit, a limit on the number of calls in flight, and a bounded
+// queue of calls that wait when either limit is reached.  Calls that cannot be admitted are rejected with a
+// [RejectedError], which can be recognised with [IsRejected].
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the ratelimit plugin is used in a wiring spec.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+)
+
+// Matched by [RejectedError]s with [errors.Is]
+var ErrRejected = errors.New("rejected by rate limiter")
+
+// The error returned for calls that are rejected by a [Limiter]
+type RejectedError struct {
+	Method string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("%v %v: %v", e.Method, ErrRejected.Error(), e.Reason)
+}
+
+func (e *RejectedError) Is(target error) bool {
+	return target == ErrRejected
+}
+
+// Rejections are sent over RPC with code ResourceExhausted, which is HTTP status 429
+func init() {
+	rpcerrors.Register[*RejectedError]("ratelimit.Rejected", rpcerrors.ResourceExhausted)
+}
+
+// Reports whether err is a rejection by a [Limiter], including rejections that are returned by a service that is
+// deployed over RPC.
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrRejected)
+}
+
+// Enforces a [Limit] on calls
+type Limiter struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+
+	inFlight chan struct{} // nil if concurrency is unlimited
+	queue    chan struct{} // nil if calls cannot wait
+}
+
+// Instantiates a [Limiter] for limit
+func NewLimiter(limit Limit) *Limiter {
+	l := &Limiter{
+		rate:  limit.Rate,
+		burst: float64(max(limit.Burst, 1)),
+		now:   time.Now,
+	}
+	l.tokens = l.burst
+	l.last = l.now()
+	if limit.Concurrency > 0 {
+		l.inFlight = make(chan struct{}, limit.Concurrency)
+	}
+	if limit.Queue > 0 {
+		l.queue = make(chan struct{}, limit.Queue)
+	}
+	return l
+}
+
+// Admits a call to method, waiting in the limiter's queue if necessary.  If the call is admitted, the caller must
+// call release once the call completes.  Otherwise, returns a [RejectedError], or the context's error if the caller's
+// context is done while waiting, and the rate token reserved for the call is returned.
+func (l *Limiter) Acquire(ctx context.Context, method string) (release func(), err error) {
+	delay := l.reserve()
+	if delay == 0 && l.tryAcquire() {
+		return l.release, nil
+	}
+
+	reason := "concurrency limit exceeded"
+	if delay > 0 {
+		reason = "rate limit exceeded"
+	}
+	if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) <= delay {
+		l.unreserve()
+		return nil, &RejectedError{method, reason}
+	}
+	select {
+	case l.queue <- struct{}{}:
+		defer func() { <-l.queue }()
+	default:
+		// A nil queue is never ready
+		l.unreserve()
+		return nil, &RejectedError{method, reason}
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			l.unreserve()
+			return nil, ctx.Err()
+		}
+	}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			l.unreserve()
+			return nil, ctx.Err()
+		}
+	}
+	return l.release, nil
+}
+
+// Takes a token from the bucket, returning how long until the token is available.  Every call that does not go on
+// to be admitted must return the token with unreserve.
+func (l *Limiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := l.now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens -= 1
+	if l.tokens >= 0 {
+		return 0
+	}
+	return max(time.Duration(-l.tokens/l.rate*float64(time.Second)), time.Nanosecond)
+}
+
+// Returns a token that was reserved for a call that won't be made
+func (l *Limiter) unreserve() {
+	if l.rate <= 0 {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+func (l *Limiter) tryAcquire() bool {
+	if l.inFlight == nil {
+		return true
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Limiter) release() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// Enforces [Limits] on the calls to a service
+type ServiceLimiter struct {
+	service *Limiter            // nil if there is no limit for "*"
+	methods map[string]*Limiter // limiters of individual methods
+}
+
+// Instantiates a [ServiceLimiter] with the limits parsed by [ParseLimits]
+func NewServiceLimiter(limits string) (*ServiceLimiter, error) {
+	parsed, err := ParseLimits(limits)
+	if err != nil {
+		return nil, err
+	}
+	s := &ServiceLimiter{methods: make(map[string]*Limiter)}
+	for method, limit := range parsed {
+		if method == "*" {
+			s.service = NewLimiter(limit)
+		} else {
+			s.methods[method] = NewLimiter(limit)
+		}
+	}
+	return s, nil
+}
+
+// Calls call if it is admitted by the limiters of method and of the service; otherwise returns the error from
+// [Limiter.Acquire].  If the service's limiter rejects the call, the method's limiter is given back its token.
+func (s *ServiceLimiter) Call(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	limiter, exists := s.methods[method]
+	if exists {
+		release, err := limiter.Acquire(ctx, method)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	if s.service != nil {
+		release, err := s.service.Acquire(ctx, method)
+		if err != nil {
+			if exists {
+				limiter.unreserve()
+			}
+			return err
+		}
+		defer release()
+	}
+	return call(ctx)
+}
@@ -0,0 +1,232 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("GetCart=concurrency:5; *=rate:100,burst:10,queue:20")
+	require.NoError(t, err)
+	assert.Equal(t, Limits{
+		"*":       {Rate: 100, Burst: 10, Queue: 20},
+		"GetCart": {Concurrency: 5},
+	}, limits)
+	assert.Equal(t, "*=rate:100,burst:10,queue:20;GetCart=concurrency:5", limits.String())
+
+	limits, err = ParseLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+
+	for _, invalid := range []string{"rate:100", "*=rate", "*=speed:10", "*=rate:-1", "*=rate:1;*=rate:2", "=rate:1"} {
+		_, err := ParseLimits(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 10, Burst: 2})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		release, err := limiter.Acquire(ctx, "GetCart")
+		require.NoError(t, err)
+		release()
+	}
+	_, err := limiter.Acquire(ctx, "GetCart")
+	assert.True(t, errors.Is(err, ErrRejected))
+	assert.Equal(t, "GetCart rejected by rate limiter: rate limit exceeded", err.Error())
+
+	// Tokens are refilled at the rate
+	now = now.Add(100 * time.Millisecond)
+	release, err := limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+	release()
+	_, err = limiter.Acquire(ctx, "GetCart")
+	assert.True(t, IsRejected(err))
+}
+
+func TestRateLimitQueue(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 100, Queue: 1})
+
+	ctx := context.Background()
+	release, err := limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+	release()
+
+	// The next call waits for a token
+	start := time.Now()
+	release, err = limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+	release()
+	assert.Greater(t, time.Since(start), 5*time.Millisecond)
+
+	// Calls are rejected if the caller's deadline would expire while waiting
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	_, err = limiter.Acquire(ctx, "GetCart")
+	assert.True(t, IsRejected(err))
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	limiter := NewLimiter(Limit{Concurrency: 1, Queue: 1})
+
+	ctx := context.Background()
+	release, err := limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+
+	// The second call waits in the queue, so the third is rejected
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		release, err := limiter.Acquire(ctx, "GetCart")
+		assert.NoError(t, err)
+		release()
+	}()
+	assert.Eventually(t, func() bool { return len(limiter.queue) == 1 }, time.Second, time.Millisecond)
+	_, err = limiter.Acquire(ctx, "GetCart")
+	assert.Equal(t, "GetCart rejected by rate limiter: concurrency limit exceeded", err.Error())
+
+	release()
+	wg.Wait()
+
+	// Waiting calls return when the caller's context is done
+	release, err = limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+	defer release()
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = limiter.Acquire(ctx, "GetCart")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestRejectionsReturnTokens(t *testing.T) {
+	limiter := NewLimiter(Limit{Rate: 0.001, Burst: 2, Concurrency: 1})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+
+	ctx := context.Background()
+	release, err := limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, limiter.tokens)
+
+	// Calls rejected for concurrency don't use a rate token
+	_, err = limiter.Acquire(ctx, "GetCart")
+	assert.Equal(t, "GetCart rejected by rate limiter: concurrency limit exceeded", err.Error())
+	assert.Equal(t, 1.0, limiter.tokens)
+
+	// Nor do calls whose context is done while waiting for concurrency
+	limiter.queue = make(chan struct{}, 1)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = limiter.Acquire(cancelled, "GetCart")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1.0, limiter.tokens)
+
+	release()
+	limiter.queue = nil
+	release, err = limiter.Acquire(ctx, "GetCart")
+	require.NoError(t, err)
+	release()
+}
+
+func TestServiceLimiter(t *testing.T) {
+	limiter, err := NewServiceLimiter("*=concurrency:2;GetCart=concurrency:1")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	blocked := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-block
+		return nil
+	}
+	noop := func(ctx context.Context) error { return nil }
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, limiter.Call(ctx, "GetCart", blocked))
+	}()
+	<-started
+
+	// GetCart is at its own limit, but other methods can still be called
+	assert.True(t, IsRejected(limiter.Call(ctx, "GetCart", noop)))
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, limiter.Call(ctx, "AddItem", blocked))
+	}()
+	<-started
+
+	// The service is at its limit
+	assert.True(t, IsRejected(limiter.Call(ctx, "DeleteCart", noop)))
+
+	close(block)
+	wg.Wait()
+	assert.NoError(t, limiter.Call(ctx, "GetCart", noop))
+}
+
+func TestServiceLimiterReturnsTokens(t *testing.T) {
+	limiter, err := NewServiceLimiter("*=concurrency:1;GetCart=rate:0.001,burst:1")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	block := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, limiter.Call(ctx, "AddItem", func(ctx context.Context) error {
+			close(started)
+			<-block
+			return nil
+		}))
+	}()
+	<-started
+
+	// The service rejects GetCart, which keeps its own rate token for the next call
+	noop := func(ctx context.Context) error { return nil }
+	assert.True(t, IsRejected(limiter.Call(ctx, "GetCart", noop)))
+	close(block)
+	<-done
+	assert.NoError(t, limiter.Call(ctx, "GetCart", noop))
+}
+
+func TestIsRejected(t *testing.T) {
+	assert.False(t, IsRejected(nil))
+	assert.False(t, IsRejected(errors.New("not found")))
+	assert.True(t, IsRejected(fmt.Errorf("call failed: %w", &RejectedError{"GetCart", "rate limit exceeded"})))
+
+	// Messages that look like rejections are not rejections
+	assert.False(t, IsRejected(errors.New("GetCart rejected by rate limiter: rate limit exceeded")))
+}
+
+func TestRejectedOverRPC(t *testing.T) {
+	envelope := rpcerrors.Encode(fmt.Errorf("call failed: %w", &RejectedError{"GetCart", "rate limit exceeded"}))
+	assert.Equal(t, rpcerrors.ResourceExhausted, envelope.Code)
+	assert.Equal(t, http.StatusTooManyRequests, envelope.Code.HTTPStatus())
+
+	// The error that is received by a client
+	err := envelope.Err()
+	assert.True(t, IsRejected(err))
+	var rejected *RejectedError
+	require.True(t, errors.As(err, &rejected))
+	assert.Equal(t, RejectedError{"GetCart", "rate limit exceeded"}, *rejected)
+}
@@ -4,6 +4,7 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	redis_impl "github.com/go-redis/redis/v8"
 )
@@ -34,6 +35,19 @@ func (r *RedisCache) Put(ctx context.Context, key string, value interface{}) err
 	return r.client.Set(ctx, key, val_str, 0).Err()
 }
 
+// Implements the backend.Cache interface
+func (r *RedisCache) PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		// A negative expiration means KEEPTTL to the redis client
+		ttl = 0
+	}
+	return r.client.Set(ctx, key, string(val), ttl).Err()
+}
+
 // Implements the backend.Cache interface
 func (r *RedisCache) Get(ctx context.Context, key string, value interface{}) (bool, error) {
 	val, err := r.client.Get(ctx, key).Result()
@@ -57,6 +71,12 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// Implements the backend.Cache interface
+func (r *RedisCache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	// Redis deletes the key if ttl is not positive
+	return r.client.PExpire(ctx, key, ttl).Result()
+}
+
 // Implements the backend.Cache interface
 func (r *RedisCache) Mget(ctx context.Context, keys []string, values []interface{}) error {
 	result, err := r.client.MGet(ctx, keys...).Result()
@@ -79,6 +79,38 @@ func TestRedisIncr(t *testing.T) {
 	}
 }
 
+func TestRedisExpire(t *testing.T) {
+	ctx := context.Background()
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	if err != nil {
+		t.Error(err)
+	}
+	err = redis.PutWithTTL(ctx, "ttlKey", 7, time.Second)
+	assert.NoError(t, err)
+	err = redis.Put(ctx, "expireKey", 8)
+	assert.NoError(t, err)
+	exists, err := redis.Expire(ctx, "expireKey", time.Second)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	var val int
+	exists, err = redis.Get(ctx, "ttlKey", &val)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	time.Sleep(2100 * time.Millisecond)
+	exists, err = redis.Get(ctx, "ttlKey", &val)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = redis.Get(ctx, "expireKey", &val)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = redis.Expire(ctx, "expireKey", time.Second)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestRedisDelete(t *testing.T) {
 	ctx := context.Background()
 	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
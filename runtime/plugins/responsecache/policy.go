@@ -0,0 +1,100 @@
+package responsecache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/methodpolicy"
+)
+
+// How a method's calls use the cache.  A method with a TTL is cached; a method with invalidations is a write
+// method, whose calls invalidate the cached results of other methods.
+type Policy struct {
+	TTL         time.Duration // Caches the results of the method for this duration
+	Invalidates []string      // The cached methods whose results are invalidated by calls to the method
+}
+
+// Caching policies for a service, keyed by method name.  Methods without a policy are not cached.
+type Policies map[string]Policy
+
+// Parses a policy from a comma-separated list of key:value pairs, e.g. "ttl:30s" or "invalidates:GetCart|ListItems".
+func ParsePolicy(s string) (Policy, error) {
+	var policy Policy
+	err := methodpolicy.ParseFields(s, func(key string, value string) (err error) {
+		switch key {
+		case "ttl":
+			policy.TTL, err = time.ParseDuration(value)
+		case "invalidates":
+			for _, method := range strings.Split(value, "|") {
+				if method = strings.TrimSpace(method); method != "" {
+					policy.Invalidates = append(policy.Invalidates, method)
+				}
+			}
+		default:
+			return methodpolicy.ErrUnknownKey
+		}
+		return err
+	})
+	if err != nil {
+		return Policy{}, err
+	}
+	return policy, policy.Validate()
+}
+
+// Returns an error if the policy neither caches nor invalidates, or its TTL is negative
+func (p Policy) Validate() error {
+	if p.TTL < 0 {
+		return fmt.Errorf("invalid policy %v; the ttl must not be negative", p)
+	}
+	if p.TTL == 0 && len(p.Invalidates) == 0 {
+		return fmt.Errorf("invalid policy %v; expected a ttl or methods to invalidate", p)
+	}
+	return nil
+}
+
+// Returns the policy in the format parsed by [ParsePolicy]
+func (p Policy) String() string {
+	var fields []string
+	if p.TTL != 0 {
+		fields = append(fields, "ttl:"+p.TTL.String())
+	}
+	if len(p.Invalidates) > 0 {
+		fields = append(fields, "invalidates:"+strings.Join(p.Invalidates, "|"))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Parses policies from a semicolon-separated list of method=policy pairs, where each policy is in the format parsed
+// by [ParsePolicy], e.g. "GetCart=ttl:30s;AddItem=invalidates:GetCart".  The invalidated methods are not checked;
+// see [Policies.Validate].
+func ParsePolicies(s string) (Policies, error) {
+	policies, err := methodpolicy.Parse(s, ParsePolicy)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := policies["*"]; exists {
+		return nil, fmt.Errorf("response cache policies must name each method")
+	}
+	return policies, nil
+}
+
+// Returns an error if a policy is invalid, or invalidates a method that is not cached
+func (p Policies) Validate() error {
+	for method, policy := range p {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("invalid policy for %v: %w", method, err)
+		}
+		for _, invalidated := range policy.Invalidates {
+			if p[invalidated].TTL == 0 {
+				return fmt.Errorf("%v invalidates %v, which is not cached", method, invalidated)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the policies in the format parsed by [ParsePolicies], with methods in alphabetical order
+func (p Policies) String() string {
+	return methodpolicy.Format(p)
+}
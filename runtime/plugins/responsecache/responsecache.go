@@ -0,0 +1,179 @@
+// Package responsecache implements the response caching used by server handlers generated by the Blueprint
+// responsecache plugin.
+//
+// The results of a cached method are stored in a [backend.Cache] with a TTL, under a key that is derived from the
+// service, the method, and the JSON-serialized arguments of the call.  Results are JSON-serialized too, so they are
+// cached the same way by every cache implementation.  Calls that fail are not cached.
+//
+// Calls to a write method invalidate the cached results of the methods it lists.  The keys of an invalidated method
+// include a generation that is stored in the cache, and invalidation replaces the generation, so that all of the
+// method's results are invalidated at once and the old entries expire with their TTL.  The generation is shared
+// through the cache, so invalidation applies to every replica of the service.  If the cache evicts or loses a
+// generation, results might be stale for up to their TTL.
+//
+// A cache that is unavailable does not fail calls: lookups that fail are treated as misses.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the responsecache plugin is used in a wiring spec.
+package responsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Caches the results of the methods of a service, used by a server handler
+type ServiceCache struct {
+	service  string
+	cache    backend.Cache
+	policies Policies
+
+	invalidated map[string]bool // The cached methods that are invalidated by a write method, and so have generations
+
+	metrics       backend.LazyInstruments
+	lookups       metric.Int64Counter
+	invalidations metric.Int64Counter
+}
+
+// Instantiates a [ServiceCache] for `service` that stores results in `cache`, with the policies parsed by
+// [ParsePolicies].
+func NewServiceCache(ctx context.Context, service string, cache backend.Cache, policies string) (*ServiceCache, error) {
+	parsed, err := ParsePolicies(policies)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.Validate(); err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		return nil, fmt.Errorf("no cache for the response cache of %v", service)
+	}
+	c := &ServiceCache{service: service, cache: cache, policies: parsed, invalidated: make(map[string]bool)}
+	for _, policy := range parsed {
+		for _, method := range policy.Invalidates {
+			c.invalidated[method] = true
+		}
+	}
+	return c, nil
+}
+
+// Calls call, which stores the results of method in `results`, a slice of pointers.
+//
+// If method is cached, `results` are looked up in the cache with a key derived from `args`, and call is only called
+// on a miss.  If method is a write method, the methods it invalidates are invalidated after call returns, whether or
+// not it succeeds.  Otherwise call is called as-is.
+func (c *ServiceCache) Call(ctx context.Context, method string, args []any, results []any, call func(ctx context.Context) error) error {
+	policy, exists := c.policies[method]
+	if !exists {
+		return call(ctx)
+	}
+
+	c.initMetrics()
+	attributes := []attribute.KeyValue{attribute.String("service", c.service), attribute.String("method", method)}
+
+	var key string
+	if policy.TTL > 0 {
+		var hit bool
+		var err error
+		if key, err = c.key(ctx, method, args); err == nil {
+			hit, err = c.lookup(ctx, key, results)
+		}
+		switch {
+		case err != nil:
+			key = ""
+			c.record(ctx, c.lookups, "error", attributes)
+		case hit:
+			c.record(ctx, c.lookups, "hit", attributes)
+			return nil
+		default:
+			c.record(ctx, c.lookups, "miss", attributes)
+		}
+	}
+
+	err := call(ctx)
+
+	if err == nil && key != "" {
+		if value, err := json.Marshal(results); err == nil {
+			c.cache.PutWithTTL(ctx, key, string(value), policy.TTL)
+		}
+	}
+	for _, invalidated := range policy.Invalidates {
+		outcome := "ok"
+		if err := c.Invalidate(ctx, invalidated); err != nil {
+			outcome = "error"
+		}
+		c.record(ctx, c.invalidations, outcome, []attribute.KeyValue{attribute.String("service", c.service), attribute.String("method", invalidated)})
+	}
+	return err
+}
+
+// Invalidates all of the cached results of method.  Only methods that are invalidated by a write method can be
+// invalidated; for other methods this is a no-op.
+func (c *ServiceCache) Invalidate(ctx context.Context, method string) error {
+	if !c.invalidated[method] {
+		return nil
+	}
+	return c.cache.Put(ctx, c.generationKey(method), time.Now().UnixNano())
+}
+
+// Returns the key of a call to method with args
+func (c *ServiceCache) key(ctx context.Context, method string, args []any) (string, error) {
+	serialized, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	var generation int64
+	if c.invalidated[method] {
+		if _, err := c.cache.Get(ctx, c.generationKey(method), &generation); err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256(serialized)
+	return fmt.Sprintf("responsecache/%v/%v/%v/%v", c.service, method, generation, hex.EncodeToString(sum[:])), nil
+}
+
+func (c *ServiceCache) generationKey(method string) string {
+	return fmt.Sprintf("responsecache/%v/%v/generation", c.service, method)
+}
+
+// Looks up the results stored at key, and reports whether they were found
+func (c *ServiceCache) lookup(ctx context.Context, key string, results []any) (bool, error) {
+	var value string
+	if found, err := c.cache.Get(ctx, key, &value); !found || err != nil {
+		return false, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return false, err
+	}
+	if len(raw) != len(results) {
+		return false, fmt.Errorf("cached %v results for %v but expected %v", len(raw), key, len(results))
+	}
+	for i := range raw {
+		if err := json.Unmarshal(raw[i], results[i]); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c *ServiceCache) initMetrics() {
+	c.metrics.Init("github.com/blueprint-uservices/blueprint/runtime/plugins/responsecache", func(meter metric.Meter) {
+		c.lookups, _ = meter.Int64Counter("responsecache.lookups", metric.WithDescription("Lookups of cached methods by outcome: hit, miss, or error"))
+		c.invalidations, _ = meter.Int64Counter("responsecache.invalidations", metric.WithDescription("Invalidations of cached methods by outcome: ok or error"))
+	})
+}
+
+func (c *ServiceCache) record(ctx context.Context, counter metric.Int64Counter, outcome string, attributes []attribute.KeyValue) {
+	if counter != nil {
+		counter.Add(ctx, 1, metric.WithAttributes(append(attributes, attribute.String("outcome", outcome))...))
+	}
+}
@@ -0,0 +1,165 @@
+package responsecache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies("GetCart=ttl:30s; AddItem=invalidates:GetCart|ListItems;ListItems=ttl:1m")
+	require.NoError(t, err)
+	assert.Equal(t, Policies{
+		"AddItem":   {Invalidates: []string{"GetCart", "ListItems"}},
+		"GetCart":   {TTL: 30 * time.Second},
+		"ListItems": {TTL: time.Minute},
+	}, policies)
+	assert.Equal(t, "AddItem=invalidates:GetCart|ListItems;GetCart=ttl:30s;ListItems=ttl:1m0s", policies.String())
+
+	for _, invalid := range []string{"GetCart", "GetCart=", "*=ttl:1s", "GetCart=ttl:-1s", "GetCart=ttl:soon",
+		"GetCart=ttl:1s,size:2", "GetCart=ttl:1s;GetCart=ttl:2s"} {
+		_, err := ParsePolicies(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// Only cached methods can be invalidated
+	policies, err = ParsePolicies("AddItem=invalidates:GetCart")
+	require.NoError(t, err)
+	assert.Error(t, policies.Validate())
+}
+
+type item struct {
+	Name  string
+	Count int
+}
+
+// A service with a cached method, Get, and a write method, Add
+type service struct {
+	calls int
+	items map[string][]item
+}
+
+func (s *service) Get(ctx context.Context, c *ServiceCache, cart string) (items []item, total int, err error) {
+	err = c.Call(ctx, "Get", []any{cart}, []any{&items, &total}, func(ctx context.Context) error {
+		s.calls++
+		if cart == "" {
+			return errors.New("no cart")
+		}
+		items = s.items[cart]
+		total = len(items)
+		return nil
+	})
+	return
+}
+
+func (s *service) Add(ctx context.Context, c *ServiceCache, cart string, name string) error {
+	return c.Call(ctx, "Add", []any{cart, name}, nil, func(ctx context.Context) error {
+		s.items[cart] = append(s.items[cart], item{Name: name, Count: 1})
+		return nil
+	})
+}
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+	cache, err := simplecache.NewSimpleCache(ctx)
+	require.NoError(t, err)
+	c, err := NewServiceCache(ctx, "cart", cache, "Get=ttl:1m;Add=invalidates:Get")
+	require.NoError(t, err)
+
+	s := &service{items: map[string][]item{"a": {{Name: "sock", Count: 2}}}}
+	for i := 0; i < 3; i++ {
+		items, total, err := s.Get(ctx, c, "a")
+		require.NoError(t, err)
+		assert.Equal(t, []item{{Name: "sock", Count: 2}}, items)
+		assert.Equal(t, 1, total)
+	}
+	assert.Equal(t, 1, s.calls)
+
+	// Keys include the arguments
+	items, _, err := s.Get(ctx, c, "b")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, 2, s.calls)
+
+	// Errors are not cached
+	for i := 0; i < 2; i++ {
+		_, _, err = s.Get(ctx, c, "")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 4, s.calls)
+
+	// Writes invalidate the results for all arguments
+	require.NoError(t, s.Add(ctx, c, "a", "shoe"))
+	items, total, err := s.Get(ctx, c, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, items, 2)
+	s.Get(ctx, c, "b")
+	assert.Equal(t, 6, s.calls)
+	s.Get(ctx, c, "a")
+	assert.Equal(t, 6, s.calls)
+
+	// Invalidation is shared with the other instances that use the cache
+	other, err := NewServiceCache(ctx, "cart", cache, "Get=ttl:1m;Add=invalidates:Get")
+	require.NoError(t, err)
+	require.NoError(t, s.Add(ctx, other, "a", "hat"))
+	_, total, _ = s.Get(ctx, c, "a")
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 7, s.calls)
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, err := simplecache.NewSimpleCache(ctx)
+	require.NoError(t, err)
+	c, err := NewServiceCache(ctx, "cart", cache, "Get=ttl:20ms")
+	require.NoError(t, err)
+
+	s := &service{items: map[string][]item{}}
+	s.Get(ctx, c, "a")
+	s.Get(ctx, c, "a")
+	assert.Equal(t, 1, s.calls)
+	time.Sleep(30 * time.Millisecond)
+	s.Get(ctx, c, "a")
+	assert.Equal(t, 2, s.calls)
+
+	// Methods without a policy are not cached
+	require.NoError(t, s.Add(ctx, c, "a", "sock"))
+	require.NoError(t, s.Add(ctx, c, "a", "sock"))
+	assert.Len(t, s.items["a"], 2)
+}
+
+// A cache whose lookups fail
+type unavailable struct {
+	*simplecache.SimpleCache
+}
+
+func (unavailable) Get(ctx context.Context, key string, val interface{}) (bool, error) {
+	return false, errors.New("unavailable")
+}
+
+func TestUnavailableCache(t *testing.T) {
+	ctx := context.Background()
+	cache, err := simplecache.NewSimpleCache(ctx)
+	require.NoError(t, err)
+	c, err := NewServiceCache(ctx, "cart", unavailable{cache}, "Get=ttl:1m")
+	require.NoError(t, err)
+
+	s := &service{items: map[string][]item{"a": {{Name: "sock"}}}}
+	for i := 0; i < 2; i++ {
+		items, _, err := s.Get(ctx, c, "a")
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	}
+	assert.Equal(t, 2, s.calls)
+
+	_, err = NewServiceCache(ctx, "cart", nil, "Get=ttl:1m")
+	assert.Error(t, err)
+	_, err = NewServiceCache(ctx, "cart", cache, "Add=invalidates:Get")
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package retries
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Determines how long to wait between attempts.
+type Backoff interface {
+	// Returns the delay before retry number attempt, starting from 1 for the first retry.  prev is the
+	// delay that was returned for the previous retry, or 0 for the first retry.
+	Delay(attempt int, prev time.Duration) time.Duration
+}
+
+type (
+	// Waits a fixed delay between attempts
+	constantBackoff struct {
+		delay time.Duration
+	}
+
+	// Doubles the delay after each attempt, starting from base, up to max
+	exponentialBackoff struct {
+		base time.Duration
+		max  time.Duration
+	}
+
+	// The "decorrelated jitter" policy: a random delay between base and three times the previous delay, up to max
+	decorrelatedJitter struct {
+		base time.Duration
+		max  time.Duration
+	}
+)
+
+// Policy names accepted by [NewBackoff]
+const (
+	NoBackoffPolicy          = "none"
+	ConstantBackoffPolicy    = "constant"
+	ExponentialBackoffPolicy = "exponential"
+	DecorrelatedJitterPolicy = "decorrelated_jitter"
+)
+
+// Instantiates a [Backoff] from its policy name and duration strings such as "10ms".
+//   - [NoBackoffPolicy] retries immediately; base and max are ignored
+//   - [ConstantBackoffPolicy] waits base between attempts; max is ignored
+//   - [ExponentialBackoffPolicy] waits base, then doubles the delay after each attempt, up to max
+//   - [DecorrelatedJitterPolicy] waits a random delay between base and three times the previous delay, up to max
+func NewBackoff(policy string, base string, max string) (Backoff, error) {
+	if policy == NoBackoffPolicy || policy == "" {
+		return constantBackoff{}, nil
+	}
+	baseDelay, err := parseDelay(base)
+	if err != nil {
+		return nil, err
+	}
+	if policy == ConstantBackoffPolicy {
+		return constantBackoff{baseDelay}, nil
+	}
+	maxDelay, err := parseDelay(max)
+	if err != nil {
+		return nil, err
+	}
+	if maxDelay < baseDelay {
+		return nil, fmt.Errorf("max backoff %v is less than base backoff %v", max, base)
+	}
+	switch policy {
+	case ExponentialBackoffPolicy:
+		return exponentialBackoff{baseDelay, maxDelay}, nil
+	case DecorrelatedJitterPolicy:
+		return decorrelatedJitter{baseDelay, maxDelay}, nil
+	}
+	return nil, fmt.Errorf("unknown backoff policy %v", policy)
+}
+
+func parseDelay(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid backoff %v", s)
+	}
+	return d, nil
+}
+
+func (b constantBackoff) Delay(attempt int, prev time.Duration) time.Duration {
+	return b.delay
+}
+
+func (b exponentialBackoff) Delay(attempt int, prev time.Duration) time.Duration {
+	delay := b.base
+	for i := 1; i < attempt && delay < b.max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.max)
+}
+
+func (b decorrelatedJitter) Delay(attempt int, prev time.Duration) time.Duration {
+	upper := max(prev, b.base) * 3
+	if upper <= b.base {
+		return min(b.base, b.max)
+	}
+	delay := b.base + time.Duration(rand.Int63n(int64(upper-b.base)))
+	return min(delay, b.max)
+}
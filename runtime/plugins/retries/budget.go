@@ -0,0 +1,43 @@
+package retries
+
+import "sync"
+
+// Limits the ratio of retries to calls, so that retries can't amplify the load on a struggling service.
+//
+// The budget is a token bucket shared by all callers of a [Retrier].  Each call deposits ratio tokens and
+// each retry withdraws one token; retries are not attempted when the bucket is empty.  The bucket holds at
+// most burst tokens and starts full, so that a small number of retries are allowed before any calls have
+// been made.
+type Budget struct {
+	lock   sync.Mutex
+	ratio  float64
+	burst  float64
+	tokens float64
+}
+
+// Instantiates a [Budget] that allows approximately ratio retries per call, e.g. 0.1 allows a 10% increase
+// in load, plus up to burst retries in excess of the ratio.
+func NewBudget(ratio float64, burst int64) *Budget {
+	return &Budget{
+		ratio:  ratio,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+func (b *Budget) deposit() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.burst)
+}
+
+// Reports whether a retry is allowed, in which case a token is withdrawn
+func (b *Budget) withdraw() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens -= 1
+	return true
+}
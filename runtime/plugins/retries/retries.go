@@ -0,0 +1,119 @@
+// Package retries implements the retry logic used by clients generated by the Blueprint retries plugin.
+//
+// A [Retrier] retries failed calls with a [Backoff] between attempts.  Only errors that are classified as
+// retryable by [IsRetryable] are retried, and an optional [Budget] limits the ratio of retries to calls across
+// all callers that share the Retrier.  Retries stop early if the caller's context is done or if the caller's
+// deadline would expire before the next attempt.  An optional overall deadline bounds the total duration of a call,
+// including all of its attempts and the backoff between them.
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the retries plugin is used in a wiring spec.
+package retries
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+)
+
+// Retries calls that fail with a retryable error.
+type Retrier struct {
+	MaxTries  int              // The maximum number of attempts for a call, including the first attempt
+	Backoff   Backoff          // The delay between attempts
+	Budget    *Budget          // If non-nil, limits the ratio of retries to calls
+	Retryable func(error) bool // Classifies errors; only retryable errors are retried
+	Deadline  time.Duration    // If non-zero, the maximum duration of a call including all attempts
+}
+
+// Instantiates a [Retrier] that makes at most maxTries attempts for each call, waiting between attempts
+// according to backoff.  budget is optional and can be nil.  Errors are classified with [IsRetryable].
+func NewRetrier(maxTries int64, backoff Backoff, budget *Budget) *Retrier {
+	return &Retrier{
+		MaxTries:  int(maxTries),
+		Backoff:   backoff,
+		Budget:    budget,
+		Retryable: IsRetryable,
+	}
+}
+
+// Calls call until it succeeds, it returns an error that isn't retryable, or no more retries are
+// allowed.  Returns the error of the final attempt.
+func (r *Retrier) Do(ctx context.Context, call func(ctx context.Context) error) error {
+	if r.Budget != nil {
+		r.Budget.deposit()
+	}
+	if r.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Deadline)
+		defer cancel()
+	}
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := call(ctx)
+		if err == nil || attempt >= r.MaxTries || !r.Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		// Don't retry if the caller would give up before the next attempt
+		delay = r.Backoff.Delay(attempt, delay)
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) <= delay {
+			return err
+		}
+		if r.Budget != nil && !r.Budget.withdraw() {
+			return err
+		}
+		if !sleep(ctx, delay) {
+			return err
+		}
+	}
+}
+
+// Waits for d or until ctx is done.  Reports whether d elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Reports whether err is a transport or timeout error that might succeed if retried.  Errors returned by
+// the called service's business logic are not retryable.
+//
+// The following errors are retryable:
+//   - [context.DeadlineExceeded], e.g. from a timeout on the client
+//   - [net.Error] and [url.Error], e.g. if a connection is refused
+//   - errors whose [rpcerrors.Code] is Unavailable, DeadlineExceeded, ResourceExhausted, or Aborted, e.g. the
+//     errors that gRPC clients return for those statuses
+//   - rejections by a server-side rate limiter, as reported by [ratelimit.IsRejected]
+//
+// [context.Canceled] is not retryable.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) || ratelimit.IsRejected(err) {
+		return true
+	}
+	switch rpcerrors.CodeOf(err) {
+	case rpcerrors.DeadlineExceeded, rpcerrors.ResourceExhausted, rpcerrors.Aborted, rpcerrors.Unavailable:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package retries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/rpcerrors"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.False(t, IsRetryable(nil))
+	assert.False(t, IsRetryable(errors.New("user not found")))
+	assert.False(t, IsRetryable(context.Canceled))
+	assert.True(t, IsRetryable(context.DeadlineExceeded))
+	assert.True(t, IsRetryable(fmt.Errorf("request was timed out: %w", context.DeadlineExceeded)))
+	assert.True(t, IsRetryable(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+
+	// The errors that gRPC clients return for transport statuses
+	assert.True(t, IsRetryable(rpcerrors.FromStatus(rpcerrors.Unavailable, "connection refused", nil)))
+	assert.True(t, IsRetryable(rpcerrors.FromStatus(rpcerrors.ResourceExhausted, "too many requests", nil)))
+	assert.True(t, IsRetryable(rpcerrors.FromStatus(rpcerrors.Aborted, "aborted", nil)))
+	assert.True(t, IsRetryable(fmt.Errorf("wrapped: %w", rpcerrors.FromStatus(rpcerrors.DeadlineExceeded, "timed out", nil))))
+
+	// Errors returned by a service's business logic have other codes
+	assert.False(t, IsRetryable(rpcerrors.FromStatus(rpcerrors.Unknown, "user not found", nil)))
+	assert.False(t, IsRetryable(rpcerrors.New(rpcerrors.NotFound, "user not found")))
+
+	assert.True(t, IsRetryable(&ratelimit.RejectedError{Method: "GetCart", Reason: "rate limit exceeded"}))
+	assert.True(t, IsRetryable(rpcerrors.Encode(&ratelimit.RejectedError{Method: "GetCart", Reason: "shed"}).Err()))
+}
+
+func TestBackoff(t *testing.T) {
+	none, err := NewBackoff(NoBackoffPolicy, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), none.Delay(3, 0))
+
+	constant, err := NewBackoff(ConstantBackoffPolicy, "10ms", "")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Millisecond, constant.Delay(3, 10*time.Millisecond))
+
+	exponential, err := NewBackoff(ExponentialBackoffPolicy, "10ms", "50ms")
+	require.NoError(t, err)
+	var delays []time.Duration
+	var prev time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		prev = exponential.Delay(attempt, prev)
+		delays = append(delays, prev)
+	}
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}, delays)
+
+	jitter, err := NewBackoff(DecorrelatedJitterPolicy, "10ms", "50ms")
+	require.NoError(t, err)
+	prev = 0
+	for attempt := 1; attempt <= 100; attempt++ {
+		delay := jitter.Delay(attempt, prev)
+		assert.GreaterOrEqual(t, delay, 10*time.Millisecond)
+		assert.LessOrEqual(t, delay, 50*time.Millisecond)
+		assert.LessOrEqual(t, delay, max(prev, 10*time.Millisecond)*3)
+		prev = delay
+	}
+
+	_, err = NewBackoff("linear", "10ms", "50ms")
+	assert.Error(t, err)
+	_, err = NewBackoff(ExponentialBackoffPolicy, "10ms", "5ms")
+	assert.Error(t, err)
+	_, err = NewBackoff(ConstantBackoffPolicy, "soon", "")
+	assert.Error(t, err)
+}
+
+// Returns a call that fails with err the first failures times it is called
+func failing(failures int, err error) (func(ctx context.Context) error, *int) {
+	calls := 0
+	return func(ctx context.Context) error {
+		calls++
+		if calls <= failures {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestRetrier(t *testing.T) {
+	ctx := context.Background()
+	backoff, _ := NewBackoff(ConstantBackoffPolicy, "1ms", "")
+	r := NewRetrier(3, backoff, nil)
+
+	call, calls := failing(2, context.DeadlineExceeded)
+	assert.NoError(t, r.Do(ctx, call))
+	assert.Equal(t, 3, *calls)
+
+	call, calls = failing(5, context.DeadlineExceeded)
+	assert.ErrorIs(t, r.Do(ctx, call), context.DeadlineExceeded)
+	assert.Equal(t, 3, *calls)
+
+	// Business errors aren't retried
+	call, calls = failing(5, errors.New("out of stock"))
+	assert.Error(t, r.Do(ctx, call))
+	assert.Equal(t, 1, *calls)
+
+	// At least one attempt is always made
+	call, calls = failing(0, nil)
+	assert.NoError(t, NewRetrier(0, backoff, nil).Do(ctx, call))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetrierRespectsDeadline(t *testing.T) {
+	backoff, _ := NewBackoff(ConstantBackoffPolicy, "1s", "")
+	r := NewRetrier(10, backoff, nil)
+
+	// The next attempt would be after the caller's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	call, calls := failing(5, context.DeadlineExceeded)
+	start := time.Now()
+	assert.Error(t, r.Do(ctx, call))
+	assert.Equal(t, 1, *calls)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// The caller gives up while waiting to retry
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	call, calls = failing(5, context.DeadlineExceeded)
+	assert.Error(t, r.Do(ctx, call))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetrierDeadline(t *testing.T) {
+	backoff, _ := NewBackoff(NoBackoffPolicy, "", "")
+	r := NewRetrier(10, backoff, nil)
+	r.Deadline = 100 * time.Millisecond
+
+	// Attempts that time out are retried until the overall deadline
+	calls := 0
+	start := time.Now()
+	err := r.Do(context.Background(), func(ctx context.Context) error {
+		calls++
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 4, calls)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestBudget(t *testing.T) {
+	ctx := context.Background()
+	backoff, _ := NewBackoff(NoBackoffPolicy, "", "")
+	r := NewRetrier(3, backoff, NewBudget(0.5, 2))
+
+	// The initial burst allows two retries
+	call, calls := failing(5, context.DeadlineExceeded)
+	assert.Error(t, r.Do(ctx, call))
+	assert.Equal(t, 3, *calls)
+
+	// The budget is empty
+	call, calls = failing(5, context.DeadlineExceeded)
+	assert.Error(t, r.Do(ctx, call))
+	assert.Equal(t, 1, *calls)
+
+	// Successful calls refill the budget
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, r.Do(ctx, func(ctx context.Context) error { return nil }))
+	}
+	call, calls = failing(5, context.DeadlineExceeded)
+	assert.Error(t, r.Do(ctx, call))
+	assert.Equal(t, 3, *calls)
+}
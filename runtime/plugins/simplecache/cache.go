@@ -1,40 +1,91 @@
 // Package simplecache implements a key-value [backend.Cache] using a golang map.
+//
+// Keys with a TTL are expired lazily, when they are next accessed, and also periodically in the background.
+// Optionally the cache can be bounded to a maximum number of entries, in which case the least recently
+// used entries are evicted; see [SimpleCache.SetMaxEntries].
 package simplecache
 
 import (
+	"container/list"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 )
 
+// How often expired keys are removed in the background
+const expiryInterval = time.Second
+
 // A simple map-based cache that implements the [backend.Cache] interface
+//
+// The cache's contents can be persisted with the [snapshot] package; values are saved as JSON.
+//
+// [snapshot]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/snapshot
 type SimpleCache struct {
 	backend.Cache
 	sync.RWMutex
-	values map[string]any
+	entries    map[string]*list.Element // Elements of lru
+	lru        *list.List               // Entries ordered from most to least recently used
+	maxEntries int                      // If positive, the maximum number of entries before evicting
+	now        func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   any       // Values loaded from a snapshot are json.RawMessage until they are next Put
+	expires time.Time // Zero if the entry never expires
 }
 
-// Instantiates a map-based [SimpleCache]
+// Instantiates a map-based [SimpleCache] with no maximum number of entries.
+//
+// Expired keys are removed in the background until ctx is cancelled.
 func NewSimpleCache(ctx context.Context) (*SimpleCache, error) {
-	cache := &SimpleCache{}
-	cache.values = make(map[string]any)
+	cache := &SimpleCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+	go cache.expireInBackground(ctx)
 	return cache, nil
 }
 
+// Bounds the cache to at most maxEntries entries; when the bound is exceeded, the least recently used
+// entries are evicted.  If maxEntries is zero or negative, the cache is unbounded.
+func (cache *SimpleCache) SetMaxEntries(maxEntries int) {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.maxEntries = maxEntries
+	cache.evict()
+}
+
 func (cache *SimpleCache) Put(ctx context.Context, key string, value interface{}) error {
+	return cache.PutWithTTL(ctx, key, value, 0)
+}
+
+func (cache *SimpleCache) PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	cache.Lock()
 	defer cache.Unlock()
-	cache.values[key] = value
+	cache.put(key, value, cache.expiry(ttl))
 	return nil
 }
 
 func (cache *SimpleCache) Get(ctx context.Context, key string, val interface{}) (bool, error) {
-	if v, exists := cache.values[key]; exists {
-		return true, backend.CopyResult(v, val)
+	cache.Lock()
+	e := cache.lookup(key)
+	var v any
+	if e != nil {
+		v = e.value
+	}
+	cache.Unlock()
+	if e == nil {
+		return false, nil
 	}
-	return false, nil
+	return true, decode(v, val)
 }
 
 func (cache *SimpleCache) Mset(ctx context.Context, keys []string, values []interface{}) error {
@@ -68,16 +119,193 @@ func (cache *SimpleCache) Mget(ctx context.Context, keys []string, values []inte
 func (cache *SimpleCache) Delete(ctx context.Context, key string) error {
 	cache.Lock()
 	defer cache.Unlock()
-	delete(cache.values, key)
+	if elem, exists := cache.entries[key]; exists {
+		cache.remove(elem)
+	}
 	return nil
 }
 
+func (cache *SimpleCache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	e := cache.lookup(key)
+	if e == nil {
+		return false, nil
+	}
+	if ttl <= 0 {
+		cache.remove(cache.entries[key])
+	} else {
+		e.expires = cache.expiry(ttl)
+	}
+	return true, nil
+}
+
 func (cache *SimpleCache) Incr(ctx context.Context, key string) (int64, error) {
+	cache.Lock()
+	defer cache.Unlock()
 	cur := int64(0)
-	_, err := cache.Get(ctx, key, &cur)
-	if err != nil {
-		return cur, err
+	e := cache.lookup(key)
+	if e != nil {
+		if err := decode(e.value, &cur); err != nil {
+			return cur, err
+		}
 	}
 	cur += 1
-	return cur, cache.Put(ctx, key, cur)
+	if e != nil {
+		e.value = cur
+	} else {
+		cache.put(key, cur, time.Time{})
+	}
+	return cur, nil
+}
+
+// Converts a ttl to an expiry time.  Returns the zero time if ttl is zero or negative.
+func (cache *SimpleCache) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return cache.now().Add(ttl)
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// Returns the entry for key and marks it as most recently used.  Expired entries are removed and not
+// returned.  Must be called with the lock held.
+func (cache *SimpleCache) lookup(key string) *entry {
+	elem, exists := cache.entries[key]
+	if !exists {
+		return nil
+	}
+	e := elem.Value.(*entry)
+	if e.expired(cache.now()) {
+		cache.remove(elem)
+		return nil
+	}
+	cache.lru.MoveToFront(elem)
+	return e
+}
+
+// Must be called with the lock held.
+func (cache *SimpleCache) put(key string, value any, expires time.Time) {
+	if elem, exists := cache.entries[key]; exists {
+		e := elem.Value.(*entry)
+		e.value = value
+		e.expires = expires
+		cache.lru.MoveToFront(elem)
+		return
+	}
+	cache.entries[key] = cache.lru.PushFront(&entry{key: key, value: value, expires: expires})
+	cache.evict()
+}
+
+// Must be called with the lock held.
+func (cache *SimpleCache) remove(elem *list.Element) {
+	cache.lru.Remove(elem)
+	delete(cache.entries, elem.Value.(*entry).key)
+}
+
+// Evicts least recently used entries until the cache is within its maximum size.  Must be called with
+// the lock held.
+func (cache *SimpleCache) evict() {
+	for cache.maxEntries > 0 && cache.lru.Len() > cache.maxEntries {
+		cache.remove(cache.lru.Back())
+	}
+}
+
+// Removes all expired entries
+func (cache *SimpleCache) removeExpired() {
+	cache.Lock()
+	defer cache.Unlock()
+	now := cache.now()
+	for elem := cache.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*entry).expired(now) {
+			cache.remove(elem)
+		}
+		elem = next
+	}
+}
+
+func (cache *SimpleCache) expireInBackground(ctx context.Context) {
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			cache.removeExpired()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func decode(v any, dst any) error {
+	if raw, isRaw := v.(json.RawMessage); isRaw {
+		return json.Unmarshal(raw, dst)
+	}
+	return backend.CopyResult(v, dst)
+}
+
+type cacheSnapshot struct {
+	Values  map[string]json.RawMessage `json:"values"`
+	Expires map[string]time.Time       `json:"expires,omitempty"`
+}
+
+// Writes the contents of the cache to w as a JSON object, e.g.
+//
+//	{"values": {"a": 1, "b": "hello"}, "expires": {"b": "2024-01-01T00:00:00Z"}}
+//
+// Used for snapshots.  Expiry times are absolute, so keys that expire while the cache is not running
+// are not restored.
+func (cache *SimpleCache) Save(ctx context.Context, w io.Writer) error {
+	cache.RLock()
+	defer cache.RUnlock()
+	snapshot := cacheSnapshot{Values: make(map[string]json.RawMessage), Expires: make(map[string]time.Time)}
+	now := cache.now()
+	for key, elem := range cache.entries {
+		e := elem.Value.(*entry)
+		if e.expired(now) {
+			continue
+		}
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return err
+		}
+		snapshot.Values[key] = value
+		if !e.expires.IsZero() {
+			snapshot.Expires[key] = e.expires
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// Replaces the contents of the cache with a JSON object read from r.  Used for snapshots.
+func (cache *SimpleCache) Load(ctx context.Context, r io.Reader) error {
+	var snapshot cacheSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(snapshot.Values))
+	for key := range snapshot.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	cache.Lock()
+	defer cache.Unlock()
+	cache.entries = make(map[string]*list.Element, len(keys))
+	cache.lru.Init()
+	now := cache.now()
+	for _, key := range keys {
+		e := &entry{key: key, value: snapshot.Values[key], expires: snapshot.Expires[key]}
+		if !e.expired(now) {
+			cache.entries[key] = cache.lru.PushFront(e)
+		}
+	}
+	cache.evict()
+	return nil
 }
@@ -1,8 +1,10 @@
 package simplecache
 
 import (
+	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -73,3 +75,202 @@ func TestMget(t *testing.T) {
 	err = cache.Mget(ctx, []string{}, getvalues)
 	assert.Error(t, err)
 }
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := NewSimpleCache(ctx)
+
+	type point struct {
+		X, Y int
+	}
+	assert.NoError(t, cache.Put(ctx, "str", "hello"))
+	assert.NoError(t, cache.Put(ctx, "point", point{1, 2}))
+	_, err := cache.Incr(ctx, "counter")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.Save(ctx, &buf))
+
+	restored, _ := NewSimpleCache(ctx)
+	assert.NoError(t, restored.Put(ctx, "stale", "value"))
+	assert.NoError(t, restored.Load(ctx, &buf))
+
+	var s string
+	exists, err := restored.Get(ctx, "str", &s)
+	assert.True(t, exists)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", s)
+
+	var p point
+	exists, err = restored.Get(ctx, "point", &p)
+	assert.True(t, exists)
+	assert.NoError(t, err)
+	assert.Equal(t, point{1, 2}, p)
+
+	counter, err := restored.Incr(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), counter)
+
+	exists, err = restored.Get(ctx, "stale", &s)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// A fake clock for testing expiry
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestCache(t *testing.T) (*SimpleCache, *clock) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	cache, err := NewSimpleCache(ctx)
+	assert.NoError(t, err)
+	c := &clock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache.now = c.now
+	return cache, c
+}
+
+func TestPutWithTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, clock := newTestCache(t)
+
+	assert.NoError(t, cache.PutWithTTL(ctx, "short", "a", time.Second))
+	assert.NoError(t, cache.PutWithTTL(ctx, "long", "b", time.Minute))
+	assert.NoError(t, cache.PutWithTTL(ctx, "forever", "c", 0))
+
+	var v string
+	exists, err := cache.Get(ctx, "short", &v)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "a", v)
+
+	// Expired lazily on Get
+	clock.advance(time.Second)
+	exists, err = cache.Get(ctx, "short", &v)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Expired by the background sweep
+	clock.advance(time.Hour)
+	cache.removeExpired()
+	assert.Len(t, cache.entries, 1)
+
+	exists, err = cache.Get(ctx, "forever", &v)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "c", v)
+
+	// Put clears the TTL
+	assert.NoError(t, cache.PutWithTTL(ctx, "key", "d", time.Second))
+	assert.NoError(t, cache.Put(ctx, "key", "e"))
+	clock.advance(time.Minute)
+	exists, err = cache.Get(ctx, "key", &v)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestExpire(t *testing.T) {
+	ctx := context.Background()
+	cache, clock := newTestCache(t)
+
+	exists, err := cache.Expire(ctx, "missing", time.Second)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, cache.Put(ctx, "key", "value"))
+	exists, err = cache.Expire(ctx, "key", time.Second)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// Incr doesn't change the expiry
+	_, err = cache.Incr(ctx, "counter")
+	assert.NoError(t, err)
+	_, err = cache.Expire(ctx, "counter", time.Second)
+	assert.NoError(t, err)
+	count, err := cache.Incr(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	clock.advance(time.Second)
+	var v string
+	exists, err = cache.Get(ctx, "key", &v)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	count, err = cache.Incr(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// A non-positive ttl deletes the key
+	assert.NoError(t, cache.Put(ctx, "key", "value"))
+	exists, err = cache.Expire(ctx, "key", 0)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = cache.Get(ctx, "key", &v)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestCache(t)
+	cache.SetMaxEntries(2)
+
+	assert.NoError(t, cache.Put(ctx, "a", 1))
+	assert.NoError(t, cache.Put(ctx, "b", 2))
+
+	// Touch a so that b is the least recently used
+	var v int
+	exists, err := cache.Get(ctx, "a", &v)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.NoError(t, cache.Put(ctx, "c", 3))
+	for key, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		exists, err := cache.Get(ctx, key, &v)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, exists, key)
+	}
+
+	// Shrinking the cache evicts immediately
+	cache.SetMaxEntries(1)
+	assert.Len(t, cache.entries, 1)
+	exists, err = cache.Get(ctx, "c", &v)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestSnapshotWithTTL(t *testing.T) {
+	ctx := context.Background()
+	cache, clock := newTestCache(t)
+
+	assert.NoError(t, cache.PutWithTTL(ctx, "short", "a", time.Second))
+	assert.NoError(t, cache.PutWithTTL(ctx, "long", "b", time.Minute))
+	assert.NoError(t, cache.Put(ctx, "forever", "c"))
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.Save(ctx, &buf))
+
+	restored, restoredClock := newTestCache(t)
+	restoredClock.t = clock.t.Add(30 * time.Second)
+	assert.NoError(t, restored.Load(ctx, &buf))
+	assert.Len(t, restored.entries, 2)
+
+	restoredClock.advance(30 * time.Second)
+	var v string
+	exists, err := restored.Get(ctx, "long", &v)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = restored.Get(ctx, "forever", &v)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, "c", v)
+}
@@ -0,0 +1,203 @@
+package simplenosqldb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func aggregate(t *testing.T, ctx context.Context, coll *simplenosqldb.SimpleCollection, pipeline ...bson.D) []bson.D {
+	cursor, err := coll.Aggregate(ctx, pipeline)
+	require.NoError(t, err)
+	var results []bson.D
+	require.NoError(t, cursor.All(ctx, &results))
+	return results
+}
+
+func TestAggregateMatchSortSkipLimit(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t)
+	results := aggregate(t, ctx, coll,
+		bson.D{{Key: "$match", Value: bson.D{{Key: "rating", Value: bson.D{{Key: "$gte", Value: 6}}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "rating", Value: -1}}}},
+		bson.D{{Key: "$skip", Value: 1}},
+		bson.D{{Key: "$limit", Value: 2}},
+		bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 0}, {Key: "type", Value: 1}}}},
+	)
+	assert.Equal(t, []bson.D{
+		{{Key: "type", Value: "Earl Grey"}},
+		{{Key: "type", Value: "Oolong"}},
+	}, results)
+}
+
+func TestAggregateProject(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t)
+	match := bson.D{{Key: "$match", Value: bson.D{{Key: "type", Value: "Assam"}}}}
+
+	// Inclusion of nested fields and computed fields
+	results := aggregate(t, ctx, coll, match, bson.D{{Key: "$project", Value: bson.D{
+		{Key: "_id", Value: false},
+		{Key: "packaging.kind", Value: 1},
+		{Key: "name", Value: "$type"},
+		{Key: "info", Value: bson.D{{Key: "stars", Value: "$rating"}, {Key: "note", Value: bson.D{{Key: "$literal", Value: "$cheap"}}}}},
+	}}})
+	assert.Equal(t, []bson.D{{
+		{Key: "packaging", Value: bson.D{{Key: "kind", Value: "Cardboard"}}},
+		{Key: "name", Value: "Assam"},
+		{Key: "info", Value: bson.D{{Key: "stars", Value: int32(5)}, {Key: "note", Value: "$cheap"}}},
+	}}, results)
+
+	// Exclusion
+	results = aggregate(t, ctx, coll, match, bson.D{{Key: "$project", Value: bson.D{
+		{Key: "_id", Value: 0}, {Key: "packaging", Value: 0}, {Key: "sizes", Value: 0},
+	}}})
+	assert.Equal(t, []bson.D{{{Key: "type", Value: "Assam"}, {Key: "rating", Value: int32(5)}}}, results)
+
+	// Mixing inclusion and exclusion is an error
+	_, err := coll.Aggregate(ctx, []bson.D{{{Key: "$project", Value: bson.D{{Key: "type", Value: 1}, {Key: "rating", Value: 0}}}}})
+	assert.Error(t, err)
+}
+
+func TestAggregateGroup(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t)
+	results := aggregate(t, ctx, coll,
+		bson.D{{Key: "$unwind", Value: "$sizes"}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$sizes"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$rating"}}},
+			{Key: "avg", Value: bson.D{{Key: "$avg", Value: "$rating"}}},
+			{Key: "min", Value: bson.D{{Key: "$min", Value: "$type"}}},
+			{Key: "max", Value: bson.D{{Key: "$max", Value: "$rating"}}},
+			{Key: "types", Value: bson.D{{Key: "$push", Value: "$type"}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	)
+	assert.Equal(t, []bson.D{
+		{{Key: "_id", Value: int32(4)}, {Key: "count", Value: int32(2)}, {Key: "total", Value: int32(16)}, {Key: "avg", Value: 8.0},
+			{Key: "min", Value: "English Breakfast"}, {Key: "max", Value: int32(10)}, {Key: "types", Value: bson.A{"Masala", "English Breakfast"}}},
+		{{Key: "_id", Value: int32(8)}, {Key: "count", Value: int32(2)}, {Key: "total", Value: int32(13)}, {Key: "avg", Value: 6.5},
+			{Key: "min", Value: "English Breakfast"}, {Key: "max", Value: int32(7)}, {Key: "types", Value: bson.A{"English Breakfast", "Oolong"}}},
+		{{Key: "_id", Value: int32(16)}, {Key: "count", Value: int32(3)}, {Key: "total", Value: int32(18)}, {Key: "avg", Value: 6.0},
+			{Key: "min", Value: "Assam"}, {Key: "max", Value: int32(7)}, {Key: "types", Value: bson.A{"English Breakfast", "Oolong", "Assam"}}},
+		{{Key: "_id", Value: int32(32)}, {Key: "count", Value: int32(1)}, {Key: "total", Value: int32(8)}, {Key: "avg", Value: 8.0},
+			{Key: "min", Value: "Earl Grey"}, {Key: "max", Value: int32(8)}, {Key: "types", Value: bson.A{"Earl Grey"}}},
+	}, results)
+
+	// A null _id groups all documents together
+	results = aggregate(t, ctx, coll, bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: nil},
+		{Key: "total", Value: bson.D{{Key: "$sum", Value: "$rating"}}},
+	}}})
+	assert.Equal(t, []bson.D{{{Key: "_id", Value: nil}, {Key: "total", Value: int32(36)}}}, results)
+}
+
+func TestAggregateUnwind(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t)
+	project := bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 0}, {Key: "type", Value: 1}, {Key: "vendor", Value: 1}, {Key: "idx", Value: 1}}}}
+
+	results := aggregate(t, ctx, coll, bson.D{{Key: "$unwind", Value: "$vendor"}}, project)
+	assert.Equal(t, []bson.D{
+		{{Key: "type", Value: "Masala"}, {Key: "vendor", Value: "A"}},
+		{{Key: "type", Value: "Masala"}, {Key: "vendor", Value: "C"}},
+		{{Key: "type", Value: "Oolong"}, {Key: "vendor", Value: "C"}},
+		{{Key: "type", Value: "Earl Grey"}, {Key: "vendor", Value: "A"}},
+		{{Key: "type", Value: "Earl Grey"}, {Key: "vendor", Value: "B"}},
+	}, results)
+
+	results = aggregate(t, ctx, coll,
+		bson.D{{Key: "$match", Value: bson.D{{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{"Oolong", "Assam"}}}}}}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$vendor"}, {Key: "includeArrayIndex", Value: "idx"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+		project,
+	)
+	assert.Equal(t, []bson.D{
+		{{Key: "type", Value: "Oolong"}, {Key: "vendor", Value: "C"}, {Key: "idx", Value: int64(0)}},
+		{{Key: "type", Value: "Assam"}, {Key: "idx", Value: nil}},
+	}, results)
+}
+
+func TestAggregateLookup(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+	teaColl, err := db.GetCollection(ctx, "testdb", "teas")
+	require.NoError(t, err)
+	coll := teaColl.(*simplenosqldb.SimpleCollection)
+	for _, tea := range teas {
+		require.NoError(t, coll.InsertOne(ctx, tea))
+	}
+	vendors, err := db.GetCollection(ctx, "testdb", "vendors")
+	require.NoError(t, err)
+	require.NoError(t, vendors.InsertOne(ctx, bson.D{{Key: "code", Value: "A"}, {Key: "name", Value: "Acme"}}))
+	require.NoError(t, vendors.InsertOne(ctx, bson.D{{Key: "code", Value: "C"}, {Key: "name", Value: "Cuppa"}}))
+
+	results := aggregate(t, ctx, coll,
+		bson.D{{Key: "$match", Value: bson.D{{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{"Masala", "Earl Grey", "Assam"}}}}}}},
+		bson.D{{Key: "$lookup", Value: bson.D{{Key: "from", Value: "vendors"}, {Key: "localField", Value: "vendor"}, {Key: "foreignField", Value: "code"}, {Key: "as", Value: "vendors"}}}},
+		bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 0}, {Key: "type", Value: 1}, {Key: "vendors.name", Value: 1}}}},
+	)
+	assert.Equal(t, []bson.D{
+		{{Key: "type", Value: "Masala"}, {Key: "vendors", Value: bson.A{bson.D{{Key: "name", Value: "Acme"}}, bson.D{{Key: "name", Value: "Cuppa"}}}}},
+		{{Key: "type", Value: "Assam"}, {Key: "vendors", Value: bson.A{}}},
+		{{Key: "type", Value: "Earl Grey"}, {Key: "vendors", Value: bson.A{bson.D{{Key: "name", Value: "Acme"}}}}},
+	}, results)
+
+	// Lookups from a collection that doesn't exist find nothing
+	results = aggregate(t, ctx, coll,
+		bson.D{{Key: "$match", Value: bson.D{{Key: "type", Value: "Masala"}}}},
+		bson.D{{Key: "$lookup", Value: bson.D{{Key: "from", Value: "missing"}, {Key: "localField", Value: "vendor"}, {Key: "foreignField", Value: "code"}, {Key: "as", Value: "vendors"}}}},
+		bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 0}, {Key: "vendors", Value: 1}}}},
+	)
+	assert.Equal(t, []bson.D{{{Key: "vendors", Value: bson.A{}}}}, results)
+}
+
+func TestAggregateDoesNotModifyCollection(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t)
+	aggregate(t, ctx, coll,
+		bson.D{{Key: "$unwind", Value: "$sizes"}},
+		bson.D{{Key: "$project", Value: bson.D{{Key: "packaging.kind", Value: "$type"}, {Key: "sizes", Value: 1}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "sizes", Value: -1}}}},
+	)
+	assert.Equal(t, []string{"Masala", "English Breakfast", "Oolong", "Assam", "Earl Grey"}, findTypes(t, ctx, coll, bson.D{}))
+	assert.Equal(t, []string{"Masala"}, findTypes(t, ctx, coll, bson.D{{Key: "packaging.kind", Value: "Paper"}}))
+	assert.Equal(t, []string{"English Breakfast", "Oolong"}, findTypes(t, ctx, coll, bson.D{{Key: "sizes", Value: 8}}))
+}
+
+func TestAggregateInvalidPipeline(t *testing.T) {
+	ctx, coll := makeSimpleCollection(t)
+	invalid := [][]bson.D{
+		{{{Key: "$out", Value: "other"}}},
+		{{{Key: "$limit", Value: 0}}},
+		{{{Key: "$skip", Value: -1}}},
+		{{{Key: "$unwind", Value: "sizes"}}},
+		{{{Key: "$group", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}}},
+		{{{Key: "$group", Value: bson.D{{Key: "_id", Value: nil}, {Key: "first", Value: bson.D{{Key: "$first", Value: "$type"}}}}}}},
+		{{{Key: "$project", Value: bson.D{{Key: "total", Value: bson.D{{Key: "$add", Value: bson.A{"$rating", 1}}}}}}}},
+		{{{Key: "$lookup", Value: bson.D{{Key: "from", Value: "vendors"}}}}},
+	}
+	for _, pipeline := range invalid {
+		_, err := coll.Aggregate(ctx, pipeline)
+		assert.Error(t, err, "%v", pipeline)
+	}
+}
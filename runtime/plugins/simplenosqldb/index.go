@@ -0,0 +1,310 @@
+package simplenosqldb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Secondary indexes on SimpleCollection.
+
+An index maps the values at a (possibly dotted) path of each document to the documents
+with that value.  Like MongoDB, if the value at the path is an array, then each of its
+elements is indexed, and documents that don't have a value at the path are indexed as null.
+
+The filter engine uses indexes to find candidate documents for equality, $in, and range
+conditions; candidates are then checked against the full filter.
+*/
+
+type (
+	// Options for [SimpleCollection.CreateIndex]
+	IndexOptions struct {
+		// If true, inserts and updates that would result in two documents having
+		// the same value at the index's path are rejected.
+		Unique bool
+	}
+
+	// Information about an index on a [SimpleCollection]
+	IndexInfo struct {
+		Path   string
+		Unique bool
+	}
+
+	// Returned when an insert or update would result in two documents with the same
+	// value for a unique index
+	DuplicateKeyError struct {
+		Path  string
+		Value any
+	}
+
+	collectionIndex struct {
+		path    string
+		unique  bool
+		entries map[indexKey]map[*document]struct{}
+		keys    []indexKey // The keys of entries in sorted order, for range lookups
+	}
+
+	// An indexable value.  Values of the same kind are ordered by num and then str.
+	indexKey struct {
+		kind keyKind
+		num  float64
+		str  string
+	}
+
+	keyKind int
+)
+
+// The order of kinds follows MongoDB's comparison order
+const (
+	nullKey keyKind = iota
+	numberKey
+	stringKey
+	objectIDKey
+	boolKey
+	dateKey
+)
+
+func (e DuplicateKeyError) Error() string {
+	return fmt.Sprintf("duplicate key error: a document with %v %v already exists", e.Path, e.Value)
+}
+
+// Returns the key for an indexable value.  Numbers of different types have the same key if they
+// are equal.  Values such as embedded documents are not indexed.
+func keyOf(value any) (indexKey, bool) {
+	if f, isNumber := numberValue(value); isNumber {
+		return indexKey{kind: numberKey, num: f}, true
+	}
+	switch v := value.(type) {
+	case nil:
+		return indexKey{kind: nullKey}, true
+	case primitive.Null:
+		return indexKey{kind: nullKey}, true
+	case string:
+		return indexKey{kind: stringKey, str: v}, true
+	case primitive.ObjectID:
+		return indexKey{kind: objectIDKey, str: string(v[:])}, true
+	case bool:
+		if v {
+			return indexKey{kind: boolKey, num: 1}, true
+		}
+		return indexKey{kind: boolKey}, true
+	case primitive.DateTime:
+		return indexKey{kind: dateKey, num: float64(v)}, true
+	case time.Time:
+		return indexKey{kind: dateKey, num: float64(primitive.NewDateTimeFromTime(v))}, true
+	}
+	return indexKey{}, false
+}
+
+func numberValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, !math.IsNaN(v)
+	}
+	return 0, false
+}
+
+func (k indexKey) compare(other indexKey) int {
+	switch {
+	case k.kind != other.kind:
+		return int(k.kind) - int(other.kind)
+	case k.num < other.num:
+		return -1
+	case k.num > other.num:
+		return 1
+	default:
+		return bytes.Compare([]byte(k.str), []byte(other.str))
+	}
+}
+
+func newCollectionIndex(path string, unique bool) *collectionIndex {
+	return &collectionIndex{
+		path:    path,
+		unique:  unique,
+		entries: make(map[indexKey]map[*document]struct{}),
+	}
+}
+
+// Returns the distinct keys of a document for this index, and the values they were derived from
+func (idx *collectionIndex) keysOf(d bson.D) ([]indexKey, []any) {
+	values := query.PathValues(d, idx.path)
+	if len(values) == 0 {
+		values = []any{nil}
+	}
+	var keys []indexKey
+	var keyValues []any
+	seen := make(map[indexKey]struct{})
+	for _, value := range values {
+		if key, ok := keyOf(value); ok {
+			if _, dup := seen[key]; !dup {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+				keyValues = append(keyValues, value)
+			}
+		}
+	}
+	return keys, keyValues
+}
+
+// Returns an error if adding d to the index would violate uniqueness.  doc is the document
+// that d will replace, if any.
+func (idx *collectionIndex) check(doc *document, d bson.D) error {
+	if !idx.unique {
+		return nil
+	}
+	keys, values := idx.keysOf(d)
+	for i, key := range keys {
+		for existing := range idx.entries[key] {
+			if existing != doc {
+				return DuplicateKeyError{Path: idx.path, Value: values[i]}
+			}
+		}
+	}
+	return nil
+}
+
+func (idx *collectionIndex) add(doc *document) {
+	keys, _ := idx.keysOf(doc.d)
+	for _, key := range keys {
+		docs, exists := idx.entries[key]
+		if !exists {
+			docs = make(map[*document]struct{})
+			idx.entries[key] = docs
+			i := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i].compare(key) >= 0 })
+			idx.keys = append(idx.keys, indexKey{})
+			copy(idx.keys[i+1:], idx.keys[i:])
+			idx.keys[i] = key
+		}
+		docs[doc] = struct{}{}
+	}
+}
+
+func (idx *collectionIndex) remove(doc *document) {
+	keys, _ := idx.keysOf(doc.d)
+	for _, key := range keys {
+		docs := idx.entries[key]
+		delete(docs, doc)
+		if len(docs) == 0 {
+			delete(idx.entries, key)
+			i := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i].compare(key) >= 0 })
+			idx.keys = append(idx.keys[:i], idx.keys[i+1:]...)
+		}
+	}
+}
+
+// Returns the documents that might satisfy the condition, or false if the condition
+// can't be evaluated with the index
+func (idx *collectionIndex) lookup(c query.Condition) (map[*document]struct{}, bool) {
+	candidates := make(map[*document]struct{})
+	addAll := func(key indexKey) {
+		for doc := range idx.entries[key] {
+			candidates[doc] = struct{}{}
+		}
+	}
+
+	if len(c.Values) > 0 {
+		for _, value := range c.Values {
+			key, ok := keyOf(value)
+			if !ok {
+				return nil, false
+			}
+			addAll(key)
+		}
+		return candidates, true
+	}
+
+	// Range conditions are only on numbers
+	lower, upper := indexKey{kind: numberKey, num: math.Inf(-1)}, indexKey{kind: numberKey, num: math.Inf(1)}
+	if c.Lower != nil {
+		f, ok := numberValue(c.Lower)
+		if !ok {
+			return nil, false
+		}
+		lower.num = f
+	}
+	if c.Upper != nil {
+		f, ok := numberValue(c.Upper)
+		if !ok {
+			return nil, false
+		}
+		upper.num = f
+	}
+	i := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i].compare(lower) >= 0 })
+	for ; i < len(idx.keys) && idx.keys[i].compare(upper) <= 0; i++ {
+		addAll(idx.keys[i])
+	}
+	return candidates, true
+}
+
+// Creates an index on the values at path, which can be a dotted path into embedded documents
+// and arrays, e.g. "address.city".  Creating an index that already exists with the same options
+// is a no-op.
+//
+// Returns an error if the index already exists with different options, or if a unique index is
+// requested but the existing documents contain duplicate values.
+func (db *SimpleCollection) CreateIndex(ctx context.Context, path string, opts IndexOptions) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if existing, exists := db.indexes[path]; exists {
+		if existing.unique != opts.Unique {
+			return fmt.Errorf("an index on %v already exists with different options", path)
+		}
+		return nil
+	}
+
+	idx := newCollectionIndex(path, opts.Unique)
+	for _, doc := range db.items {
+		if err := idx.check(doc, doc.d); err != nil {
+			return err
+		}
+		idx.add(doc)
+	}
+	db.indexes[path] = idx
+	return nil
+}
+
+// Drops the index on path.  Returns an error if there is no such index.
+func (db *SimpleCollection) DropIndex(ctx context.Context, path string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, exists := db.indexes[path]; !exists {
+		return fmt.Errorf("no index on %v exists", path)
+	}
+	delete(db.indexes, path)
+	return nil
+}
+
+// Returns the indexes of the collection, sorted by path
+func (db *SimpleCollection) Indexes() []IndexInfo {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var infos []IndexInfo
+	for _, idx := range db.indexes {
+		infos = append(infos, IndexInfo{Path: idx.path, Unique: idx.unique})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos
+}
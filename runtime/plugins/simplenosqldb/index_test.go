@@ -0,0 +1,160 @@
+package simplenosqldb_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func makeSimpleCollection(t *testing.T, indexes ...string) (context.Context, *simplenosqldb.SimpleCollection) {
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+	coll, err := db.GetCollection(ctx, "testdb", "teas")
+	require.NoError(t, err)
+	simple := coll.(*simplenosqldb.SimpleCollection)
+	for _, path := range indexes {
+		require.NoError(t, simple.CreateIndex(ctx, path, simplenosqldb.IndexOptions{}))
+	}
+	for _, tea := range teas {
+		require.NoError(t, simple.InsertOne(ctx, tea))
+	}
+	return ctx, simple
+}
+
+func findTypes(t *testing.T, ctx context.Context, coll *simplenosqldb.SimpleCollection, filter bson.D) []string {
+	cursor, err := coll.FindMany(ctx, filter)
+	require.NoError(t, err)
+	var results []Tea
+	require.NoError(t, cursor.All(ctx, &results))
+	types := []string{}
+	for _, tea := range results {
+		types = append(types, tea.Type)
+	}
+	return types
+}
+
+func TestIndexedQueriesMatchScan(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, scanned := makeSimpleCollection(t)
+	_, indexed := makeSimpleCollection(t, "type", "rating", "sizes", "packaging.kind", "vendor")
+
+	filters := []bson.D{
+		{{Key: "type", Value: "Oolong"}},
+		{{Key: "type", Value: "Lapsang"}},
+		{{Key: "rating", Value: 7}},
+		{{Key: "rating", Value: bson.D{{Key: "$gt", Value: 6}}}},
+		{{Key: "rating", Value: bson.D{{Key: "$gte", Value: 6}, {Key: "$lt", Value: 10}}}},
+		{{Key: "rating", Value: bson.D{{Key: "$lte", Value: 7.5}}}},
+		{{Key: "rating", Value: bson.D{{Key: "$in", Value: bson.A{5, 8, 12}}}}},
+		{{Key: "sizes", Value: 16}},
+		{{Key: "sizes", Value: bson.D{{Key: "$gt", Value: 10}}}},
+		{{Key: "sizes", Value: bson.D{{Key: "$gt", Value: 10}}}, {Key: "sizes", Value: bson.D{{Key: "$lt", Value: 5}}}},
+		{{Key: "packaging.kind", Value: "Paper"}},
+		{{Key: "vendor", Value: "C"}},
+		{{Key: "vendor", Value: "C"}, {Key: "rating", Value: bson.D{{Key: "$gt", Value: 8}}}},
+		{{Key: "vendor", Value: bson.D{{Key: "$ne", Value: "C"}}}},
+		{{Key: "$or", Value: bson.A{bson.D{{Key: "type", Value: "Assam"}}, bson.D{{Key: "rating", Value: 8}}}}},
+	}
+	for _, filter := range filters {
+		assert.Equal(t, findTypes(t, ctx, scanned, filter), findTypes(t, ctx, indexed, filter), "%v", filter)
+	}
+}
+
+func TestIndexMaintainedOnWrite(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t, "rating", "sizes")
+
+	_, err := coll.UpdateOne(ctx, bson.D{{Key: "type", Value: "Assam"}}, bson.D{{Key: "$set", Value: bson.D{{Key: "rating", Value: 9}}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, findTypes(t, ctx, coll, bson.D{{Key: "rating", Value: 5}}))
+	assert.Equal(t, []string{"Assam"}, findTypes(t, ctx, coll, bson.D{{Key: "rating", Value: 9}}))
+
+	_, err = coll.UpdateMany(ctx, bson.D{}, bson.D{{Key: "$push", Value: bson.D{{Key: "sizes", Value: 64}}}})
+	require.NoError(t, err)
+	assert.Len(t, findTypes(t, ctx, coll, bson.D{{Key: "sizes", Value: 64}}), len(teas))
+
+	require.NoError(t, coll.DeleteOne(ctx, bson.D{{Key: "rating", Value: 9}}))
+	assert.Equal(t, []string{}, findTypes(t, ctx, coll, bson.D{{Key: "rating", Value: 9}}))
+
+	_, err = coll.ReplaceOne(ctx, bson.D{{Key: "type", Value: "Masala"}}, newtea)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Scottish Breakfast"}, findTypes(t, ctx, coll, bson.D{{Key: "sizes", Value: 5}}))
+	assert.Equal(t, []string{}, findTypes(t, ctx, coll, bson.D{{Key: "rating", Value: 10}}))
+
+	require.NoError(t, coll.DeleteMany(ctx, bson.D{{Key: "sizes", Value: 16}}))
+	assert.Equal(t, []string{"Scottish Breakfast", "Earl Grey"}, findTypes(t, ctx, coll, bson.D{}))
+}
+
+func TestUniqueIndex(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t)
+	require.NoError(t, coll.CreateIndex(ctx, "type", simplenosqldb.IndexOptions{Unique: true}))
+	assert.Equal(t, []simplenosqldb.IndexInfo{{Path: "type", Unique: true}}, coll.Indexes())
+
+	// Inserting a duplicate is rejected
+	err := coll.InsertOne(ctx, Tea{Type: "Oolong", Rating: 1})
+	var dupErr simplenosqldb.DuplicateKeyError
+	require.ErrorAs(t, err, &dupErr)
+	assert.Equal(t, "type", dupErr.Path)
+	assert.Equal(t, []string{"Oolong"}, findTypes(t, ctx, coll, bson.D{{Key: "type", Value: "Oolong"}}))
+
+	// Updating to a duplicate is rejected and leaves the document unchanged
+	_, err = coll.UpdateOne(ctx, bson.D{{Key: "type", Value: "Assam"}}, bson.D{{Key: "$set", Value: bson.D{{Key: "type", Value: "Oolong"}}}})
+	require.ErrorAs(t, err, &dupErr)
+	assert.Equal(t, []string{"Assam"}, findTypes(t, ctx, coll, bson.D{{Key: "type", Value: "Assam"}}))
+
+	// Updating a document without changing its unique value is fine
+	_, err = coll.UpdateOne(ctx, bson.D{{Key: "type", Value: "Assam"}}, bson.D{{Key: "$inc", Value: bson.D{{Key: "rating", Value: 1}}}})
+	require.NoError(t, err)
+
+	// A unique index can't be created over duplicate values
+	require.NoError(t, coll.InsertOne(ctx, Tea{Type: "Oolong2", Rating: 7}))
+	assert.Error(t, coll.CreateIndex(ctx, "rating", simplenosqldb.IndexOptions{Unique: true}))
+	assert.Error(t, coll.CreateIndex(ctx, "type", simplenosqldb.IndexOptions{}))
+
+	require.NoError(t, coll.DropIndex(ctx, "type"))
+	assert.Empty(t, coll.Indexes())
+	require.NoError(t, coll.InsertOne(ctx, Tea{Type: "Oolong", Rating: 1}))
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx, coll := makeSimpleCollection(t, "rating")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				assert.NoError(t, coll.InsertOne(ctx, Tea{Type: fmt.Sprintf("tea-%v-%v", i, j), Rating: 100 + i}))
+				_, err := coll.UpdateMany(ctx, bson.D{{Key: "rating", Value: 100 + i}}, bson.D{{Key: "$push", Value: bson.D{{Key: "sizes", Value: j}}}})
+				assert.NoError(t, err)
+				cursor, err := coll.FindMany(ctx, bson.D{{Key: "rating", Value: bson.D{{Key: "$gte", Value: 100}}}})
+				assert.NoError(t, err)
+				var results []Tea
+				assert.NoError(t, cursor.All(ctx, &results))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		assert.Len(t, findTypes(t, ctx, coll, bson.D{{Key: "rating", Value: 100 + i}}), 50)
+	}
+}
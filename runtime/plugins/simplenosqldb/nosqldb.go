@@ -8,7 +8,10 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb/query"
@@ -24,17 +27,35 @@ type (
 	//
 	// Only a small set of common basic filter and update operators are supported, but typically this is sufficient
 	// for most applications and enables writing service-level unit tests.
+	//
+	// SimpleNoSQLDB and its collections are safe for concurrent use.
 	SimpleNoSQLDB struct {
+		lock        sync.Mutex
 		collections map[string]map[string]*SimpleCollection
 	}
 
+	// A collection of documents.  Collections can have secondary indexes; see [SimpleCollection.CreateIndex].
 	SimpleCollection struct {
-		items []bson.D
+		lock    sync.RWMutex
+		items   []*document // In insertion order
+		indexes map[string]*collectionIndex
+		nextSeq uint64
+
+		// The database and database name that the collection belongs to, for $lookup
+		parent *SimpleNoSQLDB
+		dbName string
 	}
 
 	SimpleCursor struct {
 		results []bson.D
 	}
+
+	// Documents are never modified in place; updates replace d with an updated copy, so that
+	// cursors can safely hold on to documents after the collection's lock is released.
+	document struct {
+		seq uint64 // Orders documents by insertion
+		d   bson.D
+	}
 )
 
 // Instantiate a new in-memory NoSQLDB
@@ -45,6 +66,9 @@ func NewSimpleNoSQLDB(ctx context.Context) (*SimpleNoSQLDB, error) {
 }
 
 func (impl *SimpleNoSQLDB) GetCollection(ctx context.Context, db_name string, collection_name string) (backend.NoSQLCollection, error) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
 	db, dbExists := impl.collections[db_name]
 	if !dbExists {
 		db = make(map[string]*SimpleCollection)
@@ -53,7 +77,11 @@ func (impl *SimpleNoSQLDB) GetCollection(ctx context.Context, db_name string, co
 
 	collection, collectionExists := db[collection_name]
 	if !collectionExists {
-		collection = &SimpleCollection{}
+		collection = &SimpleCollection{
+			indexes: make(map[string]*collectionIndex),
+			parent:  impl,
+			dbName:  db_name,
+		}
 		db[collection_name] = collection
 	}
 
@@ -95,15 +123,97 @@ func (c *SimpleCursor) All(ctx context.Context, obj interface{}) error {
 	return copyResult(c.results, obj)
 }
 
-func (db *SimpleCollection) InsertOne(ctx context.Context, document interface{}) error {
-	d, isD := document.(bson.D)
-	if !isD {
-		var err error
-		d, err = toBson(document)
-		if err != nil {
+// Returns the documents that might match filter, in insertion order.  If the filter has
+// conditions on indexed paths, the most selective index is used; otherwise all documents
+// are returned.  The caller must hold the lock.
+func (db *SimpleCollection) candidates(filter query.Filter) []*document {
+	var best map[*document]struct{}
+	for _, condition := range query.Conditions(filter) {
+		idx, indexed := db.indexes[condition.Path]
+		if !indexed {
+			continue
+		}
+		if docs, ok := idx.lookup(condition); ok && (best == nil || len(docs) < len(best)) {
+			best = docs
+			if verbose.Load() {
+				fmt.Printf("Using index on %v (%v candidates)\n", condition, len(docs))
+			}
+		}
+	}
+	if best == nil {
+		return db.items
+	}
+	candidates := make([]*document, 0, len(best))
+	for doc := range best {
+		candidates = append(candidates, doc)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].seq < candidates[j].seq })
+	return candidates
+}
+
+// Adds a document, checking unique indexes.  The caller must hold the lock.
+func (db *SimpleCollection) insert(d bson.D) error {
+	for _, idx := range db.indexes {
+		if err := idx.check(nil, d); err != nil {
+			return err
+		}
+	}
+	doc := &document{seq: db.nextSeq, d: d}
+	db.nextSeq++
+	db.items = append(db.items, doc)
+	for _, idx := range db.indexes {
+		idx.add(doc)
+	}
+	return nil
+}
+
+// Replaces the contents of a document, checking unique indexes.  The caller must hold the lock.
+func (db *SimpleCollection) replace(doc *document, d bson.D) error {
+	for _, idx := range db.indexes {
+		if err := idx.check(doc, d); err != nil {
 			return err
 		}
 	}
+	for _, idx := range db.indexes {
+		idx.remove(doc)
+	}
+	doc.d = d
+	for _, idx := range db.indexes {
+		idx.add(doc)
+	}
+	return nil
+}
+
+// Removes documents.  The caller must hold the lock.
+func (db *SimpleCollection) remove(docs map[*document]struct{}) {
+	if len(docs) == 0 {
+		return
+	}
+	for doc := range docs {
+		for _, idx := range db.indexes {
+			idx.remove(doc)
+		}
+	}
+	remaining := make([]*document, 0, len(db.items))
+	for _, doc := range db.items {
+		if _, removed := docs[doc]; !removed {
+			remaining = append(remaining, doc)
+		}
+	}
+	db.items = remaining
+}
+
+func (db *SimpleCollection) InsertOne(ctx context.Context, document interface{}) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	return db.insertOne(document)
+}
+
+func (db *SimpleCollection) insertOne(document interface{}) error {
+	d, err := toBson(document)
+	if err != nil {
+		return err
+	}
 	hasId := false
 	for _, e := range d {
 		if e.Key == "_id" {
@@ -115,13 +225,14 @@ func (db *SimpleCollection) InsertOne(ctx context.Context, document interface{})
 		d = append(bson.D{{"_id", primitive.NewObjectID()}}, d...)
 	}
 
-	db.items = append(db.items, d)
-	return nil
+	return db.insert(d)
 }
 
 func (db *SimpleCollection) InsertMany(ctx context.Context, documents []interface{}) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
 	for _, d := range documents {
-		err := db.InsertOne(ctx, d)
+		err := db.insertOne(d)
 		if err != nil {
 			return err
 		}
@@ -134,15 +245,18 @@ func (db *SimpleCollection) FindOne(ctx context.Context, filter bson.D, projecti
 	if err != nil {
 		return nil, err
 	}
-	if verbose {
+	if verbose.Load() {
 		fmt.Printf("---- FindOne\n%v\n", query)
 	}
+
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	cursor := &SimpleCursor{}
-	for _, item := range db.items {
-		if query.Apply(item) {
-			cursor.results = append(cursor.results, item)
-			if verbose {
-				fmt.Printf("MATCH: %v\n", item)
+	for _, item := range db.candidates(query) {
+		if query.Apply(item.d) {
+			cursor.results = append(cursor.results, item.d)
+			if verbose.Load() {
+				fmt.Printf("MATCH: %v\n", item.d)
 			}
 			break
 		}
@@ -150,47 +264,150 @@ func (db *SimpleCollection) FindOne(ctx context.Context, filter bson.D, projecti
 	return cursor, nil
 }
 
-var verbose = false
+var verbose atomic.Bool
 
 // Enable or disable verbose logging; used for testing
 func SetVerbose(enabled bool) bool {
-	before := verbose
-	verbose = enabled
-	return before
+	return verbose.Swap(enabled)
 }
 
 func (db *SimpleCollection) FindMany(ctx context.Context, filter bson.D, projection ...bson.D) (backend.NoSQLCursor, error) {
+	opts := backend.FindOptions{}
+	if len(projection) > 0 {
+		opts.Projection = projection[0]
+	}
+	return db.FindManyWithOptions(ctx, filter, opts)
+}
+
+func (db *SimpleCollection) FindManyWithOptions(ctx context.Context, filter bson.D, opts backend.FindOptions) (backend.NoSQLCursor, error) {
+	if opts.Skip < 0 || opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid find options: skip (%v) and limit (%v) must not be negative", opts.Skip, opts.Limit)
+	}
+	sortOrder, err := query.ParseSort(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	var projection query.Pipeline
+	if len(opts.Projection) > 0 {
+		if projection, err = query.ParseProjection(opts.Projection); err != nil {
+			return nil, err
+		}
+	}
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return nil, err
 	}
-	if verbose {
+	if verbose.Load() {
 		fmt.Printf("---- FindMany\n%v\n", query)
 	}
+
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	cursor := &SimpleCursor{}
-	for _, item := range db.items {
-		if query.Apply(item) {
-			cursor.results = append(cursor.results, item)
-			if verbose {
-				fmt.Printf("MATCH: %v\n", item)
+	for _, item := range db.candidates(query) {
+		if query.Apply(item.d) {
+			cursor.results = append(cursor.results, item.d)
+			if verbose.Load() {
+				fmt.Printf("MATCH: %v\n", item.d)
 			}
 		} else {
-			if verbose {
-				fmt.Printf("       %v\n", item)
+			if verbose.Load() {
+				fmt.Printf("       %v\n", item.d)
 			}
 		}
 	}
+
+	sortOrder.Apply(cursor.results)
+	if opts.Skip >= int64(len(cursor.results)) {
+		cursor.results = nil
+	} else {
+		cursor.results = cursor.results[opts.Skip:]
+	}
+	if opts.Limit > 0 && opts.Limit < int64(len(cursor.results)) {
+		cursor.results = cursor.results[:opts.Limit]
+	}
+	if projection != nil {
+		if cursor.results, err = projection.Apply(cursor.results, nil); err != nil {
+			return nil, err
+		}
+	}
 	return cursor, nil
 }
 
+func (db *SimpleCollection) CountDocuments(ctx context.Context, filter bson.D) (int64, error) {
+	query, err := query.ParseFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	count := int64(0)
+	for _, item := range db.candidates(query) {
+		if query.Apply(item.d) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Runs an aggregation pipeline over the collection.  The supported stages are $match, $project,
+// $group, $sort, $limit, $skip, $unwind, and $lookup on collections of the same database; see
+// [query.ParsePipeline] for details.
+func (db *SimpleCollection) Aggregate(ctx context.Context, pipeline []bson.D) (backend.NoSQLCursor, error) {
+	p, err := query.ParsePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if verbose.Load() {
+		fmt.Printf("---- Aggregate\n%v\n", p)
+	}
+
+	// The pipeline runs on a snapshot of the collection so that $lookup doesn't hold two locks
+	results, err := p.Apply(db.snapshot(), db.resolve)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleCursor{results: results}, nil
+}
+
+// Returns the current documents of the collection
+func (db *SimpleCollection) snapshot() []bson.D {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	docs := make([]bson.D, 0, len(db.items))
+	for _, item := range db.items {
+		docs = append(docs, item.d)
+	}
+	return docs
+}
+
+// Returns the documents of another collection in the same database.  Like MongoDB, a collection
+// that doesn't exist is treated as empty.
+func (db *SimpleCollection) resolve(collection string) ([]bson.D, error) {
+	if db.parent == nil {
+		return nil, fmt.Errorf("unable to $lookup %v; the collection does not belong to a database", collection)
+	}
+	db.parent.lock.Lock()
+	other, exists := db.parent.collections[db.dbName][collection]
+	db.parent.lock.Unlock()
+	if !exists {
+		return nil, nil
+	}
+	return other.snapshot(), nil
+}
+
 func (db *SimpleCollection) DeleteOne(ctx context.Context, filter bson.D) error {
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return err
 	}
-	for i, item := range db.items {
-		if query.Apply(item) {
-			db.items = append(db.items[:i], db.items[i+1:]...)
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	for _, item := range db.candidates(query) {
+		if query.Apply(item.d) {
+			db.remove(map[*document]struct{}{item: {}})
 			return nil
 		}
 	}
@@ -202,22 +419,17 @@ func (db *SimpleCollection) DeleteMany(ctx context.Context, filter bson.D) error
 	if err != nil {
 		return err
 	}
-	copyrangebegin := 0
-	newitems := make([]bson.D, 0, len(db.items))
-	for i, item := range db.items {
-		if query.Apply(item) {
-			if i > copyrangebegin {
-				newitems = append(newitems, db.items[copyrangebegin:i]...)
-			}
-			copyrangebegin = i + 1
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	toDelete := make(map[*document]struct{})
+	for _, item := range db.candidates(query) {
+		if query.Apply(item.d) {
+			toDelete[item] = struct{}{}
 		}
 	}
-	if copyrangebegin < len(db.items) {
-		newitems = append(newitems, db.items[copyrangebegin:len(db.items)]...)
-	}
-	db.items = newitems
+	db.remove(toDelete)
 	return nil
-
 }
 
 func (db *SimpleCollection) UpdateOne(ctx context.Context, filter bson.D, update bson.D) (int, error) {
@@ -230,25 +442,39 @@ func (db *SimpleCollection) UpdateOne(ctx context.Context, filter bson.D, update
 		return 0, err
 	}
 
-	if verbose {
+	if verbose.Load() {
 		fmt.Printf("---- UpdateOne\n%v\n%v\n", filter, update)
 	}
 
-	for i := range db.items {
-		if filterOp.Apply(db.items[i]) {
-			if verbose {
-				fmt.Printf("MATCH: %v\n", db.items[i])
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	for _, item := range db.candidates(filterOp) {
+		if filterOp.Apply(item.d) {
+			if verbose.Load() {
+				fmt.Printf("MATCH: %v\n", item.d)
 			}
-			return 1, updateOp.Apply(&db.items[i])
+			return 1, db.update(item, updateOp)
 		} else {
-			if verbose {
-				fmt.Printf("      %v\n", db.items[i])
+			if verbose.Load() {
+				fmt.Printf("      %v\n", item.d)
 			}
 		}
 	}
 	return 0, nil
 }
 
+// Applies the update to a copy of the document, then replaces the document.  The caller must hold the lock.
+func (db *SimpleCollection) update(doc *document, updateOp query.Update) error {
+	updated, err := toBson(doc.d)
+	if err != nil {
+		return err
+	}
+	if err := updateOp.Apply(&updated); err != nil {
+		return err
+	}
+	return db.replace(doc, updated)
+}
+
 func (db *SimpleCollection) UpdateMany(ctx context.Context, filter bson.D, update bson.D) (int, error) {
 	filterOp, err := query.ParseFilter(filter)
 	if err != nil {
@@ -258,29 +484,31 @@ func (db *SimpleCollection) UpdateMany(ctx context.Context, filter bson.D, updat
 	if err != nil {
 		return 0, err
 	}
-	if verbose {
+	if verbose.Load() {
 		fmt.Printf("---- UpdateMany\n")
 		fmt.Printf(" MATCH:  %v\n", filterOp)
 		fmt.Printf(" UPDATE: %v\n", updateOp)
 	}
 
+	db.lock.Lock()
+	defer db.lock.Unlock()
 	updated := 0
-	for i := range db.items {
-		if filterOp.Apply(db.items[i]) {
-			if verbose {
-				fmt.Printf("UPDATING: %v\n", db.items[i])
+	for _, item := range db.candidates(filterOp) {
+		if filterOp.Apply(item.d) {
+			if verbose.Load() {
+				fmt.Printf("UPDATING: %v\n", item.d)
 			}
-			err := updateOp.Apply(&db.items[i])
+			err := db.update(item, updateOp)
 			if err != nil {
 				return updated, err
 			}
-			if verbose {
-				fmt.Printf("      --> %v\n", db.items[i])
+			if verbose.Load() {
+				fmt.Printf("      --> %v\n", item.d)
 			}
 			updated += 1
 		} else {
-			if verbose {
-				fmt.Printf("          %v\n", db.items[i])
+			if verbose.Load() {
+				fmt.Printf("          %v\n", item.d)
 			}
 		}
 	}
@@ -288,31 +516,42 @@ func (db *SimpleCollection) UpdateMany(ctx context.Context, filter bson.D, updat
 }
 
 func (db *SimpleCollection) Upsert(ctx context.Context, filter bson.D, document interface{}) (bool, error) {
-	updatedCount, err := db.ReplaceOne(ctx, filter, document)
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	updatedCount, err := db.replaceOne(filter, document)
 	if updatedCount == 1 || err != nil {
 		return true, err
 	}
-	return false, db.InsertOne(ctx, document)
+	return false, db.insertOne(document)
 }
 
 func (db *SimpleCollection) UpsertID(ctx context.Context, id primitive.ObjectID, document interface{}) (bool, error) {
 	filter := bson.D{{"_id", id}}
 	updated, err := db.Upsert(ctx, filter, document)
-	if updated && verbose && err == nil {
+	if updated && verbose.Load() && err == nil {
 		fmt.Printf("Upsert replaced existing %v\n", id)
 	}
 	return updated, err
 }
 
 func (db *SimpleCollection) ReplaceOne(ctx context.Context, filter bson.D, replacement interface{}) (int, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	return db.replaceOne(filter, replacement)
+}
+
+func (db *SimpleCollection) replaceOne(filter bson.D, replacement interface{}) (int, error) {
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return 0, err
 	}
-	for i, item := range db.items {
-		if query.Apply(item) {
-			db.items[i], err = toBson(replacement)
-			return 1, err
+	for _, item := range db.candidates(query) {
+		if query.Apply(item.d) {
+			d, err := toBson(replacement)
+			if err != nil {
+				return 1, err
+			}
+			return 1, db.replace(item, d)
 		}
 	}
 	return 0, nil
@@ -323,13 +562,20 @@ func (db *SimpleCollection) ReplaceMany(ctx context.Context, filter bson.D, repl
 	if err != nil {
 		return 0, nil
 	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
 	updateCount := 0
-	for i := 0; updateCount < len(replacements) && i < len(db.items); i++ {
-		if query.Apply(db.items[i]) {
-			db.items[i], err = toBson(replacements[updateCount])
+	candidates := db.candidates(query)
+	for i := 0; updateCount < len(replacements) && i < len(candidates); i++ {
+		if query.Apply(candidates[i].d) {
+			d, err := toBson(replacements[updateCount])
 			if err != nil {
 				return updateCount, err
 			}
+			if err := db.replace(candidates[i], d); err != nil {
+				return updateCount, err
+			}
 			updateCount++
 		}
 	}
@@ -355,9 +601,11 @@ func fromBson(d bson.D, dst any) error {
 }
 
 func (db *SimpleCollection) String() string {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	var strs []string
 	for i := range db.items {
-		strs = append(strs, fmt.Sprintf("%v", db.items[i]))
+		strs = append(strs, fmt.Sprintf("%v", db.items[i].d))
 	}
 	return strings.Join(strs, "\n")
 }
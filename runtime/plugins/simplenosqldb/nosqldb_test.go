@@ -1689,3 +1689,112 @@ func dbstring(ctx context.Context, db backend.NoSQLCollection, t *testing.T) str
 	}
 	return fmt.Sprintf("\nDocuments in DB:\n%v\n\n", strings.Join(docstrings, "\n"))
 }
+
+func findTeaTypes(t *testing.T, ctx context.Context, db backend.NoSQLCollection, filter bson.D, opts backend.FindOptions) []string {
+	cursor, err := db.FindManyWithOptions(ctx, filter, opts)
+	require.NoError(t, err)
+	var results []Tea
+	require.NoError(t, cursor.All(ctx, &results))
+	types := []string{}
+	for _, tea := range results {
+		types = append(types, tea.Type)
+	}
+	return types
+}
+
+func TestFindSorted(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	byRating := backend.FindOptions{Sort: bson.D{{Key: "rating", Value: 1}}}
+	require.Equal(t, []string{"Assam", "English Breakfast", "Oolong", "Earl Grey", "Masala"}, findTeaTypes(t, ctx, db, bson.D{}, byRating))
+
+	byRatingDesc := backend.FindOptions{Sort: bson.D{{Key: "rating", Value: -1}}}
+	require.Equal(t, []string{"Masala", "Earl Grey", "Oolong", "English Breakfast", "Assam"}, findTeaTypes(t, ctx, db, bson.D{}, byRatingDesc))
+
+	// Missing values sort first, like null
+	byVendor := backend.FindOptions{Sort: bson.D{{Key: "vendor", Value: 1}, {Key: "type", Value: 1}}}
+	require.Equal(t, []string{"Assam", "English Breakfast", "Earl Grey", "Masala", "Oolong"}, findTeaTypes(t, ctx, db, bson.D{}, byVendor))
+
+	// Dotted paths
+	byKind := backend.FindOptions{Sort: bson.D{{Key: "packaging.kind", Value: -1}, {Key: "type", Value: 1}}}
+	require.Equal(t, []string{"Masala", "Assam", "Earl Grey", "English Breakfast", "Oolong"}, findTeaTypes(t, ctx, db, bson.D{}, byKind))
+
+	// Arrays sort by their smallest element ascending and their largest element descending
+	bySizes := backend.FindOptions{Sort: bson.D{{Key: "sizes", Value: 1}, {Key: "type", Value: 1}}}
+	require.Equal(t, []string{"English Breakfast", "Masala", "Oolong", "Assam", "Earl Grey"}, findTeaTypes(t, ctx, db, bson.D{}, bySizes))
+	bySizesDesc := backend.FindOptions{Sort: bson.D{{Key: "sizes", Value: -1}, {Key: "type", Value: 1}}}
+	require.Equal(t, []string{"Earl Grey", "Assam", "English Breakfast", "Oolong", "Masala"}, findTeaTypes(t, ctx, db, bson.D{}, bySizesDesc))
+}
+
+func TestFindSkipAndLimit(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	page := func(skip, limit int64) []string {
+		return findTeaTypes(t, ctx, db, bson.D{}, backend.FindOptions{Sort: bson.D{{Key: "rating", Value: 1}}, Skip: skip, Limit: limit, BatchSize: 1})
+	}
+	require.Equal(t, []string{"Assam", "English Breakfast"}, page(0, 2))
+	require.Equal(t, []string{"Oolong", "Earl Grey"}, page(2, 2))
+	require.Equal(t, []string{"Masala"}, page(4, 2))
+	require.Equal(t, []string{}, page(6, 2))
+	require.Equal(t, []string{"Earl Grey", "Masala"}, page(3, 0))
+
+	// Skip and limit apply after filtering
+	filter := bson.D{{Key: "rating", Value: bson.D{{Key: "$gt", Value: 5}}}}
+	require.Equal(t, []string{"Oolong"}, findTeaTypes(t, ctx, db, filter, backend.FindOptions{Sort: bson.D{{Key: "rating", Value: 1}}, Skip: 1, Limit: 1}))
+
+	_, err := db.FindManyWithOptions(ctx, bson.D{}, backend.FindOptions{Skip: -1})
+	require.Error(t, err)
+	_, err = db.FindManyWithOptions(ctx, bson.D{}, backend.FindOptions{Limit: -1})
+	require.Error(t, err)
+}
+
+func TestFindProjection(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	find := func(opts backend.FindOptions) []bson.D {
+		cursor, err := db.FindManyWithOptions(ctx, bson.D{{Key: "type", Value: "Masala"}}, opts)
+		require.NoError(t, err)
+		var results []bson.D
+		require.NoError(t, cursor.All(ctx, &results))
+		require.Len(t, results, 1)
+		return results
+	}
+
+	// Inclusions, including dotted paths; _id is excluded explicitly
+	included := find(backend.FindOptions{Projection: bson.D{{Key: "_id", Value: 0}, {Key: "type", Value: 1}, {Key: "packaging.kind", Value: 1}}})
+	require.Equal(t, bson.D{{Key: "type", Value: "Masala"}, {Key: "packaging", Value: bson.D{{Key: "kind", Value: "Paper"}}}}, included[0])
+
+	// Exclusions
+	excluded := find(backend.FindOptions{Projection: bson.D{{Key: "_id", Value: 0}, {Key: "packaging", Value: 0}, {Key: "sizes", Value: 0}}})
+	require.Equal(t, bson.D{{Key: "type", Value: "Masala"}, {Key: "rating", Value: int32(10)}, {Key: "vendor", Value: bson.A{"A", "C"}}}, excluded[0])
+
+	// FindMany applies its projection in the same way
+	cursor, err := db.FindMany(ctx, bson.D{{Key: "type", Value: "Masala"}}, bson.D{{Key: "rating", Value: 1}})
+	require.NoError(t, err)
+	var tea []Tea
+	require.NoError(t, cursor.All(ctx, &tea))
+	require.Equal(t, []Tea{{Rating: 10}}, tea)
+
+	// Projections apply after sort, skip, and limit
+	types := findTeaTypes(t, ctx, db, bson.D{}, backend.FindOptions{Sort: bson.D{{Key: "rating", Value: 1}}, Limit: 2, Projection: bson.D{{Key: "type", Value: 1}}})
+	require.Equal(t, []string{"Assam", "English Breakfast"}, types)
+
+	_, err = db.FindManyWithOptions(ctx, bson.D{}, backend.FindOptions{Projection: bson.D{{Key: "type", Value: 1}, {Key: "rating", Value: 0}}})
+	require.Error(t, err)
+}
+
+func TestCountDocuments(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	count, err := db.CountDocuments(ctx, bson.D{})
+	require.NoError(t, err)
+	require.Equal(t, int64(len(teas)), count)
+
+	count, err = db.CountDocuments(ctx, bson.D{{Key: "vendor", Value: "A"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	count, err = db.CountDocuments(ctx, bson.D{{Key: "type", Value: "Lapsang"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+}
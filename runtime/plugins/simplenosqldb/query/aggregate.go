@@ -0,0 +1,846 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Simple evaluation of MongoDB aggregation pipelines
+
+The following stages are supported:
+  - $match
+  - $project, with field inclusion, exclusion, and computed fields
+  - $group, with the $sum, $avg, $min, $max, and $push accumulators
+  - $sort, $limit, and $skip
+  - $unwind
+  - $lookup, with localField and foreignField, on collections in the same database
+
+Expressions (e.g. in computed fields, group keys, and accumulators) can be field paths such
+as "$address.city", literal values, embedded documents of expressions, or {$literal: value}.
+*/
+
+// Returns the documents of another collection in the same database; used by $lookup
+type CollectionResolver func(collection string) ([]bson.D, error)
+
+type Pipeline interface {
+	// Applies the pipeline to docs.  The documents in docs are not modified.
+	Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error)
+	String() string
+}
+
+type (
+	pipeline struct {
+		stages []Pipeline
+	}
+
+	matchStage struct {
+		filter Filter
+	}
+
+	projectStage struct {
+		exclude  bool
+		fields   *projectionTree
+		computed []computedField
+	}
+
+	projectionTree struct {
+		children map[string]*projectionTree // nil for a leaf
+	}
+
+	computedField struct {
+		path []string
+		expr Expression
+	}
+
+	groupStage struct {
+		key          Expression
+		accumulators []accumulatorField
+	}
+
+	accumulatorField struct {
+		name string
+		op   string
+		expr Expression
+	}
+
+	sortStage struct {
+		sort Sort
+	}
+
+	limitStage struct {
+		limit int64
+	}
+
+	skipStage struct {
+		skip int64
+	}
+
+	unwindStage struct {
+		path                       []string
+		includeArrayIndex          string
+		preserveNullAndEmptyArrays bool
+	}
+
+	lookupStage struct {
+		from         string
+		localField   string
+		foreignField string
+		as           []string
+	}
+)
+
+// An aggregation expression
+type Expression interface {
+	// Returns the value of the expression for doc, or false if the value is missing
+	Eval(doc bson.D) (any, bool)
+	String() string
+}
+
+type (
+	fieldPath struct {
+		path []string
+	}
+
+	literal struct {
+		value any
+	}
+
+	documentExpr struct {
+		fields []string
+		exprs  []Expression
+	}
+)
+
+// Parses an aggregation pipeline
+func ParsePipeline(stages []bson.D) (Pipeline, error) {
+	p := &pipeline{}
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage must have exactly one field, but got %v", stage)
+		}
+		parsed, err := parseStage(stage[0])
+		if err != nil {
+			return nil, err
+		}
+		p.stages = append(p.stages, parsed)
+	}
+	return p, nil
+}
+
+func parseStage(e bson.E) (Pipeline, error) {
+	switch e.Key {
+	case "$match":
+		d, isD := e.Value.(bson.D)
+		if !isD {
+			return nil, fmt.Errorf("$match requires a bson.D filter but got %v", e.Value)
+		}
+		filter, err := ParseFilter(d)
+		return &matchStage{filter: filter}, err
+	case "$project":
+		d, isD := e.Value.(bson.D)
+		if !isD {
+			return nil, fmt.Errorf("$project requires a bson.D but got %v", e.Value)
+		}
+		return parseProject(d)
+	case "$group":
+		d, isD := e.Value.(bson.D)
+		if !isD {
+			return nil, fmt.Errorf("$group requires a bson.D but got %v", e.Value)
+		}
+		return parseGroup(d)
+	case "$sort":
+		d, isD := e.Value.(bson.D)
+		if !isD || len(d) == 0 {
+			return nil, fmt.Errorf("$sort requires a non-empty bson.D but got %v", e.Value)
+		}
+		sort, err := ParseSort(d)
+		return &sortStage{sort: sort}, err
+	case "$limit":
+		limit, isInt := intValue(e.Value)
+		if !isInt || limit <= 0 {
+			return nil, fmt.Errorf("$limit requires a positive integer but got %v", e.Value)
+		}
+		return &limitStage{limit: limit}, nil
+	case "$skip":
+		skip, isInt := intValue(e.Value)
+		if !isInt || skip < 0 {
+			return nil, fmt.Errorf("$skip requires a non-negative integer but got %v", e.Value)
+		}
+		return &skipStage{skip: skip}, nil
+	case "$unwind":
+		return parseUnwind(e.Value)
+	case "$lookup":
+		d, isD := e.Value.(bson.D)
+		if !isD {
+			return nil, fmt.Errorf("$lookup requires a bson.D but got %v", e.Value)
+		}
+		return parseLookup(d)
+	default:
+		return nil, fmt.Errorf("unsupported pipeline stage %v", e.Key)
+	}
+}
+
+// Parses an aggregation expression
+func ParseExpression(value any) (Expression, error) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "$") {
+			if strings.HasPrefix(v, "$$") {
+				return nil, fmt.Errorf("unsupported variable %v", v)
+			}
+			return &fieldPath{path: strings.Split(v[1:], ".")}, nil
+		}
+		return &literal{value: v}, nil
+	case bson.D:
+		if len(v) > 0 && strings.HasPrefix(v[0].Key, "$") {
+			if len(v) == 1 && v[0].Key == "$literal" {
+				return &literal{value: v[0].Value}, nil
+			}
+			return nil, fmt.Errorf("unsupported expression operator %v", v[0].Key)
+		}
+		expr := &documentExpr{}
+		for _, e := range v {
+			fieldExpr, err := ParseExpression(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			expr.fields = append(expr.fields, e.Key)
+			expr.exprs = append(expr.exprs, fieldExpr)
+		}
+		return expr, nil
+	case bson.A:
+		return nil, fmt.Errorf("array expressions are not supported: %v", v)
+	default:
+		return &literal{value: v}, nil
+	}
+}
+
+// Parses the projection of a find, which has the same syntax as a $project stage
+func ParseProjection(projection bson.D) (Pipeline, error) {
+	return parseProject(projection)
+}
+
+func parseProject(d bson.D) (Pipeline, error) {
+	stage := &projectStage{fields: &projectionTree{children: make(map[string]*projectionTree)}}
+	hasInclusions, hasExclusions, excludeID := false, false, false
+	for _, e := range d {
+		if strings.HasPrefix(e.Key, "$") {
+			return nil, fmt.Errorf("invalid $project field %v", e.Key)
+		}
+		include, isFlag := projectionFlag(e.Value)
+		switch {
+		case !isFlag:
+			expr, err := ParseExpression(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			stage.computed = append(stage.computed, computedField{path: strings.Split(e.Key, "."), expr: expr})
+			hasInclusions = true
+		case e.Key == "_id" && !include:
+			excludeID = true
+		case include:
+			stage.fields.add(strings.Split(e.Key, "."))
+			hasInclusions = true
+		default:
+			stage.fields.add(strings.Split(e.Key, "."))
+			hasExclusions = true
+		}
+	}
+	if hasInclusions && hasExclusions {
+		return nil, fmt.Errorf("$project cannot mix inclusions and exclusions: %v", d)
+	}
+	if hasInclusions {
+		if !excludeID {
+			stage.fields.add([]string{"_id"})
+		}
+	} else {
+		stage.exclude = true
+		if excludeID {
+			stage.fields.add([]string{"_id"})
+		}
+	}
+	return stage, nil
+}
+
+// Returns whether a $project value includes or excludes a field, or false if it is an expression
+func projectionFlag(value any) (include bool, isFlag bool) {
+	if b, isBool := value.(bool); isBool {
+		return b, true
+	}
+	if f, isNumber := floatValue(value); isNumber {
+		return f != 0, true
+	}
+	return false, false
+}
+
+func (t *projectionTree) add(path []string) {
+	for _, name := range path {
+		if t.children == nil {
+			// A parent of this path is already projected in its entirety
+			return
+		}
+		child, exists := t.children[name]
+		if !exists {
+			child = &projectionTree{children: make(map[string]*projectionTree)}
+			t.children[name] = child
+		}
+		t = child
+	}
+	t.children = nil
+}
+
+// Applies an inclusion or exclusion projection to a value
+func (t *projectionTree) apply(value any, exclude bool) (any, bool) {
+	switch v := value.(type) {
+	case bson.D:
+		var projected bson.D
+		for _, e := range v {
+			child, inTree := t.children[e.Key]
+			switch {
+			case !inTree:
+				if exclude {
+					projected = append(projected, e)
+				}
+			case child.children == nil:
+				if !exclude {
+					projected = append(projected, e)
+				}
+			default:
+				if childValue, keep := child.apply(e.Value, exclude); keep {
+					projected = append(projected, bson.E{Key: e.Key, Value: childValue})
+				}
+			}
+		}
+		if projected == nil {
+			projected = bson.D{}
+		}
+		return projected, true
+	case bson.A:
+		projected := bson.A{}
+		for _, elem := range v {
+			if elemValue, keep := t.apply(elem, exclude); keep {
+				projected = append(projected, elemValue)
+			}
+		}
+		return projected, true
+	default:
+		// Scalars within a projected subdocument are excluded by inclusion projections
+		return value, exclude
+	}
+}
+
+func parseGroup(d bson.D) (Pipeline, error) {
+	stage := &groupStage{}
+	hasID := false
+	for _, e := range d {
+		if e.Key == "_id" {
+			key, err := ParseExpression(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			stage.key = key
+			hasID = true
+			continue
+		}
+		acc, isD := e.Value.(bson.D)
+		if !isD || len(acc) != 1 {
+			return nil, fmt.Errorf("$group field %v must be an accumulator such as {$sum: 1}, but got %v", e.Key, e.Value)
+		}
+		switch acc[0].Key {
+		case "$sum", "$avg", "$min", "$max", "$push":
+		default:
+			return nil, fmt.Errorf("unsupported $group accumulator %v", acc[0].Key)
+		}
+		expr, err := ParseExpression(acc[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		stage.accumulators = append(stage.accumulators, accumulatorField{name: e.Key, op: acc[0].Key, expr: expr})
+	}
+	if !hasID {
+		return nil, fmt.Errorf("$group requires an _id field: %v", d)
+	}
+	return stage, nil
+}
+
+func parseUnwind(value any) (Pipeline, error) {
+	stage := &unwindStage{}
+	var path string
+	switch v := value.(type) {
+	case string:
+		path = v
+	case bson.D:
+		for _, e := range v {
+			switch e.Key {
+			case "path":
+				path, _ = e.Value.(string)
+			case "includeArrayIndex":
+				stage.includeArrayIndex, _ = e.Value.(string)
+			case "preserveNullAndEmptyArrays":
+				stage.preserveNullAndEmptyArrays, _ = e.Value.(bool)
+			default:
+				return nil, fmt.Errorf("unsupported $unwind option %v", e.Key)
+			}
+		}
+	}
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("$unwind requires a field path starting with $ but got %v", value)
+	}
+	stage.path = strings.Split(path[1:], ".")
+	return stage, nil
+}
+
+func parseLookup(d bson.D) (Pipeline, error) {
+	stage := &lookupStage{}
+	var as string
+	for _, e := range d {
+		value, isStr := e.Value.(string)
+		if !isStr {
+			return nil, fmt.Errorf("unsupported $lookup option %v; only from, localField, foreignField, and as are supported", e)
+		}
+		switch e.Key {
+		case "from":
+			stage.from = value
+		case "localField":
+			stage.localField = value
+		case "foreignField":
+			stage.foreignField = value
+		case "as":
+			as = value
+		default:
+			return nil, fmt.Errorf("unsupported $lookup option %v; only from, localField, foreignField, and as are supported", e.Key)
+		}
+	}
+	if stage.from == "" || stage.localField == "" || stage.foreignField == "" || as == "" {
+		return nil, fmt.Errorf("$lookup requires from, localField, foreignField, and as: %v", d)
+	}
+	stage.as = strings.Split(as, ".")
+	return stage, nil
+}
+
+func (p *pipeline) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	var err error
+	for _, stage := range p.stages {
+		if docs, err = stage.Apply(docs, resolve); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func (s *matchStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	var matched []bson.D
+	for _, doc := range docs {
+		if s.filter.Apply(doc) {
+			matched = append(matched, doc)
+		}
+	}
+	return matched, nil
+}
+
+func (s *projectStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	projected := make([]bson.D, 0, len(docs))
+	for _, doc := range docs {
+		value, _ := s.fields.apply(doc, s.exclude)
+		out := value.(bson.D)
+		for _, field := range s.computed {
+			if fieldValue, exists := field.expr.Eval(doc); exists {
+				out = setPath(out, field.path, fieldValue)
+			}
+		}
+		projected = append(projected, out)
+	}
+	return projected, nil
+}
+
+// Accumulated values of a single group
+type group struct {
+	key    any
+	values [][]any // For each accumulator
+}
+
+func (s *groupStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	// Groups are output in the order in which their first document was encountered
+	var groups []*group
+	for _, doc := range docs {
+		key, _ := s.key.Eval(doc)
+		var g *group
+		for _, existing := range groups {
+			if Compare(existing.key, key) == 0 {
+				g = existing
+				break
+			}
+		}
+		if g == nil {
+			g = &group{key: key, values: make([][]any, len(s.accumulators))}
+			groups = append(groups, g)
+		}
+		for i, acc := range s.accumulators {
+			if value, exists := acc.expr.Eval(doc); exists {
+				g.values[i] = append(g.values[i], value)
+			}
+		}
+	}
+
+	results := make([]bson.D, 0, len(groups))
+	for _, g := range groups {
+		out := bson.D{{Key: "_id", Value: g.key}}
+		for i, acc := range s.accumulators {
+			out = append(out, bson.E{Key: acc.name, Value: accumulate(acc.op, g.values[i])})
+		}
+		results = append(results, out)
+	}
+	return results, nil
+}
+
+func accumulate(op string, values []any) any {
+	switch op {
+	case "$sum":
+		return sum(values)
+	case "$avg":
+		total, count := 0.0, 0
+		for _, value := range values {
+			if f, isNumber := floatValue(value); isNumber {
+				total += f
+				count++
+			}
+		}
+		if count == 0 {
+			return nil
+		}
+		return total / float64(count)
+	case "$min", "$max":
+		var result any
+		for _, value := range values {
+			if value == nil {
+				continue
+			}
+			if result == nil || (op == "$min" && Compare(value, result) < 0) || (op == "$max" && Compare(value, result) > 0) {
+				result = value
+			}
+		}
+		return result
+	case "$push":
+		pushed := bson.A{}
+		return append(pushed, values...)
+	}
+	return nil
+}
+
+// Like MongoDB, the sum of int32s is an int32 unless it overflows, the sum of integers is an
+// int64, and the sum of any other numbers is a float64.  Non-numeric values are ignored.
+func sum(values []any) any {
+	var intSum int64
+	var floatSum float64
+	allInt32, allInt := true, true
+	for _, value := range values {
+		if i, isInt := intValue(value); isInt {
+			intSum += i
+			floatSum += float64(i)
+			if !isInt32(value) {
+				allInt32 = false
+			}
+		} else if f, isNumber := floatValue(value); isNumber {
+			floatSum += f
+			allInt, allInt32 = false, false
+		}
+	}
+	switch {
+	case allInt32 && intSum >= math.MinInt32 && intSum <= math.MaxInt32:
+		return int32(intSum)
+	case allInt:
+		return intSum
+	default:
+		return floatSum
+	}
+}
+
+// Literal ints in a pipeline are encoded as int32s by the mongo driver if they fit
+func isInt32(value any) bool {
+	switch v := value.(type) {
+	case int32:
+		return true
+	case int:
+		return v >= math.MinInt32 && v <= math.MaxInt32
+	}
+	return false
+}
+
+func (s *sortStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	sorted := make([]bson.D, len(docs))
+	copy(sorted, docs)
+	s.sort.Apply(sorted)
+	return sorted, nil
+}
+
+func (s *limitStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	if s.limit < int64(len(docs)) {
+		return docs[:s.limit], nil
+	}
+	return docs, nil
+}
+
+func (s *skipStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	if s.skip < int64(len(docs)) {
+		return docs[s.skip:], nil
+	}
+	return nil, nil
+}
+
+func (s *unwindStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	var unwound []bson.D
+	for _, doc := range docs {
+		value, exists := getPath(doc, s.path)
+		var elems bson.A
+		switch v := value.(type) {
+		case bson.A:
+			elems = v
+		case nil:
+		default:
+			// Non-array values are treated as a single-element array
+			elems = bson.A{v}
+		}
+		if !exists || len(elems) == 0 {
+			if s.preserveNullAndEmptyArrays {
+				out := doc
+				if exists {
+					out = removePath(doc, s.path)
+					if value != nil {
+						out = setPath(out, s.path, nil)
+					}
+				}
+				if s.includeArrayIndex != "" {
+					out = setPath(out, strings.Split(s.includeArrayIndex, "."), nil)
+				}
+				unwound = append(unwound, out)
+			}
+			continue
+		}
+		for i, elem := range elems {
+			out := setPath(doc, s.path, elem)
+			if s.includeArrayIndex != "" {
+				out = setPath(out, strings.Split(s.includeArrayIndex, "."), int64(i))
+			}
+			unwound = append(unwound, out)
+		}
+	}
+	return unwound, nil
+}
+
+func (s *lookupStage) Apply(docs []bson.D, resolve CollectionResolver) ([]bson.D, error) {
+	if resolve == nil {
+		return nil, fmt.Errorf("$lookup of %v is not supported here", s.from)
+	}
+	foreign, err := resolve(s.from)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []bson.D
+	for _, doc := range docs {
+		// Missing local values match foreign documents with a null or missing foreign field
+		localValues := PathValues(doc, s.localField)
+		if len(localValues) == 0 {
+			localValues = []any{nil}
+		}
+		matches := bson.A{}
+		for _, foreignDoc := range foreign {
+			foreignValues := PathValues(foreignDoc, s.foreignField)
+			if len(foreignValues) == 0 {
+				foreignValues = []any{nil}
+			}
+			if anyEqual(localValues, foreignValues) {
+				matches = append(matches, foreignDoc)
+			}
+		}
+		results = append(results, setPath(doc, s.as, matches))
+	}
+	return results, nil
+}
+
+func anyEqual(a, b []any) bool {
+	for _, va := range a {
+		for _, vb := range b {
+			if Compare(va, vb) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *fieldPath) Eval(doc bson.D) (any, bool) {
+	return getPath(doc, e.path)
+}
+
+func (e *literal) Eval(doc bson.D) (any, bool) {
+	return e.value, true
+}
+
+func (e *documentExpr) Eval(doc bson.D) (any, bool) {
+	out := bson.D{}
+	for i, expr := range e.exprs {
+		if value, exists := expr.Eval(doc); exists {
+			out = append(out, bson.E{Key: e.fields[i], Value: value})
+		}
+	}
+	return out, true
+}
+
+// Returns the value at path.  Like MongoDB field paths, if an array is encountered along the
+// path, the remainder of the path is applied to each of its elements, and an array is returned.
+func getPath(value any, path []string) (any, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	switch v := value.(type) {
+	case bson.D:
+		for _, e := range v {
+			if e.Key == path[0] {
+				return getPath(e.Value, path[1:])
+			}
+		}
+	case bson.A:
+		values := bson.A{}
+		for _, elem := range v {
+			if _, isD := elem.(bson.D); isD {
+				if elemValue, exists := getPath(elem, path); exists {
+					values = append(values, elemValue)
+				}
+			}
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// Returns a copy of d with the value at path set.  Embedded documents along the path are
+// copied rather than modified, and created if they don't exist.
+func setPath(d bson.D, path []string, value any) bson.D {
+	out := make(bson.D, 0, len(d)+1)
+	found := false
+	for _, e := range d {
+		if e.Key == path[0] {
+			found = true
+			if len(path) == 1 {
+				e = bson.E{Key: e.Key, Value: value}
+			} else {
+				child, _ := e.Value.(bson.D)
+				e = bson.E{Key: e.Key, Value: setPath(child, path[1:], value)}
+			}
+		}
+		out = append(out, e)
+	}
+	if !found {
+		if len(path) == 1 {
+			out = append(out, bson.E{Key: path[0], Value: value})
+		} else {
+			out = append(out, bson.E{Key: path[0], Value: setPath(nil, path[1:], value)})
+		}
+	}
+	return out
+}
+
+// Returns a copy of d without the value at path
+func removePath(d bson.D, path []string) bson.D {
+	out := make(bson.D, 0, len(d))
+	for _, e := range d {
+		if e.Key == path[0] {
+			if len(path) == 1 {
+				continue
+			}
+			if child, isD := e.Value.(bson.D); isD {
+				e = bson.E{Key: e.Key, Value: removePath(child, path[1:])}
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func (p *pipeline) String() string {
+	var stages []string
+	for _, stage := range p.stages {
+		stages = append(stages, stage.String())
+	}
+	return strings.Join(stages, " | ")
+}
+
+func (s *matchStage) String() string {
+	return fmt.Sprintf("$match(%v)", s.filter)
+}
+
+func (s *projectStage) String() string {
+	var computed []string
+	for _, field := range s.computed {
+		computed = append(computed, fmt.Sprintf("%v: %v", strings.Join(field.path, "."), field.expr))
+	}
+	return fmt.Sprintf("$project(exclude=%v, %v, %v)", s.exclude, s.fields, strings.Join(computed, ", "))
+}
+
+func (t *projectionTree) String() string {
+	if t.children == nil {
+		return "*"
+	}
+	var fields []string
+	for name, child := range t.children {
+		fields = append(fields, fmt.Sprintf("%v: %v", name, child))
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func (s *groupStage) String() string {
+	var accs []string
+	for _, acc := range s.accumulators {
+		accs = append(accs, fmt.Sprintf("%v: %v(%v)", acc.name, acc.op, acc.expr))
+	}
+	return fmt.Sprintf("$group(_id: %v, %v)", s.key, strings.Join(accs, ", "))
+}
+
+func (s *sortStage) String() string {
+	var fields []string
+	for _, field := range s.sort {
+		direction := 1
+		if field.descending {
+			direction = -1
+		}
+		fields = append(fields, fmt.Sprintf("%v: %v", field.path, direction))
+	}
+	return fmt.Sprintf("$sort(%v)", strings.Join(fields, ", "))
+}
+
+func (s *limitStage) String() string {
+	return fmt.Sprintf("$limit(%v)", s.limit)
+}
+
+func (s *skipStage) String() string {
+	return fmt.Sprintf("$skip(%v)", s.skip)
+}
+
+func (s *unwindStage) String() string {
+	return fmt.Sprintf("$unwind(%v)", strings.Join(s.path, "."))
+}
+
+func (s *lookupStage) String() string {
+	return fmt.Sprintf("$lookup(%v.%v = %v as %v)", s.from, s.foreignField, s.localField, strings.Join(s.as, "."))
+}
+
+func (e *fieldPath) String() string {
+	return "$" + strings.Join(e.path, ".")
+}
+
+func (e *literal) String() string {
+	return fmt.Sprintf("%v", e.value)
+}
+
+func (e *documentExpr) String() string {
+	var fields []string
+	for i, expr := range e.exprs {
+		fields = append(fields, fmt.Sprintf("%v: %v", e.fields[i], expr))
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
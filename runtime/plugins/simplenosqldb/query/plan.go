@@ -0,0 +1,208 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Extraction of the equality and range conditions of a filter, so that indexes can be used
+to find candidate documents instead of scanning the whole collection.
+*/
+
+// A condition on the values at a (possibly dotted) path that every document matched by a filter
+// must satisfy.  A document satisfies the condition if any of its values at the path (see
+// [PathValues]) satisfies it.
+//
+// If Values is non-empty, the condition is an equality condition, satisfied by a value equal
+// to any of Values.  Otherwise the condition is a range condition; nil bounds are unbounded.
+//
+// Conditions are necessary but not sufficient: documents satisfying a condition must still be
+// checked with the filter.
+type Condition struct {
+	Path           string
+	Values         []any
+	Lower          any
+	Upper          any
+	LowerInclusive bool
+	UpperInclusive bool
+}
+
+// Returns the conditions that are implied by filter.  Returns nil if the filter has no
+// conditions that can be evaluated with an index.
+func Conditions(filter Filter) []Condition {
+	switch f := filter.(type) {
+	case *and:
+		var conditions []Condition
+		for _, subfilter := range f.filters {
+			conditions = append(conditions, Conditions(subfilter)...)
+		}
+		return conditions
+	case *selectFilter, *index:
+		path, terminal := pathOf(f)
+		if c := valueCondition(terminal); c != nil {
+			c.Path = path
+			return []Condition{*c}
+		}
+	}
+	return nil
+}
+
+// Returns the values at a dotted path within a document, in the same way that a filter
+// selects values: arrays along the path are broadcast over, and numeric path components
+// index into arrays.  If the value at the path is an array, its elements are returned
+// in addition to the array.  Returns nil if there is no value at the path.
+func PathValues(d bson.D, path string) []any {
+	values := []any{d}
+	for i, component := range strings.Split(path, ".") {
+		var next []any
+		for _, value := range values {
+			if a, isA := value.(bson.A); isA {
+				if j, err := strconv.Atoi(component); err == nil {
+					if j < len(a) {
+						next = append(next, a[j])
+					}
+					continue
+				} else if i > 0 {
+					next = append(next, selectAll(a, component)...)
+					continue
+				}
+			}
+			next = append(next, selectAll(bson.A{value}, component)...)
+		}
+		values = next
+	}
+	var result []any
+	for _, value := range values {
+		result = append(result, value)
+		if a, isA := value.(bson.A); isA {
+			result = append(result, a...)
+		}
+	}
+	return result
+}
+
+func selectAll(a bson.A, fieldName string) []any {
+	var values []any
+	for _, item := range a {
+		if d, isD := item.(bson.D); isD {
+			for _, e := range d {
+				if e.Key == fieldName {
+					values = append(values, e.Value)
+					break
+				}
+			}
+		}
+	}
+	return values
+}
+
+// Follows a chain of selects, indexes, and broadcasts to the condition on the selected value
+func pathOf(filter Filter) (string, Filter) {
+	var path []string
+	for {
+		switch f := filter.(type) {
+		case *selectFilter:
+			path = append(path, f.fieldName)
+			filter = f.next
+		case *index:
+			path = append(path, strconv.Itoa(f.i))
+			filter = f.next
+		case *broadcast:
+			filter = f.next
+		default:
+			return strings.Join(path, "."), filter
+		}
+	}
+}
+
+func valueCondition(filter Filter) *Condition {
+	switch f := filter.(type) {
+	case *equals:
+		return &Condition{Values: []any{f.value}}
+	case *cmpInt:
+		return cmpCondition(f.value, f.cmp)
+	case *cmpFloat:
+		return cmpCondition(f.value, f.cmp)
+	case *and:
+		// All of the subconditions apply to the same value, so they can be combined
+		var combined *Condition
+		for _, subfilter := range f.filters {
+			if c := valueCondition(subfilter); c != nil {
+				combined = combined.intersect(c)
+			}
+		}
+		return combined
+	case *or:
+		// Only equality conditions can be combined, e.g. $in
+		combined := &Condition{}
+		for _, subfilter := range f.filters {
+			c := valueCondition(subfilter)
+			if c == nil || len(c.Values) == 0 {
+				return nil
+			}
+			combined.Values = append(combined.Values, c.Values...)
+		}
+		return combined
+	}
+	return nil
+}
+
+func cmpCondition(value any, cmp CmpType) *Condition {
+	switch cmp {
+	case Eq:
+		return &Condition{Values: []any{value}}
+	case Gt:
+		return &Condition{Lower: value}
+	case Gte:
+		return &Condition{Lower: value, LowerInclusive: true}
+	case Lt:
+		return &Condition{Upper: value}
+	case Lte:
+		return &Condition{Upper: value, UpperInclusive: true}
+	}
+	return nil
+}
+
+// Combines two conditions on the same value.  Equality conditions are preferred since they
+// are more selective; otherwise the range bounds are combined.
+func (c *Condition) intersect(other *Condition) *Condition {
+	if c == nil || len(c.Values) > 0 {
+		return c.orElse(other)
+	}
+	if len(other.Values) > 0 {
+		return other
+	}
+	combined := *c
+	if other.Lower != nil && (combined.Lower == nil || Compare(other.Lower, combined.Lower) >= 0) {
+		combined.Lower, combined.LowerInclusive = other.Lower, other.LowerInclusive
+	}
+	if other.Upper != nil && (combined.Upper == nil || Compare(other.Upper, combined.Upper) <= 0) {
+		combined.Upper, combined.UpperInclusive = other.Upper, other.UpperInclusive
+	}
+	return &combined
+}
+
+func (c *Condition) orElse(other *Condition) *Condition {
+	if c == nil {
+		return other
+	}
+	return c
+}
+
+func (c Condition) String() string {
+	if len(c.Values) > 0 {
+		return fmt.Sprintf("%v in %v", c.Path, c.Values)
+	}
+	lower, upper := "(", ")"
+	if c.LowerInclusive {
+		lower = "["
+	}
+	if c.UpperInclusive {
+		upper = "]"
+	}
+	return fmt.Sprintf("%v in %v%v, %v%v", c.Path, lower, c.Lower, c.Upper, upper)
+}
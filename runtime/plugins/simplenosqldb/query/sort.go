@@ -0,0 +1,249 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Sorting of documents, following MongoDB's sort semantics:
+https://www.mongodb.com/docs/manual/reference/method/cursor.sort/
+
+  - Values of different types are ordered by MongoDB's BSON comparison order, e.g. null
+    sorts before numbers, which sort before strings.
+  - Documents without a value for a sort field sort as if the value were null.
+  - If a sort field is an array, then an ascending sort uses the array's smallest element,
+    and a descending sort uses its largest element.
+
+Documents that compare equal retain their relative order.
+*/
+
+// A sort order, parsed from a MongoDB sort specification with [ParseSort]
+type Sort []sortField
+
+type sortField struct {
+	path       string
+	descending bool
+}
+
+// Parses a sort specification such as bson.D{{"price", -1}, {"name", 1}}
+func ParseSort(sortSpec bson.D) (Sort, error) {
+	var fields Sort
+	for _, e := range sortSpec {
+		direction, isNumber := floatValue(e.Value)
+		switch {
+		case isNumber && direction == 1:
+			fields = append(fields, sortField{path: e.Key})
+		case isNumber && direction == -1:
+			fields = append(fields, sortField{path: e.Key, descending: true})
+		default:
+			return nil, fmt.Errorf("invalid sort direction %v for %v; expected 1 or -1", e.Value, e.Key)
+		}
+	}
+	return fields, nil
+}
+
+// Sorts docs in place
+func (fields Sort) Apply(docs []bson.D) {
+	if len(fields) == 0 {
+		return
+	}
+	// Precompute the sort keys of each document
+	keys := make([][]any, len(docs))
+	for i, d := range docs {
+		for _, field := range fields {
+			keys[i] = append(keys[i], sortKey(d, field))
+		}
+	}
+	indices := make([]int, len(docs))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		for i, field := range fields {
+			cmp := Compare(keys[indices[a]][i], keys[indices[b]][i])
+			if field.descending {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	sorted := make([]bson.D, len(docs))
+	for i, j := range indices {
+		sorted[i] = docs[j]
+	}
+	copy(docs, sorted)
+}
+
+// Returns the value of a document that is used when sorting by field
+func sortKey(d bson.D, field sortField) any {
+	var candidates []any
+	for _, value := range PathValues(d, field.path) {
+		// Arrays are represented by their elements, which PathValues also returns
+		if _, isA := value.(bson.A); !isA {
+			candidates = append(candidates, value)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	key := candidates[0]
+	for _, candidate := range candidates[1:] {
+		cmp := Compare(candidate, key)
+		if (cmp < 0 && !field.descending) || (cmp > 0 && field.descending) {
+			key = candidate
+		}
+	}
+	return key
+}
+
+// The rank of a value's type in MongoDB's BSON comparison order
+func typeRank(value any) int {
+	if _, isNumber := floatValue(value); isNumber {
+		return 2
+	}
+	switch value.(type) {
+	case primitive.MinKey:
+		return 0
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case string, primitive.Symbol:
+		return 3
+	case bson.D, bson.M:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary, []byte:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime, time.Time:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	case primitive.MaxKey:
+		return 13
+	default:
+		return 12
+	}
+}
+
+// Compares two values with MongoDB's BSON comparison order.  Returns a negative number if a < b,
+// zero if a == b, and a positive number if a > b.
+func Compare(a, b any) int {
+	if rankA, rankB := typeRank(a), typeRank(b); rankA != rankB {
+		return rankA - rankB
+	}
+	switch va := a.(type) {
+	case string:
+		return compareStrings(va, stringValue(b))
+	case primitive.Symbol:
+		return compareStrings(string(va), stringValue(b))
+	case bson.D:
+		if vb, isD := b.(bson.D); isD {
+			for i := 0; i < len(va) && i < len(vb); i++ {
+				if cmp := compareStrings(va[i].Key, vb[i].Key); cmp != 0 {
+					return cmp
+				}
+				if cmp := Compare(va[i].Value, vb[i].Value); cmp != 0 {
+					return cmp
+				}
+			}
+			return len(va) - len(vb)
+		}
+	case bson.A:
+		vb := b.(bson.A)
+		for i := 0; i < len(va) && i < len(vb); i++ {
+			if cmp := Compare(va[i], vb[i]); cmp != 0 {
+				return cmp
+			}
+		}
+		return len(va) - len(vb)
+	case primitive.Binary:
+		if vb, isBinary := b.(primitive.Binary); isBinary {
+			return bytes.Compare(va.Data, vb.Data)
+		}
+	case primitive.ObjectID:
+		vb := b.(primitive.ObjectID)
+		return bytes.Compare(va[:], vb[:])
+	case bool:
+		vb := b.(bool)
+		switch {
+		case va == vb:
+			return 0
+		case !va:
+			return -1
+		default:
+			return 1
+		}
+	case primitive.DateTime, time.Time:
+		return compareOrdered(dateValue(a), dateValue(b))
+	case primitive.Timestamp:
+		vb := b.(primitive.Timestamp)
+		return primitive.CompareTimestamp(va, vb)
+	}
+	if fa, isNumber := floatValue(a); isNumber {
+		fb, _ := floatValue(b)
+		if ia, isInt := intValue(a); isInt {
+			if ib, isInt := intValue(b); isInt {
+				return compareOrdered(ia, ib)
+			}
+		}
+		return compareOrdered(fa, fb)
+	}
+	return 0
+}
+
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case primitive.Symbol:
+		return string(v)
+	}
+	return ""
+}
+
+func dateValue(value any) int64 {
+	switch v := value.(type) {
+	case primitive.DateTime:
+		return int64(v)
+	case time.Time:
+		return int64(primitive.NewDateTimeFromTime(v))
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package simplenosqldb
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Snapshots of a SimpleNoSQLDB.
+
+Snapshots are MongoDB Extended JSON documents that list the documents and indexes of each
+collection.  Snapshots are saved in canonical mode so that values round-trip exactly, but
+hand-written snapshots (e.g. fixture datasets) can use relaxed mode, e.g.:
+
+	{"collections": [{
+		"database": "user_db",
+		"collection": "users",
+		"indexes": [{"path": "username", "unique": true}],
+		"documents": [{"username": "alice", "age": 30}]
+	}]}
+
+Documents without an _id are assigned one when the snapshot is loaded.
+*/
+
+type (
+	dbSnapshot struct {
+		Collections []collectionSnapshot `bson:"collections"`
+	}
+
+	collectionSnapshot struct {
+		Database   string      `bson:"database"`
+		Collection string      `bson:"collection"`
+		Indexes    []IndexInfo `bson:"indexes,omitempty"`
+		Documents  []bson.D    `bson:"documents"`
+	}
+)
+
+// Writes the contents of every collection to w as MongoDB Extended JSON.  Used for snapshots.
+func (impl *SimpleNoSQLDB) Save(ctx context.Context, w io.Writer) error {
+	var snapshot dbSnapshot
+	for _, c := range impl.allCollections() {
+		snapshot.Collections = append(snapshot.Collections, collectionSnapshot{
+			Database:   c.database,
+			Collection: c.collection,
+			Indexes:    c.coll.Indexes(),
+			Documents:  c.coll.snapshot(),
+		})
+	}
+	data, err := bson.MarshalExtJSONIndent(snapshot, true, false, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Replaces the contents of every collection with the MongoDB Extended JSON read from r.  Used
+// for snapshots.  Collections that aren't in the snapshot are emptied.
+func (impl *SimpleNoSQLDB) Load(ctx context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var snapshot dbSnapshot
+	if err := bson.UnmarshalExtJSON(data, false, &snapshot); err != nil {
+		return err
+	}
+
+	for _, c := range impl.allCollections() {
+		c.coll.clear()
+	}
+	for _, c := range snapshot.Collections {
+		coll, err := impl.GetCollection(ctx, c.Database, c.Collection)
+		if err != nil {
+			return err
+		}
+		if err := coll.(*SimpleCollection).restore(c.Indexes, c.Documents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type namedCollection struct {
+	database   string
+	collection string
+	coll       *SimpleCollection
+}
+
+// Returns all collections, sorted by database and collection name
+func (impl *SimpleNoSQLDB) allCollections() []namedCollection {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+	var colls []namedCollection
+	for dbName, db := range impl.collections {
+		for collName, coll := range db {
+			colls = append(colls, namedCollection{dbName, collName, coll})
+		}
+	}
+	sort.Slice(colls, func(i, j int) bool {
+		if colls[i].database != colls[j].database {
+			return colls[i].database < colls[j].database
+		}
+		return colls[i].collection < colls[j].collection
+	})
+	return colls
+}
+
+// Removes all documents and indexes from the collection
+func (db *SimpleCollection) clear() {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.items = nil
+	db.indexes = make(map[string]*collectionIndex)
+}
+
+// Replaces the documents and indexes of the collection
+func (db *SimpleCollection) restore(indexes []IndexInfo, documents []bson.D) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	db.items = nil
+	db.indexes = make(map[string]*collectionIndex)
+	for _, info := range indexes {
+		db.indexes[info.Path] = newCollectionIndex(info.Path, info.Unique)
+	}
+	for _, d := range documents {
+		if err := db.insertOne(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package simplenosqldb_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSnapshot(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx := context.Background()
+	original, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+	teaColl, err := original.GetCollection(ctx, "testdb", "teas")
+	require.NoError(t, err)
+	coll := teaColl.(*simplenosqldb.SimpleCollection)
+	require.NoError(t, coll.CreateIndex(ctx, "rating", simplenosqldb.IndexOptions{}))
+	require.NoError(t, coll.CreateIndex(ctx, "type", simplenosqldb.IndexOptions{Unique: true}))
+	for _, tea := range teas {
+		require.NoError(t, coll.InsertOne(ctx, tea))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, original.Save(ctx, &buf))
+
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+	stale, err := db.GetCollection(ctx, "testdb", "stale")
+	require.NoError(t, err)
+	require.NoError(t, stale.InsertOne(ctx, newtea))
+	require.NoError(t, db.Load(ctx, &buf))
+
+	restoredColl, err := db.GetCollection(ctx, "testdb", "teas")
+	require.NoError(t, err)
+	restored := restoredColl.(*simplenosqldb.SimpleCollection)
+
+	// Documents, including their IDs and value types, are restored
+	var expected, actual []bson.D
+	cursor, err := coll.FindMany(ctx, bson.D{})
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &expected))
+	cursor, err = restored.FindMany(ctx, bson.D{})
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &actual))
+	assert.Equal(t, expected, actual)
+
+	// Indexes are restored
+	assert.Equal(t, coll.Indexes(), restored.Indexes())
+	assert.Error(t, restored.InsertOne(ctx, Tea{Type: "Oolong"}))
+	assert.Equal(t, []string{"Oolong"}, findTypes(t, ctx, restored, bson.D{{Key: "rating", Value: 7}}))
+
+	// Collections that weren't in the snapshot are emptied
+	count, err := stale.CountDocuments(ctx, bson.D{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestLoadRelaxedSnapshot(t *testing.T) {
+	before := simplenosqldb.SetVerbose(false)
+	defer simplenosqldb.SetVerbose(before)
+
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+
+	fixture := `{"collections": [{
+		"database": "testdb",
+		"collection": "teas",
+		"indexes": [{"path": "type", "unique": true}],
+		"documents": [
+			{"type": "Masala", "rating": 10, "sizes": [4]},
+			{"type": "Oolong", "rating": 7, "sizes": [8, 16]}
+		]
+	}]}`
+	require.NoError(t, db.Load(ctx, strings.NewReader(fixture)))
+
+	coll, err := db.GetCollection(ctx, "testdb", "teas")
+	require.NoError(t, err)
+	simple := coll.(*simplenosqldb.SimpleCollection)
+	assert.Equal(t, []string{"Oolong"}, findTypes(t, ctx, simple, bson.D{{Key: "sizes", Value: 16}}))
+	assert.Equal(t, []simplenosqldb.IndexInfo{{Path: "type", Unique: true}}, simple.Indexes())
+
+	var tea Tea
+	cursor, err := simple.FindOne(ctx, bson.D{{Key: "type", Value: "Masala"}})
+	require.NoError(t, err)
+	found, err := cursor.One(ctx, &tea)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 10, tea.Rating)
+
+	// Documents were assigned IDs
+	var docs []bson.M
+	cursor, err = simple.FindMany(ctx, bson.D{})
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &docs))
+	require.Len(t, docs, 2)
+	for _, doc := range docs {
+		assert.IsType(t, primitive.ObjectID{}, doc["_id"])
+	}
+}
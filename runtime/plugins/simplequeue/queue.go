@@ -1,22 +1,31 @@
-// Package simplequeue implements an simple in-memory [backend.Queue] that internally
-// uses a golang channel of capacity 10 for passing items from producer to consumer.
+// Package simplequeue implements an simple in-memory [backend.Queue] with a capacity of 10 items.
 //
 // Calls to [backend.Queue.Push] will block once the queue capacity reaches 10.
 package simplequeue
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"sync"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 )
 
-// A simple chan-based queue that implements the [backend.Queue] interface
+// A simple in-memory queue that implements the [backend.Queue] interface
+//
+// The queue's contents can be persisted with the [snapshot] package; items are saved as JSON.
+//
+// [snapshot]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/snapshot
 type SimpleQueue struct {
 	backend.Queue
-	q chan any
+	lock     sync.Mutex
+	items    []any // Items loaded from a snapshot are json.RawMessage
+	capacity int
+	changed  chan struct{} // Closed and replaced whenever items changes
 }
 
-// Instantiates a [backend.Queue] that internally uses a golang channel of capacity 10.
+// Instantiates a [backend.Queue] with a capacity of 10 items.
 //
 // Calls to [q.Push] will block once the queue capacity reaches 10.
 func NewSimpleQueue(ctx context.Context) (q *SimpleQueue, err error) {
@@ -26,40 +35,90 @@ func NewSimpleQueue(ctx context.Context) (q *SimpleQueue, err error) {
 // Instantiates a [simpleQueue] with the specified capacity.
 func newSimpleQueueWithCapacity(capacity int) *SimpleQueue {
 	return &SimpleQueue{
-		q: make(chan any, capacity),
+		capacity: capacity,
+		changed:  make(chan struct{}),
 	}
 }
 
+// Wakes up any blocked calls to Push and Pop.  Must be called with the lock held.
+func (q *SimpleQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
 // Pop implements backend.Queue.
 func (q *SimpleQueue) Pop(ctx context.Context, dst interface{}) (bool, error) {
-	select {
-	case v := <-q.q:
-		return true, backend.CopyResult(v, dst)
-	default:
-		{
-			select {
-			case v := <-q.q:
-				return true, backend.CopyResult(v, dst)
-			case <-ctx.Done():
-				return false, nil
+	for {
+		q.lock.Lock()
+		if len(q.items) > 0 {
+			v := q.items[0]
+			q.items = q.items[1:]
+			q.notify()
+			q.lock.Unlock()
+			if raw, isRaw := v.(json.RawMessage); isRaw {
+				return true, json.Unmarshal(raw, dst)
 			}
+			return true, backend.CopyResult(v, dst)
+		}
+		changed := q.changed
+		q.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false, nil
 		}
 	}
 }
 
 // Push implements backend.Queue.
 func (q *SimpleQueue) Push(ctx context.Context, item interface{}) (bool, error) {
-	select {
-	case q.q <- item:
-		return true, nil
-	default:
-		{
-			select {
-			case q.q <- item:
-				return true, nil
-			case <-ctx.Done():
-				return false, nil
-			}
+	for {
+		q.lock.Lock()
+		if len(q.items) < q.capacity {
+			q.items = append(q.items, item)
+			q.notify()
+			q.lock.Unlock()
+			return true, nil
+		}
+		changed := q.changed
+		q.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false, nil
 		}
 	}
 }
+
+// Writes the items in the queue to w as a JSON array.  Used for snapshots.
+func (q *SimpleQueue) Save(ctx context.Context, w io.Writer) error {
+	q.lock.Lock()
+	items := make([]any, len(q.items))
+	copy(items, q.items)
+	q.lock.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(items)
+}
+
+// Replaces the items in the queue with a JSON array read from r.  Used for snapshots.
+//
+// The loaded items can exceed the queue's capacity, in which case calls to Push will block
+// until enough items have been popped.
+func (q *SimpleQueue) Load(ctx context.Context, r io.Reader) error {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items = make([]any, 0, len(items))
+	for _, item := range items {
+		q.items = append(q.items, item)
+	}
+	q.notify()
+	return nil
+}
@@ -1,6 +1,7 @@
 package simplequeue
 
 import (
+	"bytes"
 	"context"
 	"sync/atomic"
 	"testing"
@@ -248,3 +249,40 @@ func TestTryTimeout(t *testing.T) {
 		require.Equal(t, second, rcv)
 	}
 }
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	type message struct {
+		ID   int
+		Body string
+	}
+
+	q := newSimpleQueueWithCapacity(2)
+	for i, body := range []string{"hello", "world"} {
+		success, err := q.Push(ctx, message{i, body})
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, q.Save(ctx, &buf))
+
+	restored := newSimpleQueueWithCapacity(2)
+	require.NoError(t, restored.Load(ctx, &buf))
+
+	// The restored queue is full
+	timeoutCtx, cancel := context.WithTimeout(ctx, 0*time.Second)
+	defer cancel()
+	success, err := restored.Push(timeoutCtx, message{2, "goodbye"})
+	require.NoError(t, err)
+	require.False(t, success)
+
+	for i, body := range []string{"hello", "world"} {
+		var rcv message
+		success, err := restored.Pop(ctx, &rcv)
+		require.NoError(t, err)
+		require.True(t, success)
+		require.Equal(t, message{i, body}, rcv)
+	}
+}
@@ -0,0 +1,134 @@
+// Package snapshot implements file-backed persistence for Blueprint's in-memory simple backends.
+//
+// A backend that implements [Snapshottable] can be restored from a snapshot file when it is
+// instantiated, and then periodically snapshotted back to the same file.  Snapshot files are
+// written atomically, so a crash while saving a snapshot never leaves a partially-written file.
+//
+// Snapshots do not need to be used directly by application workflow specs.  Instead, this code
+// is included in a compiled application when persistence is configured for a simple backend.
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Implemented by backends whose state can be saved to and loaded from a snapshot file
+type Snapshottable interface {
+	// Writes the current state of the backend to w
+	Save(ctx context.Context, w io.Writer) error
+
+	// Replaces the state of the backend with the state read from r
+	Load(ctx context.Context, r io.Reader) error
+}
+
+// Restores the state of target from the snapshot file at path, if it exists, and then, if
+// interval is non-empty, saves the state of target to path every interval.  When ctx is
+// cancelled, a final snapshot is saved.
+//
+// interval is a duration string such as "30s".  If interval is empty, target is restored from
+// path but never saved, which is useful for restoring fixture datasets that are checked in
+// to a repository.
+//
+// Returns a function that stops saving snapshots, saves a final snapshot if one hasn't been saved
+// already, and waits for it to be written.  Call it before the process exits, e.g. with
+// golang.Namespace.OnShutdown.
+func Persist(ctx context.Context, target Snapshottable, path string, interval string) (func(), error) {
+	var period time.Duration
+	if interval != "" {
+		var err error
+		if period, err = time.ParseDuration(interval); err != nil {
+			return nil, err
+		}
+		if period <= 0 {
+			return nil, fmt.Errorf("invalid snapshot interval %v for %v", interval, path)
+		}
+	}
+
+	if _, err := LoadFile(ctx, target, path); err != nil {
+		return nil, err
+	}
+
+	if period <= 0 {
+		return func() {}, nil
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	go run(ctx, target, path, period, stop, done)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stop) })
+		<-done
+	}, nil
+}
+
+func run(ctx context.Context, target Snapshottable, path string, period time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			save(target, path)
+		case <-ctx.Done():
+			save(target, path)
+			return
+		case <-stop:
+			save(target, path)
+			return
+		}
+	}
+}
+
+func save(target Snapshottable, path string) {
+	if err := SaveFile(context.Background(), target, path); err != nil {
+		slog.Error(fmt.Sprintf("unable to save snapshot %v: %v", path, err))
+	}
+}
+
+// Loads the state of target from the snapshot file at path.  Returns false if the file
+// does not exist, in which case target is unchanged.
+func LoadFile(ctx context.Context, target Snapshottable, path string) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err := target.Load(ctx, f); err != nil {
+		return false, fmt.Errorf("unable to load snapshot %v: %w", path, err)
+	}
+	return true, nil
+}
+
+// Saves the state of target to the snapshot file at path.  The snapshot is first written to a
+// temporary file in the same directory, which then replaces path.
+func SaveFile(ctx context.Context, target Snapshottable, path string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := target.Save(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
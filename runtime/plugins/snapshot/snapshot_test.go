@@ -0,0 +1,148 @@
+package snapshot_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/snapshot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type state struct {
+	lock  sync.Mutex
+	value string
+}
+
+func (c *state) set(value string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.value = value
+}
+
+func (c *state) get() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.value
+}
+
+func (c *state) Save(ctx context.Context, w io.Writer) error {
+	_, err := io.WriteString(w, c.get())
+	return err
+}
+
+func (c *state) Load(ctx context.Context, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	c.set(string(b))
+	return err
+}
+
+func TestSaveAndLoadFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "state.snapshot")
+
+	loaded, err := snapshot.LoadFile(ctx, &state{}, path)
+	require.NoError(t, err)
+	assert.False(t, loaded)
+
+	require.NoError(t, snapshot.SaveFile(ctx, &state{value: "hello"}, path))
+
+	c := &state{}
+	loaded, err = snapshot.LoadFile(ctx, c, path)
+	require.NoError(t, err)
+	assert.True(t, loaded)
+	assert.Equal(t, "hello", c.get())
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	require.NoError(t, os.WriteFile(path, []byte("initial"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &state{}
+	stop, err := snapshot.Persist(ctx, c, path, "10ms")
+	require.NoError(t, err)
+	assert.Equal(t, "initial", c.get())
+
+	// Snapshots are saved periodically
+	c.set("periodic")
+	assert.Eventually(t, func() bool {
+		b, err := os.ReadFile(path)
+		return err == nil && string(b) == "periodic"
+	}, time.Second, 5*time.Millisecond)
+
+	// A final snapshot is saved when the context is cancelled
+	c.set("final")
+	cancel()
+	assert.Eventually(t, func() bool {
+		b, err := os.ReadFile(path)
+		return err == nil && string(b) == "final"
+	}, time.Second, 5*time.Millisecond)
+
+	// Stopping after the context is cancelled doesn't save again
+	c.set("after")
+	stop()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "final", string(b))
+}
+
+func TestPersistStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+
+	c := &state{}
+	stop, err := snapshot.Persist(context.Background(), c, path, "1h")
+	require.NoError(t, err)
+
+	// Stopping saves a final snapshot, and returns once it is written
+	c.set("final")
+	stop()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "final", string(b))
+
+	// Stopping again is a no-op
+	c.set("again")
+	stop()
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "final", string(b))
+}
+
+func TestPersistWithoutInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	require.NoError(t, os.WriteFile(path, []byte("fixture"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &state{}
+	stop, err := snapshot.Persist(ctx, c, path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "fixture", c.get())
+
+	// The fixture is never overwritten
+	c.set("modified")
+	cancel()
+	stop()
+	time.Sleep(20 * time.Millisecond)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fixture", string(b))
+}
+
+func TestPersistInvalidInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.snapshot")
+	_, err := snapshot.Persist(context.Background(), &state{}, path, "often")
+	assert.Error(t, err)
+	_, err = snapshot.Persist(context.Background(), &state{}, path, "-1s")
+	assert.Error(t, err)
+}
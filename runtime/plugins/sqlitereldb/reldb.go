@@ -9,6 +9,10 @@ package sqlitereldb
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/jmoiron/sqlx"
 
@@ -16,6 +20,10 @@ import (
 )
 
 // An in-memory relational DB that uses the go-sqlite3 package
+//
+// The database's contents can be persisted with the [snapshot] package; snapshots are SQLite database files.
+//
+// [snapshot]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/snapshot
 type SqliteRelDB struct {
 	db *sqlx.DB
 }
@@ -53,3 +61,96 @@ func (s *SqliteRelDB) Prepare(ctx context.Context, query string) (*sql.Stmt, err
 func (s *SqliteRelDB) Select(ctx context.Context, dst interface{}, query string, args ...any) error {
 	return s.db.SelectContext(ctx, dst, query, args...)
 }
+
+// Writes the contents of the database to w as an SQLite database file.  Used for snapshots.
+func (s *SqliteRelDB) Save(ctx context.Context, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "sqlitereldb")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "snapshot.db")
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", filename); err != nil {
+		return err
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Reads an SQLite database file from r and copies its tables and indexes into the database,
+// replacing any existing tables with the same names.  Used for snapshots.
+func (s *SqliteRelDB) Load(ctx context.Context, r io.Reader) error {
+	dir, err := os.MkdirTemp("", "sqlitereldb")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "snapshot.db")
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Attached databases are per-connection, so use a single connection throughout
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS snapshot", filename); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE snapshot")
+
+	var tables []struct {
+		Name string `db:"name"`
+		SQL  string `db:"sql"`
+	}
+	err = sqlx.SelectContext(ctx, conn, &tables, "SELECT name, sql FROM snapshot.sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	err = sqlx.SelectContext(ctx, conn, &indexes, "SELECT sql FROM snapshot.sqlite_master WHERE type = 'index' AND sql IS NOT NULL")
+	if err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range tables {
+		statements := []string{
+			fmt.Sprintf("DROP TABLE IF EXISTS main.%q", table.Name),
+			table.SQL,
+			fmt.Sprintf("INSERT INTO main.%q SELECT * FROM snapshot.%q", table.Name, table.Name),
+		}
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("unable to restore table %v: %w", table.Name, err)
+			}
+		}
+	}
+	for _, index := range indexes {
+		if _, err := tx.ExecContext(ctx, index); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
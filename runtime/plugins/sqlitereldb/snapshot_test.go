@@ -0,0 +1,44 @@
+package sqlitereldb_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/sqlitereldb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlitereldb.NewSqliteRelDB(ctx)
+	require.NoError(t, err)
+
+	batch := []string{
+		`CREATE TABLE snapshot_teas (id INTEGER PRIMARY KEY, name TEXT, rating INT);`,
+		`CREATE INDEX snapshot_teas_rating ON snapshot_teas (rating);`,
+		`INSERT INTO snapshot_teas (name, rating) VALUES ('Masala', 10);`,
+		`INSERT INTO snapshot_teas (name, rating) VALUES ('Oolong', 7);`,
+	}
+	for _, b := range batch {
+		_, err = db.Exec(ctx, b)
+		require.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, db.Save(ctx, &buf))
+
+	// Modify the database, then restore the snapshot
+	_, err = db.Exec(ctx, `DELETE FROM snapshot_teas WHERE name = 'Masala';`)
+	require.NoError(t, err)
+	require.NoError(t, db.Load(ctx, &buf))
+
+	var names []string
+	require.NoError(t, db.Select(ctx, &names, `SELECT name FROM snapshot_teas ORDER BY id;`))
+	require.Equal(t, []string{"Masala", "Oolong"}, names)
+
+	var index string
+	require.NoError(t, db.Get(ctx, &index, `SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'snapshot_teas';`))
+	require.Equal(t, "snapshot_teas_rating", index)
+}
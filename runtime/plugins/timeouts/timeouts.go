@@ -0,0 +1,171 @@
+// Package timeouts implements the deadlines used by clients generated by the Blueprint timeouts plugin.
+//
+// Each call is made with a deadline that is determined by a [Timeout]:
+//   - a fixed duration, after which the call times out
+//   - a deadline budget, where a call may use at most a fraction of the time remaining until the caller's own
+//     deadline, so that a service leaves time for its other calls and for any retries
+//
+// If a timeout has both, the earlier deadline applies.  A call's deadline never exceeds the caller's deadline.
+//
+// Timeouts are configured for a service as a whole, and can be overridden for individual methods; see
+// [ParseTimeouts].
+//
+// This package does not need to be used directly by application workflow specs.  Instead, this code is
+// included in a compiled application when the timeouts plugin is used in a wiring spec.
+package timeouts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/methodpolicy"
+)
+
+// The deadline of calls
+type Timeout struct {
+	Duration time.Duration // The maximum duration of calls; 0 is unlimited
+	Budget   float64       // The maximum fraction of the time remaining until the caller's deadline; 0 is unlimited
+}
+
+// Timeouts for a service, keyed by method name.  The timeout for "*" applies to all methods; the fields that are
+// set in the timeout of a method override those of "*".
+type Timeouts map[string]Timeout
+
+// Parses a timeout from a comma-separated list of key:value pairs, e.g. "timeout:1s,budget:0.5".  Omitted keys
+// are 0.
+func ParseTimeout(s string) (Timeout, error) {
+	var timeout Timeout
+	err := methodpolicy.ParseFields(s, func(key string, value string) (err error) {
+		switch key {
+		case "timeout":
+			timeout.Duration, err = time.ParseDuration(value)
+		case "budget":
+			timeout.Budget, err = strconv.ParseFloat(value, 64)
+		default:
+			return methodpolicy.ErrUnknownKey
+		}
+		return err
+	})
+	if err != nil {
+		return Timeout{}, err
+	}
+	return timeout, timeout.Validate()
+}
+
+// Returns an error if the timeout is empty or any of its values are out of range
+func (t Timeout) Validate() error {
+	if t.Duration < 0 || t.Budget < 0 || t.Budget > 1 {
+		return fmt.Errorf("invalid timeout %v; the timeout must not be negative and the budget must be between 0 and 1", t)
+	}
+	if t.Duration == 0 && t.Budget == 0 {
+		return fmt.Errorf("invalid timeout; expected a timeout or a budget")
+	}
+	return nil
+}
+
+// Returns the timeout in the format parsed by [ParseTimeout]
+func (t Timeout) String() string {
+	var fields []string
+	if t.Duration != 0 {
+		fields = append(fields, "timeout:"+t.Duration.String())
+	}
+	if t.Budget != 0 {
+		fields = append(fields, "budget:"+strconv.FormatFloat(t.Budget, 'g', -1, 64))
+	}
+	return strings.Join(fields, ",")
+}
+
+// Returns t with the fields that are set in override replaced
+func (t Timeout) merge(override Timeout) Timeout {
+	if override.Duration != 0 {
+		t.Duration = override.Duration
+	}
+	if override.Budget != 0 {
+		t.Budget = override.Budget
+	}
+	return t
+}
+
+// Returns the deadline for a call made at now with ctx, and whether the call has a deadline other than that of ctx
+func (t Timeout) deadline(ctx context.Context, now time.Time) (deadline time.Time, ok bool) {
+	if t.Duration > 0 {
+		deadline, ok = now.Add(t.Duration), true
+	}
+	if incoming, hasDeadline := ctx.Deadline(); hasDeadline && t.Budget > 0 {
+		budget := now.Add(time.Duration(t.Budget * float64(incoming.Sub(now))))
+		if !ok || budget.Before(deadline) {
+			deadline, ok = budget, true
+		}
+	}
+	return
+}
+
+// Parses timeouts from a semicolon-separated list of method=timeout pairs, where each timeout is in the format
+// parsed by [ParseTimeout], e.g. "*=timeout:100ms;WriteCart=timeout:1s,budget:0.5".  As a shorthand, a duration
+// such as "1s" is a timeout for all methods.  The empty string is no timeouts.
+func ParseTimeouts(s string) (Timeouts, error) {
+	if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+		timeouts := Timeouts{"*": {Duration: d}}
+		return timeouts, timeouts["*"].Validate()
+	}
+	return methodpolicy.Parse(s, ParseTimeout)
+}
+
+// Returns the timeouts in the format parsed by [ParseTimeouts], with the timeout for "*" first and the remaining
+// methods in alphabetical order.
+func (t Timeouts) String() string {
+	return methodpolicy.Format(t)
+}
+
+// Enforces [Timeouts] on the calls to a service
+type ServiceTimeouts struct {
+	methods map[string]Timeout // The timeout of each method, merged with that of "*"
+	service Timeout            // The timeout of other methods
+}
+
+// Instantiates [ServiceTimeouts] with the timeouts parsed by [ParseTimeouts]
+func NewServiceTimeouts(timeouts string) (*ServiceTimeouts, error) {
+	parsed, err := ParseTimeouts(timeouts)
+	if err != nil {
+		return nil, err
+	}
+	s := &ServiceTimeouts{methods: make(map[string]Timeout), service: parsed["*"]}
+	for method, timeout := range parsed {
+		s.methods[method] = s.service.merge(timeout)
+	}
+	return s, nil
+}
+
+// Returns the timeout of method
+func (s *ServiceTimeouts) Timeout(method string) Timeout {
+	if timeout, exists := s.methods[method]; exists {
+		return timeout
+	}
+	return s.service
+}
+
+// Calls call with the deadline of method.  If the deadline expires, returns without waiting for call to return.
+func (s *ServiceTimeouts) Call(ctx context.Context, method string, call func(ctx context.Context) error) error {
+	deadline, ok := s.Timeout(method).deadline(ctx, time.Now())
+	if !ok {
+		return call(ctx)
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	complete := make(chan error, 1)
+	go func() {
+		complete <- call(ctx)
+	}()
+
+	// Wait till we either complete the request or it gets timed out
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("Request was timed out: %w", ctx.Err())
+	case err := <-complete:
+		return err
+	}
+}
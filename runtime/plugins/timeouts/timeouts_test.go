@@ -0,0 +1,96 @@
+package timeouts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeouts(t *testing.T) {
+	timeouts, err := ParseTimeouts("WriteCart=timeout:1s,budget:0.8; *=timeout:100ms")
+	require.NoError(t, err)
+	assert.Equal(t, Timeouts{
+		"*":         {Duration: 100 * time.Millisecond},
+		"WriteCart": {Duration: time.Second, Budget: 0.8},
+	}, timeouts)
+	assert.Equal(t, "*=timeout:100ms;WriteCart=timeout:1s,budget:0.8", timeouts.String())
+
+	timeouts, err = ParseTimeouts("500ms")
+	require.NoError(t, err)
+	assert.Equal(t, Timeouts{"*": {Duration: 500 * time.Millisecond}}, timeouts)
+
+	for _, invalid := range []string{"soon", "*", "*=", "*=budget:2", "*=timeout:-1s", "*=deadline:1s", "*=budget:0.5;*=budget:0.1", "-1s"} {
+		_, err := ParseTimeouts(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	s, err := NewServiceTimeouts("*=timeout:1s;Lookup=timeout:100ms;Write=budget:0.5;Other=timeout:1h")
+	require.NoError(t, err)
+	now := time.Now()
+
+	// Methods override the service's timeout
+	background := context.Background()
+	deadline, ok := s.Timeout("Lookup").deadline(background, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(100*time.Millisecond), deadline)
+	deadline, _ = s.Timeout("Unknown").deadline(background, now)
+	assert.Equal(t, now.Add(time.Second), deadline)
+
+	// The budget only applies to callers with a deadline, and the earlier deadline applies
+	assert.Equal(t, Timeout{Duration: time.Second, Budget: 0.5}, s.Timeout("Write"))
+	deadline, _ = s.Timeout("Write").deadline(background, now)
+	assert.Equal(t, now.Add(time.Second), deadline)
+	ctx, cancel := context.WithDeadline(background, now.Add(4*time.Second))
+	defer cancel()
+	deadline, _ = s.Timeout("Write").deadline(ctx, now)
+	assert.Equal(t, now.Add(time.Second), deadline)
+	ctx, cancel = context.WithDeadline(background, now.Add(time.Second))
+	defer cancel()
+	deadline, _ = s.Timeout("Write").deadline(ctx, now)
+	assert.Equal(t, now.Add(500*time.Millisecond), deadline)
+
+	// Methods without a timeout
+	s, err = NewServiceTimeouts("Lookup=budget:0.1")
+	require.NoError(t, err)
+	_, ok = s.Timeout("Write").deadline(ctx, now)
+	assert.False(t, ok)
+	_, ok = s.Timeout("Lookup").deadline(background, now)
+	assert.False(t, ok)
+}
+
+func TestCall(t *testing.T) {
+	s, err := NewServiceTimeouts("*=timeout:20ms;Write=budget:0.5")
+	require.NoError(t, err)
+
+	hang := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+	start := time.Now()
+	err = s.Call(context.Background(), "Lookup", hang)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	failure := errors.New("failure")
+	assert.Equal(t, failure, s.Call(context.Background(), "Lookup", func(ctx context.Context) error {
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return failure
+	}))
+
+	// The budget leaves time for the caller after the call times out
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err = s.Call(ctx, "Write", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NoError(t, ctx.Err())
+}
@@ -0,0 +1,122 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/circuitbreaker"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	spec := newWiringSpec("TestCircuitBreaker")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	circuitbreaker.AddCircuitBreaker(spec, leaf, 1000, 0.1, "1s")
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestCircuitBreaker = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.client.cb
+			  leaf.client.cb = CircuitBreaker(leaf, *=failurerate:0.1,minreqs:1000,interval:1s)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `client.cb.Call(ctx, "HelloInt", func(ctx context.Context) error {`)
+}
+
+func TestCircuitBreakerPolicies(t *testing.T) {
+	spec := newWiringSpec("TestCircuitBreakerPolicies")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf1 := workflow.Service[wf.TestNonLeafService](spec, "nonleaf1", leaf)
+	nonleaf2 := workflow.Service[wf.TestNonLeafService](spec, "nonleaf2", leaf)
+
+	circuitbreaker.Add(spec, leaf,
+		circuitbreaker.FailureRate(0.5), circuitbreaker.MinRequests(10), circuitbreaker.HalfOpen("10s", 3),
+		circuitbreaker.Method("HelloInt", circuitbreaker.ConsecutiveFailures(5)),
+		circuitbreaker.Caller("proc2", circuitbreaker.Method("HelloObject", circuitbreaker.SlowCallRate("200ms", 0.25))))
+	grpc.Deploy(spec, leaf)
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	proc1 := goproc.CreateProcess(spec, "proc1", nonleaf1)
+	proc2 := goproc.CreateProcess(spec, "proc2", nonleaf2)
+
+	app := assertBuildSuccess(t, spec, leafproc, proc1, proc2)
+
+	assertIR(t, app,
+		`TestCircuitBreakerPolicies = BlueprintApplication() {
+			leaf.grpc.addr
+			leaf.grpc.bind_addr = AddressConfig()
+			leaf.grpc.dial_addr = AddressConfig()
+			leaf.handler.visibility
+			leafproc = GolangProcessNode(leaf.grpc.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.grpc_server = GRPCServer(leaf, leaf.grpc.bind_addr)
+			  leafproc.logger = SLogger()
+			  leafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			nonleaf1.handler.visibility
+			nonleaf2.handler.visibility
+			proc1 = GolangProcessNode(leaf.grpc.dial_addr) {
+			  leaf.client = leaf.client.cb
+			  leaf.client.cb = CircuitBreaker(leaf.grpc_client, *=failurerate:0.5,minreqs:10,open:10s,probes:3;HelloInt=consecutive:5)
+			  leaf.grpc_client = GRPCClient(leaf.grpc.dial_addr)
+			  nonleaf1 = TestNonLeafService(leaf.client)
+			  proc1.logger = SLogger()
+			  proc1.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			proc2 = GolangProcessNode(leaf.grpc.dial_addr) {
+			  leaf.client = leaf.client.cb
+			  leaf.client.cb = CircuitBreaker(leaf.grpc_client, *=failurerate:0.5,minreqs:10,open:10s,probes:3;HelloInt=consecutive:5;HelloObject=slowcall:200ms,slowrate:0.25)
+			  leaf.grpc_client = GRPCClient(leaf.grpc.dial_addr)
+			  nonleaf2 = TestNonLeafService(leaf.client)
+			  proc2.logger = SLogger()
+			  proc2.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestCircuitBreakerInvalid(t *testing.T) {
+	for _, options := range [][]circuitbreaker.Option{
+		{},
+		{circuitbreaker.MinRequests(10)},
+		{circuitbreaker.FailureRate(1.5)},
+		{circuitbreaker.ConsecutiveFailures(5), circuitbreaker.HalfOpen("soon", 1)},
+		{circuitbreaker.Method("HelloInt", circuitbreaker.Method("HelloObject", circuitbreaker.ConsecutiveFailures(1)))},
+		{circuitbreaker.Method("HelloInt", circuitbreaker.Caller("proc", circuitbreaker.ConsecutiveFailures(1)))},
+		{circuitbreaker.Policies("*=consecutive:5,speed:1")},
+	} {
+		spec := newWiringSpec("TestCircuitBreakerInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		circuitbreaker.Add(spec, leaf, options...)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// Methods must exist
+	spec := newWiringSpec("TestCircuitBreakerInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	circuitbreaker.Add(spec, leaf, circuitbreaker.Policies("HelloNobody=consecutive:5"))
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	app := assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
@@ -0,0 +1,196 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/cmdbuilder"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/loadbalancer"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Tests for wiring specs declared in YAML or JSON files
+*/
+
+const declarativeYAML = `
+services:
+  - name: leaf
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl
+  - name: nonleaf
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestNonLeafService
+    args: [leaf]
+modifiers:
+  - type: retries.AddRetries
+    services: [leaf]
+    args: [10]
+  - type: grpc.Deploy
+  - type: goproc.CreateProcess
+    name: leafproc
+    services: [leaf]
+  - type: goproc.CreateProcess
+    name: nonleafproc
+    services: [nonleaf]
+instantiate: [leafproc, nonleafproc]
+`
+
+func TestDeclarativeSpecMatchesGoSpec(t *testing.T) {
+	// The equivalent wiring spec written in Go
+	spec := newWiringSpec("TestDeclarativeSpec")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	retries.AddRetries(spec, leaf, 10)
+	grpc.Deploy(spec, leaf)
+	grpc.Deploy(spec, nonleaf)
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+	expected := assertBuildSuccess(t, spec, leafproc, nonleafproc)
+
+	decl, err := cmdbuilder.ParseSpec("spec.yaml", []byte(declarativeYAML))
+	require.NoError(t, err)
+	assert.Equal(t, "spec", decl.Name)
+
+	declSpec := newWiringSpec("TestDeclarativeSpec")
+	nodesToBuild, err := decl.Build(declSpec)
+	require.NoError(t, err)
+	app := assertBuildSuccess(t, declSpec, nodesToBuild...)
+
+	assert.Equal(t, expected.String(), app.String())
+}
+
+func TestDeclarativeSpecJSON(t *testing.T) {
+	decl, err := cmdbuilder.ParseSpec("spec.json", []byte(`{
+		"name": "json_spec",
+		"services": [
+			{"name": "leaf", "type": "github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl"}
+		],
+		"modifiers": [
+			{"type": "goproc.CreateProcess", "name": "leafproc"}
+		],
+		"instantiate": ["leafproc"]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "json_spec", decl.SpecOption().Name)
+
+	spec := newWiringSpec("TestDeclarativeSpecJSON")
+	nodesToBuild, err := decl.Build(spec)
+	require.NoError(t, err)
+	assertBuildSuccess(t, spec, nodesToBuild...)
+}
+
+// The load balancer of TestLoadBalancer, with the replicas declared explicitly or from a template
+const declarativeLoadBalancerYAML = `
+services:
+  - name: leaf_1
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl
+  - name: leaf_2
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl
+  - name: nonleaf
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestNonLeafService
+    args: [leaf]
+modifiers:
+  - type: grpc.Deploy
+    services: [leaf_1, leaf_2]
+  - type: goproc.Deploy
+    services: [leaf_1, leaf_2]
+  - type: loadbalancer.Create
+    name: leaf
+    services: [leaf_1, leaf_2]
+    args: ["policy:p2c,failures:3,eject:10s"]
+  - type: retries.AddRetries
+    services: [leaf]
+    args: [3]
+  - type: goproc.CreateProcess
+    name: proc
+    services: [nonleaf]
+instantiate: [proc]
+`
+
+const declarativeReplicateYAML = `
+services:
+  - name: leaf_replica
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl
+  - name: nonleaf
+    type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestNonLeafService
+    args: [leaf]
+modifiers:
+  - type: loadbalancer.Replicate
+    name: leaf
+    services: [leaf_replica]
+    args: [2, "policy:p2c,failures:3,eject:10s"]
+  - type: grpc.Deploy
+    services: [leaf_1, leaf_2]
+  - type: goproc.Deploy
+    services: [leaf_1, leaf_2]
+  - type: retries.AddRetries
+    services: [leaf]
+    args: [3]
+  - type: goproc.CreateProcess
+    name: proc
+    services: [nonleaf]
+instantiate: [proc]
+`
+
+func TestDeclarativeLoadBalancer(t *testing.T) {
+	// The equivalent wiring spec written in Go
+	spec := newWiringSpec("TestDeclarativeLoadBalancer")
+	leaf := loadbalancer.Replicate(spec, "leaf", 2, func(replica string) {
+		workflow.Service[*wf.TestLeafServiceImpl](spec, replica)
+		grpc.Deploy(spec, replica)
+		goproc.Deploy(spec, replica)
+	}, loadbalancer.PowerOfTwoChoices(), loadbalancer.Eject(3, "10s"))
+	retries.AddRetries(spec, leaf, 3)
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	expected := assertBuildSuccess(t, spec, proc)
+
+	for name, yaml := range map[string]string{"create": declarativeLoadBalancerYAML, "replicate": declarativeReplicateYAML} {
+		decl, err := cmdbuilder.ParseSpec("spec.yaml", []byte(yaml))
+		require.NoError(t, err, name)
+
+		declSpec := newWiringSpec("TestDeclarativeLoadBalancer")
+		nodesToBuild, err := decl.Build(declSpec)
+		require.NoError(t, err, name)
+		app := assertBuildSuccess(t, declSpec, nodesToBuild...)
+
+		assert.Equal(t, expected.String(), app.String(), name)
+	}
+}
+
+func TestDeclarativeSpecErrors(t *testing.T) {
+	invalid := map[string]string{
+		"unknown field": `
+backends: [{name: db, type: simple.NoSQLDB, replicas: 3}]
+instantiate: [db]`,
+		"unknown modifier": `
+services: [{name: leaf, type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl}]
+modifiers: [{type: grpc.Undeploy}]
+instantiate: [leaf]`,
+		"unknown backend": `
+backends: [{name: db, type: oracle.Container}]
+instantiate: [db]`,
+		"undeclared service": `
+modifiers: [{type: grpc.Deploy, services: [leaf]}]
+instantiate: [leaf]`,
+		"unqualified service type": `
+services: [{name: leaf, type: TestLeafServiceImpl}]
+instantiate: [leaf]`,
+		"nothing to instantiate": `
+backends: [{name: db, type: simple.NoSQLDB}]`,
+		"undeclared replica": `
+services: [{name: leaf_replica, type: github.com/blueprint-uservices/blueprint/test/workflow/workflow.TestLeafServiceImpl}]
+modifiers:
+  - {type: loadbalancer.Replicate, name: leaf, services: [leaf_replica], args: [2]}
+  - {type: grpc.Deploy, services: [leaf_3]}
+instantiate: [leaf_1]`,
+	}
+	for name, yaml := range invalid {
+		_, err := cmdbuilder.ParseSpec("spec.yaml", []byte(yaml))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,97 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/faults"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultsServer(t *testing.T) {
+	spec := newWiringSpec("TestFaultsServer")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	faults.AddServer(spec, leaf, faults.Error("HelloInt", 0.1), faults.Hang("*", 0.01))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestFaultsServer = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf.server.faults.control.addr
+			leaf.server.faults.control.bind_addr = AddressConfig()
+			nonleaf.handler.visibility
+			proc = GolangProcessNode(leaf.server.faults.control.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.faults
+			  leaf.server.faults = FaultInjector(leaf, HelloInt:error:0.1,*:hang:0.01, control=leaf.server.faults.control.bind_addr)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `handler.injector.Call(ctx, "HelloInt", func(ctx context.Context) error {`)
+}
+
+func TestFaultsClient(t *testing.T) {
+	spec := newWiringSpec("TestFaultsClient")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	faults.AddClient(spec, leaf, faults.Drop("HelloNothing", 0.5), faults.Panic("HelloObject", 0.001))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestFaultsClient = BlueprintApplication() {
+			leaf.client.faults.control.addr
+			leaf.client.faults.control.bind_addr = AddressConfig()
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode(leaf.client.faults.control.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.client.faults
+			  leaf.client.faults = FaultInjector(leaf, HelloNothing:drop:0.5,HelloObject:panic:0.001, control=leaf.client.faults.control.bind_addr)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestFaultsInvalid(t *testing.T) {
+	spec := newWiringSpec("TestFaultsInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	faults.AddServer(spec, leaf, faults.Error("HelloInt", 1.5))
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	assertBuildFailure(t, spec, proc)
+
+	spec = newWiringSpec("TestFaultsInvalid")
+	leaf = workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf = workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	faults.AddClient(spec, leaf, faults.Schedule("HelloInt:crash:0.1"))
+	proc = goproc.CreateProcess(spec, "proc", nonleaf)
+	assertBuildFailure(t, spec, proc)
+
+	// Methods must exist
+	spec = newWiringSpec("TestFaultsInvalid")
+	leaf = workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf = workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	faults.AddServer(spec, leaf, faults.Error("HelloNobody", 0.5))
+	proc = goproc.CreateProcess(spec, "proc", nonleaf)
+	app := assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
@@ -123,6 +123,42 @@ func TestBasicServicesOverGRPCDifferentProcesses(t *testing.T) {
 		  }`)
 }
 
+func TestServicesOverGRPCWithTLS(t *testing.T) {
+	spec := newWiringSpec("TestServicesOverGRPCWithTLS")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	grpc.Deploy(spec, leaf, grpc.TLS())
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, leafproc, nonleafproc)
+
+	assertIR(t, app,
+		`TestServicesOverGRPCWithTLS = BlueprintApplication() {
+			leaf.grpc.addr
+			leaf.grpc.bind_addr = AddressConfig()
+			leaf.grpc.dial_addr = AddressConfig()
+			leaf.handler.visibility
+			leafproc = GolangProcessNode(leaf.grpc.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.grpc_server = GRPCServer(leaf, leaf.grpc.bind_addr, TLS)
+			  leafproc.logger = SLogger()
+			  leafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			nonleaf.handler.visibility
+			nonleafproc = GolangProcessNode(leaf.grpc.dial_addr) {
+			  leaf.client = leaf.grpc_client
+			  leaf.grpc_client = GRPCClient(leaf.grpc.dial_addr, TLS)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  nonleafproc.logger = SLogger()
+			  nonleafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
 func TestReachabilityErrorForServiceNotDeployedWithGRPC(t *testing.T) {
 	spec := newWiringSpec("TestReachabilityErrorForServiceNotDeployedWithGRPC")
 
@@ -0,0 +1,74 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/hedging"
+	"github.com/blueprint-uservices/blueprint/plugins/timeouts"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedging(t *testing.T) {
+	spec := newWiringSpec("TestHedging")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	hedging.Add(spec, leaf, hedging.Idempotent("HelloInt", "HelloNothing"), hedging.Delay("50ms"),
+		hedging.Method("HelloInt", hedging.Percentile(0.95), hedging.MaxHedges(2), hedging.MaxRate(0.2)))
+	timeouts.Add(spec, leaf, "1s")
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestHedging = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.client.hedging
+			  leaf.client.hedging = Hedging(leaf.client.timeout, HelloInt=delay:50ms,percentile:0.95,hedges:2,rate:0.2;HelloNothing=delay:50ms)
+			  leaf.client.timeout = TimeoutClient(leaf, *=timeout:1s)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `err = client.hedger.Call(ctx, "HelloObject", func(ctx context.Context) (func(), error) {`)
+}
+
+func TestHedgingInvalid(t *testing.T) {
+	for _, options := range [][]hedging.Option{
+		{},
+		{hedging.Delay("50ms")},
+		{hedging.Idempotent("HelloInt")},
+		{hedging.Idempotent("HelloInt"), hedging.Delay("soon")},
+		{hedging.Idempotent("HelloInt"), hedging.Percentile(1.5)},
+		{hedging.Idempotent("HelloInt"), hedging.Delay("50ms"), hedging.Method("HelloObject", hedging.MaxHedges(2))},
+		{hedging.Idempotent("HelloInt"), hedging.Method("HelloInt", hedging.Idempotent("HelloObject"))},
+		{hedging.Policies("*=delay:50ms")},
+	} {
+		spec := newWiringSpec("TestHedgingInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		hedging.Add(spec, leaf, options...)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// Methods must exist
+	spec := newWiringSpec("TestHedgingInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	hedging.Add(spec, leaf, hedging.Policies("HelloNobody=delay:50ms"))
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	app := assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
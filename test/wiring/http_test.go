@@ -0,0 +1,181 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/http"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/openapi"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP(t *testing.T) {
+	spec := newWiringSpec("TestHTTP")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	http.Deploy(spec, leaf)
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, leafproc, nonleafproc)
+
+	assertIR(t, app,
+		`TestHTTP = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf.http.addr
+			leaf.http.bind_addr = AddressConfig()
+			leaf.http.dial_addr = AddressConfig()
+			leafproc = GolangProcessNode(leaf.http.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.http_server = HTTPServer(leaf, leaf.http.bind_addr)
+			  leafproc.logger = SLogger()
+			  leafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			nonleaf.handler.visibility
+			nonleafproc = GolangProcessNode(leaf.http.dial_addr) {
+			  leaf.client = leaf.http_client
+			  leaf.http_client = HTTPClient(leaf.http.dial_addr)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  nonleafproc.logger = SLogger()
+			  nonleafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `router.Path("/HelloInt").HandlerFunc(handler.HelloInt)`)
+	assertGeneratedCode(t, app, `router.Methods("GET").Path("/openapi.json").HandlerFunc(handler.serveOpenAPI)`)
+	assertGeneratedCode(t, app, `ctx, cancel := propagation.Extract(r.Context(), propagation.HeaderCarrier(r.Header))`)
+	assertGeneratedCode(t, app, `propagation.Inject(ctx, propagation.HeaderCarrier(http_req.Header))`)
+	assertGeneratedCode(t, app, `rpcerrors.WriteHTTP(w, err)`)
+	assertGeneratedCode(t, app, `err = rpcerrors.FromHTTP(resp.StatusCode, resp_bytes)`)
+	assertGeneratedCode(t, app, `\"/HelloInt\": {\n      \"get\": {\n        \"operationId\": \"HelloInt\"`)
+}
+
+func TestHTTPMutualTLS(t *testing.T) {
+	spec := newWiringSpec("TestHTTPMutualTLS")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	http.Deploy(spec, leaf, http.MutualTLS())
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, leafproc, nonleafproc)
+
+	assertIR(t, app,
+		`TestHTTPMutualTLS = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf.http.addr
+			leaf.http.bind_addr = AddressConfig()
+			leaf.http.dial_addr = AddressConfig()
+			leafproc = GolangProcessNode(leaf.http.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.http_server = HTTPServer(leaf, leaf.http.bind_addr, MutualTLS)
+			  leafproc.logger = SLogger()
+			  leafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			nonleaf.handler.visibility
+			nonleafproc = GolangProcessNode(leaf.http.dial_addr) {
+			  leaf.client = leaf.http_client
+			  leaf.http_client = HTTPClient(leaf.http.dial_addr, MutualTLS)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  nonleafproc.logger = SLogger()
+			  nonleafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `tlsConfig, err := tlsconfig.Server(tlsCredentials)`)
+	assertGeneratedCode(t, app, `return srv.ListenAndServeTLS("", "")`)
+	assertGeneratedCode(t, app, `tlsConfig, err := tlsconfig.Client(tlsCredentials)`)
+	assertGeneratedCode(t, app, `tlsconfig.Register("leaf.http_server.tls", tlsconfig.Credentials{`)
+	assertGeneratedCode(t, app, `ServerName: "leaf",`)
+}
+
+func TestHTTPREST(t *testing.T) {
+	spec := newWiringSpec("TestHTTPREST")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	http.Deploy(spec, leaf, http.Route("HelloInt", "GET /hello/{a}"), http.Routes("HelloObject=PUT /objects"))
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, leafproc, nonleafproc)
+
+	assertIR(t, app,
+		`TestHTTPREST = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf.http.addr
+			leaf.http.bind_addr = AddressConfig()
+			leaf.http.dial_addr = AddressConfig()
+			leafproc = GolangProcessNode(leaf.http.bind_addr) {
+			  leaf = TestLeafService()
+			  leaf.http_server = HTTPServer(leaf, leaf.http.bind_addr, REST(HelloInt=GET /hello/{a};HelloObject=PUT /objects))
+			  leafproc.logger = SLogger()
+			  leafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			nonleaf.handler.visibility
+			nonleafproc = GolangProcessNode(leaf.http.dial_addr) {
+			  leaf.client = leaf.http_client
+			  leaf.http_client = HTTPClient(leaf.http.dial_addr)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  nonleafproc.logger = SLogger()
+			  nonleafproc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `router.Methods("GET").Path("/hello/{a}").HandlerFunc(handler.HelloInt)`)
+	assertGeneratedCode(t, app, `router.Methods("POST").Path("/HelloNothing").HandlerFunc(handler.HelloNothing)`)
+	assertGeneratedCode(t, app, `Obj workflow.TestLeafObject `+"`json:\"obj\"`")
+	assertGeneratedCode(t, app, `Result int32 `+"`json:\"result\"`")
+	assertGeneratedCode(t, app, `err = client.do(ctx, "PUT", req_path, req_query, &req_body, &response)`)
+	assertGeneratedCode(t, app, `\"/hello/{a}\": {\n      \"get\": {\n        \"operationId\": \"HelloInt\"`)
+	assertGeneratedCode(t, app, `\"TestNestedLeafObject\": {\n        \"type\": \"object\"`)
+}
+
+func TestHTTPRESTInvalid(t *testing.T) {
+	for _, option := range []http.Option{
+		http.Route("HelloInt", "GET"),
+		http.Route("HelloInt", "FETCH /hello"),
+		http.Route("HelloInt", "GET hello"),
+		http.Routes("HelloInt"),
+	} {
+		spec := newWiringSpec("TestHTTPRESTInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		http.Deploy(spec, leaf, option)
+		assertBuildFailure(t, spec, goproc.CreateProcess(spec, "leafproc", leaf))
+	}
+
+	// Routes must be for methods that exist, bind path variables to arguments, and be distinct
+	for _, routes := range []string{
+		"HelloNobody=GET /hello",
+		"HelloInt=GET /hello/{b}",
+		"HelloInt=GET /hello/{a};HelloObject=GET /hello/{obj}",
+		"HelloNothing=GET /openapi.json",
+	} {
+		spec := newWiringSpec("TestHTTPRESTInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		http.Deploy(spec, leaf, http.Routes(routes))
+		app := assertBuildSuccess(t, spec, goproc.CreateProcess(spec, "leafproc", leaf))
+		require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")), routes)
+	}
+}
+
+func TestHTTPOpenAPIUnknownTypes(t *testing.T) {
+	spec := newWiringSpec("TestHTTPOpenAPIUnknownTypes")
+
+	links := workflow.Service[openapi.LinkService](spec, "links")
+	http.Deploy(spec, links)
+
+	app := assertBuildSuccess(t, spec, goproc.CreateProcess(spec, "linksproc", links))
+
+	// Types that cannot be described are any value rather than failing the build, and embedded fields are left out
+	assertGeneratedCode(t, app, `\"Link\": {\n        \"type\": \"object\",\n        \"properties\": {\n          \"Tags\": {\n            \"type\": \"array\",\n            \"nullable\": true,\n            \"items\": {\n              \"type\": \"string\"\n            }\n          },\n          \"Target\": {}\n        }\n      }`)
+	assertGeneratedCode(t, app, `\"Ret0\": {\n                      \"nullable\": true\n                    }`)
+}
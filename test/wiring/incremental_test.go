@@ -0,0 +1,140 @@
+package wiring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/http"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Tests for incremental compilation
+*/
+
+// Builds an application with a process for each of procs; retryProcs also have retries
+func buildIncrementalApp(t *testing.T, procs []string, retryProcs ...string) *ir.ApplicationNode {
+	spec := newWiringSpec("TestIncremental")
+	retry := make(map[string]bool)
+	for _, proc := range retryProcs {
+		retry[proc] = true
+	}
+	var toInstantiate []string
+	for _, proc := range procs {
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, proc+"_leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, proc+"_nonleaf", leaf)
+		if retry[proc] {
+			retries.AddRetries(spec, leaf, 3)
+		}
+		toInstantiate = append(toInstantiate, goproc.CreateProcess(spec, proc, leaf, nonleaf))
+	}
+	return assertBuildSuccess(t, spec, toInstantiate...)
+}
+
+func modTime(t *testing.T, path string) time.Time {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.ModTime()
+}
+
+func TestIncrementalCompilation(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "build")
+	ir.SetIncrementalCompilation(true)
+	defer ir.SetIncrementalCompilation(false)
+
+	app := buildIncrementalApp(t, []string{"proc1", "proc2"})
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	proc1Main := filepath.Join(outputDir, "proc1", "proc1", "main.go")
+	proc2Main := filepath.Join(outputDir, "proc2", "proc2", "main.go")
+	proc1Time, proc2Time := modTime(t, proc1Main), modTime(t, proc2Main)
+	time.Sleep(10 * time.Millisecond)
+
+	// Recompiling the same wiring spec doesn't regenerate anything
+	app = buildIncrementalApp(t, []string{"proc1", "proc2"})
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	assert.Equal(t, proc1Time, modTime(t, proc1Main))
+	assert.Equal(t, proc2Time, modTime(t, proc2Main))
+
+	// Only the process whose services changed is regenerated
+	app = buildIncrementalApp(t, []string{"proc1", "proc2"}, "proc1")
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	assert.NotEqual(t, proc1Time, modTime(t, proc1Main))
+	assert.Equal(t, proc2Time, modTime(t, proc2Main))
+
+	// Processes that were removed from the wiring spec are pruned
+	app = buildIncrementalApp(t, []string{"proc1"}, "proc1")
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	assert.NoDirExists(t, filepath.Join(outputDir, "proc2"))
+	assert.FileExists(t, proc1Main)
+}
+
+func TestIncrementalCompilationRequiresCache(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "notes.txt"), []byte("not generated by blueprint"), 0644))
+
+	ir.SetIncrementalCompilation(true)
+	defer ir.SetIncrementalCompilation(false)
+
+	app := buildIncrementalApp(t, []string{"proc1"})
+	assert.Error(t, app.GenerateArtifacts(outputDir))
+	assert.FileExists(t, filepath.Join(outputDir, "notes.txt"))
+}
+
+// Builds an application whose leaf service is deployed with mutual TLS; the client of the leaf has retries if retry is set
+func buildIncrementalTLSApp(t *testing.T, retry bool) *ir.ApplicationNode {
+	spec := newWiringSpec("TestIncrementalTLS")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	if retry {
+		retries.AddRetries(spec, leaf, 3)
+	}
+	http.Deploy(spec, leaf, http.MutualTLS())
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+	return assertBuildSuccess(t, spec, leafproc, nonleafproc)
+}
+
+func TestIncrementalCompilationTLS(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "build")
+	ir.SetIncrementalCompilation(true)
+	defer ir.SetIncrementalCompilation(false)
+
+	app := buildIncrementalTLSApp(t, false)
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	serverCerts := filepath.Join(outputDir, "leafproc", "leafproc", "tlscerts", "leaf_http_server_tls")
+	clientCerts := filepath.Join(outputDir, "nonleafproc", "nonleafproc", "tlscerts", "leaf_http_client_tls")
+	serverTime, clientTime := modTime(t, filepath.Join(serverCerts, "cert.pem")), modTime(t, filepath.Join(clientCerts, "cert.pem"))
+	clientCert, err := os.ReadFile(filepath.Join(clientCerts, "cert.pem"))
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	// The certificates don't change the content hash of the processes, so nothing is regenerated
+	app = buildIncrementalTLSApp(t, false)
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	assert.Equal(t, serverTime, modTime(t, filepath.Join(serverCerts, "cert.pem")))
+	assert.Equal(t, clientTime, modTime(t, filepath.Join(clientCerts, "cert.pem")))
+
+	// The client is regenerated with the same certificates, so it still trusts the server, and vice versa
+	app = buildIncrementalTLSApp(t, true)
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	assert.Equal(t, serverTime, modTime(t, filepath.Join(serverCerts, "cert.pem")))
+	assert.NotEqual(t, clientTime, modTime(t, filepath.Join(clientCerts, "cert.pem")))
+	regenerated, err := os.ReadFile(filepath.Join(clientCerts, "cert.pem"))
+	require.NoError(t, err)
+	assert.Equal(t, clientCert, regenerated)
+	for _, file := range []string{"ca.pem", "client_ca.pem"} {
+		ca, err := os.ReadFile(filepath.Join(serverCerts, file))
+		require.NoError(t, err)
+		clientCA, err := os.ReadFile(filepath.Join(clientCerts, "ca.pem"))
+		require.NoError(t, err)
+		assert.Equal(t, ca, clientCA)
+	}
+}
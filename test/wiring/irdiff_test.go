@@ -0,0 +1,83 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/irdiff"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/irexport"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Tests for structural diffs between the IR of two wiring specs
+*/
+
+func TestDiffSameProcessToDifferentProcesses(t *testing.T) {
+	oldSpec := newWiringSpec("TestDiff")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](oldSpec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](oldSpec, "nonleaf", leaf)
+	myproc := goproc.CreateProcess(oldSpec, "myproc", leaf, nonleaf)
+	oldApp := assertBuildSuccess(t, oldSpec, myproc)
+
+	newSpec := newWiringSpec("TestDiff")
+	leaf = workflow.Service[*wf.TestLeafServiceImpl](newSpec, "leaf")
+	nonleaf = workflow.Service[wf.TestNonLeafService](newSpec, "nonleaf", leaf)
+	retries.AddRetries(newSpec, leaf, 10)
+	grpc.Deploy(newSpec, leaf)
+	leafproc := goproc.CreateProcess(newSpec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(newSpec, "nonleafproc", nonleaf)
+	newApp := assertBuildSuccess(t, newSpec, leafproc, nonleafproc)
+
+	d := irdiff.Compare(irexport.Export(oldSpec, oldApp), irexport.Export(newSpec, newApp))
+	require.False(t, d.Empty())
+
+	var added, removed []string
+	for _, n := range d.Added {
+		added = append(added, n.Name)
+	}
+	for _, n := range d.Removed {
+		removed = append(removed, n.Name)
+	}
+	assert.Contains(t, added, "leafproc")
+	assert.Contains(t, added, "nonleafproc")
+	assert.Contains(t, added, "leaf.grpc_server")
+	assert.Contains(t, added, "leaf.client.retrier")
+	assert.Equal(t, []string{"myproc", "myproc.logger", "myproc.stdoutmetriccollector"}, removed)
+
+	assert.Contains(t, d.Moved, irdiff.PlacementDiff{Name: "leaf", Old: []string{"myproc"}, New: []string{"leafproc"}})
+	assert.Contains(t, d.Moved, irdiff.PlacementDiff{Name: "nonleaf", Old: []string{"myproc"}, New: []string{"nonleafproc"}})
+
+	// Server-side modifier chains include the namespaces that the services are deployed to
+	assert.Equal(t, []irdiff.ModifierDiff{
+		{
+			Name:   "leaf",
+			OldSrc: []string{"leaf.client"},
+			NewSrc: []string{"leaf.client", "leaf.client.retrier", "leaf.grpc_client"},
+			OldDst: []string{"leaf.myproc", "leaf.dst"},
+			NewDst: []string{"leaf.leafproc", "leaf.grpc_server", "leaf.dst"},
+		},
+		{
+			Name:   "nonleaf",
+			OldSrc: []string{"nonleaf.client"},
+			NewSrc: []string{"nonleaf.client"},
+			OldDst: []string{"nonleaf.myproc", "nonleaf.dst"},
+			NewDst: []string{"nonleaf.nonleafproc", "nonleaf.dst"},
+		},
+	}, d.Modifiers)
+
+	text := d.Text()
+	assert.Contains(t, text, "  ~ leaf: myproc -> leafproc\n")
+	assert.Contains(t, text, "  - myproc (goproc.Process) in (application)\n")
+
+	_, err := d.JSON()
+	require.NoError(t, err)
+
+	// A spec compared with itself has no differences
+	assert.True(t, irdiff.Compare(irexport.Export(oldSpec, oldApp), irexport.Export(oldSpec, oldApp)).Empty())
+}
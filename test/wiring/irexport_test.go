@@ -0,0 +1,79 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/irexport"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Tests for exporting the IR as a graph
+*/
+
+func TestExportServicesOverGRPCDifferentProcesses(t *testing.T) {
+	spec := newWiringSpec("TestExportServicesOverGRPCDifferentProcesses")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	grpc.Deploy(spec, leaf)
+	grpc.Deploy(spec, nonleaf)
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, leafproc, nonleafproc)
+	graph := irexport.Export(spec, app)
+
+	app_ns := "TestExportServicesOverGRPCDifferentProcesses"
+	var namespaces []string
+	for _, ns := range graph.Namespaces {
+		namespaces = append(namespaces, ns.ID)
+	}
+	assert.Equal(t, []string{app_ns, app_ns + "/leafproc", app_ns + "/nonleafproc"}, namespaces)
+
+	nodes := make(map[string]irexport.Node)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	assert.Equal(t, irexport.KindNamespace, nodes[app_ns+"/leafproc"].Kind)
+	assert.Equal(t, irexport.KindConfig, nodes[app_ns+"/leaf.grpc.dial_addr"].Kind)
+	assert.Equal(t, app_ns+"/nonleafproc", nodes[app_ns+"/nonleafproc/leaf.grpc_client"].Namespace)
+
+	assert.Contains(t, graph.Edges, irexport.Edge{From: app_ns + "/leafproc/leaf.grpc_server", To: app_ns + "/leafproc/leaf", Label: "Wrapped"})
+	assert.Contains(t, graph.Edges, irexport.Edge{From: app_ns + "/nonleafproc", To: app_ns + "/leaf.grpc.dial_addr", Label: "arg"})
+
+	require.Len(t, graph.Addresses, 2)
+	assert.Equal(t, irexport.Address{
+		Name:   "leaf.grpc.addr",
+		Server: app_ns + "/leafproc/leaf.grpc_server",
+		Bind:   app_ns + "/leaf.grpc.bind_addr",
+		Dial:   app_ns + "/leaf.grpc.dial_addr",
+	}, graph.Addresses[0])
+
+	var leafPtr *irexport.Pointer
+	for i := range graph.Pointers {
+		if graph.Pointers[i].Name == "leaf" {
+			leafPtr = &graph.Pointers[i]
+		}
+	}
+	require.NotNil(t, leafPtr)
+	assert.Equal(t, []string{"leaf.client", "leaf.grpc_client"}, leafPtr.SrcModifiers)
+
+	// The JSON export should be deterministic
+	json1, err := graph.JSON()
+	require.NoError(t, err)
+	json2, err := irexport.Export(spec, app).JSON()
+	require.NoError(t, err)
+	assert.Equal(t, string(json1), string(json2))
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, "subgraph \"cluster_"+app_ns+"/leafproc\"")
+	assert.Contains(t, dot, "\""+app_ns+"/leaf.grpc.dial_addr\" -> \""+app_ns+"/leaf.grpc.bind_addr\"")
+}
@@ -0,0 +1,101 @@
+package wiring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/latency"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyDistribution(t *testing.T) {
+	spec := newWiringSpec("TestLatencyDistribution")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	latency.Add(spec, leaf, latency.LogNormal("50ms", 0.5), latency.Methods("HelloInt"), latency.Fraction(0.1))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestLatencyDistribution = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.latency
+			  leaf.server.latency = LatencyInjector(leaf, lognormal(50ms, 0.5), methods=[HelloInt], fraction=0.1)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `server.injector.Inject(ctx, "HelloInt")`)
+
+	// Methods must exist
+	spec = newWiringSpec("TestLatencyDistribution")
+	leaf = workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf = workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	latency.AddFixed(spec, leaf, "10ms", latency.Methods("HelloNobody"))
+	proc = goproc.CreateProcess(spec, "proc", nonleaf)
+	app = assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
+
+func TestLatencyEmpirical(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cdf.csv")
+	require.NoError(t, os.WriteFile(path, []byte("# latency, probability\n5ms, 0.5\n20ms, 0.99\n250ms, 1\n"), 0644))
+
+	spec := newWiringSpec("TestLatencyEmpirical")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	latency.Add(spec, leaf, latency.Empirical(path))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestLatencyEmpirical = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.latency
+			  leaf.server.latency = LatencyInjector(leaf, empirical(5ms:0.5, 20ms:0.99, 250ms:1))
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestLatencyInvalid(t *testing.T) {
+	for _, dist := range []latency.Distribution{
+		latency.Normal("100ms", "often"),
+		latency.Pareto("10ms", 0),
+		latency.Empirical(filepath.Join(t.TempDir(), "missing.csv")),
+	} {
+		spec := newWiringSpec("TestLatencyInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		latency.Add(spec, leaf, dist)
+		assertBuildFailure(t, spec, nonleaf)
+	}
+
+	spec := newWiringSpec("TestLatencyInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	latency.AddFixed(spec, leaf, "10ms", latency.Fraction(2))
+	assertBuildFailure(t, spec, nonleaf)
+}
@@ -0,0 +1,131 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/healthchecker"
+	"github.com/blueprint-uservices/blueprint/plugins/loadbalancer"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+func TestLoadBalancer(t *testing.T) {
+	spec := newWiringSpec("TestLoadBalancer")
+
+	leaf := loadbalancer.Replicate(spec, "leaf", 2, func(replica string) {
+		workflow.Service[*wf.TestLeafServiceImpl](spec, replica)
+		grpc.Deploy(spec, replica)
+		goproc.Deploy(spec, replica)
+	}, loadbalancer.PowerOfTwoChoices(), loadbalancer.Eject(3, "10s"))
+	retries.AddRetries(spec, leaf, 3)
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestLoadBalancer = BlueprintApplication() {
+			leaf_1.grpc.addr
+			leaf_1.grpc.bind_addr = AddressConfig()
+			leaf_1.grpc.dial_addr = AddressConfig()
+			leaf_1.handler.visibility
+			leaf_1_proc = GolangProcessNode(leaf_1.grpc.bind_addr) {
+			  leaf_1 = TestLeafService()
+			  leaf_1.grpc_server = GRPCServer(leaf_1, leaf_1.grpc.bind_addr)
+			  leaf_1_proc.logger = SLogger()
+			  leaf_1_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			leaf_2.grpc.addr
+			leaf_2.grpc.bind_addr = AddressConfig()
+			leaf_2.grpc.dial_addr = AddressConfig()
+			leaf_2.handler.visibility
+			leaf_2_proc = GolangProcessNode(leaf_2.grpc.bind_addr) {
+			  leaf_2 = TestLeafService()
+			  leaf_2.grpc_server = GRPCServer(leaf_2, leaf_2.grpc.bind_addr)
+			  leaf_2_proc.logger = SLogger()
+			  leaf_2_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+			nonleaf.handler.visibility
+			proc = GolangProcessNode(leaf_1.grpc.dial_addr, leaf_2.grpc.dial_addr) {
+			  leaf.client.retrier = Retrier(leaf.lb)
+			  leaf.lb = LoadBalancer(leaf_1.client, leaf_2.client, policy:p2c,failures:3,eject:10s)
+			  leaf_1.client = leaf_1.grpc_client
+			  leaf_1.grpc_client = GRPCClient(leaf_1.grpc.dial_addr)
+			  leaf_2.client = leaf_2.grpc_client
+			  leaf_2.grpc_client = GRPCClient(leaf_2.grpc.dial_addr)
+			  nonleaf = TestNonLeafService(leaf.client.retrier)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestLoadBalancerHealthCheck(t *testing.T) {
+	spec := newWiringSpec("TestLoadBalancerHealthCheck")
+
+	var replicas []string
+	for _, name := range []string{"leaf_a", "leaf_b"} {
+		replica := workflow.Service[*wf.TestLeafServiceImpl](spec, name)
+		healthchecker.AddHealthCheckAPI(spec, replica)
+		replicas = append(replicas, replica)
+	}
+	leaf := loadbalancer.Create(spec, "leaf", replicas, loadbalancer.HealthCheck("1s"))
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestLoadBalancerHealthCheck = BlueprintApplication() {
+			leaf_a.handler.visibility
+			leaf_b.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf.lb = LoadBalancer(leaf_a.client, leaf_b.client, healthcheck:1s)
+			  leaf_a = TestLeafService()
+			  leaf_a.client = leaf_a.server.hc
+			  leaf_a.server.hc = HealthCheckerServerWrapper(leaf_a)
+			  leaf_b = TestLeafService()
+			  leaf_b.client = leaf_b.server.hc
+			  leaf_b.server.hc = HealthCheckerServerWrapper(leaf_b)
+			  nonleaf = TestNonLeafService(leaf.lb)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `err = client.balancer.Call(ctx, func(ctx context.Context, replica int) error {`)
+}
+
+func TestLoadBalancerInvalid(t *testing.T) {
+	for _, option := range []loadbalancer.Option{
+		loadbalancer.Eject(3, "soon"),
+		loadbalancer.HealthCheck("-1s"),
+		loadbalancer.Config("policy:fastest"),
+	} {
+		spec := newWiringSpec("TestLoadBalancerInvalid")
+		leaf := loadbalancer.Replicate(spec, "leaf", 2, func(replica string) {
+			workflow.Service[*wf.TestLeafServiceImpl](spec, replica)
+		}, option)
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// No replicas
+	spec := newWiringSpec("TestLoadBalancerInvalid")
+	leaf := loadbalancer.Create(spec, "leaf", nil)
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	assertBuildFailure(t, spec, goproc.CreateProcess(spec, "proc", nonleaf))
+
+	// Undefined replicas
+	spec = newWiringSpec("TestLoadBalancerInvalid")
+	leaf = loadbalancer.Create(spec, "leaf", []string{"leaf_1"})
+	nonleaf = workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	assertBuildFailure(t, spec, goproc.CreateProcess(spec, "proc", nonleaf))
+}
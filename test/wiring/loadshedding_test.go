@@ -0,0 +1,92 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/loadshedding"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSheddingCoDel(t *testing.T) {
+	spec := newWiringSpec("TestLoadSheddingCoDel")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	loadshedding.Add(spec, leaf, loadshedding.CoDel("5ms", "100ms", 100), loadshedding.Priority("HelloObject", -1), loadshedding.Priority("HelloInt", 2))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestLoadSheddingCoDel = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.loadshedding
+			  leaf.server.loadshedding = LoadShedder(leaf, codel(5ms, 100ms, 100), priorities=HelloInt:2,HelloObject:-1)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `server.shedder.Call(ctx, "HelloObject", func(ctx context.Context) error {`)
+}
+
+func TestLoadSheddingGradient(t *testing.T) {
+	spec := newWiringSpec("TestLoadSheddingGradient")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	loadshedding.Add(spec, leaf, loadshedding.Gradient("50ms", 1000))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestLoadSheddingGradient = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.loadshedding
+			  leaf.server.loadshedding = LoadShedder(leaf, gradient(50ms, 1000))
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestLoadSheddingInvalid(t *testing.T) {
+	for _, policy := range []loadshedding.Policy{
+		loadshedding.CoDel("100ms", "5ms", 100),
+		loadshedding.Gradient("50ms", 0),
+		loadshedding.Parse("fifo(10)"),
+	} {
+		spec := newWiringSpec("TestLoadSheddingInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		loadshedding.Add(spec, leaf, policy)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// Methods must exist
+	spec := newWiringSpec("TestLoadSheddingInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	loadshedding.Add(spec, leaf, loadshedding.Gradient("50ms", 1000), loadshedding.Priorities("HelloNobody:1"))
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	app := assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
@@ -0,0 +1,95 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/ratelimit"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	spec := newWiringSpec("TestRateLimit")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	ratelimit.Add(spec, leaf,
+		ratelimit.Rate(100, 10), ratelimit.Concurrency(50),
+		ratelimit.Method("HelloInt", ratelimit.Concurrency(5), ratelimit.Queue(20)))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestRateLimit = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.ratelimit
+			  leaf.server.ratelimit = RateLimiter(leaf, *=rate:100,burst:10,concurrency:50;HelloInt=concurrency:5,queue:20)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `server.limiter.Call(ctx, "HelloInt", func(ctx context.Context) error {`)
+}
+
+func TestRateLimitLimits(t *testing.T) {
+	spec := newWiringSpec("TestRateLimitLimits")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	ratelimit.Add(spec, leaf, ratelimit.Limits("HelloObject=rate:2.5;*=concurrency:8"))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestRateLimitLimits = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.ratelimit
+			  leaf.server.ratelimit = RateLimiter(leaf, *=concurrency:8;HelloObject=rate:2.5)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestRateLimitInvalid(t *testing.T) {
+	for _, option := range []ratelimit.Option{
+		ratelimit.Rate(-1, 0),
+		ratelimit.Method("HelloInt", ratelimit.Method("HelloObject", ratelimit.Concurrency(1))),
+		ratelimit.Method("*", ratelimit.Concurrency(1)),
+		ratelimit.Limits("*=speed:10"),
+	} {
+		spec := newWiringSpec("TestRateLimitInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		ratelimit.Add(spec, leaf, option)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// Methods must exist
+	spec := newWiringSpec("TestRateLimitInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	ratelimit.Add(spec, leaf, ratelimit.Method("HelloNobody", ratelimit.Concurrency(1)))
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	app := assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
@@ -0,0 +1,85 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/responsecache"
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	spec := newWiringSpec("TestResponseCache")
+
+	leaf_cache := simple.Cache(spec, "leaf_cache")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	responsecache.Add(spec, leaf, leaf_cache, responsecache.Cached("1m", "HelloInt", "HelloObject"),
+		responsecache.Invalidates("HelloNothing", "HelloInt"))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestResponseCache = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_cache.backend.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.server.responsecache
+			  leaf.server.responsecache = ResponseCache(leaf, leaf_cache, HelloInt=ttl:1m0s;HelloNothing=invalidates:HelloInt;HelloObject=ttl:1m0s)
+			  leaf_cache = SimpleCache()
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `err = server.cache.Call(ctx, "HelloInt", []any{a}, []any{&ret0}, func(ctx context.Context) error {`)
+}
+
+func TestResponseCacheInvalid(t *testing.T) {
+	for _, options := range [][]responsecache.Option{
+		{},
+		{responsecache.Invalidates("HelloNothing", "HelloInt")},
+		{responsecache.Cached("soon", "HelloInt")},
+		{responsecache.Cached("-1m", "HelloInt")},
+		{responsecache.Cached("1m", "HelloInt"), responsecache.Invalidates("HelloNothing", "HelloObject")},
+		{responsecache.Cached("1m", "Hello,Int")},
+		{responsecache.Policies("HelloInt=ttl:1m;*=invalidates:HelloInt")},
+	} {
+		spec := newWiringSpec("TestResponseCacheInvalid")
+		leaf_cache := simple.Cache(spec, "leaf_cache")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		responsecache.Add(spec, leaf, leaf_cache, options...)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// The cache must be defined
+	spec := newWiringSpec("TestResponseCacheInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	responsecache.Add(spec, leaf, "leaf_cache", responsecache.Cached("1m", "HelloInt"))
+	assertBuildFailure(t, spec, goproc.CreateProcess(spec, "proc", nonleaf))
+
+	// Methods must exist, and cached methods must return results
+	for _, policies := range []string{"HelloNobody=ttl:1m", "HelloNothing=ttl:1m"} {
+		spec := newWiringSpec("TestResponseCacheInvalid")
+		leaf_cache := simple.Cache(spec, "leaf_cache")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		responsecache.Add(spec, leaf, leaf_cache, responsecache.Policies(policies))
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		app := assertBuildSuccess(t, spec, proc)
+		require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")), policies)
+	}
+}
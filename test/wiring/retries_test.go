@@ -0,0 +1,54 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+func TestRetriesWithBackoffAndBudget(t *testing.T) {
+	spec := newWiringSpec("TestRetriesWithBackoffAndBudget")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	retries.AddRetries(spec, leaf, 3, retries.DecorrelatedJitter("10ms", "1s"), retries.Budget(0.1, 10))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestRetriesWithBackoffAndBudget = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.client.retrier
+			  leaf.client.retrier = Retrier(leaf, backoff=decorrelated_jitter(10ms, 1s), budget=0.1, burst=10)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `retries.NewBackoff("decorrelated_jitter", "10ms", "1s")`)
+	assertGeneratedCode(t, app, `retries.NewBudget(0.1, 10)`)
+}
+
+func TestRetriesInvalidOptions(t *testing.T) {
+	spec := newWiringSpec("TestRetriesInvalidOptions")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	retries.AddRetries(spec, leaf, 3, retries.ExponentialBackoff("1s", "10ms"))
+	assertBuildFailure(t, spec, nonleaf)
+
+	spec = newWiringSpec("TestRetriesInvalidOptions")
+	leaf = workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf = workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	retries.AddRetries(spec, leaf, 3, retries.Budget(0, 10))
+	assertBuildFailure(t, spec, nonleaf)
+}
@@ -3,6 +3,7 @@ package wiring
 import (
 	"testing"
 
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
 	"github.com/blueprint-uservices/blueprint/plugins/simple"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow"
 	"github.com/blueprint-uservices/blueprint/test/workflow/cache"
@@ -47,3 +48,38 @@ func TestSimpleCacheAndServices(t *testing.T) {
 			nonleaf.handler.visibility
           }`)
 }
+
+func TestSimpleCacheMaxEntries(t *testing.T) {
+	spec := newWiringSpec("TestSimpleCacheMaxEntries")
+
+	leaf_cache := simple.Cache(spec, "leaf_cache", simple.MaxEntries(100))
+	leaf := workflow.Service[*cache.TestLeafServiceImplWithCache](spec, "leaf", leaf_cache)
+	proc := goproc.CreateProcess(spec, "proc", leaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestSimpleCacheMaxEntries = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_cache.backend.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService(leaf_cache)
+			  leaf_cache = SimpleCache(max_entries=100)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `instance.SetMaxEntries(100)`)
+}
+
+func TestSimpleCacheInvalidMaxEntries(t *testing.T) {
+	spec := newWiringSpec("TestSimpleCacheInvalidMaxEntries")
+	leaf_cache := simple.Cache(spec, "leaf_cache", simple.MaxEntries(0))
+	assertBuildFailure(t, spec, leaf_cache)
+
+	// Only caches support MaxEntries
+	spec = newWiringSpec("TestSimpleCacheInvalidMaxEntries")
+	leaf_queue := simple.Queue(spec, "leaf_queue", simple.MaxEntries(100))
+	assertBuildFailure(t, spec, leaf_queue)
+}
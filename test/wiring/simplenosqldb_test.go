@@ -3,10 +3,14 @@ package wiring
 import (
 	"testing"
 
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
 	"github.com/blueprint-uservices/blueprint/plugins/simple"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow"
 	"github.com/blueprint-uservices/blueprint/test/workflow/nosqldb"
 	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSimpleNoSQLDB(t *testing.T) {
@@ -33,3 +37,67 @@ func TestSimpleNoSQLDB(t *testing.T) {
 			nonleaf.handler.visibility
 		  }`)
 }
+
+func TestSimpleNoSQLDBPersisted(t *testing.T) {
+	spec := newWiringSpec("TestSimpleNoSQLDBPersisted")
+
+	leaf_cache := simple.Cache(spec, "leaf_cache")
+	leaf_db := simple.NoSQLDB(spec, "leaf_db", simple.Persist("data/leaf_db.json", "30s"))
+	leaf := workflow.Service[*nosqldb.TestLeafServiceImplWithDB](spec, "leaf", leaf_cache, leaf_db)
+	proc := goproc.CreateProcess(spec, "proc", leaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestSimpleNoSQLDBPersisted = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_cache.backend.visibility
+			leaf_db.backend.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService(leaf_cache, leaf_db)
+			  leaf_cache = SimpleCache()
+			  leaf_db = SimpleNoSQLDB(persist=data/leaf_db.json, interval=30s)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	// The generated code restores and snapshots the backend
+	assertGeneratedCode(t, app, `snapshot.Persist(n.Context(), instance, "data/leaf_db.json", "30s")`)
+	assertGeneratedCode(t, app, `n.OnShutdown(stop)`)
+}
+
+func TestSimpleBackendInterfaces(t *testing.T) {
+	// The simple backends also implement snapshot.Snapshottable, but must always be used as their backend interface
+	expected := map[string]string{
+		"db":    "NoSQLDatabase",
+		"reldb": "RelationalDB",
+		"queue": "Queue",
+		"cache": "Cache",
+	}
+	for i := 0; i < 10; i++ {
+		spec := newWiringSpec("TestSimpleBackendInterfaces")
+		db := simple.NoSQLDB(spec, "db")
+		reldb := simple.RelationalDB(spec, "reldb")
+		queue := simple.Queue(spec, "queue")
+		cache := simple.Cache(spec, "cache")
+
+		app := assertBuildSuccess(t, spec, db, reldb, queue, cache)
+
+		backends := ir.Filter[*simple.SimpleBackend](app.Children)
+		require.Len(t, backends, len(expected))
+		for _, backend := range backends {
+			assert.Equal(t, expected[backend.InstanceName], backend.BackendType)
+		}
+	}
+}
+
+func TestSimpleNoSQLDBInvalidPersist(t *testing.T) {
+	spec := newWiringSpec("TestSimpleNoSQLDBInvalidPersist")
+	leaf_db := simple.NoSQLDB(spec, "leaf_db", simple.Persist("data/leaf_db.json", "often"))
+	assertBuildFailure(t, spec, leaf_db)
+
+	spec = newWiringSpec("TestSimpleNoSQLDBInvalidPersist")
+	leaf_db = simple.NoSQLDB(spec, "leaf_db", simple.Persist("", "30s"))
+	assertBuildFailure(t, spec, leaf_db)
+}
@@ -2,6 +2,10 @@ package wiring
 
 import (
 	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -64,3 +68,57 @@ func assertIR(t *testing.T, app *ir.ApplicationNode, expected string) bool {
 	require.Equal(t, b, a, "Got unexpected application\n%v", app.String())
 	return true
 }
+
+// Generates the application's artifacts and checks that the generated Go code contains the expected snippet and
+// type-checks
+func assertGeneratedCode(t *testing.T, app *ir.ApplicationNode, expected string) bool {
+	outputDir := filepath.Join(t.TempDir(), "build")
+	require.NoError(t, app.GenerateArtifacts(outputDir))
+	found := false
+	var modules []string
+	compilable := true
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".proto", ".thrift":
+			// Code for gRPC and Thrift is incomplete until protoc or thrift is run
+			compilable = false
+		case ".go":
+			if d.Name() == "main.go" {
+				modules = append(modules, filepath.Dir(path))
+			}
+			code, err := os.ReadFile(path)
+			found = found || strings.Contains(string(code), expected)
+			return err
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, found, "Generated code does not contain %v", expected)
+	if compilable {
+		for _, module := range modules {
+			assertTypeChecks(t, module)
+		}
+	}
+	return true
+}
+
+// Type-checks the generated Go module in dir, unless tests are run with -short.  Dependencies are only loaded from
+// the local module cache, so that tests do not download modules; if a dependency is not in the cache, the module is
+// not type-checked.  -trimpath lets the build cache be reused across temporary directories.
+func assertTypeChecks(t *testing.T, dir string) {
+	if testing.Short() {
+		return
+	}
+	cmd := exec.Command("go", "vet", "-trimpath", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil && strings.Contains(string(out), "GOPROXY=off") {
+		t.Logf("Not type-checking %v because its dependencies are not in the module cache:\n%s", dir, out)
+		return
+	}
+	require.NoError(t, err, "Generated code in %v does not type-check:\n%s", dir, out)
+}
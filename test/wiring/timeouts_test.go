@@ -0,0 +1,107 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/retries"
+	"github.com/blueprint-uservices/blueprint/plugins/timeouts"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeouts(t *testing.T) {
+	spec := newWiringSpec("TestTimeouts")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	timeouts.Add(spec, leaf, "100ms",
+		timeouts.Method("HelloObject", timeouts.Timeout("1s"), timeouts.Budget(0.5)),
+		timeouts.Method("HelloInt", timeouts.Budget(0.25)))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestTimeouts = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.client.timeout
+			  leaf.client.timeout = TimeoutClient(leaf, *=timeout:100ms;HelloInt=budget:0.25;HelloObject=timeout:1s,budget:0.5)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `client.timeouts.Call(ctx, "HelloInt", func(ctx context.Context) error {`)
+}
+
+func TestRetriesWithTimeoutsAndDeadline(t *testing.T) {
+	spec := newWiringSpec("TestRetriesWithTimeoutsAndDeadline")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	retries.AddRetriesWithTimeouts(spec, leaf, 3, "*=timeout:100ms;HelloObject=budget:0.5", retries.Deadline("2s"))
+
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+
+	app := assertBuildSuccess(t, spec, proc)
+
+	assertIR(t, app,
+		`TestRetriesWithTimeoutsAndDeadline = BlueprintApplication() {
+			leaf.handler.visibility
+			nonleaf.handler.visibility
+			proc = GolangProcessNode() {
+			  leaf = TestLeafService()
+			  leaf.client = leaf.client.retrier
+			  leaf.client.retrier = Retrier(leaf.client.timeout, deadline=2s)
+			  leaf.client.timeout = TimeoutClient(leaf, *=timeout:100ms;HelloObject=budget:0.5)
+			  nonleaf = TestNonLeafService(leaf.client)
+			  proc.logger = SLogger()
+			  proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+
+	assertGeneratedCode(t, app, `handler.retrier.Deadline = 2000000000 // 2s`)
+}
+
+func TestTimeoutsInvalid(t *testing.T) {
+	for _, add := range []func(spec wiring.WiringSpec, serviceName string){
+		func(spec wiring.WiringSpec, serviceName string) { timeouts.Add(spec, serviceName, "soon") },
+		func(spec wiring.WiringSpec, serviceName string) { timeouts.Add(spec, serviceName, "") },
+		func(spec wiring.WiringSpec, serviceName string) {
+			timeouts.Add(spec, serviceName, "1s", timeouts.Budget(1.5))
+		},
+		func(spec wiring.WiringSpec, serviceName string) {
+			timeouts.Add(spec, serviceName, "1s", timeouts.Method("HelloInt", timeouts.Method("HelloObject", timeouts.Timeout("1s"))))
+		},
+		func(spec wiring.WiringSpec, serviceName string) {
+			retries.AddRetriesWithTimeouts(spec, serviceName, 3, "1s", retries.Deadline("0s"))
+		},
+	} {
+		spec := newWiringSpec("TestTimeoutsInvalid")
+		leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+		nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+		add(spec, leaf)
+		proc := goproc.CreateProcess(spec, "proc", nonleaf)
+		assertBuildFailure(t, spec, proc)
+	}
+
+	// Methods must exist
+	spec := newWiringSpec("TestTimeoutsInvalid")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+	timeouts.Add(spec, leaf, "1s", timeouts.Method("HelloNobody", timeouts.Timeout("2s")))
+	proc := goproc.CreateProcess(spec, "proc", nonleaf)
+	app := assertBuildSuccess(t, spec, proc)
+	require.Error(t, app.GenerateArtifacts(filepath.Join(t.TempDir(), "build")))
+}
@@ -0,0 +1,130 @@
+package wiring
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/grpc"
+	"github.com/blueprint-uservices/blueprint/plugins/jaeger"
+	"github.com/blueprint-uservices/blueprint/plugins/opentelemetry"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Tests for validation of wiring specs prior to building the IR
+*/
+
+func TestValidateValidSpec(t *testing.T) {
+	spec := newWiringSpec("TestValidateValidSpec")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	grpc.Deploy(spec, leaf)
+	grpc.Deploy(spec, nonleaf)
+
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	diagnostics := spec.Validate(leafproc, nonleafproc)
+	assert.Empty(t, diagnostics)
+	assertBuildSuccess(t, spec, leafproc, nonleafproc)
+}
+
+func TestValidateDanglingReference(t *testing.T) {
+	spec := newWiringSpec("TestValidateDanglingReference")
+
+	proc := goproc.CreateProcess(spec, "proc", "missing")
+
+	errs := spec.Validate(proc).Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, wiring.DanglingReference, errs[0].Kind)
+	assert.Equal(t, "proc", errs[0].Name)
+	assert.Equal(t, "validate_test.go", filepath.Base(errs[0].File))
+	assert.NotZero(t, errs[0].Line)
+
+	err := assertBuildFailure(t, spec, proc)
+	var validationErr *wiring.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, errs, validationErr.Diagnostics)
+}
+
+func TestValidateAliasCycle(t *testing.T) {
+	spec := newWiringSpec("TestValidateAliasCycle")
+
+	spec.Alias("a", "b")
+	spec.Alias("b", "c")
+	spec.Alias("c", "a")
+
+	errs := spec.Validate().Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, wiring.AliasCycle, errs[0].Kind)
+	assert.Equal(t, "validate_test.go", filepath.Base(errs[0].File))
+}
+
+func TestValidatePointerWithoutDestination(t *testing.T) {
+	spec := newWiringSpec("TestValidatePointerWithoutDestination")
+
+	pointer.CreatePointer[golang.Service](spec, "ptr", "missing")
+
+	errs := spec.Validate().Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, wiring.PointerWithoutDestination, errs[0].Kind)
+	assert.Equal(t, "ptr", errs[0].Name)
+}
+
+func TestValidateAddressWithoutServer(t *testing.T) {
+	spec := newWiringSpec("TestValidateAddressWithoutServer")
+
+	address.Define[golang.Service](spec, "addr", "missing")
+
+	errs := spec.Validate().Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, wiring.AddressWithoutServer, errs[0].Kind)
+	assert.Equal(t, "addr", errs[0].Name)
+}
+
+func TestValidateUnreachable(t *testing.T) {
+	spec := newWiringSpec("TestValidateUnreachable")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	workflow.Service[*wf.TestLeafServiceImpl](spec, "unused")
+
+	diagnostics := spec.Validate(leaf)
+	assert.Empty(t, diagnostics.Errors())
+
+	var unreachable []string
+	for _, d := range diagnostics.Warnings() {
+		if d.Kind == wiring.Unreachable {
+			unreachable = append(unreachable, d.Name)
+		}
+	}
+	assert.Contains(t, unreachable, "unused")
+	assert.NotContains(t, unreachable, "leaf")
+
+	// Warnings don't prevent the application from building
+	assertBuildSuccess(t, spec, leaf)
+}
+
+func TestValidateUnreachableMetadata(t *testing.T) {
+	spec := newWiringSpec("TestValidateUnreachableMetadata")
+
+	collector := jaeger.Collector(spec, "jaeger")
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	opentelemetry.Instrument(spec, leaf, collector)
+	grpc.Deploy(spec, leaf)
+	leafproc := goproc.CreateProcess(spec, "leafproc", leaf)
+
+	// Addresses such as the collector's UI address, and uniqueness checks, are metadata rather than parts
+	// of the application, so they aren't reported if they are unused
+	diagnostics := spec.Validate(leafproc, collector)
+	assert.Empty(t, diagnostics)
+}
@@ -25,8 +25,8 @@ type (
 Constructors
 */
 
-func NewTestLeafServiceImplWithCache(ctx ctxx.Context, cache backend.Cache) (*TestLeafServiceImplWithCache, error) {
-	return &TestLeafServiceImplWithCache{Cache: cache}, nil
+func NewTestLeafServiceImplWithCache(ctx ctxx.Context, leafCache backend.Cache) (*TestLeafServiceImplWithCache, error) {
+	return &TestLeafServiceImplWithCache{Cache: leafCache}, nil
 }
 
 /*
@@ -0,0 +1,34 @@
+// Package openapi is a service whose types cannot all be described in OpenAPI documents, used for testing.
+package openapi
+
+import (
+	"context"
+	"net/url"
+)
+
+type (
+	LinkService interface {
+		Shorten(ctx context.Context, link Link) (*url.URL, error)
+	}
+
+	Audit struct {
+		CreatedBy string
+	}
+
+	// Link embeds Audit, whose fields are left out of the OpenAPI document
+	Link struct {
+		Audit
+		Target url.URL
+		Tags   []string
+	}
+
+	LinkServiceImpl struct{}
+)
+
+func NewLinkServiceImpl(ctx context.Context) (LinkService, error) {
+	return &LinkServiceImpl{}, nil
+}
+
+func (s *LinkServiceImpl) Shorten(ctx context.Context, link Link) (*url.URL, error) {
+	return &url.URL{Scheme: link.Target.Scheme, Host: link.Target.Host, Path: "/s"}, nil
+}